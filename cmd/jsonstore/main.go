@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"jsonstore/pkg/audit"
+	"jsonstore/pkg/auth"
+	"jsonstore/pkg/config"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/router"
+	"jsonstore/pkg/service"
+)
+
+// Upper bound on the time given to in-flight requests to complete once a shutdown signal is received
+const shutdownTimeout = 25 * time.Second
+
+const (
+	auditLogFileName = "audit.log"
+	// Number of audit entries kept when there is no file to write them to
+	auditMemoryCapacity = 10000
+)
+
+func main() {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("init logger: %v", err)
+	}
+	defer logger.Sync() //nolint:errcheck
+	zap.ReplaceGlobals(logger)
+
+	if err := run(); err != nil {
+		zap.S().Fatalf("jsonstore: %v", err)
+	}
+}
+
+func run() error {
+	conf, err := config.New()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	configRepo, err := newConfigRepo(conf)
+	if err != nil {
+		return fmt.Errorf("init config repo: %w", err)
+	}
+	if closer, ok := configRepo.(io.Closer); ok {
+		defer func() {
+			if err := closer.Close(); err != nil {
+				zap.S().Errorf("Close config repo: %v", err)
+			}
+		}()
+	}
+
+	schemaRepo, err := newSchemaRepo(conf)
+	if err != nil {
+		return fmt.Errorf("init schema repo: %w", err)
+	}
+
+	webhookRepo, err := newWebhookRepo(conf)
+	if err != nil {
+		return fmt.Errorf("init webhook repo: %w", err)
+	}
+
+	manager := service.NewConfigManager(configRepo, schemaRepo, webhookRepo)
+	if closer, ok := manager.(io.Closer); ok {
+		defer func() {
+			if err := closer.Close(); err != nil {
+				zap.S().Errorf("Close config manager: %v", err)
+			}
+		}()
+	}
+	authenticators, err := newAuthenticators(conf)
+	if err != nil {
+		return fmt.Errorf("init authentication: %w", err)
+	}
+
+	auditLog, err := newAuditLog(conf)
+	if err != nil {
+		return fmt.Errorf("init audit log: %w", err)
+	}
+	if closer, ok := auditLog.(io.Closer); ok {
+		defer func() {
+			if err := closer.Close(); err != nil {
+				zap.S().Errorf("Close audit log: %v", err)
+			}
+		}()
+	}
+
+	routerCtx := router.Context{Manager: manager, Authenticators: authenticators, AuditLog: auditLog}
+	if conf.PolicyFile != "" {
+		if len(authenticators) == 0 {
+			return errors.New("init authorization: a policy requires authentication to be configured")
+		}
+		policy, err := auth.NewPolicyFile(conf.PolicyFile)
+		if err != nil {
+			return fmt.Errorf("init authorization: %w", err)
+		}
+		routerCtx.Authorizer = policy
+
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		defer signal.Stop(reload)
+		go reloadPolicy(policy, reload)
+	}
+
+	timeout := time.Duration(conf.ServerTimeoutMS) * time.Millisecond
+	routerCtx.Timeout = timeout
+
+	stopWatches := make(chan struct{})
+	routerCtx.StopWatches = stopWatches
+
+	// Requests are timed out by the router rather than the server, which would cut watches short
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", conf.ServePort),
+		Handler:           routerCtx.New(),
+		ReadHeaderTimeout: timeout,
+		IdleTimeout:       timeout,
+	}
+	// Shutdown waits for in-flight requests to complete, which watches only do once stopped
+	server.RegisterOnShutdown(func() { close(stopWatches) })
+
+	serveErr := make(chan error, 1)
+	go func() {
+		zap.S().Infof("Starting jsonstore server on %s", server.Addr)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(stop)
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("serve: %w", err)
+	case sig := <-stop:
+		zap.S().Infof("Received %s, draining in-flight requests", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serve: %w", err)
+	}
+
+	zap.S().Infof("Server stopped")
+	return nil
+}
+
+func newConfigRepo(conf *config.Config) (db.Config, error) {
+	if conf.DataDir == "" {
+		zap.S().Infof("Using in-memory config store")
+		return db.NewConfigRepo(), nil
+	}
+
+	zap.S().Infof("Using file-backed config store in %s", conf.DataDir)
+	return db.NewFileConfigRepo(conf.DataDir, conf.SnapshotThreshold)
+}
+
+func newSchemaRepo(conf *config.Config) (db.Schema, error) {
+	if conf.DataDir == "" {
+		return db.NewSchemaRepo(), nil
+	}
+
+	return db.NewFileSchemaRepo(conf.DataDir)
+}
+
+func newWebhookRepo(conf *config.Config) (db.Webhook, error) {
+	if conf.DataDir == "" {
+		return db.NewWebhookRepo(), nil
+	}
+
+	return db.NewFileWebhookRepo(conf.DataDir)
+}
+
+func newAuditLog(conf *config.Config) (audit.Log, error) {
+	path := conf.AuditLogFile
+	if path == "" && conf.DataDir != "" {
+		path = filepath.Join(conf.DataDir, auditLogFileName)
+	}
+	if path == "" {
+		zap.S().Infof("Keeping the latest %d audit entries in memory", auditMemoryCapacity)
+		return audit.NewMemoryLog(auditMemoryCapacity), nil
+	}
+
+	zap.S().Infof("Writing audit log to %s", path)
+	return audit.NewFileLog(path, int64(conf.AuditLogMaxSizeMB)<<20, conf.AuditLogMaxFiles)
+}
+
+// reloadPolicy reads the policy again whenever a signal is received, keeping the current one when
+// the file is invalid
+func reloadPolicy(policy *auth.PolicyFile, signals <-chan os.Signal) {
+	for range signals {
+		if err := policy.Reload(); err != nil {
+			zap.S().Errorf("Reload policy: %v", err)
+			continue
+		}
+		zap.S().Infof("Reloaded policy")
+	}
+}
+
+func newAuthenticators(conf *config.Config) ([]auth.Authenticator, error) {
+	var authenticators []auth.Authenticator
+	if conf.APIKeys != nil {
+		apiKeys, err := auth.NewAPIKeys(conf.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, apiKeys)
+	}
+
+	if conf.JWTSecret != nil || conf.JWTPublicKey != nil {
+		opts := auth.JWTOptions{
+			// Files usually end with a newline, which is not part of the secret
+			Secret:   bytes.TrimSpace(conf.JWTSecret),
+			Issuer:   conf.JWTIssuer,
+			Audience: conf.JWTAudience,
+			Leeway:   time.Minute,
+		}
+		if conf.JWTPublicKey != nil {
+			publicKey, err := auth.ParseRSAPublicKey(conf.JWTPublicKey)
+			if err != nil {
+				return nil, fmt.Errorf("parse jwt public key: %w", err)
+			}
+			opts.PublicKey = publicKey
+		}
+		jwt, err := auth.NewJWT(opts)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, jwt)
+	}
+
+	if len(authenticators) == 0 {
+		zap.S().Warnf("No API keys nor JWT keys configured, authentication is disabled")
+	}
+	return authenticators, nil
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/httperr"
+)
+
+// Number of configs fetched by each request of list and search
+const listPageSize = 500
+
+// Header of the YAML document given to the editor
+const editHeader = `# Edit the config below and save the file to store it, or leave it unchanged to cancel.
+# The name of the config cannot be edited.
+`
+
+func (c *cli) get(args []string) error {
+	fs := c.flagSet()
+	c.outputFlag(fs, "json", "yaml", "table")
+	positional, err := c.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	config, err := c.client.Get(c.ctx, c.settings.Namespace, positional[0])
+	if err != nil {
+		return err
+	}
+	return c.print(config, configsTable([]contract.GetConfigResponse{*config}))
+}
+
+func (c *cli) list(args []string) error {
+	fs := c.flagSet()
+	c.outputFlag(fs, "table", "json", "yaml")
+	limit := fs.Int("limit", 0, "Maximum number of configs listed; every config is listed when 0")
+	sort := fs.String("sort", "", `Path of the value to order configs by, descending when prefixed by "-" (default "name")`)
+	if _, err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	configs, err := c.listAll(*limit, *sort, func(opts contract.ListOptions) (*contract.ListConfigsResponse, error) {
+		return c.client.List(c.ctx, c.settings.Namespace, opts)
+	})
+	if err != nil {
+		return err
+	}
+	return c.print(configs, configsTable(configs))
+}
+
+func (c *cli) search(args []string) error {
+	fs := c.flagSet()
+	c.outputFlag(fs, "table", "json", "yaml")
+	limit := fs.Int("limit", 0, "Maximum number of configs listed; every matching config is listed when 0")
+	sort := fs.String("sort", "", `Path of the value to order configs by, descending when prefixed by "-" (default "name")`)
+	positional, err := c.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	configs, err := c.listAll(*limit, *sort, func(opts contract.ListOptions) (*contract.ListConfigsResponse, error) {
+		return c.client.Search(c.ctx, c.settings.Namespace, positional[0], opts)
+	})
+	if err != nil {
+		return err
+	}
+	return c.print(configs, configsTable(configs))
+}
+
+// listAll follows the pages of a listing until limit configs are listed, or every config when
+// limit is 0
+func (c *cli) listAll(limit int, sort string, page func(contract.ListOptions) (*contract.ListConfigsResponse, error)) ([]contract.GetConfigResponse, error) {
+	configs := []contract.GetConfigResponse{}
+	opts := contract.ListOptions{Sort: sort}
+	for {
+		opts.Limit = listPageSize
+		if limit > 0 && limit-len(configs) < listPageSize {
+			opts.Limit = limit - len(configs)
+		}
+		res, err := page(opts)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, res.Configs...)
+		if res.Next == "" || (limit > 0 && len(configs) >= limit) {
+			return configs, nil
+		}
+		opts.Cursor = res.Next
+	}
+}
+
+// apply creates or replaces the configs of a file that differ from the stored ones. A config
+// changed by someone else since it was read is not replaced.
+func (c *cli) apply(args []string) error {
+	fs := c.flagSet()
+	file := fileFlag(fs, "JSON or YAML file of the configs, - for stdin")
+	if _, err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *file == "" {
+		return usageError("missing -f")
+	}
+	configs, err := c.readConfigs(*file)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, config := range configs {
+		if err := c.applyConfig(config); err != nil {
+			fmt.Fprintf(c.stderr, "%s: %v\n", config.Name, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d configs not applied", failed, len(configs))
+	}
+	return nil
+}
+
+func (c *cli) applyConfig(config contract.Config) error {
+	cond := contract.Precondition{IfNoneMatchAny: true}
+	current, err := c.client.Get(c.ctx, c.settings.Namespace, config.Name)
+	switch {
+	case errors.Is(err, httperr.ConfigNotFound):
+	case err != nil:
+		return err
+	case sameConfig(current.Config, config):
+		fmt.Fprintf(c.stdout, "%s unchanged\n", config.Name)
+		return nil
+	default:
+		cond = contract.Precondition{IfMatch: []int64{current.Version}}
+	}
+
+	updated, err := c.client.Update(c.ctx, contract.UpsertConfigRequest{Config: config,
+		Namespace: c.settings.Namespace, Author: c.settings.Author}, cond)
+	if err != nil {
+		return err
+	}
+	action := "configured"
+	if current == nil {
+		action = "created"
+	}
+	fmt.Fprintf(c.stdout, "%s %s (version %d)\n", config.Name, action, updated.Version)
+	return nil
+}
+
+// edit opens a config in the editor and stores the edited config, unless it was changed by
+// someone else in the meantime. The edited file is kept when it cannot be stored.
+func (c *cli) edit(args []string) error {
+	fs := c.flagSet()
+	c.outputFlag(fs, "yaml", "json")
+	positional, err := c.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	current, err := c.client.Get(c.ctx, c.settings.Namespace, positional[0])
+	if err != nil {
+		return err
+	}
+	data, err := encode(c.output, current.Config)
+	if err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	if c.output == "yaml" {
+		data = append([]byte(editHeader), data...)
+	}
+	file, err := ioutil.TempFile("", "jsonstorectl-*."+c.output)
+	if err != nil {
+		return fmt.Errorf("create edited file: %w", err)
+	}
+	path := file.Name()
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("write edited file: %w", err)
+	}
+
+	changed, err := c.storeEdited(current, path)
+	if err != nil {
+		return fmt.Errorf("%w; edits kept in %s", err, path)
+	}
+	os.Remove(path)
+	if changed == nil {
+		fmt.Fprintf(c.stdout, "Edit cancelled, no changes made\n")
+		return nil
+	}
+	fmt.Fprintf(c.stdout, "%s edited (version %d)\n", changed.Name, changed.Version)
+	return nil
+}
+
+// storeEdited runs the editor on the file of a config and stores the result, returning nil when
+// the config is unchanged
+func (c *cli) storeEdited(current *contract.GetConfigResponse, path string) (*contract.GetConfigResponse, error) {
+	editor := strings.Fields(c.getenv("VISUAL"))
+	if len(editor) == 0 {
+		editor = strings.Fields(c.getenv("EDITOR"))
+	}
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = c.stdin, c.stdout, c.stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("run editor: %w", err)
+	}
+
+	configs, err := c.readConfigs(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(configs) != 1 {
+		return nil, fmt.Errorf("edited file holds %d configs instead of 1", len(configs))
+	}
+	edited := configs[0]
+	if edited.Name != current.Name {
+		return nil, fmt.Errorf("the name of config %s cannot be edited", current.Name)
+	}
+	if sameConfig(current.Config, edited) {
+		return nil, nil
+	}
+
+	updated, err := c.client.Update(c.ctx, contract.UpsertConfigRequest{Config: edited,
+		Namespace: c.settings.Namespace, Author: c.settings.Author}, contract.Precondition{IfMatch: []int64{current.Version}})
+	if errors.Is(err, httperr.PreconditionFailed) {
+		return nil, fmt.Errorf("config %s was changed since it was opened", current.Name)
+	}
+	return updated, err
+}
+
+func (c *cli) delete(args []string) error {
+	fs := c.flagSet()
+	version := fs.Int64("version", 0, "Only delete the config if it is still at this version")
+	positional, err := c.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	var cond contract.Precondition
+	if *version != 0 {
+		cond.IfMatch = []int64{*version}
+	}
+	revision, err := c.client.Delete(c.ctx, contract.DeleteConfigRequest{Namespace: c.settings.Namespace,
+		Name: positional[0], Author: c.settings.Author}, cond)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "%s deleted (version %d)\n", revision.Name, revision.Version)
+	return nil
+}
+
+// diff prints the leaves of the configs of a file that differ from those stored, by their JSON
+// pointer, and returns errDiffers when any does
+func (c *cli) diff(args []string) error {
+	fs := c.flagSet()
+	file := fileFlag(fs, "JSON or YAML file of the configs, - for stdin")
+	if _, err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *file == "" {
+		return usageError("missing -f")
+	}
+	configs, err := c.readConfigs(*file)
+	if err != nil {
+		return err
+	}
+
+	differs := false
+	for _, config := range configs {
+		stored, from := "missing", map[string]string{}
+		current, err := c.client.Get(c.ctx, c.settings.Namespace, config.Name)
+		switch {
+		case errors.Is(err, httperr.ConfigNotFound):
+		case err != nil:
+			return err
+		default:
+			stored, from = fmt.Sprintf("version %d", current.Version), leaves(current.Config)
+		}
+
+		lines := diffLeaves(from, leaves(config))
+		if len(lines) == 0 {
+			continue
+		}
+		differs = true
+		fmt.Fprintf(c.stdout, "--- %s (%s)\n+++ %s (%s)\n", config.Name, stored, config.Name, *file)
+		for _, line := range lines {
+			fmt.Fprintln(c.stdout, line)
+		}
+	}
+	if differs {
+		return errDiffers
+	}
+	return nil
+}
+
+// leaves returns the leaves of a config but its name
+func leaves(config contract.Config) map[string]string {
+	value, _ := generic(config)
+	delete(value.(map[string]interface{}), "name")
+	return flatten(map[string]string{}, "", value)
+}
+
+// diffLeaves returns a line for each leaf removed, prefixed by "-", and added, prefixed by "+",
+// ordered by pointer
+func diffLeaves(from, to map[string]string) []string {
+	pointers := make([]string, 0, len(from)+len(to))
+	for pointer := range from {
+		pointers = append(pointers, pointer)
+	}
+	for pointer := range to {
+		if _, ok := from[pointer]; !ok {
+			pointers = append(pointers, pointer)
+		}
+	}
+	sort.Strings(pointers)
+
+	var lines []string
+	for _, pointer := range pointers {
+		old, inFrom := from[pointer]
+		updated, inTo := to[pointer]
+		if inFrom && inTo && old == updated {
+			continue
+		}
+		if inFrom {
+			lines = append(lines, fmt.Sprintf("- %s: %s", pointer, old))
+		}
+		if inTo {
+			lines = append(lines, fmt.Sprintf("+ %s: %s", pointer, updated))
+		}
+	}
+	return lines
+}
+
+func (c *cli) export(args []string) error {
+	fs := c.flagSet()
+	c.outputFlag(fs, "json", "yaml")
+	file := fileFlag(fs, "File to write the configs to (default stdout)")
+	if _, err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	configs, err := c.client.Export(c.ctx, c.settings.Namespace)
+	if err != nil {
+		return err
+	}
+	if *file == "" || *file == "-" {
+		return c.print(configs, nil)
+	}
+	data, err := encode(c.output, configs)
+	if err != nil {
+		return fmt.Errorf("encode configs: %w", err)
+	}
+	if err := ioutil.WriteFile(*file, data, 0o600); err != nil {
+		return fmt.Errorf("write configs: %w", err)
+	}
+	fmt.Fprintf(c.stdout, "%d configs exported to %s\n", len(configs), filepath.Clean(*file))
+	return nil
+}
+
+func (c *cli) importConfigs(args []string) error {
+	fs := c.flagSet()
+	c.outputFlag(fs, "table", "json", "yaml")
+	file := fileFlag(fs, "JSON or YAML file of the configs, - for stdin")
+	mode := fs.String("mode", contract.ImportMerge, "Import mode: merge, or replace-all to also delete the configs that are not imported")
+	dryRun := fs.Bool("dry-run", false, "Report what the import would do without writing anything")
+	if _, err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *file == "" {
+		return usageError("missing -f")
+	}
+	configs, err := c.readConfigs(*file)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.client.Import(c.ctx, contract.ImportRequest{Namespace: c.settings.Namespace, Mode: *mode,
+		DryRun: *dryRun, Author: c.settings.Author, Configs: configs})
+	if err != nil {
+		return err
+	}
+	if err := c.print(res, importTable(res)); err != nil {
+		return err
+	}
+	if res.Invalid+res.Failed > 0 {
+		return fmt.Errorf("%d of %d configs not imported", res.Invalid+res.Failed, len(configs))
+	}
+	return nil
+}
+
+// fileFlag adds the -f flag naming a file
+func fileFlag(fs *flag.FlagSet, usage string) *string {
+	var file string
+	fs.StringVar(&file, "file", "", usage)
+	fs.StringVar(&file, "f", "", "Shorthand for -file")
+	return &file
+}
+
+// sameConfig tells whether two configs have the same content
+func sameConfig(a, b contract.Config) bool {
+	aValue, aErr := generic(a)
+	bValue, bErr := generic(b)
+	return aErr == nil && bErr == nil && reflect.DeepEqual(aValue, bValue)
+}
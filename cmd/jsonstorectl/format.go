@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"jsonstore/pkg/contract"
+)
+
+// readConfigs reads the configs of a file, or of stdin when path is "-". The file holds JSON or
+// YAML, told apart by its extension or else by its content, where each document or JSON value is
+// either a config or a list of configs. Exported configs can be read as is.
+func (c *cli) readConfigs(path string) ([]contract.Config, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = ioutil.ReadAll(c.stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read configs: %w", err)
+	}
+
+	var values []interface{}
+	if isJSON(path, data) {
+		values, err = decodeJSON(data)
+	} else {
+		values, err = decodeYAML(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read configs %s: %w", path, err)
+	}
+
+	var items []interface{}
+	for _, value := range values {
+		if list, ok := value.([]interface{}); ok {
+			items = append(items, list...)
+		} else if value != nil {
+			items = append(items, value)
+		}
+	}
+	configs := make([]contract.Config, len(items))
+	for i, item := range items {
+		if err := decodeConfig(item, &configs[i]); err != nil {
+			return nil, fmt.Errorf("read configs %s: config %d: %w", path, i+1, err)
+		}
+	}
+	return configs, nil
+}
+
+func isJSON(path string, data []byte) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".ndjson", ".jsonl":
+		return true
+	case ".yaml", ".yml":
+		return false
+	}
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && (data[0] == '{' || data[0] == '[')
+}
+
+// decodeJSON decodes a sequence of JSON values, such as a single value or newline delimited JSON
+func decodeJSON(data []byte) ([]interface{}, error) {
+	var values []interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var value interface{}
+		if err := decoder.Decode(&value); errors.Is(err, io.EOF) {
+			return values, nil
+		} else if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+}
+
+// decodeYAML decodes the documents of a YAML stream
+func decodeYAML(data []byte) ([]interface{}, error) {
+	var values []interface{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var value interface{}
+		if err := decoder.Decode(&value); errors.Is(err, io.EOF) {
+			return values, nil
+		} else if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+}
+
+// decodeConfig decodes a config from its generic representation
+func decodeConfig(value interface{}, config *contract.Config) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return err
+	}
+	if config.Name == "" {
+		return errors.New("missing name")
+	}
+	return nil
+}
+
+// generic returns the representation of v decoded from its JSON encoding, which YAML encodes with
+// the JSON field names
+func generic(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// encode encodes v in the json or yaml format
+func encode(format string, v interface{}) ([]byte, error) {
+	if format == "json" {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+
+	value, err := generic(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// print writes v in the output format, using table to write the table format
+func (c *cli) print(v interface{}, table func(w io.Writer)) error {
+	if c.output == "table" {
+		w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+		table(w)
+		return w.Flush()
+	}
+
+	data, err := encode(c.output, v)
+	if err != nil {
+		return fmt.Errorf("encode output: %w", err)
+	}
+	_, err = c.stdout.Write(data)
+	return err
+}
+
+func configsTable(configs []contract.GetConfigResponse) func(w io.Writer) {
+	return func(w io.Writer) {
+		fmt.Fprintln(w, "NAME\tVERSION\tUPDATED\tUPDATED BY")
+		for _, config := range configs {
+			updated := ""
+			if config.UpdatedAt != nil {
+				updated = config.UpdatedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", config.Name, config.Version, updated, config.UpdatedBy)
+		}
+	}
+}
+
+func importTable(res *contract.ImportResponse) func(w io.Writer) {
+	return func(w io.Writer) {
+		fmt.Fprintln(w, "NAME\tRESULT\tVERSION\tERROR")
+		for _, result := range res.Results {
+			version := ""
+			if result.Version != 0 {
+				version = strconv.FormatInt(result.Version, 10)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Name, result.Result, version, result.Error)
+		}
+		summary := fmt.Sprintf("%d created, %d updated, %d unchanged, %d deleted, %d invalid, %d failed",
+			res.Created, res.Updated, res.Unchanged, res.Deleted, res.Invalid, res.Failed)
+		if res.DryRun {
+			summary += " (dry run)"
+		}
+		fmt.Fprintf(w, "\n%s\n", summary)
+	}
+}
+
+// flatten returns the JSON encoding of the leaves of a generic value by their JSON pointer. Arrays
+// and empty objects are leaves.
+func flatten(leaves map[string]string, pointer string, value interface{}) map[string]string {
+	if obj, ok := value.(map[string]interface{}); ok && len(obj) > 0 {
+		for key, member := range obj {
+			token := strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+			flatten(leaves, pointer+"/"+token, member)
+		}
+		return leaves
+	}
+	data, _ := json.Marshal(value)
+	leaves[pointer] = string(data)
+	return leaves
+}
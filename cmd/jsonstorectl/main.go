@@ -0,0 +1,264 @@
+// Command jsonstorectl manages the configs of a jsonstore server from the command line.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"jsonstore/pkg/client"
+)
+
+const (
+	defaultServer = "http://localhost:8080"
+	// Name of the settings file looked up in the home directory
+	settingsFileName = ".jsonstorectl.yaml"
+)
+
+// Exit codes
+const (
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+var (
+	// errDiffers is returned by diff when the configs differ, which only sets the exit code
+	errDiffers = errors.New("configs differ")
+	// errFlags is returned when the flags cannot be parsed, which the flag set reports itself
+	errFlags = errors.New("invalid flags")
+)
+
+// command is a subcommand of jsonstorectl
+type command struct {
+	args    string
+	summary string
+	run     func(cli *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"get":    {"NAME", "Print a config", (*cli).get},
+	"list":   {"", "List the configs of the namespace", (*cli).list},
+	"search": {"EXPR", `List the configs matching a filter expression, such as 'metadata.region = "eu"'`, (*cli).search},
+	"apply":  {"-f FILE", "Create or replace the configs of a JSON or YAML file", (*cli).apply},
+	"edit":   {"NAME", "Edit a config in $EDITOR and store the result", (*cli).edit},
+	"delete": {"NAME", "Delete a config", (*cli).delete},
+	"diff":   {"-f FILE", "Show how the configs of a file differ from the stored ones; exits with 1 when they do", (*cli).diff},
+	"export": {"[-f FILE]", "Write every config of the namespace to a file or stdout", (*cli).export},
+	"import": {"-f FILE", "Import the configs of a file into the namespace", (*cli).importConfigs},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv))
+}
+
+// run runs the command given by args and returns the exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer, getenv func(string) string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stderr)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return 0
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "jsonstorectl: unknown command %q\n\n", args[0])
+		usage(stderr)
+		return exitUsage
+	}
+
+	c := &cli{name: args[0], stdin: stdin, stdout: stdout, stderr: stderr, getenv: getenv,
+		ctx: context.Background()}
+	err := cmd.run(c, args[1:])
+	var usageErr usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errFlags):
+		return exitUsage
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "jsonstorectl %s: %v\nUsage: jsonstorectl %s %s [flags]\n", args[0], err, args[0], cmd.args)
+		return exitUsage
+	case errors.Is(err, errDiffers):
+		return exitFailure
+	default:
+		fmt.Fprintf(stderr, "jsonstorectl %s: %v\n", args[0], err)
+		return exitFailure
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: jsonstorectl COMMAND [flags] [args]\n\nCommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-7s %-10s %s\n", name, commands[name].args, commands[name].summary)
+	}
+	fmt.Fprintf(w, "\nRun jsonstorectl COMMAND -h for the flags of a command.\n")
+}
+
+// usageError is an error in the arguments of a command
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// settings say which server to call and how, as read from the settings file and overridden by
+// the environment and the flags
+type settings struct {
+	Server    string        `yaml:"server"`
+	APIKey    string        `yaml:"apiKey"`
+	Token     string        `yaml:"token"`
+	Namespace string        `yaml:"namespace"`
+	Author    string        `yaml:"author"`
+	Timeout   time.Duration `yaml:"timeout"`
+}
+
+// cli holds the state shared by the commands
+type cli struct {
+	name   string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+	ctx    context.Context
+
+	// Set by the flags
+	settingsFile string
+	flags        settings
+	output       string
+	// Formats -output accepts, when the command has the flag
+	formats []string
+
+	settings settings
+	client   *client.Client
+}
+
+// flagSet returns the flags of a command, starting with those common to every command
+func (c *cli) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("jsonstorectl "+c.name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.StringVar(&c.settingsFile, "config", "",
+		"Settings file (default $JSONSTORECTL_CONFIG, or ~/"+settingsFileName+" when it exists)")
+	fs.StringVar(&c.flags.Server, "server", "", "URL of the server (default $JSONSTORE_SERVER, or "+defaultServer+")")
+	fs.StringVar(&c.flags.APIKey, "api-key", "", "API key (default $JSONSTORE_API_KEY)")
+	fs.StringVar(&c.flags.Token, "token", "", "JWT bearer token (default $JSONSTORE_TOKEN)")
+	fs.StringVar(&c.flags.Namespace, "namespace", "", "Namespace of the configs (default $JSONSTORE_NAMESPACE, or the default namespace)")
+	fs.StringVar(&c.flags.Namespace, "n", "", "Shorthand for -namespace")
+	fs.StringVar(&c.flags.Author, "author", "", "Author recorded in the history of the changes when authentication is disabled (default $USER)")
+	fs.DurationVar(&c.flags.Timeout, "timeout", 0, "Time given to each request (default 10s)")
+	return fs
+}
+
+// outputFlag adds the -o flag choosing among formats, the first of which is the default
+func (c *cli) outputFlag(fs *flag.FlagSet, formats ...string) {
+	c.formats = formats
+	fs.StringVar(&c.output, "output", formats[0], "Output format: "+strings.Join(formats, ", "))
+	fs.StringVar(&c.output, "o", formats[0], "Shorthand for -output")
+}
+
+// parse parses the flags and arguments of a command, which may be interleaved, checks there are
+// as many arguments as wanted and connects to the server
+func (c *cli) parse(fs *flag.FlagSet, args []string, want int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		} else if err != nil {
+			return nil, errFlags
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) != want {
+		return nil, usageError(fmt.Sprintf("expected %d argument(s), got %d", want, len(positional)))
+	}
+	if c.formats != nil && !contains(c.formats, c.output) {
+		return nil, usageError(fmt.Sprintf("unsupported output format %q", c.output))
+	}
+
+	if err := c.loadSettings(); err != nil {
+		return nil, err
+	}
+	cl, err := client.New(c.settings.Server, client.Options{
+		APIKey:  c.settings.APIKey,
+		Token:   c.settings.Token,
+		Timeout: c.settings.Timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.client = cl
+	return positional, nil
+}
+
+// loadSettings reads the settings file, then overrides it with the environment and the flags
+func (c *cli) loadSettings() error {
+	c.settings = settings{Server: defaultServer, Author: c.getenv("USER")}
+
+	path, required := c.settingsFile, true
+	if path == "" {
+		path = c.getenv("JSONSTORECTL_CONFIG")
+	}
+	if home := c.getenv("HOME"); path == "" && home != "" {
+		path, required = filepath.Join(home, settingsFileName), false
+	}
+	data, err := ioutil.ReadFile(path)
+	switch {
+	case path == "", os.IsNotExist(err) && !required:
+	case err != nil:
+		return fmt.Errorf("read settings: %w", err)
+	default:
+		if err := yaml.Unmarshal(data, &c.settings); err != nil {
+			return fmt.Errorf("read settings %s: %w", path, err)
+		}
+	}
+
+	override(&c.settings.Server, c.getenv("JSONSTORE_SERVER"), c.flags.Server)
+	override(&c.settings.APIKey, c.getenv("JSONSTORE_API_KEY"), c.flags.APIKey)
+	override(&c.settings.Token, c.getenv("JSONSTORE_TOKEN"), c.flags.Token)
+	override(&c.settings.Namespace, c.getenv("JSONSTORE_NAMESPACE"), c.flags.Namespace)
+	override(&c.settings.Author, c.flags.Author)
+	if c.flags.Timeout != 0 {
+		c.settings.Timeout = c.flags.Timeout
+	}
+	return nil
+}
+
+// override sets a setting to the last of the values that is not empty, if any
+func override(setting *string, values ...string) {
+	for _, value := range values {
+		if value != "" {
+			*setting = value
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/router"
+	"jsonstore/pkg/service"
+)
+
+const configsYAML = `name: datacenter-1
+metadata:
+  region: eu
+  limits:
+    cpu: 300m
+---
+- name: datacenter-2
+  metadata:
+    region: us
+`
+
+// testCLI runs commands against a server of its own
+type testCLI struct {
+	t   *testing.T
+	dir string
+	env map[string]string
+}
+
+func newTestCLI(t *testing.T) *testCLI {
+	manager := service.NewConfigManager(db.NewConfigRepo(), db.NewSchemaRepo(), db.NewWebhookRepo())
+	server := httptest.NewServer(router.Context{Manager: manager, Timeout: time.Second}.New())
+	t.Cleanup(server.Close)
+	dir, err := ioutil.TempDir("", "jsonstorectl")
+	require.NoError(t, err, "Unexpected temp dir error")
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return &testCLI{t: t, dir: dir, env: map[string]string{"JSONSTORE_SERVER": server.URL, "USER": "alice"}}
+}
+
+// run runs a command and returns its exit code and output
+func (tc *testCLI) run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(""), &stdout, &stderr, func(key string) string { return tc.env[key] })
+	return code, stdout.String(), stderr.String()
+}
+
+func (tc *testCLI) file(name, content string) string {
+	path := filepath.Join(tc.dir, name)
+	require.NoError(tc.t, ioutil.WriteFile(path, []byte(content), 0o600), "Unexpected write error")
+	return path
+}
+
+func TestApplyAndGet(t *testing.T) {
+	tc := newTestCLI(t)
+	path := tc.file("configs.yaml", configsYAML)
+
+	code, created, stderr := tc.run("apply", "-f", path)
+	require.Equal(t, 0, code, "Incorrect exit code: %s", stderr)
+	_, unchanged, _ := tc.run("apply", "-f", path)
+	code, out, _ := tc.run("get", "datacenter-1")
+
+	assert.Equal(t, "datacenter-1 created (version 1)\ndatacenter-2 created (version 1)\n", created, "Incorrect apply output")
+	assert.Equal(t, "datacenter-1 unchanged\ndatacenter-2 unchanged\n", unchanged, "Incorrect apply output")
+	assert.Equal(t, 0, code, "Incorrect exit code")
+	var config contract.GetConfigResponse
+	require.NoError(t, json.Unmarshal([]byte(out), &config), "Unexpected decode error")
+	assert.Equal(t, int64(1), config.Version, "Incorrect version")
+	assert.Equal(t, "alice", config.UpdatedBy, "Incorrect author")
+	assert.Equal(t, map[string]interface{}{"region": "eu", "limits": map[string]interface{}{"cpu": "300m"}},
+		config.Metadata, "Incorrect metadata")
+}
+
+func TestGetAsYAML(t *testing.T) {
+	tc := newTestCLI(t)
+	tc.run("apply", "-f", tc.file("configs.yaml", configsYAML))
+
+	code, out, _ := tc.run("get", "datacenter-2", "-o", "yaml", "--namespace", "")
+
+	assert.Equal(t, 0, code, "Incorrect exit code")
+	assert.Contains(t, out, "name: datacenter-2\n", "Incorrect name")
+	assert.Contains(t, out, "metadata:\n  region: us\n", "Incorrect metadata")
+}
+
+func TestGetForMissingConfig(t *testing.T) {
+	tc := newTestCLI(t)
+
+	code, _, stderr := tc.run("get", "datacenter-1")
+
+	assert.Equal(t, exitFailure, code, "Incorrect exit code")
+	assert.Contains(t, stderr, "CONFIG_NOT_FOUND", "Incorrect error")
+}
+
+func TestListAndSearch(t *testing.T) {
+	tc := newTestCLI(t)
+	tc.run("apply", "-f", tc.file("configs.yaml", configsYAML))
+
+	_, listed, _ := tc.run("list", "-o", "json")
+	_, limited, _ := tc.run("list", "--limit", "1", "-o", "json")
+	code, found, stderr := tc.run("search", `metadata.region = "us"`)
+
+	var configs, first []contract.GetConfigResponse
+	require.NoError(t, json.Unmarshal([]byte(listed), &configs), "Unexpected decode error")
+	require.NoError(t, json.Unmarshal([]byte(limited), &first), "Unexpected decode error")
+	assert.Len(t, configs, 2, "Incorrect number of listed configs")
+	require.Len(t, first, 1, "Incorrect number of limited configs")
+	assert.Equal(t, "datacenter-1", first[0].Name, "Incorrect limited config")
+	require.Equal(t, 0, code, "Incorrect exit code: %s", stderr)
+	lines := strings.Split(strings.TrimSpace(found), "\n")
+	require.Len(t, lines, 2, "Incorrect table")
+	assert.Equal(t, []string{"NAME", "VERSION", "UPDATED", "UPDATED", "BY"}, strings.Fields(lines[0]), "Incorrect header")
+	assert.Equal(t, []string{"datacenter-2", "1"}, strings.Fields(lines[1])[:2], "Incorrect row")
+}
+
+func TestDiff(t *testing.T) {
+	tc := newTestCLI(t)
+	tc.run("apply", "-f", tc.file("configs.yaml", configsYAML))
+	path := tc.file("changed.json", `[{"name": "datacenter-1", "metadata": {"region": "eu", "limits": {"cpu": "400m"}}},
+{"name": "datacenter-2", "metadata": {"region": "us"}}, {"name": "datacenter-3", "metadata": {"region": "ap"}}]`)
+
+	code, out, _ := tc.run("diff", "-f", path)
+
+	assert.Equal(t, exitFailure, code, "Incorrect exit code")
+	assert.Equal(t, "--- datacenter-1 (version 1)\n+++ datacenter-1 ("+path+")\n"+
+		"- /metadata/limits/cpu: \"300m\"\n+ /metadata/limits/cpu: \"400m\"\n"+
+		"--- datacenter-3 (missing)\n+++ datacenter-3 ("+path+")\n"+
+		"+ /metadata/region: \"ap\"\n", out, "Incorrect diff")
+}
+
+func TestDiffForSameConfigs(t *testing.T) {
+	tc := newTestCLI(t)
+	path := tc.file("configs.yaml", configsYAML)
+	tc.run("apply", "-f", path)
+
+	code, out, _ := tc.run("diff", "-f", path)
+
+	assert.Equal(t, 0, code, "Incorrect exit code")
+	assert.Empty(t, out, "Unexpected diff")
+}
+
+func TestEdit(t *testing.T) {
+	tc := newTestCLI(t)
+	tc.run("apply", "-f", tc.file("configs.yaml", configsYAML))
+	tc.env["EDITOR"] = "sed -i s/us$/ap/"
+
+	code, out, stderr := tc.run("edit", "datacenter-2")
+	_, get, _ := tc.run("get", "datacenter-2", "-o", "yaml")
+
+	require.Equal(t, 0, code, "Incorrect exit code: %s", stderr)
+	assert.Equal(t, "datacenter-2 edited (version 2)\n", out, "Incorrect edit output")
+	assert.Contains(t, get, "region: ap", "Edited config was not stored")
+}
+
+func TestEditWithoutChanges(t *testing.T) {
+	tc := newTestCLI(t)
+	tc.run("apply", "-f", tc.file("configs.yaml", configsYAML))
+	tc.env["EDITOR"] = "true"
+
+	code, out, _ := tc.run("edit", "datacenter-2", "-o", "json")
+
+	assert.Equal(t, 0, code, "Incorrect exit code")
+	assert.Equal(t, "Edit cancelled, no changes made\n", out, "Incorrect edit output")
+}
+
+func TestDelete(t *testing.T) {
+	tc := newTestCLI(t)
+	tc.run("apply", "-f", tc.file("configs.yaml", configsYAML))
+
+	failed, _, _ := tc.run("delete", "datacenter-1", "--version", "2")
+	code, out, _ := tc.run("delete", "datacenter-1", "--version", "1")
+	missing, _, _ := tc.run("get", "datacenter-1")
+
+	assert.Equal(t, exitFailure, failed, "Config was deleted at another version")
+	assert.Equal(t, 0, code, "Incorrect exit code")
+	assert.Equal(t, "datacenter-1 deleted (version 2)\n", out, "Incorrect delete output")
+	assert.Equal(t, exitFailure, missing, "Config was not deleted")
+}
+
+func TestExportAndImport(t *testing.T) {
+	tc := newTestCLI(t)
+	tc.run("apply", "-f", tc.file("configs.yaml", configsYAML))
+	path := filepath.Join(tc.dir, "export.yaml")
+
+	_, exported, _ := tc.run("export", "-f", path, "-o", "yaml")
+	tc.run("delete", "datacenter-2")
+	_, dryRun, _ := tc.run("import", "-f", path, "--mode", "replace-all", "--dry-run", "-o", "json")
+	code, imported, stderr := tc.run("import", "-f", path)
+
+	assert.Equal(t, "2 configs exported to "+path+"\n", exported, "Incorrect export output")
+	var res contract.ImportResponse
+	require.NoError(t, json.Unmarshal([]byte(dryRun), &res), "Unexpected decode error")
+	assert.True(t, res.DryRun, "Import was not a dry run")
+	assert.Equal(t, 1, res.Created, "Incorrect number of created configs")
+	require.Equal(t, 0, code, "Incorrect exit code: %s", stderr)
+	assert.Contains(t, imported, "1 created, 0 updated, 1 unchanged, 0 deleted, 0 invalid, 0 failed\n", "Incorrect summary")
+}
+
+func TestSettingsFile(t *testing.T) {
+	tc := newTestCLI(t)
+	server := tc.env["JSONSTORE_SERVER"]
+	delete(tc.env, "JSONSTORE_SERVER")
+	tc.env["HOME"] = tc.dir
+	tc.file(settingsFileName, "server: "+server+"\ntimeout: 5s\n")
+
+	fromFile, _, stderr := tc.run("list")
+	fromFlag, _, _ := tc.run("list", "--server", "http://127.0.0.1:1", "--timeout", "10ms")
+
+	assert.Equal(t, 0, fromFile, "Incorrect exit code: %s", stderr)
+	assert.Equal(t, exitFailure, fromFlag, "Server of the settings file was not overridden")
+}
+
+func TestUsageErrors(t *testing.T) {
+	tc := newTestCLI(t)
+
+	for _, args := range [][]string{
+		{},
+		{"unknown"},
+		{"get"},
+		{"get", "datacenter-1", "datacenter-2"},
+		{"get", "datacenter-1", "-o", "xml"},
+		{"export", "-o", "table"},
+		{"apply"},
+		{"list", "--unknown"},
+	} {
+		code, _, _ := tc.run(args...)
+		assert.Equal(t, exitUsage, code, "Incorrect exit code of %v", args)
+	}
+}
+
+func TestReadConfigs(t *testing.T) {
+	tc := newTestCLI(t)
+
+	for name, content := range map[string]string{
+		"configs.yaml":   configsYAML,
+		"configs.json":   `[{"name": "datacenter-1"}, {"name": "datacenter-2"}]`,
+		"configs.ndjson": "{\"name\": \"datacenter-1\"}\n{\"name\": \"datacenter-2\"}\n",
+		"configs":        `{"name": "datacenter-1"} {"name": "datacenter-2"}`,
+	} {
+		configs, err := (&cli{}).readConfigs(tc.file(name, content))
+		assert.NoError(t, err, "Unexpected read error of %s", name)
+		assert.Len(t, configs, 2, "Incorrect number of configs in %s", name)
+	}
+	_, err := (&cli{}).readConfigs(tc.file("invalid.yaml", "- metadata: {}"))
+	assert.Error(t, err, "Missing read error for a config without a name")
+}
@@ -9,4 +9,5 @@ require (
 	github.com/stretchr/testify v1.7.0
 	github.com/tidwall/gjson v1.9.1
 	go.uber.org/zap v1.19.1
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
@@ -0,0 +1,106 @@
+// Package audit records who changed which config, when, and from what to what.
+package audit
+
+import (
+	"sync"
+	"time"
+
+	"jsonstore/pkg/patch"
+)
+
+// Entry records a request writing a config, whether it succeeded or not
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Authenticated principal making the request, or the author it claims when authentication is
+	// disabled
+	Principal  string `json:"principal,omitempty"`
+	RemoteAddr string `json:"remoteAddr"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	// Kind of write, such as create, update or delete
+	Operation string  `json:"operation"`
+	Namespace string  `json:"namespace"`
+	Name      string  `json:"name,omitempty"`
+	Outcome   Outcome `json:"outcome"`
+
+	// Version the write produced and the metadata before and after it, only set when it succeeded.
+	// The metadata of a config that did not exist, or no longer does, is omitted.
+	Version int64             `json:"version,omitempty"`
+	Before  interface{}       `json:"before,omitempty"`
+	After   interface{}       `json:"after,omitempty"`
+	Diff    []patch.Operation `json:"diff,omitempty"`
+}
+
+// Outcome is the status of the reply to a request, along with its error code if it failed
+type Outcome struct {
+	Status int    `json:"status"`
+	Code   string `json:"code,omitempty"`
+}
+
+// Filter selects entries. Empty fields select every entry.
+type Filter struct {
+	Namespace string
+	Name      string
+	// Only entries recorded at or after Since are selected
+	Since time.Time
+	// Maximum number of entries selected, the latest ones, which are still listed oldest first
+	Limit int
+}
+
+func (f Filter) matches(entry Entry) bool {
+	return (f.Namespace == "" || entry.Namespace == f.Namespace) &&
+		(f.Name == "" || entry.Name == f.Name) &&
+		!entry.Time.Before(f.Since)
+}
+
+// Log stores audit entries in the order they are recorded
+type Log interface {
+	Record(Entry) error
+	Query(Filter) ([]Entry, error)
+}
+
+// memoryLog keeps the latest entries in memory. It is safe for concurrent use.
+type memoryLog struct {
+	sync.RWMutex
+	entries  []Entry
+	capacity int
+}
+
+// NewMemoryLog returns a log keeping the latest capacity entries in memory
+func NewMemoryLog(capacity int) Log {
+	return &memoryLog{capacity: capacity}
+}
+
+func (l *memoryLog) Record(entry Entry) error {
+	l.Lock()
+	defer l.Unlock()
+
+	l.entries = append(l.entries, entry)
+	if len(l.entries) > l.capacity {
+		l.entries = l.entries[len(l.entries)-l.capacity:]
+	}
+	return nil
+}
+
+func (l *memoryLog) Query(filter Filter) ([]Entry, error) {
+	l.RLock()
+	defer l.RUnlock()
+
+	selected := []Entry{}
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(selected) == filter.Limit {
+			break
+		}
+		if filter.matches(l.entries[i]) {
+			selected = append(selected, l.entries[i])
+		}
+	}
+	reverse(selected)
+	return selected, nil
+}
+
+func reverse(entries []Entry) {
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+}
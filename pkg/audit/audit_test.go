@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var auditStart = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func testEntry(name string, minutes int) Entry {
+	return Entry{
+		Time:      auditStart.Add(time.Duration(minutes) * time.Minute),
+		Principal: "alice",
+		Method:    "PUT",
+		Operation: "update",
+		Namespace: "default",
+		Name:      name,
+		Outcome:   Outcome{Status: 200},
+	}
+}
+
+func TestMemoryLog(t *testing.T) {
+	log := NewMemoryLog(3)
+	for i, name := range []string{"dc-1", "dc-2", "dc-1", "dc-1"} {
+		require.NoError(t, log.Record(testEntry(name, i)), "Unexpected record error")
+	}
+
+	all, err := log.Query(Filter{})
+	require.NoError(t, err, "Unexpected query error")
+	assert.Equal(t, []Entry{testEntry("dc-2", 1), testEntry("dc-1", 2), testEntry("dc-1", 3)}, all,
+		"Incorrect entries")
+
+	selected, err := log.Query(Filter{Name: "dc-1", Since: auditStart.Add(2 * time.Minute), Limit: 1})
+	require.NoError(t, err, "Unexpected query error")
+	assert.Equal(t, []Entry{testEntry("dc-1", 3)}, selected, "Incorrect entries")
+}
+
+func TestFilterMatches(t *testing.T) {
+	entry := testEntry("dc-1", 5)
+
+	assert.True(t, Filter{}.matches(entry), "Empty filter does not match")
+	assert.True(t, Filter{Namespace: "default", Name: "dc-1", Since: entry.Time}.matches(entry), "Filter does not match")
+	assert.False(t, Filter{Namespace: "team-a"}.matches(entry), "Filter matches other namespace")
+	assert.False(t, Filter{Name: "dc-2"}.matches(entry), "Filter matches other name")
+	assert.False(t, Filter{Since: entry.Time.Add(time.Nanosecond)}.matches(entry), "Filter matches older entry")
+}
@@ -0,0 +1,229 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Longest entry read back from the files
+const maxEntrySize = 16 << 20
+
+// fileLog appends entries as JSON lines to a file, fsynced before Record returns. Once the file
+// would grow past maxSize it is rotated: path is renamed path.1, path.1 is renamed path.2, and so
+// on, the oldest of the maxBackups rotated files being removed.
+type fileLog struct {
+	sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+var _ io.Closer = (*fileLog)(nil)
+
+// NewFileLog returns a log appending to the file at path, which is created if needed
+func NewFileLog(path string, maxSize int64, maxBackups int) (Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create audit dir: %w", err)
+	}
+
+	l := &fileLog{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *fileLog) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stat audit log: %w", err)
+	}
+	l.file, l.size = file, info.Size()
+
+	// Terminates a line torn by a crash, so that it does not swallow the next entry
+	if l.size > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, l.size-1); err != nil {
+			file.Close()
+			return fmt.Errorf("read audit log: %w", err)
+		}
+		if last[0] != '\n' {
+			n, err := file.Write([]byte{'\n'})
+			l.size += int64(n)
+			if err != nil {
+				file.Close()
+				return fmt.Errorf("write audit log: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (l *fileLog) Record(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.Lock()
+	defer l.Unlock()
+
+	if l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("write audit entry: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("sync audit log: %w", err)
+	}
+	return nil
+}
+
+// rotate shifts the rotated files by one and starts a new file
+func (l *fileLog) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("close audit log: %w", err)
+	}
+
+	// Without backups, this removes the current file
+	if err := os.Remove(l.backup(l.maxBackups)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove audit log: %w", err)
+	}
+	for i := l.maxBackups; i > 0; i-- {
+		if err := os.Rename(l.backup(i-1), l.backup(i)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("rotate audit log: %w", err)
+		}
+	}
+
+	return l.open()
+}
+
+// backup returns the path of the i-th rotated file, the current file being the 0-th
+func (l *fileLog) backup(i int) string {
+	if i == 0 {
+		return l.path
+	}
+	return fmt.Sprintf("%s.%d", l.path, i)
+}
+
+// Query scans the current file, then the rotated files from the newest, until the limit is
+// reached. The files are opened while the log is locked, and read once it is unlocked so that
+// recording entries does not wait for the scan: rotating renames or removes the files but leaves
+// them readable through the open descriptors.
+func (l *fileLog) Query(filter Filter) ([]Entry, error) {
+	files, size, err := l.openAll()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+
+	var selected []Entry
+	for i, file := range files {
+		var r io.Reader = file
+		if i == 0 {
+			// Entries recorded after the files were opened are left out, as they may be partly written
+			r = io.LimitReader(file, size)
+		}
+		limit := 0
+		if filter.Limit > 0 {
+			limit = filter.Limit - len(selected)
+		}
+		matches, err := scan(r, file.Name(), filter, limit)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(matches, selected...)
+		if filter.Limit > 0 && len(selected) == filter.Limit {
+			break
+		}
+	}
+	if selected == nil {
+		selected = []Entry{}
+	}
+	return selected, nil
+}
+
+// openAll opens the current file and the rotated files that exist, the newest first, along with
+// the size of the current file
+func (l *fileLog) openAll() ([]*os.File, int64, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	var files []*os.File
+	for i := 0; i <= l.maxBackups; i++ {
+		file, err := os.Open(l.backup(i))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			for _, file := range files {
+				file.Close()
+			}
+			return nil, 0, fmt.Errorf("open audit log: %w", err)
+		}
+		files = append(files, file)
+	}
+	return files, l.size, nil
+}
+
+// scan returns the entries of a file selected by filter, only the last limit ones unless limit is 0
+func scan(r io.Reader, path string, filter Filter, limit int) ([]Entry, error) {
+	var selected []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxEntrySize)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// A line torn by a crash while it was written
+			zap.S().Warnf("Audit log %s: skipping malformed entry: %v", path, err)
+			continue
+		}
+		if !filter.matches(entry) {
+			continue
+		}
+		selected = append(selected, entry)
+		// Drops the earlier entries now and then rather than on every append
+		if limit > 0 && len(selected) == 2*limit {
+			selected = append(selected[:0], selected[limit:]...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read audit log %s: %w", path, err)
+	}
+	if limit > 0 && len(selected) > limit {
+		selected = selected[len(selected)-limit:]
+	}
+	return selected, nil
+}
+
+func (l *fileLog) Close() error {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.file.Close()
+}
@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err, "Unexpected create temp dir error")
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func entrySize(t *testing.T, entry Entry) int64 {
+	line, err := json.Marshal(entry)
+	require.NoError(t, err, "Unexpected encode error")
+	return int64(len(line) + 1)
+}
+
+func closeLog(t *testing.T, log Log) {
+	require.NoError(t, log.(io.Closer).Close(), "Unexpected close error")
+}
+
+func TestFileLog(t *testing.T) {
+	path := filepath.Join(tempDir(t), "audit", "audit.log")
+	log, err := NewFileLog(path, 1<<20, 2)
+	require.NoError(t, err, "Unexpected new file log error")
+	entries := []Entry{testEntry("dc-1", 0), testEntry("dc-2", 1)}
+	for _, entry := range entries {
+		require.NoError(t, log.Record(entry), "Unexpected record error")
+	}
+	closeLog(t, log)
+
+	log, err = NewFileLog(path, 1<<20, 2)
+	require.NoError(t, err, "Unexpected reopen file log error")
+	t.Cleanup(func() { closeLog(t, log) })
+	require.NoError(t, log.Record(testEntry("dc-1", 2)), "Unexpected record error")
+
+	selected, err := log.Query(Filter{Name: "dc-1"})
+	require.NoError(t, err, "Unexpected query error")
+	assert.Equal(t, []Entry{testEntry("dc-1", 0), testEntry("dc-1", 2)}, selected, "Incorrect entries")
+}
+
+func TestFileLogRotation(t *testing.T) {
+	path := filepath.Join(tempDir(t), "audit.log")
+	// Room for two entries per file
+	log, err := NewFileLog(path, 2*entrySize(t, testEntry("dc-1", 0)), 2)
+	require.NoError(t, err, "Unexpected new file log error")
+	t.Cleanup(func() { closeLog(t, log) })
+
+	for i := 0; i < 7; i++ {
+		require.NoError(t, log.Record(testEntry("dc-1", i)), "Unexpected record error")
+	}
+
+	for _, name := range []string{"audit.log", "audit.log.1", "audit.log.2"} {
+		assert.FileExists(t, filepath.Join(filepath.Dir(path), name), "Missing audit log file")
+	}
+	assert.NoFileExists(t, path+".3", "Unexpected audit log file")
+	all, err := log.Query(Filter{})
+	require.NoError(t, err, "Unexpected query error")
+	assert.Equal(t, []Entry{testEntry("dc-1", 2), testEntry("dc-1", 3), testEntry("dc-1", 4), testEntry("dc-1", 5),
+		testEntry("dc-1", 6)}, all, "Incorrect entries")
+
+	limited, err := log.Query(Filter{Limit: 3})
+	require.NoError(t, err, "Unexpected query error")
+	assert.Equal(t, []Entry{testEntry("dc-1", 4), testEntry("dc-1", 5), testEntry("dc-1", 6)}, limited,
+		"Incorrect entries")
+
+	recent, err := log.Query(Filter{Limit: 1})
+	require.NoError(t, err, "Unexpected query error")
+	assert.Equal(t, []Entry{testEntry("dc-1", 6)}, recent, "Incorrect entries")
+}
+
+func TestFileLogQueryForLatestEntries(t *testing.T) {
+	log, err := NewFileLog(filepath.Join(tempDir(t), "audit.log"), 1<<20, 1)
+	require.NoError(t, err, "Unexpected new file log error")
+	t.Cleanup(func() { closeLog(t, log) })
+	for i := 0; i < 10; i++ {
+		require.NoError(t, log.Record(testEntry("dc-1", i)), "Unexpected record error")
+		require.NoError(t, log.Record(testEntry("dc-2", i)), "Unexpected record error")
+	}
+
+	selected, err := log.Query(Filter{Name: "dc-1", Limit: 3})
+
+	require.NoError(t, err, "Unexpected query error")
+	assert.Equal(t, []Entry{testEntry("dc-1", 7), testEntry("dc-1", 8), testEntry("dc-1", 9)}, selected,
+		"Incorrect entries")
+}
+
+func TestFileLogForTornEntry(t *testing.T) {
+	path := filepath.Join(tempDir(t), "audit.log")
+	line, err := json.Marshal(testEntry("dc-1", 0))
+	require.NoError(t, err, "Unexpected encode error")
+	require.NoError(t, ioutil.WriteFile(path, append(append(line, '\n'), line[:10]...), 0o600),
+		"Unexpected write error")
+
+	log, err := NewFileLog(path, 1<<20, 1)
+	require.NoError(t, err, "Unexpected new file log error")
+	t.Cleanup(func() { closeLog(t, log) })
+	require.NoError(t, log.Record(testEntry("dc-2", 1)), "Unexpected record error")
+
+	all, err := log.Query(Filter{})
+	require.NoError(t, err, "Unexpected query error")
+	assert.Equal(t, []Entry{testEntry("dc-1", 0), testEntry("dc-2", 1)}, all, "Incorrect entries")
+}
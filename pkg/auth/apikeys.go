@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Header carrying the API key of a request
+const APIKeyHeader = "X-API-Key"
+
+// APIKey is a static key identifying a principal
+type APIKey struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// apiKeys authenticates requests by the key in their X-API-Key header. Keys are held by their
+// SHA-256 digest, so that looking a key up takes the same time whatever its value.
+type apiKeys struct {
+	names map[[sha256.Size]byte]string
+}
+
+// NewAPIKeys returns an authenticator accepting the keys of a JSON array of APIKey, such as
+// [{"name": "ci", "key": "..."}]
+func NewAPIKeys(data []byte) (Authenticator, error) {
+	var keys []APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("decode api keys: %w", err)
+	}
+
+	names := make(map[[sha256.Size]byte]string, len(keys))
+	for i, key := range keys {
+		if key.Name == "" || key.Key == "" {
+			return nil, fmt.Errorf("api key %d: missing name or key", i)
+		}
+		digest := sha256.Sum256([]byte(key.Key))
+		if name, ok := names[digest]; ok {
+			return nil, fmt.Errorf("api key %s: same key as %s", key.Name, name)
+		}
+		names[digest] = key.Name
+	}
+
+	return apiKeys{names: names}, nil
+}
+
+func (a apiKeys) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	name, ok := a.names[sha256.Sum256([]byte(key))]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown api key", ErrInvalidCredentials)
+	}
+	return &Principal{Name: name, Method: MethodAPIKey}, nil
+}
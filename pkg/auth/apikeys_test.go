@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeys(t *testing.T) {
+	keys, err := NewAPIKeys([]byte(`[{"name": "ci", "key": "s3cr3t"}, {"name": "ops", "key": "0ps"}]`))
+	require.NoError(t, err, "Unexpected new api keys error")
+	req, err := http.NewRequest(http.MethodGet, "/configs", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req.Header.Set(APIKeyHeader, "0ps")
+
+	principal, err := keys.Authenticate(req)
+
+	require.NoError(t, err, "Unexpected authenticate error")
+	assert.Equal(t, &Principal{Name: "ops", Method: MethodAPIKey}, principal, "Incorrect principal")
+}
+
+func TestAPIKeysForMissingKey(t *testing.T) {
+	keys, err := NewAPIKeys([]byte(`[{"name": "ci", "key": "s3cr3t"}]`))
+	require.NoError(t, err, "Unexpected new api keys error")
+	req, err := http.NewRequest(http.MethodGet, "/configs", nil)
+	require.NoError(t, err, "Unexpected create request error")
+
+	_, err = keys.Authenticate(req)
+
+	assert.True(t, errors.Is(err, ErrNoCredentials), "Incorrect authenticate error")
+}
+
+func TestAPIKeysForUnknownKey(t *testing.T) {
+	keys, err := NewAPIKeys([]byte(`[{"name": "ci", "key": "s3cr3t"}]`))
+	require.NoError(t, err, "Unexpected new api keys error")
+	req, err := http.NewRequest(http.MethodGet, "/configs", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req.Header.Set(APIKeyHeader, "guess")
+
+	_, err = keys.Authenticate(req)
+
+	assert.True(t, errors.Is(err, ErrInvalidCredentials), "Incorrect authenticate error")
+}
+
+func TestNewAPIKeysForInvalidKeys(t *testing.T) {
+	for name, data := range map[string]string{
+		"malformed":     `{"name": "ci"}`,
+		"missing name":  `[{"key": "s3cr3t"}]`,
+		"missing key":   `[{"name": "ci"}]`,
+		"duplicate key": `[{"name": "ci", "key": "s3cr3t"}, {"name": "ops", "key": "s3cr3t"}]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewAPIKeys([]byte(data))
+
+			assert.Error(t, err, "Expected new api keys error")
+		})
+	}
+}
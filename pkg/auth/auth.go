@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+var (
+	// Returned by an authenticator when a request carries none of the credentials it handles
+	ErrNoCredentials = errors.New("no credentials")
+	// Returned when the credentials of a request are not valid
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Methods a principal can be authenticated with
+const (
+	MethodAPIKey = "api-key"
+	MethodJWT    = "jwt"
+)
+
+// Principal is the identity a request is made on behalf of
+type Principal struct {
+	Name   string
+	Method string
+}
+
+// Authenticator identifies the principal making a request from its credentials. It fails with
+// ErrNoCredentials when the request carries none of the credentials it handles, so that another
+// authenticator can be tried.
+type Authenticator interface {
+	Authenticate(*http.Request) (*Principal, error)
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying a principal
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the principal carried by ctx, if any
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
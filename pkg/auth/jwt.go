@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// JWTOptions configures the verification of JWT bearer tokens. A token is only accepted when
+// signed with the algorithm of a configured key, so that an RS256 public key cannot be used as an
+// HS256 secret.
+type JWTOptions struct {
+	// Key of HS256 signatures; HS256 tokens are rejected when empty
+	Secret []byte
+	// Key of RS256 signatures; RS256 tokens are rejected when nil
+	PublicKey *rsa.PublicKey
+	// Expected iss and aud claims, which are not checked when empty
+	Issuer   string
+	Audience string
+	// Clock skew tolerated when checking the exp and nbf claims
+	Leeway time.Duration
+}
+
+// jwtVerifier authenticates requests by the JWT in their Authorization header. The principal is
+// the subject of the token, which must expire.
+type jwtVerifier struct {
+	opts JWTOptions
+	now  func() time.Time
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+type jwtClaims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+}
+
+// audience is the aud claim, which is either a string or an array of strings
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return errors.New("aud is neither a string nor an array of strings")
+	}
+	*a = multiple
+	return nil
+}
+
+// NewJWT returns an authenticator accepting the bearer tokens signed by one of the keys of opts
+func NewJWT(opts JWTOptions) (Authenticator, error) {
+	if len(opts.Secret) == 0 && opts.PublicKey == nil {
+		return nil, errors.New("jwt: no key to verify tokens with")
+	}
+
+	return jwtVerifier{opts: opts, now: time.Now}, nil
+}
+
+// ParseRSAPublicKey decodes a PEM encoded RSA public key, either in PKIX or PKCS #1 form
+func ParseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key of type %T is not an RSA key", key)
+		}
+		return rsaKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+func (v jwtVerifier) Authenticate(r *http.Request) (*Principal, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, ErrNoCredentials
+	}
+
+	claims, err := v.verify(strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return &Principal{Name: claims.Subject, Method: MethodJWT}, nil
+}
+
+// verify checks the signature and claims of a token and returns its claims
+func (v jwtVerifier) verify(token string) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token is not a JWS in compact serialization")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("decode header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("decode signature: %w", err)
+	}
+	if err := v.verifySignature(header.Alg, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("decode claims: %w", err)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+func (v jwtVerifier) verifySignature(alg, signed string, signature []byte) error {
+	switch {
+	case alg == "HS256" && len(v.opts.Secret) > 0:
+		mac := hmac.New(sha256.New, v.opts.Secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case alg == "RS256" && v.opts.PublicKey != nil:
+		digest := sha256.Sum256([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(v.opts.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("invalid signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+}
+
+func (v jwtVerifier) checkClaims(claims jwtClaims) error {
+	now := v.now()
+	switch {
+	case claims.Subject == "":
+		return errors.New("missing sub claim")
+	case claims.ExpiresAt == nil:
+		return errors.New("missing exp claim")
+	case now.Add(-v.opts.Leeway).After(unixTime(*claims.ExpiresAt)):
+		return errors.New("token expired")
+	case claims.NotBefore != nil && now.Add(v.opts.Leeway).Before(unixTime(*claims.NotBefore)):
+		return errors.New("token not valid yet")
+	case v.opts.Issuer != "" && claims.Issuer != v.opts.Issuer:
+		return fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	case v.opts.Audience != "" && !claims.Audience.contains(v.opts.Audience):
+		return errors.New("token is not meant for this audience")
+	}
+
+	return nil
+}
+
+func (a audience) contains(value string) bool {
+	for _, aud := range a {
+		if aud == value {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	jwtSecret = []byte("jwt-secret")
+	jwtNow    = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	require.NoError(t, err, "Unexpected encode error")
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func hs256Token(t *testing.T, secret []byte, claims map[string]interface{}) string {
+	signed := encodeSegment(t, map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func rs256Token(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	signed := encodeSegment(t, map[string]string{"alg": "RS256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err, "Unexpected sign error")
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub": "alice",
+		"iss": "https://issuer.example",
+		"aud": []string{"jsonstore", "other"},
+		"exp": jwtNow.Add(time.Hour).Unix(),
+		"nbf": jwtNow.Add(-time.Hour).Unix(),
+	}
+}
+
+func newTestJWT(t *testing.T, opts JWTOptions) Authenticator {
+	authenticator, err := NewJWT(opts)
+	require.NoError(t, err, "Unexpected new jwt error")
+	verifier := authenticator.(jwtVerifier)
+	verifier.now = func() time.Time { return jwtNow }
+	return verifier
+}
+
+func authenticateBearer(t *testing.T, authenticator Authenticator, token string) (*Principal, error) {
+	req, err := http.NewRequest(http.MethodGet, "/configs", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return authenticator.Authenticate(req)
+}
+
+func TestJWTWithHS256(t *testing.T) {
+	verifier := newTestJWT(t, JWTOptions{Secret: jwtSecret, Issuer: "https://issuer.example", Audience: "jsonstore"})
+
+	principal, err := authenticateBearer(t, verifier, hs256Token(t, jwtSecret, validClaims()))
+
+	require.NoError(t, err, "Unexpected authenticate error")
+	assert.Equal(t, &Principal{Name: "alice", Method: MethodJWT}, principal, "Incorrect principal")
+}
+
+func TestJWTWithRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err, "Unexpected generate key error")
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err, "Unexpected marshal key error")
+	publicKey, err := ParseRSAPublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	require.NoError(t, err, "Unexpected parse key error")
+	verifier := newTestJWT(t, JWTOptions{PublicKey: publicKey})
+
+	principal, err := authenticateBearer(t, verifier, rs256Token(t, key, validClaims()))
+	require.NoError(t, err, "Unexpected authenticate error")
+	assert.Equal(t, &Principal{Name: "alice", Method: MethodJWT}, principal, "Incorrect principal")
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err, "Unexpected generate key error")
+	_, err = authenticateBearer(t, verifier, rs256Token(t, other, validClaims()))
+	assert.True(t, errors.Is(err, ErrInvalidCredentials), "Incorrect authenticate error")
+}
+
+func TestJWTForMissingToken(t *testing.T) {
+	verifier := newTestJWT(t, JWTOptions{Secret: jwtSecret})
+
+	_, err := authenticateBearer(t, verifier, "")
+
+	assert.True(t, errors.Is(err, ErrNoCredentials), "Incorrect authenticate error")
+}
+
+func TestJWTForInvalidTokens(t *testing.T) {
+	verifier := newTestJWT(t, JWTOptions{Secret: jwtSecret, Issuer: "https://issuer.example", Audience: "jsonstore",
+		Leeway: time.Minute})
+	withClaim := func(key string, value interface{}) map[string]interface{} {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+	unsigned := encodeSegment(t, map[string]string{"alg": "none"}) + "." + encodeSegment(t, validClaims()) + "."
+
+	for name, token := range map[string]string{
+		"malformed":        "not-a-jwt",
+		"wrong secret":     hs256Token(t, []byte("other"), validClaims()),
+		"unsigned":         unsigned,
+		"expired":          hs256Token(t, jwtSecret, withClaim("exp", jwtNow.Add(-2*time.Minute).Unix())),
+		"missing exp":      hs256Token(t, jwtSecret, withClaim("exp", nil)),
+		"missing sub":      hs256Token(t, jwtSecret, withClaim("sub", nil)),
+		"not valid yet":    hs256Token(t, jwtSecret, withClaim("nbf", jwtNow.Add(2*time.Minute).Unix())),
+		"wrong issuer":     hs256Token(t, jwtSecret, withClaim("iss", "https://other.example")),
+		"wrong audience":   hs256Token(t, jwtSecret, withClaim("aud", "other")),
+		"missing audience": hs256Token(t, jwtSecret, withClaim("aud", nil)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := authenticateBearer(t, verifier, token)
+
+			assert.True(t, errors.Is(err, ErrInvalidCredentials), "Incorrect authenticate error: %v", err)
+		})
+	}
+}
+
+func TestJWTWithinLeeway(t *testing.T) {
+	verifier := newTestJWT(t, JWTOptions{Secret: jwtSecret, Leeway: time.Minute})
+	claims := validClaims()
+	claims["exp"] = jwtNow.Add(-30 * time.Second).Unix()
+
+	_, err := authenticateBearer(t, verifier, hs256Token(t, jwtSecret, claims))
+
+	assert.NoError(t, err, "Unexpected authenticate error")
+}
+
+func TestNewJWTForMissingKey(t *testing.T) {
+	_, err := NewJWT(JWTOptions{Issuer: "https://issuer.example"})
+
+	assert.Error(t, err, "Expected new jwt error")
+}
+
+func TestParseRSAPublicKeyForPKCS1(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err, "Unexpected generate key error")
+
+	publicKey, err := ParseRSAPublicKey(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY",
+		Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)}))
+
+	require.NoError(t, err, "Unexpected parse key error")
+	assert.Equal(t, &key.PublicKey, publicKey, "Incorrect public key")
+}
@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sync"
+)
+
+// Returned when the principal of a request is not allowed to act upon a resource
+var ErrForbidden = errors.New("forbidden")
+
+// Role is a set of actions granted to a principal
+type Role string
+
+// Roles in increasing order of privilege, each granting the actions of the previous one
+const (
+	// Reads configs, their history and changes, namespaces, indexes and schemas
+	RoleReader Role = "reader"
+	// Also writes, rolls back and deletes configs
+	RoleEditor Role = "editor"
+	// Also manages namespaces, indexes, schemas and webhooks
+	RoleAdmin Role = "admin"
+)
+
+// Action is the kind of operation a request makes
+type Action string
+
+const (
+	ActionRead  Action = "read"
+	ActionWrite Action = "write"
+	ActionAdmin Action = "admin"
+)
+
+var grants = map[Role]map[Action]bool{
+	RoleReader: {ActionRead: true},
+	RoleEditor: {ActionRead: true, ActionWrite: true},
+	RoleAdmin:  {ActionRead: true, ActionWrite: true, ActionAdmin: true},
+}
+
+// Resource is what a request acts upon: a config when both fields are set, every config of a
+// namespace when only Namespace is, and the whole store, such as its indexes or webhooks, when
+// neither is.
+type Resource struct {
+	Namespace string
+	Name      string
+}
+
+// Authorizer decides whether a principal may act upon a resource, failing with ErrForbidden when
+// it may not
+type Authorizer interface {
+	Authorize(*Principal, Action, Resource) error
+}
+
+// Binding grants a role to a principal, optionally scoped to a namespace and to the configs whose
+// name matches a glob as understood by path.Match. A binding scoped to some configs does not
+// grant access to the endpoints acting upon every config of a namespace, such as list and search.
+type Binding struct {
+	// Name of the principal, or * for every authenticated principal
+	Principal string `json:"principal"`
+	Role      Role   `json:"role"`
+	// Namespace the binding is scoped to, or every namespace when empty
+	Namespace string `json:"namespace,omitempty"`
+	// Glob of the config names the binding is scoped to, or every config when empty
+	Names string `json:"names,omitempty"`
+}
+
+// Policy is the set of bindings that grant access, every other access being denied
+type Policy struct {
+	Bindings []Binding `json:"bindings"`
+}
+
+// ParsePolicy decodes and validates a JSON policy, such as
+// {"bindings": [{"principal": "ci", "role": "editor", "namespace": "team-a", "names": "dc-*"}]}
+func ParsePolicy(data []byte) (*Policy, error) {
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("decode policy: %w", err)
+	}
+
+	for i, binding := range policy.Bindings {
+		if binding.Principal == "" {
+			return nil, fmt.Errorf("binding %d: missing principal", i)
+		}
+		if _, ok := grants[binding.Role]; !ok {
+			return nil, fmt.Errorf("binding %d: unknown role %q", i, binding.Role)
+		}
+		if _, err := path.Match(binding.Names, ""); err != nil {
+			return nil, fmt.Errorf("binding %d: invalid names glob %q: %w", i, binding.Names, err)
+		}
+	}
+	return &policy, nil
+}
+
+func (p *Policy) Authorize(principal *Principal, action Action, resource Resource) error {
+	if principal == nil {
+		return fmt.Errorf("%w: request is not authenticated", ErrForbidden)
+	}
+
+	for _, binding := range p.Bindings {
+		if binding.allows(principal, action, resource) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s may not %s %s", ErrForbidden, principal.Name, action, resource)
+}
+
+func (b Binding) allows(principal *Principal, action Action, resource Resource) bool {
+	if (b.Principal != "*" && b.Principal != principal.Name) || !grants[b.Role][action] {
+		return false
+	}
+
+	if b.Namespace != "" && b.Namespace != resource.Namespace {
+		return false
+	}
+	if b.Names == "" {
+		return true
+	}
+	matched, _ := path.Match(b.Names, resource.Name)
+	return resource.Name != "" && matched
+}
+
+func (r Resource) String() string {
+	switch {
+	case r.Name != "":
+		return fmt.Sprintf("config %s of namespace %s", r.Name, r.Namespace)
+	case r.Namespace != "":
+		return "namespace " + r.Namespace
+	default:
+		return "the store"
+	}
+}
+
+// PolicyFile is a policy loaded from a file, which is read again on Reload. It is safe for
+// concurrent use.
+type PolicyFile struct {
+	path string
+
+	mu     sync.RWMutex
+	policy *Policy
+}
+
+// NewPolicyFile loads the policy of a file
+func NewPolicyFile(path string) (*PolicyFile, error) {
+	file := &PolicyFile{path: path}
+	if err := file.Reload(); err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// Reload replaces the policy by the content of the file, unless it cannot be read or is invalid
+// in which case the current policy is kept
+func (f *PolicyFile) Reload() error {
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("read policy: %w", err)
+	}
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.policy = policy
+	return nil
+}
+
+func (f *PolicyFile) Authorize(principal *Principal, action Action, resource Resource) error {
+	f.mu.RLock()
+	policy := f.policy
+	f.mu.RUnlock()
+
+	return policy.Authorize(principal, action, resource)
+}
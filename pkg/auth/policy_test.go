@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `{"bindings": [
+  {"principal": "*", "role": "reader", "namespace": "public"},
+  {"principal": "ci", "role": "editor", "namespace": "team-a", "names": "dc-*"},
+  {"principal": "ops", "role": "admin"}
+]}`
+
+func TestPolicyAuthorize(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	require.NoError(t, err, "Unexpected parse policy error")
+	alice := &Principal{Name: "alice", Method: MethodJWT}
+	ci := &Principal{Name: "ci", Method: MethodAPIKey}
+	ops := &Principal{Name: "ops", Method: MethodAPIKey}
+
+	for name, tc := range map[string]struct {
+		principal *Principal
+		action    Action
+		resource  Resource
+		allowed   bool
+	}{
+		"anyone reads public config":        {alice, ActionRead, Resource{"public", "dc-1"}, true},
+		"anyone lists public configs":       {alice, ActionRead, Resource{"public", ""}, true},
+		"reader does not write":             {alice, ActionWrite, Resource{"public", "dc-1"}, false},
+		"reader is scoped to namespace":     {alice, ActionRead, Resource{"team-a", "dc-1"}, false},
+		"reader does not read store":        {alice, ActionRead, Resource{}, false},
+		"editor writes matching config":     {ci, ActionWrite, Resource{"team-a", "dc-1"}, true},
+		"editor reads matching config":      {ci, ActionRead, Resource{"team-a", "dc-2"}, true},
+		"editor is scoped to names":         {ci, ActionWrite, Resource{"team-a", "lb-1"}, false},
+		"editor does not list namespace":    {ci, ActionRead, Resource{"team-a", ""}, false},
+		"editor does not administrate":      {ci, ActionAdmin, Resource{"team-a", "dc-1"}, false},
+		"admin administrates store":         {ops, ActionAdmin, Resource{}, true},
+		"admin writes any config":           {ops, ActionWrite, Resource{"team-b", "lb-1"}, true},
+		"unauthenticated request is denied": {nil, ActionRead, Resource{"public", "dc-1"}, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := policy.Authorize(tc.principal, tc.action, tc.resource)
+
+			if tc.allowed {
+				assert.NoError(t, err, "Unexpected authorize error")
+			} else {
+				assert.True(t, errors.Is(err, ErrForbidden), "Incorrect authorize error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParsePolicyForInvalidPolicies(t *testing.T) {
+	for name, data := range map[string]string{
+		"malformed":         `[]`,
+		"missing principal": `{"bindings": [{"role": "reader"}]}`,
+		"unknown role":      `{"bindings": [{"principal": "ci", "role": "owner"}]}`,
+		"invalid glob":      `{"bindings": [{"principal": "ci", "role": "reader", "names": "dc-["}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParsePolicy([]byte(data))
+
+			assert.Error(t, err, "Expected parse policy error")
+		})
+	}
+}
+
+func TestPolicyFileReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policy")
+	require.NoError(t, err, "Unexpected create temp dir error")
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "policy.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"bindings": [{"principal": "ci", "role": "reader"}]}`), 0o600),
+		"Unexpected write policy error")
+	ci := &Principal{Name: "ci", Method: MethodAPIKey}
+
+	policy, err := NewPolicyFile(path)
+	require.NoError(t, err, "Unexpected new policy file error")
+	assert.Error(t, policy.Authorize(ci, ActionWrite, Resource{"default", "dc-1"}), "Expected authorize error")
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"bindings": [{"principal": "ci", "role": "editor"}]}`), 0o600),
+		"Unexpected write policy error")
+	require.NoError(t, policy.Reload(), "Unexpected reload error")
+	assert.NoError(t, policy.Authorize(ci, ActionWrite, Resource{"default", "dc-1"}), "Unexpected authorize error")
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"bindings": [{"principal": "ci"}]}`), 0o600),
+		"Unexpected write policy error")
+	assert.Error(t, policy.Reload(), "Expected reload error")
+	assert.NoError(t, policy.Authorize(ci, ActionWrite, Resource{"default", "dc-1"}), "Policy was not kept")
+}
@@ -0,0 +1,226 @@
+// Package client is a Go client of the jsonstore HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"jsonstore/pkg/auth"
+	"jsonstore/pkg/httperr"
+)
+
+const (
+	defaultTimeout      = 10 * time.Second
+	defaultRetries      = 2
+	defaultRetryBackoff = 100 * time.Millisecond
+	defaultWatchTimeout = 30 * time.Second
+
+	// Header naming who makes a change when authentication is disabled
+	authorHeader = "X-Author"
+)
+
+// Options configure a client. Zero values stand for the defaults.
+type Options struct {
+	// Client sending the requests; http.DefaultClient by default
+	HTTPClient *http.Client
+	// Time given to each attempt of a request, 10s by default. Watches are given their timeout on
+	// top of it.
+	Timeout time.Duration
+	// Number of times a GET, PUT or DELETE is retried after a network error or a 502, 503 or 504
+	// reply, 2 by default; negative disables retries
+	Retries int
+	// Delay before the first retry, doubled for every further retry; 100ms by default
+	RetryBackoff time.Duration
+	// How long a watch waits for changes before replying without any, 30s by default
+	WatchTimeout time.Duration
+
+	// Credentials sent with every request: an API key, or a JWT bearer token
+	APIKey string
+	Token  string
+}
+
+// Client calls the API of a jsonstore server. Methods taking a namespace address the configs of
+// that namespace, where an empty namespace stands for the default one. It is safe for concurrent
+// use.
+type Client struct {
+	baseURL string
+	opts    Options
+}
+
+// New returns a client of the server at baseURL, such as http://localhost:8080
+func New(baseURL string, opts Options) (*Client, error) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("base URL %q is not an http or https URL", baseURL)
+	}
+
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.Retries == 0 {
+		opts.Retries = defaultRetries
+	}
+	if opts.RetryBackoff == 0 {
+		opts.RetryBackoff = defaultRetryBackoff
+	}
+	if opts.WatchTimeout == 0 {
+		opts.WatchTimeout = defaultWatchTimeout
+	}
+	return &Client{baseURL: baseURL, opts: opts}, nil
+}
+
+// request is a call to the API
+type request struct {
+	method string
+	// Path relative to the base URL, with its segments escaped
+	path    string
+	query   url.Values
+	header  http.Header
+	body    []byte
+	timeout time.Duration
+}
+
+// do sends a request, retrying it when it is idempotent and fails transiently, and decodes the
+// JSON body of a successful reply other than 304 into out unless it is nil. Error replies are
+// returned as *Error.
+func (c *Client) do(ctx context.Context, req request, out interface{}) (*http.Response, error) {
+	retries := 0
+	if req.method == http.MethodGet || req.method == http.MethodPut || req.method == http.MethodDelete {
+		retries = c.opts.Retries
+	}
+
+	backoff := c.opts.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := c.attempt(ctx, req, out)
+		if attempt >= retries || !retryable(ctx, resp, err) {
+			return resp, err
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, req request, out interface{}) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout+req.timeout)
+	defer cancel()
+
+	target := c.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", req.method, req.path, err)
+	}
+	for key, values := range req.header {
+		httpReq.Header[key] = values
+	}
+	if req.body != nil && httpReq.Header.Get("Content-Type") == "" {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if c.opts.APIKey != "" {
+		httpReq.Header.Set(auth.APIKeyHeader, c.opts.APIKey)
+	}
+	if c.opts.Token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.opts.Token)
+	}
+
+	resp, err := c.opts.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", req.method, req.path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp, fmt.Errorf("%s %s: read reply: %w", req.method, req.path, err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp, decodeError(resp, data)
+	}
+	if out != nil && resp.StatusCode != http.StatusNotModified {
+		if err := json.Unmarshal(data, out); err != nil {
+			return resp, fmt.Errorf("%s %s: decode reply: %w", req.method, req.path, err)
+		}
+	}
+	return resp, nil
+}
+
+// retryable tells whether an attempt failed for a reason that may not last: the request could not
+// be sent or the server was unavailable
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	if resp == nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Error is an error replied by the server, as described by its error catalog. It matches the
+// catalog errors of the same code, so that errors.Is(err, httperr.ConfigNotFound) tells whether a
+// config was not found.
+type Error struct {
+	HTTPStatus int
+	// Code of the error in the catalog, empty when the reply did not come from jsonstore, such as
+	// that of a proxy
+	Code       string
+	Message    string
+	Violations []httperr.Violation
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("jsonstore: %d: %s", e.HTTPStatus, e.Message)
+	}
+	return fmt.Sprintf("jsonstore: %d %s: %s", e.HTTPStatus, e.Code, e.Message)
+}
+
+func (e *Error) Is(target error) bool {
+	catalogErr, ok := target.(httperr.Error)
+	return ok && e.Code != "" && catalogErr.Code == e.Code
+}
+
+// decodeError returns the error described by the body of an error reply, or by its status when
+// the body is not an error of the catalog
+func decodeError(resp *http.Response, data []byte) error {
+	var replied httperr.Error
+	if err := json.Unmarshal(data, &replied); err != nil || replied.Code == "" {
+		replied = httperr.Error{Message: strings.TrimSpace(string(data))}
+		if replied.Message == "" {
+			replied.Message = http.StatusText(resp.StatusCode)
+		}
+	}
+	return &Error{HTTPStatus: resp.StatusCode, Code: replied.Code, Message: replied.Message, Violations: replied.Violations}
+}
@@ -0,0 +1,253 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/client"
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/patch"
+	"jsonstore/pkg/router"
+	"jsonstore/pkg/service"
+)
+
+var dc1 = contract.Config{Name: "datacenter-1", Metadata: map[string]interface{}{"region": "eu"}}
+
+func newTestServer(t *testing.T) *client.Client {
+	manager := service.NewConfigManager(db.NewConfigRepo(), db.NewSchemaRepo(), db.NewWebhookRepo())
+	server := httptest.NewServer(router.Context{Manager: manager, Timeout: time.Second}.New())
+	t.Cleanup(server.Close)
+
+	c, err := client.New(server.URL, client.Options{RetryBackoff: time.Millisecond, WatchTimeout: time.Second})
+	require.NoError(t, err, "Unexpected new client error")
+	return c
+}
+
+func TestCreateAndGet(t *testing.T) {
+	c := newTestServer(t)
+
+	created, err := c.Create(context.Background(), contract.UpsertConfigRequest{Config: dc1, Author: "alice"})
+	require.NoError(t, err, "Unexpected create error")
+	config, err := c.Get(context.Background(), "", "datacenter-1")
+
+	assert.NoError(t, err, "Unexpected get error")
+	assert.Equal(t, created, config, "Incorrect config")
+	assert.Equal(t, int64(1), config.Version, "Incorrect version")
+	assert.Equal(t, "alice", config.UpdatedBy, "Incorrect author")
+	assert.Equal(t, dc1.Metadata, config.Metadata, "Incorrect metadata")
+}
+
+func TestGetForMissingConfig(t *testing.T) {
+	c := newTestServer(t)
+
+	_, err := c.Get(context.Background(), "", "datacenter-1")
+
+	assert.True(t, errors.Is(err, httperr.ConfigNotFound), "Incorrect get error")
+	var apiErr *client.Error
+	require.True(t, errors.As(err, &apiErr), "Error is not an API error")
+	assert.Equal(t, http.StatusNotFound, apiErr.HTTPStatus, "Incorrect error status")
+}
+
+func TestGetForMissingNamespace(t *testing.T) {
+	c := newTestServer(t)
+
+	_, err := c.Get(context.Background(), "team-a", "datacenter-1")
+
+	assert.True(t, errors.Is(err, httperr.NamespaceNotFound), "Incorrect get error")
+}
+
+func TestListPages(t *testing.T) {
+	c := newTestServer(t)
+	for _, name := range []string{"datacenter-1", "datacenter-2", "datacenter-3"} {
+		_, err := c.Create(context.Background(), contract.UpsertConfigRequest{Config: contract.Config{Name: name}})
+		require.NoError(t, err, "Unexpected create error")
+	}
+
+	first, err := c.List(context.Background(), "", contract.ListOptions{Limit: 2})
+	require.NoError(t, err, "Unexpected list error")
+	second, err := c.List(context.Background(), "", contract.ListOptions{Limit: 2, Cursor: first.Next})
+
+	assert.NoError(t, err, "Unexpected list error")
+	assert.Len(t, first.Configs, 2, "Incorrect first page")
+	assert.NotEmpty(t, first.Next, "Missing cursor of the next page")
+	require.Len(t, second.Configs, 1, "Incorrect last page")
+	assert.Equal(t, "datacenter-3", second.Configs[0].Name, "Incorrect config of the last page")
+	assert.Empty(t, second.Next, "Unexpected cursor after the last page")
+}
+
+func TestSearch(t *testing.T) {
+	c := newTestServer(t)
+	_, err := c.Create(context.Background(), contract.UpsertConfigRequest{Config: dc1})
+	require.NoError(t, err, "Unexpected create error")
+	_, err = c.Create(context.Background(), contract.UpsertConfigRequest{Config: contract.Config{Name: "datacenter-2",
+		Metadata: map[string]interface{}{"region": "us"}}})
+	require.NoError(t, err, "Unexpected create error")
+
+	page, err := c.Search(context.Background(), "", `metadata.region = "eu"`, contract.ListOptions{})
+
+	assert.NoError(t, err, "Unexpected search error")
+	require.Len(t, page.Configs, 1, "Incorrect number of configs")
+	assert.Equal(t, "datacenter-1", page.Configs[0].Name, "Incorrect config")
+}
+
+func TestUpdateForFailedPrecondition(t *testing.T) {
+	c := newTestServer(t)
+	_, err := c.Create(context.Background(), contract.UpsertConfigRequest{Config: dc1})
+	require.NoError(t, err, "Unexpected create error")
+
+	updated, err := c.Update(context.Background(), contract.UpsertConfigRequest{Config: dc1},
+		contract.Precondition{IfMatch: []int64{1}})
+	require.NoError(t, err, "Unexpected update error")
+	_, err = c.Update(context.Background(), contract.UpsertConfigRequest{Config: dc1},
+		contract.Precondition{IfMatch: []int64{1}})
+
+	assert.Equal(t, int64(2), updated.Version, "Incorrect version")
+	assert.True(t, errors.Is(err, httperr.PreconditionFailed), "Incorrect update error")
+}
+
+func TestPatch(t *testing.T) {
+	c := newTestServer(t)
+	_, err := c.Create(context.Background(), contract.UpsertConfigRequest{Config: dc1})
+	require.NoError(t, err, "Unexpected create error")
+
+	patched, err := c.Patch(context.Background(), "", "datacenter-1", contract.PatchConfigRequest{
+		ContentType: patch.JSONPatchType,
+		Patch:       []byte(`[{"op": "replace", "path": "/metadata/region", "value": "us"}]`),
+	}, contract.Precondition{})
+
+	assert.NoError(t, err, "Unexpected patch error")
+	assert.Equal(t, map[string]interface{}{"region": "us"}, patched.Metadata, "Incorrect metadata")
+}
+
+func TestDelete(t *testing.T) {
+	c := newTestServer(t)
+	_, err := c.Create(context.Background(), contract.UpsertConfigRequest{Config: dc1})
+	require.NoError(t, err, "Unexpected create error")
+
+	revision, err := c.Delete(context.Background(), contract.DeleteConfigRequest{Name: "datacenter-1", Author: "bob"},
+		contract.Precondition{})
+
+	assert.NoError(t, err, "Unexpected delete error")
+	assert.True(t, revision.Deleted, "Revision is not a deletion")
+	assert.Equal(t, "bob", revision.Author, "Incorrect author")
+	_, err = c.Get(context.Background(), "", "datacenter-1")
+	assert.True(t, errors.Is(err, httperr.ConfigNotFound), "Config was not deleted")
+}
+
+func TestWatch(t *testing.T) {
+	c := newTestServer(t)
+	first, err := c.Watch(context.Background(), contract.WatchRequest{Prefix: "datacenter-"})
+	require.NoError(t, err, "Unexpected watch error")
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = c.Create(context.Background(), contract.UpsertConfigRequest{Config: dc1})
+	}()
+
+	changes, err := c.Watch(context.Background(), contract.WatchRequest{Prefix: "datacenter-", Revision: first.Revision})
+
+	assert.NoError(t, err, "Unexpected watch error")
+	require.Len(t, changes.Events, 1, "Incorrect number of events")
+	assert.Equal(t, contract.EventPut, changes.Events[0].Type, "Incorrect event type")
+	assert.Equal(t, "datacenter-1", changes.Events[0].Name, "Incorrect event config")
+}
+
+func TestExportAndImport(t *testing.T) {
+	c := newTestServer(t)
+	_, err := c.Create(context.Background(), contract.UpsertConfigRequest{Config: dc1})
+	require.NoError(t, err, "Unexpected create error")
+
+	exported, err := c.Export(context.Background(), "")
+	require.NoError(t, err, "Unexpected export error")
+	imported, err := c.Import(context.Background(), contract.ImportRequest{
+		Configs: []contract.Config{exported[0].Config, {Name: "datacenter-2"}},
+		Mode:    contract.ImportReplaceAll,
+		DryRun:  true,
+	})
+
+	assert.NoError(t, err, "Unexpected import error")
+	require.Len(t, exported, 1, "Incorrect number of exported configs")
+	assert.Equal(t, dc1, exported[0].Config, "Incorrect exported config")
+	assert.True(t, imported.DryRun, "Import was not a dry run")
+	assert.Equal(t, 1, imported.Unchanged, "Incorrect number of unchanged configs")
+	assert.Equal(t, 1, imported.Created, "Incorrect number of created configs")
+	_, err = c.Get(context.Background(), "", "datacenter-2")
+	assert.True(t, errors.Is(err, httperr.ConfigNotFound), "Dry run created a config")
+}
+
+func TestRetriesUnavailableServer(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"name": "datacenter-1", "version": 1}`))
+	}))
+	defer server.Close()
+	c, err := client.New(server.URL, client.Options{RetryBackoff: time.Millisecond})
+	require.NoError(t, err, "Unexpected new client error")
+
+	config, err := c.Get(context.Background(), "", "datacenter-1")
+
+	assert.NoError(t, err, "Unexpected get error")
+	assert.Equal(t, "datacenter-1", config.Name, "Incorrect config")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts), "Incorrect number of attempts")
+}
+
+func TestDoesNotRetryCreate(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	c, err := client.New(server.URL, client.Options{RetryBackoff: time.Millisecond})
+	require.NoError(t, err, "Unexpected new client error")
+
+	_, err = c.Create(context.Background(), contract.UpsertConfigRequest{Config: dc1})
+
+	var apiErr *client.Error
+	require.True(t, errors.As(err, &apiErr), "Error is not an API error")
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.HTTPStatus, "Incorrect error status")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts), "Create was retried")
+}
+
+func TestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	c, err := client.New(server.URL, client.Options{Timeout: 20 * time.Millisecond, Retries: -1})
+	require.NoError(t, err, "Unexpected new client error")
+
+	_, err = c.Get(context.Background(), "", "datacenter-1")
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "Incorrect get error")
+}
+
+func TestCanceledContext(t *testing.T) {
+	c := newTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.Get(ctx, "", "datacenter-1")
+
+	assert.True(t, errors.Is(err, context.Canceled), "Incorrect get error")
+}
+
+func TestNewForInvalidURL(t *testing.T) {
+	_, err := client.New("localhost:8080", client.Options{})
+
+	assert.Error(t, err, "Missing new client error")
+}
@@ -0,0 +1,235 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/patch"
+)
+
+func (c *Client) Get(ctx context.Context, namespace, name string) (*contract.GetConfigResponse, error) {
+	return c.getIfModified(ctx, namespace, name, 0)
+}
+
+// getIfModified is Get, returning nil when the stored config is still at version
+func (c *Client) getIfModified(ctx context.Context, namespace, name string, version int64) (*contract.GetConfigResponse, error) {
+	header := http.Header{}
+	if version > 0 {
+		setPrecondition(header, contract.Precondition{IfNoneMatch: []int64{version}})
+	}
+
+	var config contract.GetConfigResponse
+	resp, err := c.do(ctx, request{method: http.MethodGet, path: configPath(namespace, name), header: header}, &config)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+	return &config, nil
+}
+
+// List returns a page of the configs of a namespace as given by the list options. The Next of
+// the page is the cursor of the following one.
+func (c *Client) List(ctx context.Context, namespace string, opts contract.ListOptions) (*contract.ListConfigsResponse, error) {
+	return c.list(ctx, request{method: http.MethodGet, path: configsPath(namespace), query: listQuery(opts)})
+}
+
+// Search returns a page of the configs of a namespace matching a filter expression, such as
+// metadata.region = "eu"
+func (c *Client) Search(ctx context.Context, namespace, expr string, opts contract.ListOptions) (*contract.ListConfigsResponse, error) {
+	query := listQuery(opts)
+	query.Set("q", expr)
+	return c.list(ctx, request{method: http.MethodGet, path: configsPath(namespace) + "/search", query: query})
+}
+
+func (c *Client) list(ctx context.Context, req request) (*contract.ListConfigsResponse, error) {
+	var page contract.ListConfigsResponse
+	resp, err := c.do(ctx, req, &page.Configs)
+	if err != nil {
+		return nil, err
+	}
+	page.Next = nextCursor(resp)
+	return &page, nil
+}
+
+// Create stores a new config, failing with an error matching httperr.ConfigExists when its name
+// is taken
+func (c *Client) Create(ctx context.Context, req contract.UpsertConfigRequest) (*contract.GetConfigResponse, error) {
+	body, err := json.Marshal(req.Config)
+	if err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+
+	var config contract.GetConfigResponse
+	_, err = c.do(ctx, request{
+		method: http.MethodPost,
+		path:   configsPath(req.Namespace),
+		header: authorHeaders(req.Author),
+		body:   body,
+	}, &config)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Update creates or replaces a config, failing with an error matching httperr.PreconditionFailed
+// when the stored config does not satisfy cond
+func (c *Client) Update(ctx context.Context, req contract.UpsertConfigRequest, cond contract.Precondition) (*contract.GetConfigResponse, error) {
+	body, err := json.Marshal(req.Config)
+	if err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+
+	header := authorHeaders(req.Author)
+	setPrecondition(header, cond)
+	var config contract.GetConfigResponse
+	_, err = c.do(ctx, request{
+		method: http.MethodPut,
+		path:   configPath(req.Namespace, req.Name),
+		header: header,
+		body:   body,
+	}, &config)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Patch applies a JSON Merge Patch, or a JSON Patch when the content type of the request says so,
+// to a config
+func (c *Client) Patch(ctx context.Context, namespace, name string, req contract.PatchConfigRequest, cond contract.Precondition) (*contract.GetConfigResponse, error) {
+	header := authorHeaders(req.Author)
+	setPrecondition(header, cond)
+	header.Set("Content-Type", patch.MergePatchType)
+	if req.ContentType != "" {
+		header.Set("Content-Type", req.ContentType)
+	}
+
+	var config contract.GetConfigResponse
+	_, err := c.do(ctx, request{method: http.MethodPatch, path: configPath(namespace, name), header: header,
+		body: req.Patch}, &config)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Delete removes a config and returns the revision recording its deletion
+func (c *Client) Delete(ctx context.Context, req contract.DeleteConfigRequest, cond contract.Precondition) (*contract.GetRevisionResponse, error) {
+	header := authorHeaders(req.Author)
+	setPrecondition(header, cond)
+
+	var revision contract.GetRevisionResponse
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: configPath(req.Namespace, req.Name), header: header},
+		&revision)
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// Watch waits for the changes to a config, or to the configs whose name starts with a prefix,
+// after the revision of the request. It returns as soon as there are changes, or with none once
+// the watch timeout of the client elapses, along with the revision to watch from next. Changed is
+// never set.
+func (c *Client) Watch(ctx context.Context, req contract.WatchRequest) (*contract.WatchResponse, error) {
+	query := url.Values{"timeout": {c.opts.WatchTimeout.String()}}
+	path := configsPath(req.Namespace) + "/watch"
+	if req.Name != "" {
+		path = configPath(req.Namespace, req.Name) + "/watch"
+	} else if req.Prefix != "" {
+		query.Set("prefix", req.Prefix)
+	}
+	if req.Revision != 0 {
+		query.Set("revision", strconv.FormatInt(req.Revision, 10))
+	}
+
+	var resp contract.WatchResponse
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: path, query: query, timeout: c.opts.WatchTimeout},
+		&resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func configsPath(namespace string) string {
+	if namespace == "" {
+		return "/configs"
+	}
+	return "/namespaces/" + url.PathEscape(namespace) + "/configs"
+}
+
+func configPath(namespace, name string) string {
+	return configsPath(namespace) + "/" + url.PathEscape(name)
+}
+
+func listQuery(opts contract.ListOptions) url.Values {
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		query.Set("cursor", opts.Cursor)
+	}
+	if opts.Sort != "" {
+		query.Set("sort", opts.Sort)
+	}
+	if len(opts.Fields) > 0 {
+		query.Set("fields", strings.Join(opts.Fields, ","))
+	}
+	return query
+}
+
+// nextCursor returns the cursor of the page the Link header of a reply points to, if any
+func nextCursor(resp *http.Response) string {
+	link := resp.Header.Get("Link")
+	if !strings.HasSuffix(link, `rel="next"`) {
+		return ""
+	}
+	start, end := strings.Index(link, "<"), strings.Index(link, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	next, err := url.Parse(link[start+1 : end])
+	if err != nil {
+		return ""
+	}
+	return next.Query().Get("cursor")
+}
+
+func authorHeaders(author string) http.Header {
+	header := http.Header{}
+	if author != "" {
+		header.Set(authorHeader, author)
+	}
+	return header
+}
+
+// setPrecondition sets the If-Match and If-None-Match headers expressing cond
+func setPrecondition(header http.Header, cond contract.Precondition) {
+	if value := etags(cond.IfMatch, cond.IfMatchAny); value != "" {
+		header.Set("If-Match", value)
+	}
+	if value := etags(cond.IfNoneMatch, cond.IfNoneMatchAny); value != "" {
+		header.Set("If-None-Match", value)
+	}
+}
+
+func etags(versions []int64, wildcard bool) string {
+	if wildcard {
+		return "*"
+	}
+	tags := make([]string, 0, len(versions))
+	for _, version := range versions {
+		tags = append(tags, strconv.Quote(strconv.FormatInt(version, 10)))
+	}
+	return strings.Join(tags, ", ")
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"jsonstore/pkg/contract"
+)
+
+// Export returns every config of a namespace ordered by name
+func (c *Client) Export(ctx context.Context, namespace string) ([]contract.GetConfigResponse, error) {
+	configs := []contract.GetConfigResponse{}
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: configsPath(namespace) + "/export"}, &configs); err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+// Import writes configs to a namespace as the mode of the request says, merge by default, and
+// returns the outcome of each config. It is never retried.
+func (c *Client) Import(ctx context.Context, req contract.ImportRequest) (*contract.ImportResponse, error) {
+	configs := req.Configs
+	if configs == nil {
+		configs = []contract.Config{}
+	}
+	body, err := json.Marshal(configs)
+	if err != nil {
+		return nil, fmt.Errorf("encode configs: %w", err)
+	}
+
+	query := url.Values{}
+	if req.Mode != "" {
+		query.Set("mode", req.Mode)
+	}
+	if req.DryRun {
+		query.Set("dryRun", "true")
+	}
+	var res contract.ImportResponse
+	_, err = c.do(ctx, request{
+		method: http.MethodPost,
+		path:   configsPath(req.Namespace) + "/import",
+		query:  query,
+		header: authorHeaders(req.Author),
+		body:   body,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,302 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/httperr"
+)
+
+const (
+	defaultRefreshInterval = 30 * time.Second
+	// Delay before watching again after a failed watch
+	watchRetryDelay = time.Second
+)
+
+// LoaderOptions configure a loader. Zero values stand for the defaults.
+type LoaderOptions struct {
+	// Namespace of the config; the default namespace when empty
+	Namespace string
+	// File the config is saved to whenever it changes, and loaded from when the server cannot be
+	// reached at start; no file is used when empty
+	FallbackFile string
+	// Watch the config for changes rather than fetching it again every RefreshInterval
+	Watch bool
+	// How often the config is fetched again with a conditional GET, 30s by default
+	RefreshInterval time.Duration
+	// Invoked with the errors met while refreshing the config, which are otherwise logged
+	OnError func(error)
+}
+
+// Loader keeps a local copy of a config up to date and notifies of its changes. A config that is
+// deleted from the server is kept as last seen. It is safe for concurrent use.
+type Loader struct {
+	client *Client
+	name   string
+	opts   LoaderOptions
+
+	mu        sync.RWMutex
+	config    *contract.GetConfigResponse
+	callbacks []func(contract.GetConfigResponse)
+	// Held while callbacks are invoked, so that they are invoked one at a time
+	notifying sync.Mutex
+}
+
+// NewLoader returns a loader of the named config, which is empty until loaded
+func NewLoader(c *Client, name string, opts LoaderOptions) *Loader {
+	if opts.RefreshInterval == 0 {
+		opts.RefreshInterval = defaultRefreshInterval
+	}
+	if opts.OnError == nil {
+		opts.OnError = func(err error) { zap.S().Warnf("Load config %s: %v", name, err) }
+	}
+	return &Loader{client: c, name: name, opts: opts}
+}
+
+// Load fetches the config, or reads the fallback file when the server cannot be reached, and
+// invokes the callbacks. A config that does not exist is not read from the fallback file.
+func (l *Loader) Load(ctx context.Context) error {
+	config, err := l.client.Get(ctx, l.opts.Namespace, l.name)
+	if err == nil {
+		l.update(config, false)
+		return nil
+	}
+
+	var apiErr *Error
+	if l.opts.FallbackFile == "" || (errors.As(err, &apiErr) && apiErr.HTTPStatus < 500) {
+		return fmt.Errorf("load config %s: %w", l.name, err)
+	}
+	data, fileErr := ioutil.ReadFile(l.opts.FallbackFile)
+	if fileErr != nil {
+		return fmt.Errorf("load config %s: %w, and read fallback: %v", l.name, err, fileErr)
+	}
+	var saved contract.GetConfigResponse
+	if fileErr := json.Unmarshal(data, &saved); fileErr != nil {
+		return fmt.Errorf("load config %s: %w, and decode fallback: %v", l.name, err, fileErr)
+	}
+
+	l.opts.OnError(fmt.Errorf("server unavailable, using fallback file: %w", err))
+	l.set(&saved, false)
+	return nil
+}
+
+// Run keeps the config up to date until ctx is done, by watching it or fetching it periodically
+func (l *Loader) Run(ctx context.Context) error {
+	if l.opts.Watch {
+		l.watch(ctx)
+	} else {
+		l.poll(ctx)
+	}
+	return ctx.Err()
+}
+
+// Config returns the current config, or nil until it is loaded
+func (l *Loader) Config() *contract.GetConfigResponse {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.config == nil {
+		return nil
+	}
+	config := *l.config
+	return &config
+}
+
+// Decode unmarshals the metadata of the current config into out
+func (l *Loader) Decode(out interface{}) error {
+	config := l.Config()
+	if config == nil {
+		return fmt.Errorf("config %s is not loaded", l.name)
+	}
+	return decodeMetadata(config, out)
+}
+
+// OnChange registers fn to be invoked with the config whenever a new version of it is loaded,
+// starting with the current one if it is loaded already. Callbacks are invoked one at a time.
+func (l *Loader) OnChange(fn func(contract.GetConfigResponse)) {
+	l.notifying.Lock()
+	defer l.notifying.Unlock()
+
+	l.mu.Lock()
+	l.callbacks = append(l.callbacks, fn)
+	config := l.config
+	l.mu.Unlock()
+
+	if config != nil {
+		fn(*config)
+	}
+}
+
+// Bind unmarshals the metadata of the config into out, a pointer, whenever it changes. The value
+// out points to is replaced as a whole while mu is locked, so that readers holding mu never see a
+// partially decoded config. Metadata that cannot be decoded leaves out unchanged.
+func (l *Loader) Bind(out interface{}, mu sync.Locker) error {
+	target := reflect.ValueOf(out)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("bind config %s: %T is not a non-nil pointer", l.name, out)
+	}
+
+	l.OnChange(func(config contract.GetConfigResponse) {
+		fresh := reflect.New(target.Elem().Type())
+		if err := decodeMetadata(&config, fresh.Interface()); err != nil {
+			l.opts.OnError(err)
+			return
+		}
+		mu.Lock()
+		target.Elem().Set(fresh.Elem())
+		mu.Unlock()
+	})
+	return nil
+}
+
+// poll fetches the config every refresh interval, unless it is not modified
+func (l *Loader) poll(ctx context.Context) {
+	ticker := time.NewTicker(l.opts.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.refresh(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// watch applies the changes to the config as they are reported. The config is fetched again
+// whenever a watch starts afresh, as changes made before it are not reported.
+func (l *Loader) watch(ctx context.Context) {
+	var revision int64
+	for ctx.Err() == nil {
+		resp, err := l.client.Watch(ctx, contract.WatchRequest{Namespace: l.opts.Namespace, Name: l.name,
+			Revision: revision})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if !errors.Is(err, httperr.WatchExpired) {
+				l.opts.OnError(err)
+			}
+			revision = 0
+			select {
+			case <-time.After(watchRetryDelay):
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+
+		if revision == 0 {
+			l.refresh(ctx)
+		}
+		for _, event := range resp.Events {
+			if event.Type == contract.EventPut && event.Config != nil {
+				l.update(event.Config, true)
+			}
+		}
+		revision = resp.Revision
+	}
+}
+
+// refresh fetches the config unless it is still at the current version
+func (l *Loader) refresh(ctx context.Context) {
+	var version int64
+	if current := l.Config(); current != nil {
+		version = current.Version
+	}
+
+	config, err := l.client.getIfModified(ctx, l.opts.Namespace, l.name, version)
+	if err != nil {
+		if ctx.Err() == nil {
+			l.opts.OnError(err)
+		}
+		return
+	}
+	if config != nil {
+		l.update(config, false)
+	}
+}
+
+// update stores a config fetched from the server and saves it to the fallback file, unless it is
+// the current one
+func (l *Loader) update(config *contract.GetConfigResponse, onlyNewer bool) {
+	if !l.set(config, onlyNewer) || l.opts.FallbackFile == "" {
+		return
+	}
+	if err := saveFallback(l.opts.FallbackFile, config); err != nil {
+		l.opts.OnError(err)
+	}
+}
+
+// set stores a config and invokes the callbacks with it, unless it is at the version of the
+// current one, and tells whether it did. Only newer versions are stored when onlyNewer is set, as
+// for the events of a watch that may be reported after the config was fetched again. A fetched
+// config replaces the current one whatever its version, as the server may have been restarted
+// from an older snapshot than the fallback file or the config it served before.
+func (l *Loader) set(config *contract.GetConfigResponse, onlyNewer bool) bool {
+	l.notifying.Lock()
+	defer l.notifying.Unlock()
+
+	l.mu.Lock()
+	if l.config != nil && (l.config.Version == config.Version || onlyNewer && l.config.Version > config.Version) {
+		l.mu.Unlock()
+		return false
+	}
+	l.config = config
+	callbacks := l.callbacks
+	l.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(*config)
+	}
+	return true
+}
+
+func decodeMetadata(config *contract.GetConfigResponse, out interface{}) error {
+	data, err := json.Marshal(config.Metadata)
+	if err != nil {
+		return fmt.Errorf("decode config %s: %w", config.Name, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode config %s: %w", config.Name, err)
+	}
+	return nil
+}
+
+// saveFallback replaces the fallback file with a config, through a rename so that a crash never
+// leaves it partially written
+func saveFallback(path string, config *contract.GetConfigResponse) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("encode fallback: %w", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("write fallback: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write fallback: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write fallback: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write fallback: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/client"
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/router"
+	"jsonstore/pkg/service"
+)
+
+func tempFile(t *testing.T, name string) string {
+	dir, err := ioutil.TempDir("", "jsonstore")
+	require.NoError(t, err, "Unexpected temp dir error")
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, name)
+}
+
+// runLoader loads the config and keeps it up to date until the test ends, sending the version of
+// every change to the returned channel
+func runLoader(t *testing.T, loader *client.Loader) <-chan int64 {
+	require.NoError(t, loader.Load(context.Background()), "Unexpected load error")
+	versions := make(chan int64, 10)
+	loader.OnChange(func(config contract.GetConfigResponse) { versions <- config.Version })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = loader.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return versions
+}
+
+func receiveVersion(t *testing.T, versions <-chan int64) int64 {
+	select {
+	case version := <-versions:
+		return version
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Change was not loaded")
+		return 0
+	}
+}
+
+func TestLoaderPollsChanges(t *testing.T) {
+	c := newTestServer(t)
+	_, err := c.Create(context.Background(), contract.UpsertConfigRequest{Config: dc1})
+	require.NoError(t, err, "Unexpected create error")
+	loader := client.NewLoader(c, "datacenter-1", client.LoaderOptions{RefreshInterval: 10 * time.Millisecond})
+
+	versions := runLoader(t, loader)
+	_, err = c.Update(context.Background(), contract.UpsertConfigRequest{Config: contract.Config{Name: "datacenter-1",
+		Metadata: map[string]interface{}{"region": "us"}}}, contract.Precondition{})
+	require.NoError(t, err, "Unexpected update error")
+
+	assert.Equal(t, int64(1), receiveVersion(t, versions), "Incorrect initial version")
+	assert.Equal(t, int64(2), receiveVersion(t, versions), "Incorrect version after update")
+	assert.Equal(t, map[string]interface{}{"region": "us"}, loader.Config().Metadata, "Incorrect metadata")
+}
+
+func TestLoaderWatchesChanges(t *testing.T) {
+	c := newTestServer(t)
+	_, err := c.Create(context.Background(), contract.UpsertConfigRequest{Config: dc1})
+	require.NoError(t, err, "Unexpected create error")
+	loader := client.NewLoader(c, "datacenter-1", client.LoaderOptions{Watch: true})
+
+	versions := runLoader(t, loader)
+	assert.Equal(t, int64(1), receiveVersion(t, versions), "Incorrect initial version")
+	_, err = c.Update(context.Background(), contract.UpsertConfigRequest{Config: dc1}, contract.Precondition{})
+	require.NoError(t, err, "Unexpected update error")
+
+	assert.Equal(t, int64(2), receiveVersion(t, versions), "Incorrect version after update")
+}
+
+func TestLoaderFallsBackToFile(t *testing.T) {
+	c := newTestServer(t)
+	_, err := c.Create(context.Background(), contract.UpsertConfigRequest{Config: dc1})
+	require.NoError(t, err, "Unexpected create error")
+	fallback := tempFile(t, "datacenter-1.json")
+	require.NoError(t, client.NewLoader(c, "datacenter-1", client.LoaderOptions{FallbackFile: fallback}).
+		Load(context.Background()), "Unexpected load error")
+	unreachable, err := client.New("http://127.0.0.1:1", client.Options{Retries: -1})
+	require.NoError(t, err, "Unexpected new client error")
+	var loadErr error
+	loader := client.NewLoader(unreachable, "datacenter-1", client.LoaderOptions{
+		FallbackFile: fallback,
+		OnError:      func(err error) { loadErr = err },
+	})
+
+	err = loader.Load(context.Background())
+
+	assert.NoError(t, err, "Unexpected load error")
+	assert.Error(t, loadErr, "Unavailable server was not reported")
+	require.NotNil(t, loader.Config(), "Config was not loaded")
+	assert.Equal(t, int64(1), loader.Config().Version, "Incorrect version")
+	assert.Equal(t, dc1.Metadata, loader.Config().Metadata, "Incorrect metadata")
+}
+
+// restartableServer serves the configs of a store that is replaced by a fresh one on restart
+type restartableServer struct {
+	mu      sync.Mutex
+	handler http.Handler
+}
+
+func (s *restartableServer) restart() service.Manager {
+	manager := service.NewConfigManager(db.NewConfigRepo(), db.NewSchemaRepo(), db.NewWebhookRepo())
+	s.mu.Lock()
+	s.handler = router.Context{Manager: manager, Timeout: time.Second}.New()
+	s.mu.Unlock()
+	return manager
+}
+
+func (s *restartableServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	handler := s.handler
+	s.mu.Unlock()
+	handler.ServeHTTP(w, r)
+}
+
+func TestLoaderFollowsServerRestartedAtOlderVersion(t *testing.T) {
+	restartable := &restartableServer{}
+	manager := restartable.restart()
+	server := httptest.NewServer(restartable)
+	t.Cleanup(server.Close)
+	c, err := client.New(server.URL, client.Options{RetryBackoff: time.Millisecond})
+	require.NoError(t, err, "Unexpected new client error")
+	for i := 0; i < 3; i++ {
+		_, err = manager.Upsert(contract.UpsertConfigRequest{Config: dc1}, contract.Precondition{})
+		require.NoError(t, err, "Unexpected upsert error")
+	}
+	loader := client.NewLoader(c, "datacenter-1", client.LoaderOptions{RefreshInterval: 10 * time.Millisecond})
+
+	versions := runLoader(t, loader)
+	assert.Equal(t, int64(3), receiveVersion(t, versions), "Incorrect initial version")
+	_, err = restartable.restart().Upsert(contract.UpsertConfigRequest{Config: contract.Config{Name: "datacenter-1",
+		Metadata: map[string]interface{}{"region": "us"}}}, contract.Precondition{})
+	require.NoError(t, err, "Unexpected upsert error")
+
+	assert.Equal(t, int64(1), receiveVersion(t, versions), "Incorrect version after restart")
+	assert.Equal(t, map[string]interface{}{"region": "us"}, loader.Config().Metadata, "Incorrect metadata")
+}
+
+func TestLoaderForMissingConfig(t *testing.T) {
+	c := newTestServer(t)
+	fallback := tempFile(t, "datacenter-1.json")
+	require.NoError(t, ioutil.WriteFile(fallback, []byte(`{"name": "datacenter-1", "version": 1}`), 0o600),
+		"Unexpected write error")
+	loader := client.NewLoader(c, "datacenter-1", client.LoaderOptions{FallbackFile: fallback})
+
+	err := loader.Load(context.Background())
+
+	assert.True(t, errors.Is(err, httperr.ConfigNotFound), "Incorrect load error")
+	assert.Nil(t, loader.Config(), "Config was loaded from the fallback file")
+}
+
+func TestLoaderBind(t *testing.T) {
+	c := newTestServer(t)
+	_, err := c.Create(context.Background(), contract.UpsertConfigRequest{Config: dc1})
+	require.NoError(t, err, "Unexpected create error")
+	loader := client.NewLoader(c, "datacenter-1", client.LoaderOptions{})
+	var mu sync.Mutex
+	var settings struct {
+		Region string `json:"region"`
+	}
+
+	require.NoError(t, loader.Bind(&settings, &mu), "Unexpected bind error")
+	err = loader.Load(context.Background())
+
+	assert.NoError(t, err, "Unexpected load error")
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "eu", settings.Region, "Incorrect bound config")
+}
+
+func TestLoaderBindForNonPointer(t *testing.T) {
+	loader := client.NewLoader(newTestServer(t), "datacenter-1", client.LoaderOptions{})
+
+	err := loader.Bind(struct{}{}, &sync.Mutex{})
+
+	assert.Error(t, err, "Missing bind error")
+}
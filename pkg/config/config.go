@@ -5,26 +5,83 @@ import (
 )
 
 const (
-	servePortConfKey     = "SERVE_PORT"
-	serverTimeOutConfKey = "SERVER_TIMEOUT_MS"
+	servePortConfKey         = "SERVE_PORT"
+	serverTimeOutConfKey     = "SERVER_TIMEOUT_MS"
+	dataDirConfKey           = "DATA_DIR"
+	snapshotThresholdConfKey = "SNAPSHOT_THRESHOLD"
+	apiKeysFileConfKey       = "AUTH_API_KEYS_FILE"
+	jwtSecretFileConfKey     = "AUTH_JWT_HS256_SECRET_FILE"
+	jwtPublicKeyFileConfKey  = "AUTH_JWT_RS256_PUBLIC_KEY_FILE"
+	jwtIssuerConfKey         = "AUTH_JWT_ISSUER"
+	jwtAudienceConfKey       = "AUTH_JWT_AUDIENCE"
+	policyFileConfKey        = "AUTH_POLICY_FILE"
+	auditLogFileConfKey      = "AUDIT_LOG_FILE"
+	auditLogMaxSizeConfKey   = "AUDIT_LOG_MAX_SIZE_MB"
+	auditLogMaxFilesConfKey  = "AUDIT_LOG_MAX_FILES"
 )
 
 type Config struct {
 	ServePort       int
 	ServerTimeoutMS int
+
+	// Directory of the file-backed store; configs are kept in memory only when empty
+	DataDir string
+	// Number of write-ahead log entries after which the file-backed store is compacted into a snapshot
+	SnapshotThreshold int
+
+	// JSON array of the API keys accepted in the X-API-Key header, as name and key objects
+	APIKeys []byte
+	// HS256 secret and PEM encoded RS256 public key verifying JWT bearer tokens
+	JWTSecret    []byte
+	JWTPublicKey []byte
+	// Expected iss and aud claims of JWT bearer tokens, which are not checked when empty
+	JWTIssuer   string
+	JWTAudience string
+	// Path of the JSON policy granting roles to principals, which is read again on SIGHUP; every
+	// authenticated principal may do anything when empty
+	PolicyFile string
+
+	// Path of the audit log, which defaults to audit.log in DataDir; the latest entries are only
+	// kept in memory when both are empty
+	AuditLogFile string
+	// Size in megabytes past which the audit log is rotated, and number of rotated files kept
+	AuditLogMaxSizeMB int
+	AuditLogMaxFiles  int
 }
 
 func New() (*Config, error) {
 	vars := Vars{}
 	serverPort := vars.MandatoryInt(servePortConfKey)
 	serverTimeoutMs := vars.OptionalInt(serverTimeOutConfKey, 10)
+	dataDir := vars.OptionalString(dataDirConfKey, "")
+	snapshotThreshold := vars.OptionalInt(snapshotThresholdConfKey, 1000)
+	apiKeys := vars.OptionalFile(apiKeysFileConfKey)
+	jwtSecret := vars.OptionalFile(jwtSecretFileConfKey)
+	jwtPublicKey := vars.OptionalFile(jwtPublicKeyFileConfKey)
+	jwtIssuer := vars.OptionalString(jwtIssuerConfKey, "")
+	jwtAudience := vars.OptionalString(jwtAudienceConfKey, "")
+	policyFile := vars.OptionalString(policyFileConfKey, "")
+	auditLogFile := vars.OptionalString(auditLogFileConfKey, "")
+	auditLogMaxSizeMB := vars.OptionalInt(auditLogMaxSizeConfKey, 100)
+	auditLogMaxFiles := vars.OptionalInt(auditLogMaxFilesConfKey, 5)
 
 	if err := vars.Error(); err != nil {
 		return nil, fmt.Errorf("config: environment variables: %s", err)
 	}
 
 	return &Config{
-		ServePort:       serverPort,
-		ServerTimeoutMS: serverTimeoutMs,
+		ServePort:         serverPort,
+		ServerTimeoutMS:   serverTimeoutMs,
+		DataDir:           dataDir,
+		SnapshotThreshold: snapshotThreshold,
+		APIKeys:           apiKeys,
+		JWTSecret:         jwtSecret,
+		JWTPublicKey:      jwtPublicKey,
+		JWTIssuer:         jwtIssuer,
+		JWTAudience:       jwtAudience,
+		PolicyFile:        policyFile,
+		AuditLogFile:      auditLogFile,
+		AuditLogMaxSizeMB: auditLogMaxSizeMB,
+		AuditLogMaxFiles:  auditLogMaxFiles,
 	}, nil
 }
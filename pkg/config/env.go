@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
@@ -45,6 +46,33 @@ func (vars *Vars) OptionalInt(key string, fallback int) int {
 	return val
 }
 
+func (vars *Vars) OptionalString(key string, fallback string) string {
+	val := os.Getenv(key)
+
+	if val == "" {
+		return fallback
+	}
+
+	return val
+}
+
+// OptionalFile returns the content of the file whose path is the value of key, or nil when unset
+func (vars *Vars) OptionalFile(key string) []byte {
+	path := os.Getenv(key)
+
+	if path == "" {
+		return nil
+	}
+
+	val, err := ioutil.ReadFile(path)
+	if err != nil {
+		vars.malformed = append(vars.malformed, fmt.Sprintf("optional %s (value=%q) is not a readable file: %v", key, path, err))
+		return nil
+	}
+
+	return val
+}
+
 func (vars Vars) Error() error {
 	if len(vars.missing) > 0 {
 		return fmt.Errorf("missing mandatory configuration: %s", strings.Join(vars.missing, ", "))
@@ -0,0 +1,46 @@
+package contract
+
+import "jsonstore/pkg/patch"
+
+// Actions an apply takes on a single config
+const (
+	ApplyCreate    = "create"
+	ApplyUpdate    = "update"
+	ApplyDelete    = "delete"
+	ApplyUnchanged = "unchanged"
+)
+
+// Represents a request for making the configs of a namespace those given
+type ApplyRequest struct {
+	Namespace string
+	Configs   []Config
+	// Deletes the configs of the namespace that are not given
+	Prune bool
+	// Returns the plan without applying it
+	DryRun bool
+	Author string
+}
+
+// Represents the response payload for an apply: the change to each given config in the order of
+// the request, followed by the deletions in name order
+type ApplyResponse struct {
+	DryRun    bool          `json:"dryRun"`
+	Prune     bool          `json:"prune"`
+	Created   int           `json:"created"`
+	Updated   int           `json:"updated"`
+	Deleted   int           `json:"deleted"`
+	Unchanged int           `json:"unchanged"`
+	Changes   []ApplyChange `json:"changes"`
+}
+
+// Represents the change an apply makes to a single config
+type ApplyChange struct {
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	// Version the config was written or deleted at, or its stored version when unchanged; on a dry
+	// run, the stored version the change would apply to
+	Version int64 `json:"version,omitempty"`
+	// RFC 6902 operations turning the stored metadata and schema into the given ones, with paths
+	// such as /metadata/region; a missing config is an empty object
+	Diff []patch.Operation `json:"diff,omitempty"`
+}
@@ -1,17 +1,101 @@
 package contract
 
+import "time"
+
 // Represents request payload for creating/updating a config
 type UpsertConfigRequest struct {
 	Config
+	// Namespace the config is stored in, as given by the path of the request
+	Namespace string `json:"-"`
+	// Who is making the change, as recorded in the history of the config
+	Author string `json:"-"`
+}
+
+// Represents request payload for patching a config, either as a JSON Merge Patch or a JSON Patch
+// depending on the content type
+type PatchConfigRequest struct {
+	ContentType string
+	Patch       []byte
+	Author      string
+}
+
+// Represents a request for deleting a config
+type DeleteConfigRequest struct {
+	Namespace string
+	Name      string
+	Author    string
+}
+
+// Represents a request for restoring a config to the metadata of one of its revisions
+type RollbackConfigRequest struct {
+	Namespace string
+	Name      string
+	Version   int64
+	Author    string
 }
 
 // Represents the response payload for a config
 type GetConfigResponse struct {
+	Namespace string `json:"namespace,omitempty"`
 	Config
+	Version   int64      `json:"version,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	UpdatedBy string     `json:"updatedBy,omitempty"`
+}
+
+// Represents how a list of configs is ordered, paginated and projected
+type ListOptions struct {
+	// Maximum number of configs in a page; every config is listed when 0
+	Limit int
+	// Opaque position of the page to return, as given by the Next of the previous page
+	Cursor string
+	// Path of the value to order configs by, ascending unless prefixed by "-"; defaults to "name"
+	Sort string
+	// Paths of the fields to return in addition to the name; every field is returned when empty
+	Fields []string
+}
+
+// Represents a page of configs
+type ListConfigsResponse struct {
+	// Configs of the page, left empty when fields are projected
+	Configs []GetConfigResponse
+	// Configs of the page holding only their name and the requested fields, when fields are given
+	Projected []map[string]interface{}
+	// Cursor of the next page, empty on the last page
+	Next string
+}
+
+// Represents the response payload for a revision of a config
+type GetRevisionResponse struct {
+	Namespace string      `json:"namespace,omitempty"`
+	Name      string      `json:"name"`
+	Version   int64       `json:"version"`
+	Timestamp time.Time   `json:"timestamp"`
+	Author    string      `json:"author,omitempty"`
+	Deleted   bool        `json:"deleted,omitempty"`
+	Metadata  interface{} `json:"metadata,omitempty"`
+	Schema    string      `json:"schema,omitempty"`
+}
+
+// Represents the conditions a write is subject to, as given by the If-Match and If-None-Match
+// headers of the request
+type Precondition struct {
+	// The stored version must be one of these
+	IfMatch []int64
+	// The config must exist
+	IfMatchAny bool
+	// The stored version must not be one of these
+	IfNoneMatch []int64
+	// The config must not exist
+	IfNoneMatchAny bool
 }
 
 // Represents a config
 type Config struct {
 	Name     string      `json:"name"`
 	Metadata interface{} `json:"metadata"`
+	// Name of the schema the metadata is validated against, in addition to those matching the
+	// config name
+	Schema string `json:"schema,omitempty"`
 }
@@ -0,0 +1,55 @@
+package contract
+
+// Modes of an import
+const (
+	// Creates and replaces the imported configs, leaving the others untouched
+	ImportMerge = "merge"
+	// Also deletes the configs of the namespace that are not imported
+	ImportReplaceAll = "replace-all"
+	// Reports what a merge would do without writing anything
+	ImportDryRun = "dry-run"
+)
+
+// Outcomes of the import of a single config
+const (
+	ImportCreated   = "created"
+	ImportUpdated   = "updated"
+	ImportUnchanged = "unchanged"
+	ImportDeleted   = "deleted"
+	ImportInvalid   = "invalid"
+	ImportFailed    = "failed"
+)
+
+// Represents a request for importing configs into a namespace
+type ImportRequest struct {
+	Namespace string
+	Mode      string
+	// Reports what the mode would do without writing anything
+	DryRun  bool
+	Author  string
+	Configs []Config
+}
+
+// Represents the response payload for an import, with the outcome of each imported config in the
+// order of the request followed by the deleted configs
+type ImportResponse struct {
+	Mode      string         `json:"mode"`
+	DryRun    bool           `json:"dryRun"`
+	Created   int            `json:"created"`
+	Updated   int            `json:"updated"`
+	Unchanged int            `json:"unchanged"`
+	Deleted   int            `json:"deleted"`
+	Invalid   int            `json:"invalid"`
+	Failed    int            `json:"failed"`
+	Results   []ImportResult `json:"results"`
+}
+
+// Represents the outcome of the import of a single config
+type ImportResult struct {
+	Name   string `json:"name"`
+	Result string `json:"result"`
+	// Version the config was written or deleted at, or its stored version when unchanged; omitted
+	// on a dry run for the configs that would be written
+	Version int64  `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
@@ -0,0 +1,15 @@
+package contract
+
+// Represents request payload for creating a secondary index on a JSON path of the configs
+type CreateIndexRequest struct {
+	Path string `json:"path"`
+}
+
+// Represents the response payload for a secondary index
+type GetIndexResponse struct {
+	Path string `json:"path"`
+	// Number of distinct values indexed
+	Values int `json:"values"`
+	// Number of config and value pairs indexed
+	Entries int `json:"entries"`
+}
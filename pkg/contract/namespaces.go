@@ -0,0 +1,23 @@
+package contract
+
+import "time"
+
+// Represents request payload for creating a namespace
+type CreateNamespaceRequest struct {
+	Name string `json:"name"`
+}
+
+// Represents a request for deleting a namespace
+type DeleteNamespaceRequest struct {
+	Name string
+	// Delete the configs of the namespace along with it, instead of failing when there are any
+	Cascade bool
+	Author  string
+}
+
+// Represents the response payload for a namespace
+type GetNamespaceResponse struct {
+	Name string `json:"name"`
+	// Unset for the default namespace, which always exists
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
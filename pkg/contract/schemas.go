@@ -0,0 +1,24 @@
+package contract
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Represents request payload for registering a JSON Schema
+type PutSchemaRequest struct {
+	Name string `json:"name"`
+	// Pattern of the names of the configs the schema applies to, in the syntax of path.Match
+	Pattern string          `json:"pattern,omitempty"`
+	Schema  json.RawMessage `json:"schema"`
+}
+
+// Represents the response payload for a JSON Schema
+type GetSchemaResponse struct {
+	Name      string          `json:"name"`
+	Pattern   string          `json:"pattern,omitempty"`
+	Schema    json.RawMessage `json:"schema"`
+	Version   int64           `json:"version"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
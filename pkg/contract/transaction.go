@@ -0,0 +1,55 @@
+package contract
+
+import "encoding/json"
+
+// Operations of a transaction on a single config
+const (
+	TransactionPut    = "put"
+	TransactionDelete = "delete"
+	TransactionCheck  = "check"
+)
+
+// Represents a request for making operations on several configs of a namespace all or nothing
+type TransactionRequest struct {
+	Operations []TransactionOperation `json:"operations"`
+	// Namespace of the configs, as given by the path of the request
+	Namespace string `json:"-"`
+	// Who is making the changes, as recorded in the history of the configs
+	Author string `json:"-"`
+}
+
+// Represents an operation of a transaction: a put creates or replaces a config, a delete removes
+// it, and a check leaves it as is. The operation is made once all its conditions hold.
+type TransactionOperation struct {
+	Op   string `json:"op"`
+	Name string `json:"name"`
+	// Metadata and schema stored by a put
+	Metadata interface{}            `json:"metadata,omitempty"`
+	Schema   string                 `json:"schema,omitempty"`
+	If       []TransactionCondition `json:"if,omitempty"`
+}
+
+// Represents a condition on a config as left by the previous operations of its transaction,
+// which is either that it exists or not, that it is at a version, or that it holds a value at
+// a path
+type TransactionCondition struct {
+	Exists  *bool `json:"exists,omitempty"`
+	Version int64 `json:"version,omitempty"`
+	// JSON pointer into the config, such as /metadata/region
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Represents the response payload for a transaction: the outcome of each operation in order
+type TransactionResponse struct {
+	Results []TransactionResult `json:"results"`
+}
+
+// Represents the outcome of an operation of a transaction
+type TransactionResult struct {
+	Op   string `json:"op"`
+	Name string `json:"name"`
+	// Version the config was written or deleted at, or its version when checked; missing for a
+	// config that does not exist
+	Version int64 `json:"version,omitempty"`
+}
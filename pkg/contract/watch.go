@@ -0,0 +1,44 @@
+package contract
+
+import "time"
+
+// Types of the changes reported to watchers
+const (
+	EventPut    = "put"
+	EventDelete = "delete"
+)
+
+// Represents a change to a config, as reported to watchers
+type Event struct {
+	// Position of the change among all changes, which watchers resume from after reconnecting
+	Revision  int64     `json:"revision"`
+	Type      string    `json:"type"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Version   int64     `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+	Author    string    `json:"author,omitempty"`
+	// The config as written by a put; unset for a delete
+	Config *GetConfigResponse `json:"config,omitempty"`
+}
+
+// Represents a request for the changes to a config, or to every config whose name starts with a
+// prefix
+type WatchRequest struct {
+	Namespace string
+	// Name of the config to watch; configs are matched by Prefix instead when empty
+	Name   string
+	Prefix string
+	// Revision of the last change seen. Only later changes are returned, and 0 stands for the
+	// current revision.
+	Revision int64
+}
+
+// Represents the changes matching a watch
+type WatchResponse struct {
+	// Revision to watch from next, which may be past the last of the events
+	Revision int64   `json:"revision"`
+	Events   []Event `json:"events"`
+	// Closed once changes later than Revision are made
+	Changed <-chan struct{} `json:"-"`
+}
@@ -0,0 +1,39 @@
+package contract
+
+import "time"
+
+// Represents request payload for subscribing a URL to the changes of configs
+type CreateWebhookRequest struct {
+	// Namespace of the configs to watch; the default one when empty
+	Namespace string `json:"namespace,omitempty"`
+	URL       string `json:"url"`
+	// Only changes to configs whose name starts with Prefix are posted
+	Prefix string `json:"prefix,omitempty"`
+	// Only changes to configs matching the filter expression are posted, besides deletions
+	Query string `json:"query,omitempty"`
+	// Key of the HMAC-SHA256 signature of the payloads
+	Secret string `json:"secret"`
+}
+
+// Represents the response payload for a webhook, whose secret is never returned
+type GetWebhookResponse struct {
+	ID         string            `json:"id"`
+	Namespace  string            `json:"namespace"`
+	URL        string            `json:"url"`
+	Prefix     string            `json:"prefix,omitempty"`
+	Query      string            `json:"query,omitempty"`
+	CreatedAt  *time.Time        `json:"createdAt,omitempty"`
+	Deliveries WebhookDeliveries `json:"deliveries"`
+}
+
+// Represents the outcome of the deliveries to a webhook since the server started
+type WebhookDeliveries struct {
+	// Number of events accepted by the webhook
+	Delivered int64 `json:"delivered"`
+	// Number of events given up on after every attempt failed
+	Failed        int64      `json:"failed"`
+	LastAttemptAt *time.Time `json:"lastAttemptAt,omitempty"`
+	// HTTP status of the last attempt, unset when no response was received
+	LastStatus int    `json:"lastStatus,omitempty"`
+	LastError  string `json:"lastError,omitempty"`
+}
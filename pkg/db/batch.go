@@ -0,0 +1,199 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"jsonstore/pkg/model"
+)
+
+// errUnchanged aborts the write of a config whose content is already stored
+var errUnchanged = errors.New("unchanged")
+
+// BatchResult is the outcome of a batch write to a single config
+type BatchResult struct {
+	Name string
+	// Stored config, or nil once deleted
+	Config *model.Config
+	// Revision recording the write, or nil when the config was left unchanged
+	Revision *model.Revision
+	// Config stored before the write, or nil when it did not exist
+	Previous *model.Config
+}
+
+// UpsertAll creates or replaces configs of a namespace, attributing each write to the UpdatedBy of
+// the config. Configs whose metadata and schema are already stored are left unchanged, so that
+// writing the same configs again adds no revision. Writers are paused meanwhile so that the
+// batch is not interleaved with other writes. On failure, the results of the configs written so
+// far are returned along with the error.
+func (c *configRepo) UpsertAll(namespace string, configs []model.Config) ([]BatchResult, error) {
+	c.writers.Lock()
+	defer c.writers.Unlock()
+
+	if !c.namespaces.exists(namespace) {
+		return nil, ErrNamespaceNotFound
+	}
+
+	return c.upsertAllLocked(canonical(namespace), configs)
+}
+
+// ReplaceAll is UpsertAll, followed by the deletion on behalf of author of every other config of
+// the namespace except those named in keep. The names of configs must be unique. The whole batch
+// is journaled at once, as by ApplyAll, so that a failure leaves the namespace as it was. The
+// results of the deletions come last in name order.
+func (c *configRepo) ReplaceAll(namespace string, configs []model.Config, keep []string, author string) ([]BatchResult, error) {
+	c.writers.Lock()
+	defer c.writers.Unlock()
+
+	if !c.namespaces.exists(namespace) {
+		return nil, ErrNamespaceNotFound
+	}
+
+	return c.applyAllLocked(canonical(namespace), configs, true, keep, author)
+}
+
+// ApplyAll makes the configs of a namespace those given, whose names must be unique, in a single
+// batch: the configs are created or replaced unless their content is already stored and, when
+// prune is set, every other config of the namespace is deleted on behalf of author. Every change
+// is journaled at once before any is applied, so that either the whole batch is made or, on
+// failure, none of it. The results follow the order of configs, followed by the deletions in name
+// order.
+func (c *configRepo) ApplyAll(namespace string, configs []model.Config, prune bool, author string) ([]BatchResult, error) {
+	c.writers.Lock()
+	defer c.writers.Unlock()
+
+	if !c.namespaces.exists(namespace) {
+		return nil, ErrNamespaceNotFound
+	}
+
+	return c.applyAllLocked(canonical(namespace), configs, prune, nil, author)
+}
+
+// applyAllLocked is ApplyAll once writers are paused, sparing the configs named in keep from the
+// pruning. The stored configs cannot change until the batch is committed.
+func (c *configRepo) applyAllLocked(namespace string, configs []model.Config, prune bool, keep []string, author string) ([]BatchResult, error) {
+	results := make([]BatchResult, 0, len(configs))
+	var changes []change
+	desired := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		config := config
+		if desired[config.Name] {
+			return nil, fmt.Errorf("config %s is repeated", config.Name)
+		}
+		desired[config.Name] = true
+
+		current, latest := c.current(namespace, config.Name)
+		if current != nil && SameContent(*current, config) {
+			results = append(results, BatchResult{Name: config.Name, Config: current, Previous: current})
+			continue
+		}
+		ch := c.revise(namespace, config.Name, current, &config, latest, config.UpdatedBy)
+		changes = append(changes, ch)
+		results = append(results, BatchResult{Name: config.Name, Config: ch.Config, Revision: ch.Revision,
+			Previous: current})
+	}
+	if prune {
+		kept := make(map[string]bool, len(keep))
+		for _, name := range keep {
+			kept[name] = true
+		}
+		for _, config := range c.valuesIn(namespace) {
+			config := config
+			if desired[config.Name] || kept[config.Name] {
+				continue
+			}
+			_, latest := c.current(namespace, config.Name)
+			ch := c.revise(namespace, config.Name, &config, nil, latest, author)
+			changes = append(changes, ch)
+			results = append(results, BatchResult{Name: config.Name, Revision: ch.Revision, Previous: &config})
+		}
+	}
+
+	if err := c.commitAll(changes); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// commitAll journals every change at once, then applies them in order under the lock of their
+// shard. Writers must be paused, so that the changes are not interleaved with other writes.
+func (c *configRepo) commitAll(changes []change) error {
+	for i := range changes {
+		if err := changes[i].encode(); err != nil {
+			return err
+		}
+	}
+	if c.journal != nil && len(changes) > 0 {
+		if err := c.journal(changes...); err != nil {
+			return err
+		}
+	}
+	for _, ch := range changes {
+		s := c.shardFor(ch.Key)
+		s.Lock()
+		c.commit(s, ch)
+		s.Unlock()
+	}
+	return nil
+}
+
+// current returns the stored config named name, or nil, along with its latest version
+func (c *configRepo) current(namespace, name string) (*model.Config, int64) {
+	k := key(namespace, name)
+	s := c.shardFor(k)
+	s.RLock()
+	defer s.RUnlock()
+
+	latest := s.latest(k)
+	if config, ok := s.data[k]; ok {
+		return &config, latest
+	}
+	return nil, latest
+}
+
+func (c *configRepo) upsertAllLocked(namespace string, configs []model.Config) ([]BatchResult, error) {
+	results := make([]BatchResult, 0, len(configs))
+	for _, config := range configs {
+		config := config
+		var current *model.Config
+		ch, err := c.writeLocked(namespace, config.Name, func(stored *model.Config) (*model.Config, string, error) {
+			current = stored
+			if current != nil && SameContent(*current, config) {
+				return nil, "", errUnchanged
+			}
+			return &config, config.UpdatedBy, nil
+		})
+		switch {
+		case errors.Is(err, errUnchanged):
+			results = append(results, BatchResult{Name: config.Name, Config: current, Previous: current})
+		case err != nil:
+			return results, fmt.Errorf("write config %s: %w", config.Name, err)
+		default:
+			results = append(results, BatchResult{Name: config.Name, Config: ch.Config, Revision: ch.Revision,
+				Previous: current})
+		}
+	}
+	return results, nil
+}
+
+// SameContent tells whether two configs have the same schema and the same metadata once encoded,
+// regardless of how the metadata is represented in memory
+func SameContent(a, b model.Config) bool {
+	if a.Schema != b.Schema {
+		return false
+	}
+
+	var docs [2]interface{}
+	for i, metadata := range []interface{}{a.Metadata, b.Metadata} {
+		data, err := json.Marshal(metadata)
+		if err != nil {
+			return false
+		}
+		if err := json.Unmarshal(data, &docs[i]); err != nil {
+			return false
+		}
+	}
+	return reflect.DeepEqual(docs[0], docs[1])
+}
@@ -0,0 +1,219 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/model"
+)
+
+func TestUpsertAll(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.Create(dc1Item)
+	require.NoError(t, err, "Unexpected create config error")
+	_, err = repo.Create(dc2Item)
+	require.NoError(t, err, "Unexpected create config error")
+	dc2Updated := model.Config{Name: "datacenter-2", Metadata: dc1, UpdatedBy: "alice"}
+	dc3 := model.Config{Name: "datacenter-3", Metadata: dc2, UpdatedBy: "alice"}
+
+	results, err := repo.UpsertAll(model.DefaultNamespace, []model.Config{dc1Item, dc2Updated, dc3})
+
+	assert.NoError(t, err, "Unexpected upsert all error")
+	require.Len(t, results, 3, "Incorrect number of results")
+	assert.Equal(t, BatchResult{Name: "datacenter-1", Config: stored(dc1Item, 1), Previous: stored(dc1Item, 1)},
+		results[0], "Incorrect result of unchanged config")
+	assert.Equal(t, stored(dc2Updated, 2), results[1].Config, "Incorrect updated config")
+	assert.Equal(t, stored(dc2Item, 1), results[1].Previous, "Incorrect previous config")
+	assert.Equal(t, int64(2), results[1].Revision.Version, "Incorrect revision of updated config")
+	assert.Equal(t, stored(dc3, 1), results[2].Config, "Incorrect created config")
+	assert.Nil(t, results[2].Previous, "Created config existed")
+
+	history, err := repo.History(model.DefaultNamespace, "datacenter-1")
+	require.NoError(t, err, "Unexpected history error")
+	assert.Len(t, history, 1, "Unchanged config was revised")
+}
+
+func TestUpsertAllComparesEncodedMetadata(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.Create(model.Config{Name: "datacenter-1", Metadata: map[string]interface{}{"a": 1, "b": "x"}})
+	require.NoError(t, err, "Unexpected create config error")
+
+	results, err := repo.UpsertAll(model.DefaultNamespace, []model.Config{
+		{Name: "datacenter-1", Metadata: map[string]interface{}{"b": "x", "a": 1.0}},
+	})
+
+	assert.NoError(t, err, "Unexpected upsert all error")
+	assert.Nil(t, results[0].Revision, "Config with the same metadata was revised")
+}
+
+func TestUpsertAllForMissingNamespace(t *testing.T) {
+	repo := newTestConfigRepo()
+
+	_, err := repo.UpsertAll("team-a", []model.Config{dc1Item})
+
+	assert.True(t, errors.Is(err, ErrNamespaceNotFound), "Incorrect upsert all error")
+}
+
+func TestReplaceAll(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.CreateNamespace("team-a")
+	require.NoError(t, err, "Unexpected create namespace error")
+	for _, item := range []model.Config{dc1Item, dc2Item, {Name: "datacenter-3", Metadata: dc2}} {
+		_, err = repo.Create(inNamespace(item, "team-a"))
+		require.NoError(t, err, "Unexpected create config error")
+	}
+	_, err = repo.Create(dc2Item)
+	require.NoError(t, err, "Unexpected create config error")
+
+	results, err := repo.ReplaceAll("team-a", []model.Config{dc1Item}, []string{"datacenter-3"}, "alice")
+
+	assert.NoError(t, err, "Unexpected replace all error")
+	assert.Equal(t, []BatchResult{
+		{Name: "datacenter-1", Config: stored(inNamespace(dc1Item, "team-a"), 1),
+			Previous: stored(inNamespace(dc1Item, "team-a"), 1)},
+		{Name: "datacenter-2", Revision: &model.Revision{Namespace: "team-a", Name: "datacenter-2", Version: 2,
+			Timestamp: testTime, Author: "alice", Deleted: true}, Previous: stored(inNamespace(dc2Item, "team-a"), 1)},
+	}, results, "Incorrect results")
+	configs, err := repo.GetAll("team-a")
+	require.NoError(t, err, "Unexpected get all configs error")
+	assert.Len(t, configs, 2, "Incorrect remaining configs")
+	_, err = repo.Get(model.DefaultNamespace, "datacenter-2")
+	assert.NoError(t, err, "Config of another namespace was deleted")
+}
+
+func TestFileRepoUpsertAllForFailedWrite(t *testing.T) {
+	repo := newTestFileRepo(t, tempDataDir(t), 100)
+	require.NoError(t, repo.wal.Close(), "Unexpected close wal error")
+	defer repo.Close() //nolint:errcheck
+
+	results, err := repo.UpsertAll(model.DefaultNamespace, []model.Config{dc1Item, dc2Item})
+
+	assert.Error(t, err, "Missing upsert all error")
+	assert.Empty(t, results, "Incorrect results")
+	_, err = repo.Get(model.DefaultNamespace, "datacenter-1")
+	assert.True(t, errors.Is(err, ErrNotFound), "Config was applied although journaling it failed")
+}
+
+func TestReplaceAllForFailedWrite(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.Create(dc2Item)
+	require.NoError(t, err, "Unexpected create config error")
+	// Fails to journal the deletions, after the configs before them would have been written
+	repo.journal = func(changes ...change) error {
+		for _, ch := range changes {
+			if ch.Config == nil {
+				return errors.New("disk failure")
+			}
+		}
+		return nil
+	}
+
+	results, err := repo.ReplaceAll(model.DefaultNamespace, []model.Config{dc1Item}, nil, "alice")
+
+	assert.Error(t, err, "Missing replace all error")
+	assert.Empty(t, results, "Incorrect results")
+	_, err = repo.Get(model.DefaultNamespace, "datacenter-1")
+	assert.True(t, errors.Is(err, ErrNotFound), "Config was applied although journaling the batch failed")
+	_, err = repo.Get(model.DefaultNamespace, "datacenter-2")
+	assert.NoError(t, err, "Config was pruned although journaling the batch failed")
+}
+
+func TestReplaceAllForRepeatedConfig(t *testing.T) {
+	repo := newTestConfigRepo()
+
+	_, err := repo.ReplaceAll(model.DefaultNamespace, []model.Config{dc1Item, dc1Item}, nil, "alice")
+
+	assert.Error(t, err, "Missing replace all error")
+	configs, err := repo.GetAll(model.DefaultNamespace)
+	require.NoError(t, err, "Unexpected get all configs error")
+	assert.Empty(t, configs, "Part of the batch was applied")
+}
+
+func TestApplyAll(t *testing.T) {
+	repo := newTestConfigRepo()
+	for _, item := range []model.Config{dc1Item, dc2Item, {Name: "datacenter-3", Metadata: dc2}} {
+		_, err := repo.Create(item)
+		require.NoError(t, err, "Unexpected create config error")
+	}
+	dc2Updated := model.Config{Name: "datacenter-2", Metadata: dc1, UpdatedBy: "alice"}
+	dc4 := model.Config{Name: "datacenter-4", Metadata: dc2, UpdatedBy: "alice"}
+
+	results, err := repo.ApplyAll(model.DefaultNamespace, []model.Config{dc4, dc2Updated, dc1Item}, true, "alice")
+
+	assert.NoError(t, err, "Unexpected apply all error")
+	require.Len(t, results, 4, "Incorrect number of results")
+	assert.Equal(t, BatchResult{Name: "datacenter-4", Config: stored(dc4, 1), Revision: &model.Revision{
+		Namespace: model.DefaultNamespace, Name: "datacenter-4", Version: 1, Timestamp: testTime, Author: "alice",
+		Metadata: dc2}}, results[0], "Incorrect result of created config")
+	assert.Equal(t, stored(dc2Updated, 2), results[1].Config, "Incorrect updated config")
+	assert.Equal(t, stored(dc2Item, 1), results[1].Previous, "Incorrect previous config")
+	assert.Nil(t, results[2].Revision, "Unchanged config was revised")
+	assert.Equal(t, "datacenter-3", results[3].Name, "Incorrect deleted config")
+	assert.True(t, results[3].Revision.Deleted, "Config was not deleted")
+	configs, err := repo.GetAll(model.DefaultNamespace)
+	require.NoError(t, err, "Unexpected get all configs error")
+	assert.Equal(t, []model.Config{*stored(dc1Item, 1), *stored(dc2Updated, 2), *stored(dc4, 1)}, configs,
+		"Incorrect stored configs")
+}
+
+func TestApplyAllWithoutPrune(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.Create(dc2Item)
+	require.NoError(t, err, "Unexpected create config error")
+
+	results, err := repo.ApplyAll(model.DefaultNamespace, []model.Config{dc1Item}, false, "alice")
+
+	assert.NoError(t, err, "Unexpected apply all error")
+	assert.Len(t, results, 1, "Incorrect number of results")
+	_, err = repo.Get(model.DefaultNamespace, "datacenter-2")
+	assert.NoError(t, err, "Config was pruned")
+}
+
+func TestApplyAllForRepeatedConfig(t *testing.T) {
+	repo := newTestConfigRepo()
+
+	_, err := repo.ApplyAll(model.DefaultNamespace, []model.Config{dc1Item, dc2Item, dc1Item}, false, "alice")
+
+	assert.Error(t, err, "Missing apply all error")
+	configs, err := repo.GetAll(model.DefaultNamespace)
+	require.NoError(t, err, "Unexpected get all configs error")
+	assert.Empty(t, configs, "Part of the batch was applied")
+}
+
+func TestFileRepoApplyAllForFailedWrite(t *testing.T) {
+	repo := newTestFileRepo(t, tempDataDir(t), 100)
+	_, err := repo.Create(dc1Item)
+	require.NoError(t, err, "Unexpected create config error")
+	require.NoError(t, repo.wal.Close(), "Unexpected close wal error")
+	defer repo.Close() //nolint:errcheck
+
+	_, err = repo.ApplyAll(model.DefaultNamespace, []model.Config{dc2Item}, true, "alice")
+
+	assert.Error(t, err, "Missing apply all error")
+	configs, err := repo.GetAll(model.DefaultNamespace)
+	require.NoError(t, err, "Unexpected get all configs error")
+	assert.Equal(t, []model.Config{*stored(dc1Item, 1)}, configs, "Part of the batch was applied")
+}
+
+func TestFileRepoReplaysApplyAll(t *testing.T) {
+	dir := tempDataDir(t)
+	repo := newTestFileRepo(t, dir, 100)
+	_, err := repo.Create(dc1Item)
+	require.NoError(t, err, "Unexpected create config error")
+	_, err = repo.ApplyAll(model.DefaultNamespace, []model.Config{dc2Item}, true, "alice")
+	require.NoError(t, err, "Unexpected apply all error")
+	require.NoError(t, repo.Close(), "Unexpected close error")
+
+	reopened := newTestFileRepo(t, dir, 100)
+	defer reopened.Close() //nolint:errcheck
+
+	configs, err := reopened.GetAll(model.DefaultNamespace)
+	require.NoError(t, err, "Unexpected get all configs error")
+	assert.Equal(t, []model.Config{*stored(dc2Item, 1)}, configs, "Incorrect replayed configs")
+	history, err := reopened.History(model.DefaultNamespace, "datacenter-1")
+	require.NoError(t, err, "Unexpected history error")
+	assert.Len(t, history, 2, "Deletion was not replayed")
+}
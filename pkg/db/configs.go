@@ -3,79 +3,500 @@ package db
 import (
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/tidwall/gjson"
 
 	"jsonstore/pkg/model"
+	"jsonstore/pkg/query"
 )
 
+// Number of independently locked partitions of the in-memory store
+const shardCount = 32
+
+// Config stores configs by name within namespaces. Writes assign the version and timestamps of the stored config
+// and apply their precondition atomically with the write, which makes them compare-and-swap
+// operations when the precondition names a version. Every write is also recorded as a revision
+// in the history of the config, attributed to the UpdatedBy of the written config or to the
+// author given to Delete. Searches use the secondary indexes created on JSON paths whenever they
+// narrow down the configs to evaluate. Operations on a namespace that does not exist fail with
+// ErrNamespaceNotFound, except for the default namespace which always exists.
 type Config interface {
-	Get(string) (*model.Config, error)
-	GetAll() ([]model.Config, error)
-	Search(string, string) ([]model.Config, error)
+	Get(string, string) (*model.Config, error)
+	GetAll(string) ([]model.Config, error)
+	Search(string, query.Expr) ([]model.Config, error)
+
+	Create(model.Config) (*model.Config, error)
+	Upsert(model.Config, Precondition) (*model.Config, error)
+	Update(string, string, Precondition, UpdateFunc) (*model.Config, error)
+	Delete(string, string, Precondition, string) (*model.Revision, error)
+	UpsertAll(string, []model.Config) ([]BatchResult, error)
+	ReplaceAll(string, []model.Config, []string, string) ([]BatchResult, error)
+	ApplyAll(string, []model.Config, bool, string) ([]BatchResult, error)
+	Transact(string, []TxOp) ([]BatchResult, error)
+
+	History(string, string) ([]model.Revision, error)
+	Revision(string, string, int64) (*model.Revision, error)
+
+	Indexes() ([]model.Index, error)
+	CreateIndex(string) error
+	DropIndex(string) error
 
-	Upsert(model.Config) error
-	Delete(string) error
+	Namespace(string) (*model.Namespace, error)
+	Namespaces() ([]model.Namespace, error)
+	CreateNamespace(string) (*model.Namespace, error)
+	DeleteNamespace(string, bool, string) ([]model.Revision, error)
 }
 
+// UpdateFunc computes the new state of a config from its currently stored state. It is invoked
+// while the config is locked, so it must not call back into the store.
+type UpdateFunc func(model.Config) (model.Config, error)
+
+// writeFunc computes the next state of a config, or nil to delete it, from its current state, or
+// nil when it does not exist, along with the author of the change
+type writeFunc func(*model.Config) (*model.Config, string, error)
+
+// configRepo is an in-memory store that is safe for concurrent use. Configs are spread over
+// shards by the hash of their key so that writers only contend with requests for the same shard,
+// and readers hold a shard's read lock just long enough to copy its contents.
 type configRepo struct {
+	shards     []*shard
+	indexes    *indexSet
+	namespaces *namespaceSet
+	// Held shared by every writer and exclusively by operations that need the shards to be stable
+	writers sync.RWMutex
+	// Invoked with every change while its shard is locked and before it is applied, or with every
+	// change of a batch before any is applied; a failure aborts the write. This is how the
+	// file-backed store makes changes durable.
+	journal func(...change) error
+	// Invoked with the indexed paths whenever an index is created or dropped; a failure aborts
+	// the operation
+	saveIndexes func([]string) error
+	// Invoked with every namespace but the default one whenever a namespace is created or deleted;
+	// a failure aborts the operation
+	saveNamespaces func([]model.Namespace) error
+	now            func() time.Time
+}
+
+// shard holds configs by their key, as given by key
+type shard struct {
+	sync.RWMutex
 	data map[string]model.Config
+	// JSON representation of each config, as searched by queries
+	docs map[string]string
+	// Revisions of each config ordered by version, kept after the config is deleted
+	history map[string][]model.Revision
+}
+
+// change is the outcome of a write to a single config; Config is nil when it was deleted
+type change struct {
+	// Key of the config, as given by key
+	Key      string
+	Config   *model.Config
+	Revision *model.Revision
+	// JSON representation of Config, set by encode
+	doc string
 }
 
 func NewConfigRepo() Config {
-	return configRepo{map[string]model.Config{}}
+	return newConfigRepo()
+}
+
+func newConfigRepo() *configRepo {
+	shards := make([]*shard, shardCount)
+	for i := range shards {
+		shards[i] = &shard{
+			data:    map[string]model.Config{},
+			docs:    map[string]string{},
+			history: map[string][]model.Revision{},
+		}
+	}
+
+	return &configRepo{shards: shards, indexes: newIndexSet(), namespaces: newNamespaceSet(), now: time.Now}
 }
 
-func (c configRepo) Get(name string) (*model.Config, error) {
-	config, ok := c.data[name]
+func (c *configRepo) Get(namespace, name string) (*model.Config, error) {
+	if !c.namespaces.exists(namespace) {
+		return nil, ErrNamespaceNotFound
+	}
+
+	k := key(namespace, name)
+	s := c.shardFor(k)
+	s.RLock()
+	defer s.RUnlock()
+
+	config, ok := s.data[k]
 	if !ok {
-		return nil, fmt.Errorf("config not found")
+		return nil, ErrNotFound
 	}
 
 	return &config, nil
 }
 
-func (c configRepo) GetAll() ([]model.Config, error) {
-	if len(c.data) < 1 {
-		return nil, fmt.Errorf("no configs found")
+func (c *configRepo) GetAll(namespace string) ([]model.Config, error) {
+	if !c.namespaces.exists(namespace) {
+		return nil, ErrNamespaceNotFound
+	}
+
+	return c.valuesIn(namespace), nil
+}
+
+// Search returns the configs of a namespace whose JSON representation matches expr, ordered by
+// name. When indexes narrow down the configs that may match, only those are evaluated.
+func (c *configRepo) Search(namespace string, expr query.Expr) ([]model.Config, error) {
+	if !c.namespaces.exists(namespace) {
+		return nil, ErrNamespaceNotFound
 	}
 
-	values := make([]model.Config, 0, len(c.data))
-	for _, v := range c.data {
-		values = append(values, v)
+	namespace = canonical(namespace)
+	var result []model.Config
+	if keys, ok := c.indexes.candidates(expr); ok {
+		for k := range keys {
+			s := c.shardFor(k)
+			s.RLock()
+			if doc, ok := s.docs[k]; ok && s.data[k].Namespace == namespace && matches(expr, gjson.Parse(doc)) {
+				result = append(result, s.data[k])
+			}
+			s.RUnlock()
+		}
+	} else {
+		for _, s := range c.shards {
+			s.RLock()
+			for k, doc := range s.docs {
+				if s.data[k].Namespace == namespace && matches(expr, gjson.Parse(doc)) {
+					result = append(result, s.data[k])
+				}
+			}
+			s.RUnlock()
+		}
 	}
 
-	return values, nil
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
 }
 
-func (c configRepo) Search(path, value string) ([]model.Config, error) {
-	var result []model.Config
-	for _, v := range c.data {
-		bytes, err := json.Marshal(v)
+func (c *configRepo) Create(config model.Config) (*model.Config, error) {
+	ch, err := c.write(config.Namespace, config.Name, func(current *model.Config) (*model.Config, string, error) {
+		if current != nil {
+			return nil, "", ErrAlreadyExists
+		}
+
+		return &config, config.UpdatedBy, nil
+	})
+
+	return ch.Config, err
+}
+
+func (c *configRepo) Upsert(config model.Config, cond Precondition) (*model.Config, error) {
+	ch, err := c.write(config.Namespace, config.Name, func(current *model.Config) (*model.Config, string, error) {
+		if err := cond.check(current); err != nil {
+			return nil, "", err
+		}
+
+		return &config, config.UpdatedBy, nil
+	})
+
+	return ch.Config, err
+}
+
+// Update atomically replaces the stored config with the result of fn, unless fn fails
+func (c *configRepo) Update(namespace, name string, cond Precondition, fn UpdateFunc) (*model.Config, error) {
+	ch, err := c.write(namespace, name, func(current *model.Config) (*model.Config, string, error) {
+		if err := cond.check(current); err != nil {
+			return nil, "", err
+		}
+		if current == nil {
+			return nil, "", ErrNotFound
+		}
+
+		next, err := fn(*current)
 		if err != nil {
-			return nil, fmt.Errorf("parse stored data")
+			return nil, "", err
 		}
-		got := gjson.ParseBytes(bytes).Get(path).Str
-		if got == value {
-			result = append(result, v)
+
+		return &next, next.UpdatedBy, nil
+	})
+
+	return ch.Config, err
+}
+
+// Delete removes a config and returns the revision recording its deletion
+func (c *configRepo) Delete(namespace, name string, cond Precondition, author string) (*model.Revision, error) {
+	ch, err := c.write(namespace, name, func(current *model.Config) (*model.Config, string, error) {
+		if err := cond.check(current); err != nil {
+			return nil, "", err
 		}
+		if current == nil {
+			return nil, "", ErrNotFound
+		}
+
+		return nil, author, nil
+	})
+
+	return ch.Revision, err
+}
+
+// History returns every revision of the named config ordered by version, including those
+// recorded before it was deleted
+func (c *configRepo) History(namespace, name string) ([]model.Revision, error) {
+	if !c.namespaces.exists(namespace) {
+		return nil, ErrNamespaceNotFound
 	}
 
-	return result, nil
+	k := key(namespace, name)
+	s := c.shardFor(k)
+	s.RLock()
+	defer s.RUnlock()
+
+	history, ok := s.history[k]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return append([]model.Revision(nil), history...), nil
+}
+
+func (c *configRepo) Revision(namespace, name string, version int64) (*model.Revision, error) {
+	if !c.namespaces.exists(namespace) {
+		return nil, ErrNamespaceNotFound
+	}
+
+	k := key(namespace, name)
+	s := c.shardFor(k)
+	s.RLock()
+	defer s.RUnlock()
+
+	history := s.history[k]
+	i := sort.Search(len(history), func(i int) bool { return history[i].Version >= version })
+	if i == len(history) || history[i].Version != version {
+		return nil, ErrRevisionNotFound
+	}
+
+	revision := history[i]
+	return &revision, nil
 }
 
-func (c configRepo) Upsert(config model.Config) error {
-	c.data[config.Name] = config
+// write atomically replaces the config stored under name in a namespace with the result of fn and
+// records the revision
+func (c *configRepo) write(namespace, name string, fn writeFunc) (change, error) {
+	c.writers.RLock()
+	defer c.writers.RUnlock()
+
+	// Namespaces are only deleted while writers are paused, so the namespace outlives the write
+	if !c.namespaces.exists(namespace) {
+		return change{}, ErrNamespaceNotFound
+	}
+
+	return c.writeLocked(canonical(namespace), name, fn)
+}
+
+// writeLocked is write for callers that already hold the writers lock
+func (c *configRepo) writeLocked(namespace, name string, fn writeFunc) (change, error) {
+	k := key(namespace, name)
+	s := c.shardFor(k)
+	s.Lock()
+	defer s.Unlock()
+
+	var current *model.Config
+	if v, ok := s.data[k]; ok {
+		current = &v
+	}
+
+	next, author, err := fn(current)
+	if err != nil {
+		return change{}, err
+	}
+
+	ch := c.revise(namespace, name, current, next, s.latest(k), author)
+	if err := ch.encode(); err != nil {
+		return change{}, err
+	}
+	if c.journal != nil {
+		if err := c.journal(ch); err != nil {
+			return change{}, err
+		}
+	}
+
+	c.commit(s, ch)
+	return ch, nil
+}
+
+// commit applies a change to the shard it belongs to, which must be locked, and to the indexes
+func (c *configRepo) commit(s *shard, ch change) {
+	previous := s.docs[ch.Key]
+	s.apply(ch)
+	c.indexes.update(ch.Key, previous, ch.doc)
+}
+
+// revise stamps next as the revision following the latest one, where current is nil for a
+// missing config and next is nil for a deletion. Versions keep increasing across a deletion so
+// that they identify a single revision in the history of the config.
+func (c *configRepo) revise(namespace, name string, current, next *model.Config, latest int64, author string) change {
+	now := c.now().UTC()
+	revision := model.Revision{Namespace: namespace, Name: name, Version: latest + 1, Timestamp: now, Author: author}
+	if next == nil {
+		revision.Deleted = true
+		return change{Key: key(namespace, name), Revision: &revision}
+	}
+
+	stored := *next
+	stored.Namespace = namespace
+	stored.Version, stored.CreatedAt, stored.UpdatedAt, stored.UpdatedBy = revision.Version, now, now, author
+	if current != nil {
+		stored.CreatedAt = current.CreatedAt
+	}
+	revision.Metadata, revision.Schema = stored.Metadata, stored.Schema
+
+	return change{Key: key(namespace, name), Config: &stored, Revision: &revision}
+}
+
+// restore applies a change as is, without journaling it or revising the config. Configs recorded
+// before namespaces existed are restored into the default namespace.
+func (c *configRepo) restore(ch change) error {
+	if ch.Config != nil && ch.Config.Namespace == "" {
+		config := *ch.Config
+		config.Namespace = model.DefaultNamespace
+		ch.Config = &config
+	}
+	if err := ch.encode(); err != nil {
+		return err
+	}
+
+	s := c.shardFor(ch.Key)
+	s.Lock()
+	defer s.Unlock()
+
+	c.commit(s, ch)
 	return nil
 }
 
-func (c configRepo) Delete(name string) error {
-	_, ok := c.data[name]
-	if !ok {
-		return fmt.Errorf("config not found")
+// restoreRevision adds a revision to the history of its config without changing the config
+func (c *configRepo) restoreRevision(revision model.Revision) {
+	revision.Namespace = canonical(revision.Namespace)
+	k := key(revision.Namespace, revision.Name)
+	s := c.shardFor(k)
+	s.Lock()
+	defer s.Unlock()
+
+	if revision.Version > s.latestRevision(k) {
+		s.history[k] = append(s.history[k], revision)
 	}
+}
 
-	delete(c.data, name)
+// values returns a copy of every stored config ordered by namespace and name. Each shard is read
+// under its own lock, so writes that race with the call may or may not be reflected in the result.
+func (c *configRepo) values() []model.Config {
+	return c.collect(func(model.Config) bool { return true })
+}
+
+// valuesIn is values restricted to a namespace
+func (c *configRepo) valuesIn(namespace string) []model.Config {
+	namespace = canonical(namespace)
+	return c.collect(func(config model.Config) bool { return config.Namespace == namespace })
+}
 
+func (c *configRepo) collect(keep func(model.Config) bool) []model.Config {
+	values := []model.Config{}
+	for _, s := range c.shards {
+		s.RLock()
+		for _, v := range s.data {
+			if keep(v) {
+				values = append(values, v)
+			}
+		}
+		s.RUnlock()
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Namespace != values[j].Namespace {
+			return values[i].Namespace < values[j].Namespace
+		}
+		return values[i].Name < values[j].Name
+	})
+	return values
+}
+
+// revisions returns every recorded revision ordered by namespace, name and version
+func (c *configRepo) revisions() []model.Revision {
+	revisions := []model.Revision{}
+	for _, s := range c.shards {
+		s.RLock()
+		for _, history := range s.history {
+			revisions = append(revisions, history...)
+		}
+		s.RUnlock()
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		if revisions[i].Namespace != revisions[j].Namespace {
+			return revisions[i].Namespace < revisions[j].Namespace
+		}
+		if revisions[i].Name != revisions[j].Name {
+			return revisions[i].Name < revisions[j].Name
+		}
+		return revisions[i].Version < revisions[j].Version
+	})
+	return revisions
+}
+
+func (c *configRepo) shardFor(key string) *shard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return c.shards[h.Sum32()%uint32(len(c.shards))]
+}
+
+// encode sets the JSON representation of the changed config
+func (ch *change) encode() error {
+	if ch.Config == nil {
+		ch.doc = ""
+		return nil
+	}
+
+	doc, err := json.Marshal(ch.Config)
+	if err != nil {
+		return fmt.Errorf("encode config %s: %w", ch.Key, err)
+	}
+	ch.doc = string(doc)
 	return nil
 }
+
+// apply stores the outcome of a change. Revisions that are not newer than the latest recorded
+// one are ignored, which makes replaying a change idempotent.
+func (s *shard) apply(ch change) {
+	if ch.Revision != nil && ch.Revision.Version > s.latestRevision(ch.Key) {
+		s.history[ch.Key] = append(s.history[ch.Key], *ch.Revision)
+	}
+
+	if ch.Config == nil {
+		delete(s.data, ch.Key)
+		delete(s.docs, ch.Key)
+		return
+	}
+
+	s.data[ch.Key] = *ch.Config
+	s.docs[ch.Key] = ch.doc
+}
+
+// latest returns the version of the most recent write to the config with the given key, or 0 if
+// it was never written. Configs restored without their history are accounted for by their stored
+// version.
+func (s *shard) latest(key string) int64 {
+	latest := s.latestRevision(key)
+	if current, ok := s.data[key]; ok && current.Version > latest {
+		latest = current.Version
+	}
+
+	return latest
+}
+
+func (s *shard) latestRevision(key string) int64 {
+	history := s.history[key]
+	if len(history) == 0 {
+		return 0
+	}
+
+	return history[len(history)-1].Version
+}
@@ -2,12 +2,15 @@ package db
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"jsonstore/pkg/model"
+	"jsonstore/pkg/query"
 )
 
 var (
@@ -49,96 +52,333 @@ var (
 	}
 )
 
+var testTime = time.Date(2021, time.September, 1, 10, 0, 0, 0, time.UTC)
+
+func newTestConfigRepo() *configRepo {
+	repo := newConfigRepo()
+	repo.now = func() time.Time { return testTime }
+	return repo
+}
+
+// stored returns item as stored by a test repo at the given version
+func stored(item model.Config, version int64) *model.Config {
+	if item.Namespace == "" {
+		item.Namespace = model.DefaultNamespace
+	}
+	item.Version, item.CreatedAt, item.UpdatedAt = version, testTime, testTime
+	return &item
+}
+
 func TestGet(t *testing.T) {
-	repo := NewConfigRepo()
-	err := repo.Upsert(dc1Item)
+	repo := newTestConfigRepo()
+	_, err := repo.Upsert(dc1Item, Precondition{})
 	require.NoError(t, err, "Unexpected upsert config error")
 
-	config, err := repo.Get("datacenter-1")
+	config, err := repo.Get(model.DefaultNamespace, "datacenter-1")
 
 	assert.NoError(t, err, "Unexpected get config error")
-	assert.Equal(t, &dc1Item, config, "Incorrect config")
+	assert.Equal(t, stored(dc1Item, 1), config, "Incorrect config")
 }
 
 func TestGetForMissingConfig(t *testing.T) {
 	repo := NewConfigRepo()
 
-	_, err := repo.Get("datacenter-1")
+	_, err := repo.Get(model.DefaultNamespace, "datacenter-1")
 
 	assert.Error(t, err, "Missing get config error")
-	assert.Contains(t, err.Error(), "config not found", "Incorrect get config error")
+	assert.True(t, errors.Is(err, ErrNotFound), "Incorrect get config error")
 }
 
 func TestGetAll(t *testing.T) {
-	repo := NewConfigRepo()
-	err := repo.Upsert(dc1Item)
+	repo := newTestConfigRepo()
+	_, err := repo.Upsert(dc1Item, Precondition{})
 	require.NoError(t, err, "Unexpected upsert config error")
-	err = repo.Upsert(dc2Item)
+	_, err = repo.Upsert(dc2Item, Precondition{})
 	require.NoError(t, err, "Unexpected upsert config error")
 
-	configs, err := repo.GetAll()
+	configs, err := repo.GetAll(model.DefaultNamespace)
 
 	assert.NoError(t, err, "Unexpected get all configs error")
-	assert.Equal(t, []model.Config{dc1Item, dc2Item}, configs, "Incorrect config")
+	assert.Equal(t, []model.Config{*stored(dc1Item, 1), *stored(dc2Item, 1)}, configs, "Incorrect config")
 }
 
 func TestGetAllForNoConfigs(t *testing.T) {
 	repo := NewConfigRepo()
 
-	_, err := repo.GetAll()
+	configs, err := repo.GetAll(model.DefaultNamespace)
 
-	assert.Error(t, err, "Missing get all configs error")
-	assert.Contains(t, err.Error(), "no configs found", "Incorrect get all configs error")
+	assert.NoError(t, err, "Unexpected get all configs error")
+	assert.Empty(t, configs, "Incorrect configs")
+	assert.NotNil(t, configs, "Incorrect configs")
 }
 
 func TestSearch(t *testing.T) {
-	repo := NewConfigRepo()
+	repo := newTestConfigRepo()
 	var dc1Item model.Config
 	err := json.Unmarshal([]byte(dc1), &dc1Item)
 	require.NoError(t, err, "Unexpected unmarshall error")
-	err = repo.Upsert(dc1Item)
+	_, err = repo.Upsert(dc1Item, Precondition{})
 	require.NoError(t, err, "Unexpected upsert config error")
 
-	configs, err := repo.Search("metadata.monitoring.enabled", "true")
+	expr, err := query.Parse(`metadata.monitoring.enabled = "true"`)
+	require.NoError(t, err, "Unexpected parse query error")
+
+	configs, err := repo.Search(model.DefaultNamespace, expr)
 
 	assert.NoError(t, err, "Unexpected search config error")
-	assert.Equal(t, []model.Config{dc1Item}, configs, "Incorrect config")
+	assert.Equal(t, []model.Config{*stored(dc1Item, 1)}, configs, "Incorrect config")
 }
 
 func TestCreate(t *testing.T) {
-	repo := NewConfigRepo()
+	repo := newTestConfigRepo()
 
-	err := repo.Upsert(dc1Item)
+	config, err := repo.Create(dc1Item)
 
-	assert.NoError(t, err, "Unexpected upsert config error")
+	assert.NoError(t, err, "Unexpected create config error")
+	assert.Equal(t, stored(dc1Item, 1), config, "Incorrect created config")
 
-	config, err := repo.Get("datacenter-1")
+	config, err = repo.Get(model.DefaultNamespace, "datacenter-1")
 	require.NoError(t, err, "Unexpected get config error")
-	assert.Equal(t, &dc1Item, config, "Incorrect config")
+	assert.Equal(t, stored(dc1Item, 1), config, "Incorrect config")
+}
+
+func TestCreateForExistingConfig(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.Create(dc1Item)
+	require.NoError(t, err, "Unexpected create config error")
+
+	_, err = repo.Create(model.Config{Name: "datacenter-1", Metadata: dc2})
+
+	assert.Error(t, err, "Missing create config error")
+	assert.True(t, errors.Is(err, ErrAlreadyExists), "Incorrect create config error")
+	assert.True(t, errors.Is(err, ErrConflict), "Incorrect create config error")
+	config, err := repo.Get(model.DefaultNamespace, "datacenter-1")
+	require.NoError(t, err, "Unexpected get config error")
+	assert.Equal(t, stored(dc1Item, 1), config, "Existing config was overwritten")
+}
+
+func TestUpsertIncrementsVersion(t *testing.T) {
+	repo := newConfigRepo()
+	created := time.Date(2021, time.September, 1, 10, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+	repo.now = func() time.Time { return created }
+	_, err := repo.Upsert(dc1Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+	repo.now = func() time.Time { return updated }
+
+	config, err := repo.Upsert(model.Config{Name: "datacenter-1", Metadata: dc2}, Precondition{})
+
+	assert.NoError(t, err, "Unexpected upsert config error")
+	assert.Equal(t, &model.Config{
+		Namespace: model.DefaultNamespace,
+		Name:      "datacenter-1",
+		Metadata:  dc2,
+		Version:   2,
+		CreatedAt: created,
+		UpdatedAt: updated,
+	}, config, "Incorrect upserted config")
+}
+
+func TestUpsertWithPrecondition(t *testing.T) {
+	tests := []struct {
+		name    string
+		cond    Precondition
+		missing bool
+		err     error
+	}{
+		{name: "matching version", cond: Precondition{Match: []int64{3, 1}}},
+		{name: "stale version", cond: Precondition{Match: []int64{2}}, err: ErrPreconditionFailed},
+		{name: "match any", cond: Precondition{MatchAny: true}},
+		{name: "match any when missing", cond: Precondition{MatchAny: true}, missing: true, err: ErrPreconditionFailed},
+		{name: "match version when missing", cond: Precondition{Match: []int64{1}}, missing: true, err: ErrPreconditionFailed},
+		{name: "none match any", cond: Precondition{NoneMatchAny: true}, err: ErrPreconditionFailed},
+		{name: "none match any when missing", cond: Precondition{NoneMatchAny: true}, missing: true},
+		{name: "none match version", cond: Precondition{NoneMatch: []int64{1}}, err: ErrPreconditionFailed},
+		{name: "none match other version", cond: Precondition{NoneMatch: []int64{2}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestConfigRepo()
+			if !tt.missing {
+				_, err := repo.Create(dc1Item)
+				require.NoError(t, err, "Unexpected create config error")
+			}
+
+			_, err := repo.Upsert(model.Config{Name: "datacenter-1", Metadata: dc2}, tt.cond)
+
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), "Incorrect upsert config error: %v", err)
+			} else {
+				assert.NoError(t, err, "Unexpected upsert config error")
+			}
+		})
+	}
 }
 
 func TestDelete(t *testing.T) {
-	repo := NewConfigRepo()
-	err := repo.Upsert(dc1Item)
+	repo := newTestConfigRepo()
+	_, err := repo.Upsert(dc1Item, Precondition{})
 	require.NoError(t, err, "Unexpected upsert config error")
-	config, err := repo.Get("datacenter-1")
+	config, err := repo.Get(model.DefaultNamespace, "datacenter-1")
 	require.NoError(t, err, "Unexpected get config error")
-	assert.Equal(t, &dc1Item, config, "Incorrect config")
+	assert.Equal(t, stored(dc1Item, 1), config, "Incorrect config")
 
-	err = repo.Delete("datacenter-1")
+	revision, err := repo.Delete(model.DefaultNamespace, "datacenter-1", Precondition{}, "alice")
 
 	assert.NoError(t, err, "Unexpected delete config error")
+	assert.Equal(t, &model.Revision{Namespace: model.DefaultNamespace, Name: "datacenter-1", Version: 2, Timestamp: testTime,
+		Author: "alice", Deleted: true}, revision, "Incorrect deletion revision")
 
-	_, err = repo.Get("datacenter-1")
+	_, err = repo.Get(model.DefaultNamespace, "datacenter-1")
 	assert.Error(t, err, "Missing get config error")
 	assert.Contains(t, err.Error(), "config not found", "Incorrect config")
 }
 
+func TestDeleteForStaleVersion(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.Upsert(dc1Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+
+	_, err = repo.Delete(model.DefaultNamespace, "datacenter-1", Precondition{Match: []int64{2}}, "")
+
+	assert.True(t, errors.Is(err, ErrPreconditionFailed), "Incorrect delete config error")
+	_, err = repo.Get(model.DefaultNamespace, "datacenter-1")
+	assert.NoError(t, err, "Config was deleted despite a failed precondition")
+}
+
 func TestDeleteForMissingConfig(t *testing.T) {
 	repo := NewConfigRepo()
 
-	err := repo.Delete("datacenter-1")
+	_, err := repo.Delete(model.DefaultNamespace, "datacenter-1", Precondition{}, "")
 
 	assert.Error(t, err, "Missing delete config error")
-	assert.Contains(t, err.Error(), "config not found", "Incorrect config")
+	assert.True(t, errors.Is(err, ErrNotFound), "Incorrect delete config error")
+}
+
+func TestUpdate(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.Upsert(dc1Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+
+	updated, err := repo.Update(model.DefaultNamespace, "datacenter-1", Precondition{Match: []int64{1}}, func(current model.Config) (model.Config, error) {
+		assert.Equal(t, *stored(dc1Item, 1), current, "Incorrect current config")
+		current.Metadata = dc2
+		return current, nil
+	})
+
+	expected := stored(model.Config{Name: "datacenter-1", Metadata: dc2}, 2)
+	assert.NoError(t, err, "Unexpected update config error")
+	assert.Equal(t, expected, updated, "Incorrect updated config")
+	config, err := repo.Get(model.DefaultNamespace, "datacenter-1")
+	require.NoError(t, err, "Unexpected get config error")
+	assert.Equal(t, expected, config, "Incorrect stored config")
+}
+
+func TestUpdateForFailedUpdateFunc(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.Upsert(dc1Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+
+	_, err = repo.Update(model.DefaultNamespace, "datacenter-1", Precondition{}, func(current model.Config) (model.Config, error) {
+		return dc2Item, errors.New("some error")
+	})
+
+	assert.Error(t, err, "Missing update config error")
+	config, err := repo.Get(model.DefaultNamespace, "datacenter-1")
+	require.NoError(t, err, "Unexpected get config error")
+	assert.Equal(t, stored(dc1Item, 1), config, "Config was modified by a failed update")
+}
+
+func TestUpdateForMissingConfig(t *testing.T) {
+	repo := NewConfigRepo()
+
+	_, err := repo.Update(model.DefaultNamespace, "datacenter-1", Precondition{}, func(current model.Config) (model.Config, error) {
+		return current, nil
+	})
+
+	assert.Error(t, err, "Missing update config error")
+	assert.True(t, errors.Is(err, ErrNotFound), "Incorrect update config error")
+}
+
+func TestUpdateForStaleVersion(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.Upsert(dc1Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+
+	_, err = repo.Update(model.DefaultNamespace, "datacenter-1", Precondition{Match: []int64{2}}, func(current model.Config) (model.Config, error) {
+		t.Error("Update function called despite a failed precondition")
+		return current, nil
+	})
+
+	assert.True(t, errors.Is(err, ErrPreconditionFailed), "Incorrect update config error")
+}
+
+func TestHistory(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.Create(model.Config{Name: "datacenter-1", Metadata: dc1, UpdatedBy: "alice"})
+	require.NoError(t, err, "Unexpected create config error")
+	_, err = repo.Upsert(model.Config{Name: "datacenter-1", Metadata: dc2, UpdatedBy: "bob"}, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+	_, err = repo.Delete(model.DefaultNamespace, "datacenter-1", Precondition{}, "carol")
+	require.NoError(t, err, "Unexpected delete config error")
+	recreated, err := repo.Create(dc1Item)
+	require.NoError(t, err, "Unexpected create config error")
+
+	history, err := repo.History(model.DefaultNamespace, "datacenter-1")
+
+	assert.NoError(t, err, "Unexpected history error")
+	assert.Equal(t, []model.Revision{
+		{Namespace: model.DefaultNamespace, Name: "datacenter-1", Version: 1, Timestamp: testTime, Author: "alice", Metadata: dc1},
+		{Namespace: model.DefaultNamespace, Name: "datacenter-1", Version: 2, Timestamp: testTime, Author: "bob", Metadata: dc2},
+		{Namespace: model.DefaultNamespace, Name: "datacenter-1", Version: 3, Timestamp: testTime, Author: "carol", Deleted: true},
+		{Namespace: model.DefaultNamespace, Name: "datacenter-1", Version: 4, Timestamp: testTime, Metadata: dc1},
+	}, history, "Incorrect history")
+	assert.Equal(t, int64(4), recreated.Version, "Version was reset by recreating the config")
+}
+
+func TestHistoryForMissingConfig(t *testing.T) {
+	repo := NewConfigRepo()
+
+	_, err := repo.History(model.DefaultNamespace, "datacenter-1")
+
+	assert.True(t, errors.Is(err, ErrNotFound), "Incorrect history error")
+}
+
+func TestHistoryIsNotAffectedByFailedWrites(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.Create(dc1Item)
+	require.NoError(t, err, "Unexpected create config error")
+	_, err = repo.Upsert(dc2Item, Precondition{Match: []int64{1}})
+	require.Error(t, err, "Missing upsert config error")
+
+	history, err := repo.History(model.DefaultNamespace, "datacenter-1")
+
+	assert.NoError(t, err, "Unexpected history error")
+	assert.Len(t, history, 1, "Incorrect history")
+}
+
+func TestRevision(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.Upsert(dc1Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+	_, err = repo.Upsert(model.Config{Name: "datacenter-1", Metadata: dc2}, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+
+	revision, err := repo.Revision(model.DefaultNamespace, "datacenter-1", 1)
+
+	assert.NoError(t, err, "Unexpected revision error")
+	assert.Equal(t, &model.Revision{Namespace: model.DefaultNamespace, Name: "datacenter-1", Version: 1, Timestamp: testTime, Metadata: dc1}, revision,
+		"Incorrect revision")
+}
+
+func TestRevisionForMissingVersion(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.Upsert(dc1Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+
+	_, err = repo.Revision(model.DefaultNamespace, "datacenter-1", 2)
+
+	assert.True(t, errors.Is(err, ErrRevisionNotFound), "Incorrect revision error")
+	assert.True(t, errors.Is(err, ErrNotFound), "Incorrect revision error")
 }
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// Returned when the requested config does not exist
+	ErrNotFound = errors.New("config not found")
+	// Returned when a write conflicts with the current state of a config
+	ErrConflict = errors.New("config conflict")
+	// Returned when creating a config whose name is already taken; it is also an ErrConflict
+	ErrAlreadyExists = fmt.Errorf("config already exists: %w", ErrConflict)
+	// Returned when the stored config does not satisfy the precondition of a write
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// Returned when the requested revision of a config does not exist; it is also an ErrNotFound
+	ErrRevisionNotFound = fmt.Errorf("revision not found: %w", ErrNotFound)
+	// Returned when the requested index does not exist; it is also an ErrNotFound
+	ErrIndexNotFound = fmt.Errorf("index not found: %w", ErrNotFound)
+	// Returned when creating an index on a path that is already indexed; it is also an ErrConflict
+	ErrIndexExists = fmt.Errorf("index already exists: %w", ErrConflict)
+	// Returned when the requested schema does not exist; it is also an ErrNotFound
+	ErrSchemaNotFound = fmt.Errorf("schema not found: %w", ErrNotFound)
+	// Returned when the requested namespace does not exist; it is also an ErrNotFound
+	ErrNamespaceNotFound = fmt.Errorf("namespace not found: %w", ErrNotFound)
+	// Returned when creating a namespace whose name is already taken; it is also an ErrConflict
+	ErrNamespaceExists = fmt.Errorf("namespace already exists: %w", ErrConflict)
+	// Returned when deleting a namespace that still holds configs without cascading; it is also an
+	// ErrConflict
+	ErrNamespaceNotEmpty = fmt.Errorf("namespace not empty: %w", ErrConflict)
+	// Returned when the requested webhook does not exist; it is also an ErrNotFound
+	ErrWebhookNotFound = fmt.Errorf("webhook not found: %w", ErrNotFound)
+)
@@ -0,0 +1,466 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"jsonstore/pkg/model"
+)
+
+const (
+	walFileName        = "wal.log"
+	snapshotFileName   = "snapshot.json"
+	indexesFileName    = "indexes.json"
+	schemasFileName    = "schemas.json"
+	namespacesFileName = "namespaces.json"
+	webhooksFileName   = "webhooks.json"
+
+	opUpsert = "upsert"
+	opDelete = "delete"
+	opBatch  = "batch"
+)
+
+// Represents a single mutation recorded in the write-ahead log
+type walEntry struct {
+	Op string `json:"op"`
+	// Key of the deleted config, as given by key
+	Name     string          `json:"name,omitempty"`
+	Config   *model.Config   `json:"config,omitempty"`
+	Revision *model.Revision `json:"revision,omitempty"`
+	// Entries of a batch, which are replayed all together or not at all
+	Changes []walEntry `json:"changes,omitempty"`
+}
+
+// walFile is the file the write-ahead log is appended to
+type walFile interface {
+	io.WriteSeeker
+	io.Closer
+	Truncate(int64) error
+	Sync() error
+}
+
+// Represents the compacted state of the store persisted on disk
+type snapshot struct {
+	Configs []model.Config   `json:"configs"`
+	History []model.Revision `json:"history,omitempty"`
+}
+
+// fileRepo is the in-memory store with every change journaled to an append-only write-ahead log
+// in dataDir and fsynced before it is applied. Once the log grows past snapshotThreshold
+// entries a background compaction replaces it with a snapshot, and on startup the snapshot and
+// the log are replayed in order.
+type fileRepo struct {
+	*configRepo
+
+	// Guards the write-ahead log
+	mu         sync.Mutex
+	dataDir    string
+	wal        walFile
+	walEntries int
+	// Set once an entry that failed to be written could not be discarded from the log, which may
+	// then hold it; every later write is refused
+	walErr            error
+	snapshotThreshold int
+
+	compactions chan struct{}
+	done        chan struct{}
+	compactor   sync.WaitGroup
+
+	// Close only takes effect once, and every call returns its first result
+	closed   sync.Once
+	closeErr error
+}
+
+var _ io.Closer = (*fileRepo)(nil)
+
+func NewFileConfigRepo(dataDir string, snapshotThreshold int) (Config, error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+
+	repo := &fileRepo{
+		configRepo:        newConfigRepo(),
+		dataDir:           dataDir,
+		snapshotThreshold: snapshotThreshold,
+		compactions:       make(chan struct{}, 1),
+		done:              make(chan struct{}),
+	}
+	if err := repo.loadNamespaces(); err != nil {
+		return nil, err
+	}
+	if err := repo.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := repo.replayWAL(); err != nil {
+		return nil, err
+	}
+	if err := repo.loadIndexes(); err != nil {
+		repo.wal.Close()
+		return nil, err
+	}
+	repo.journal = repo.append
+	repo.saveIndexes = repo.writeIndexes
+	repo.saveNamespaces = repo.writeNamespaces
+
+	repo.compactor.Add(1)
+	go repo.compactWhenNeeded()
+
+	return repo, nil
+}
+
+// Close stops background compaction, flushes the write-ahead log and releases the underlying file
+func (f *fileRepo) Close() error {
+	f.closed.Do(func() { f.closeErr = f.close() })
+	return f.closeErr
+}
+
+func (f *fileRepo) close() error {
+	close(f.done)
+	f.compactor.Wait()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.wal.Sync(); err != nil {
+		return fmt.Errorf("sync wal: %w", err)
+	}
+
+	return f.wal.Close()
+}
+
+// append writes changes to the write-ahead log as a single entry and fsyncs it before the store
+// applies them in memory, scheduling a compaction once the log has grown past the threshold
+func (f *fileRepo) append(changes ...change) error {
+	entry := walEntry{Op: opBatch}
+	for _, ch := range changes {
+		entry.Changes = append(entry.Changes, toWALEntry(ch))
+	}
+	if len(changes) == 1 {
+		entry = entry.Changes[0]
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode wal entry: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.walErr != nil {
+		return fmt.Errorf("wal is unusable: %w", f.walErr)
+	}
+	offset, err := f.wal.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("locate end of wal: %w", err)
+	}
+	if _, err := f.wal.Write(append(line, '\n')); err != nil {
+		return f.discard(offset, fmt.Errorf("write wal: %w", err))
+	}
+	if err := f.wal.Sync(); err != nil {
+		return f.discard(offset, fmt.Errorf("sync wal: %w", err))
+	}
+
+	f.walEntries++
+	if f.snapshotThreshold > 0 && f.walEntries >= f.snapshotThreshold {
+		select {
+		case f.compactions <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// discard truncates the log back to offset, where the entry that failed with cause was written, so
+// that neither a partial entry nor a complete one for a write reported as failed is replayed. When
+// that fails too, the log is left unusable.
+func (f *fileRepo) discard(offset int64, cause error) error {
+	err := f.wal.Truncate(offset)
+	if err == nil {
+		_, err = f.wal.Seek(offset, io.SeekStart)
+	}
+	if err == nil {
+		err = f.wal.Sync()
+	}
+	if err != nil {
+		f.walErr = fmt.Errorf("discard failed entry: %v", err)
+		zap.S().Errorf("Discard wal entry at offset %d: %v", offset, err)
+	}
+	return cause
+}
+
+func toWALEntry(ch change) walEntry {
+	if ch.Config == nil {
+		return walEntry{Op: opDelete, Name: ch.Key, Revision: ch.Revision}
+	}
+	return walEntry{Op: opUpsert, Config: ch.Config, Revision: ch.Revision}
+}
+
+func (f *fileRepo) compactWhenNeeded() {
+	defer f.compactor.Done()
+
+	for {
+		select {
+		case <-f.compactions:
+			if err := f.compact(); err != nil {
+				zap.S().Errorf("Compact wal: %v", err)
+			}
+		case <-f.done:
+			return
+		}
+	}
+}
+
+// compact persists the in-memory state as a snapshot and truncates the write-ahead log. Writers
+// are paused meanwhile so that the snapshot reflects exactly the entries being truncated. The
+// snapshot is written to a temporary file and renamed so that a crash never leaves a partial one.
+func (f *fileRepo) compact() error {
+	f.writers.Lock()
+	defer f.writers.Unlock()
+
+	snap := snapshot{Configs: f.values(), History: f.revisions()}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	tmp := filepath.Join(f.dataDir, snapshotFileName+".tmp")
+	if err := writeFileSync(tmp, data); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(f.dataDir, snapshotFileName)); err != nil {
+		return fmt.Errorf("rename snapshot: %w", err)
+	}
+	if err := syncDir(f.dataDir); err != nil {
+		return fmt.Errorf("sync data dir: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.wal.Truncate(0); err != nil {
+		return fmt.Errorf("truncate wal: %w", err)
+	}
+	if _, err := f.wal.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("rewind wal: %w", err)
+	}
+	f.walEntries = 0
+
+	return f.wal.Sync()
+}
+
+func (f *fileRepo) loadSnapshot() error {
+	data, err := ioutil.ReadFile(filepath.Join(f.dataDir, snapshotFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+	for i := range snap.Configs {
+		config := &snap.Configs[i]
+		if err := f.restore(change{Key: key(config.Namespace, config.Name), Config: config}); err != nil {
+			return fmt.Errorf("restore snapshot: %w", err)
+		}
+	}
+	for _, revision := range snap.History {
+		f.restoreRevision(revision)
+	}
+
+	return nil
+}
+
+// replayWAL applies every complete entry of the write-ahead log on top of the snapshot and
+// opens the log for appending. A trailing partial entry left behind by a crash mid-write is
+// discarded, whereas a malformed entry anywhere else is reported as corruption.
+func (f *fileRepo) replayWAL() error {
+	wal, err := os.OpenFile(filepath.Join(f.dataDir, walFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open wal: %w", err)
+	}
+
+	var offset int64
+	reader := bufio.NewReader(wal)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			wal.Close()
+			return fmt.Errorf("read wal: %w", readErr)
+		}
+
+		var entry walEntry
+		if err := json.Unmarshal(bytes.TrimSpace(line), &entry); err != nil {
+			wal.Close()
+			return fmt.Errorf("decode wal entry at offset %d: %w", offset, err)
+		}
+		if err := f.apply(entry); err != nil {
+			wal.Close()
+			return fmt.Errorf("apply wal entry at offset %d: %w", offset, err)
+		}
+		offset += int64(len(line))
+		f.walEntries++
+	}
+
+	if err := wal.Truncate(offset); err != nil {
+		wal.Close()
+		return fmt.Errorf("truncate wal: %w", err)
+	}
+	if _, err := wal.Seek(offset, io.SeekStart); err != nil {
+		wal.Close()
+		return fmt.Errorf("seek wal: %w", err)
+	}
+	f.wal = wal
+
+	return nil
+}
+
+// apply restores a replayed entry, or every entry of a batch. Entries may already be reflected in
+// a snapshot taken right before a crash, which is harmless since restoring a change is idempotent.
+func (f *fileRepo) apply(entry walEntry) error {
+	switch {
+	case entry.Op == opUpsert && entry.Config != nil:
+		k := key(entry.Config.Namespace, entry.Config.Name)
+		return f.restore(change{Key: k, Config: entry.Config, Revision: entry.Revision})
+	case entry.Op == opDelete:
+		return f.restore(change{Key: entry.Name, Revision: entry.Revision})
+	case entry.Op == opBatch:
+		for _, e := range entry.Changes {
+			if err := f.apply(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown operation %q", entry.Op)
+	}
+}
+
+// loadIndexes rebuilds the indexes declared in the data dir from the restored configs
+func (f *fileRepo) loadIndexes() error {
+	data, err := ioutil.ReadFile(filepath.Join(f.dataDir, indexesFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read indexes: %w", err)
+	}
+
+	var paths []string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return fmt.Errorf("decode indexes: %w", err)
+	}
+	for _, path := range paths {
+		if err := f.CreateIndex(path); err != nil {
+			return fmt.Errorf("create index %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// writeIndexes persists the indexed paths, replacing the previous declaration atomically
+func (f *fileRepo) writeIndexes(paths []string) error {
+	data, err := json.Marshal(paths)
+	if err != nil {
+		return fmt.Errorf("encode indexes: %w", err)
+	}
+
+	if err := replaceFile(f.dataDir, indexesFileName, data); err != nil {
+		return fmt.Errorf("write indexes: %w", err)
+	}
+
+	return nil
+}
+
+// loadNamespaces restores the namespaces created in the data dir
+func (f *fileRepo) loadNamespaces() error {
+	data, err := ioutil.ReadFile(filepath.Join(f.dataDir, namespacesFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read namespaces: %w", err)
+	}
+
+	var namespaces []model.Namespace
+	if err := json.Unmarshal(data, &namespaces); err != nil {
+		return fmt.Errorf("decode namespaces: %w", err)
+	}
+	for _, namespace := range namespaces {
+		f.namespaces.byName[namespace.Name] = namespace
+	}
+
+	return nil
+}
+
+// writeNamespaces persists the created namespaces, replacing the previous ones atomically
+func (f *fileRepo) writeNamespaces(namespaces []model.Namespace) error {
+	data, err := json.Marshal(namespaces)
+	if err != nil {
+		return fmt.Errorf("encode namespaces: %w", err)
+	}
+
+	if err := replaceFile(f.dataDir, namespacesFileName, data); err != nil {
+		return fmt.Errorf("write namespaces: %w", err)
+	}
+
+	return nil
+}
+
+// replaceFile atomically replaces the content of a file in dir, through a temporary file that is
+// fsynced and renamed over it
+func replaceFile(dir, name string, data []byte) error {
+	tmp := filepath.Join(dir, name+".tmp")
+	if err := writeFileSync(tmp, data); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, name)); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
@@ -0,0 +1,320 @@
+package db
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/model"
+)
+
+func newTestFileRepo(t *testing.T, dir string, snapshotThreshold int) *fileRepo {
+	repo, err := NewFileConfigRepo(dir, snapshotThreshold)
+	require.NoError(t, err, "Unexpected open file repo error")
+	fileRepo := repo.(*fileRepo)
+	fileRepo.now = func() time.Time { return testTime }
+	return fileRepo
+}
+
+func tempDataDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "jsonstore")
+	require.NoError(t, err, "Unexpected temp dir error")
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestFileRepoReplaysWAL(t *testing.T) {
+	dir := tempDataDir(t)
+	repo := newTestFileRepo(t, dir, 100)
+	_, err := repo.Upsert(dc1Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+	_, err = repo.Upsert(dc2Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+	_, err = repo.Delete(model.DefaultNamespace, "datacenter-1", Precondition{}, "")
+	require.NoError(t, err, "Unexpected delete config error")
+	require.NoError(t, repo.Close(), "Unexpected close error")
+
+	reopened := newTestFileRepo(t, dir, 100)
+
+	configs, err := reopened.GetAll(model.DefaultNamespace)
+	assert.NoError(t, err, "Unexpected get all configs error")
+	assert.Equal(t, []model.Config{*stored(dc2Item, 1)}, configs, "Incorrect configs after replay")
+	_, err = reopened.Get(model.DefaultNamespace, "datacenter-1")
+	assert.Error(t, err, "Deleted config was replayed")
+}
+
+func TestFileRepoCloseTwice(t *testing.T) {
+	repo := newTestFileRepo(t, tempDataDir(t), 100)
+
+	assert.NoError(t, repo.Close(), "Unexpected close error")
+	assert.NoError(t, repo.Close(), "Unexpected second close error")
+}
+
+func TestFileRepoCompactsIntoSnapshot(t *testing.T) {
+	dir := tempDataDir(t)
+	repo := newTestFileRepo(t, dir, 100)
+	_, err := repo.Upsert(dc1Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+	_, err = repo.Upsert(dc2Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+
+	require.NoError(t, repo.compact(), "Unexpected compact error")
+	_, err = repo.Delete(model.DefaultNamespace, "datacenter-2", Precondition{}, "")
+	require.NoError(t, err, "Unexpected delete config error")
+	require.NoError(t, repo.Close(), "Unexpected close error")
+
+	_, err = os.Stat(filepath.Join(dir, snapshotFileName))
+	assert.NoError(t, err, "Missing snapshot file")
+	wal, err := ioutil.ReadFile(filepath.Join(dir, walFileName))
+	require.NoError(t, err, "Unexpected read wal error")
+	assert.Contains(t, string(wal), `"op":"delete"`, "Incorrect wal after compaction")
+	assert.NotContains(t, string(wal), `"op":"upsert"`, "Wal was not truncated by compaction")
+
+	reopened := newTestFileRepo(t, dir, 100)
+
+	configs, err := reopened.GetAll(model.DefaultNamespace)
+	assert.NoError(t, err, "Unexpected get all configs error")
+	assert.Equal(t, []model.Config{*stored(dc1Item, 1)}, configs, "Incorrect configs after replay")
+}
+
+func TestFileRepoCompactsPastThreshold(t *testing.T) {
+	dir := tempDataDir(t)
+	repo := newTestFileRepo(t, dir, 2)
+	defer repo.Close()
+
+	_, err := repo.Upsert(dc1Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+	_, err = repo.Upsert(dc2Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(dir, snapshotFileName))
+		return err == nil
+	}, time.Second, 10*time.Millisecond, "Missing snapshot file")
+}
+
+func TestFileRepoPersistsHistory(t *testing.T) {
+	dir := tempDataDir(t)
+	repo := newTestFileRepo(t, dir, 100)
+	_, err := repo.Upsert(dc1Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+	_, err = repo.Delete(model.DefaultNamespace, "datacenter-1", Precondition{}, "alice")
+	require.NoError(t, err, "Unexpected delete config error")
+	require.NoError(t, repo.compact(), "Unexpected compact error")
+	_, err = repo.Upsert(dc1Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+	require.NoError(t, repo.Close(), "Unexpected close error")
+
+	reopened := newTestFileRepo(t, dir, 100)
+	defer reopened.Close()
+
+	history, err := reopened.History(model.DefaultNamespace, "datacenter-1")
+	assert.NoError(t, err, "Unexpected history error")
+	assert.Equal(t, []model.Revision{
+		{Namespace: model.DefaultNamespace, Name: "datacenter-1", Version: 1, Timestamp: testTime, Metadata: dc1},
+		{Namespace: model.DefaultNamespace, Name: "datacenter-1", Version: 2, Timestamp: testTime, Author: "alice", Deleted: true},
+		{Namespace: model.DefaultNamespace, Name: "datacenter-1", Version: 3, Timestamp: testTime, Metadata: dc1},
+	}, history, "Incorrect history after replay")
+	config, err := reopened.Upsert(dc1Item, Precondition{})
+	assert.NoError(t, err, "Unexpected upsert config error")
+	assert.Equal(t, int64(4), config.Version, "Incorrect version after replay")
+}
+
+func TestFileRepoPersistsIndexes(t *testing.T) {
+	dir := tempDataDir(t)
+	repo := newTestFileRepo(t, dir, 100)
+	_, err := repo.Upsert(regionConfig("dc-1", map[string]interface{}{"region": "eu"}), Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+	require.NoError(t, repo.CreateIndex("metadata.region"), "Unexpected create index error")
+	require.NoError(t, repo.CreateIndex("metadata.replicas"), "Unexpected create index error")
+	require.NoError(t, repo.DropIndex("metadata.replicas"), "Unexpected drop index error")
+	require.NoError(t, repo.Close(), "Unexpected close error")
+
+	reopened := newTestFileRepo(t, dir, 100)
+	defer reopened.Close()
+
+	indexes, err := reopened.Indexes()
+	assert.NoError(t, err, "Unexpected indexes error")
+	assert.Equal(t, []model.Index{{Path: "metadata.region", Values: 1, Entries: 1}}, indexes,
+		"Incorrect indexes after reopening")
+}
+
+func TestFileRepoDiscardsTornWALEntry(t *testing.T) {
+	dir := tempDataDir(t)
+	repo := newTestFileRepo(t, dir, 100)
+	_, err := repo.Upsert(dc1Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+	require.NoError(t, repo.Close(), "Unexpected close error")
+
+	wal, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err, "Unexpected open wal error")
+	_, err = wal.WriteString(`{"op":"upsert","config":{"name":"datacenter-2"`)
+	require.NoError(t, err, "Unexpected write wal error")
+	require.NoError(t, wal.Close(), "Unexpected close wal error")
+
+	reopened := newTestFileRepo(t, dir, 100)
+	_, err = reopened.Upsert(dc2Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+	require.NoError(t, reopened.Close(), "Unexpected close error")
+
+	replayed := newTestFileRepo(t, dir, 100)
+
+	configs, err := replayed.GetAll(model.DefaultNamespace)
+	assert.NoError(t, err, "Unexpected get all configs error")
+	assert.Equal(t, []model.Config{*stored(dc1Item, 1), *stored(dc2Item, 1)}, configs, "Incorrect configs after replay")
+}
+
+func TestFileRepoForCorruptWAL(t *testing.T) {
+	dir := tempDataDir(t)
+	err := ioutil.WriteFile(filepath.Join(dir, walFileName), []byte("not json\n{}\n"), 0o644)
+	require.NoError(t, err, "Unexpected write wal error")
+
+	_, err = NewFileConfigRepo(dir, 100)
+
+	assert.Error(t, err, "Missing corrupt wal error")
+	assert.Contains(t, err.Error(), "decode wal entry at offset 0", "Incorrect corrupt wal error")
+}
+
+func TestFileRepoDeleteForMissingConfig(t *testing.T) {
+	repo := newTestFileRepo(t, tempDataDir(t), 100)
+	defer repo.Close()
+
+	_, err := repo.Delete(model.DefaultNamespace, "datacenter-1", Precondition{}, "")
+
+	assert.Error(t, err, "Missing delete config error")
+	assert.True(t, errors.Is(err, ErrNotFound), "Incorrect delete config error")
+}
+
+func TestFileRepoPersistsUpdate(t *testing.T) {
+	dir := tempDataDir(t)
+	repo := newTestFileRepo(t, dir, 100)
+	_, err := repo.Upsert(dc1Item, Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+
+	_, err = repo.Update(model.DefaultNamespace, "datacenter-1", Precondition{}, func(current model.Config) (model.Config, error) {
+		current.Metadata = dc2
+		return current, nil
+	})
+	require.NoError(t, err, "Unexpected update config error")
+	require.NoError(t, repo.Close(), "Unexpected close error")
+
+	reopened := newTestFileRepo(t, dir, 100)
+
+	config, err := reopened.Get(model.DefaultNamespace, "datacenter-1")
+	assert.NoError(t, err, "Unexpected get config error")
+	assert.Equal(t, stored(model.Config{Name: "datacenter-1", Metadata: dc2}, 2), config, "Incorrect config after replay")
+}
+
+func TestFileRepoCreateForExistingConfig(t *testing.T) {
+	dir := tempDataDir(t)
+	repo := newTestFileRepo(t, dir, 100)
+	_, err := repo.Create(dc1Item)
+	require.NoError(t, err, "Unexpected create config error")
+
+	_, err = repo.Create(model.Config{Name: "datacenter-1", Metadata: dc2})
+
+	assert.Error(t, err, "Missing create config error")
+	assert.True(t, errors.Is(err, ErrAlreadyExists), "Incorrect create config error")
+	require.NoError(t, repo.Close(), "Unexpected close error")
+
+	reopened := newTestFileRepo(t, dir, 100)
+	config, err := reopened.Get(model.DefaultNamespace, "datacenter-1")
+	assert.NoError(t, err, "Unexpected get config error")
+	assert.Equal(t, stored(dc1Item, 1), config, "Incorrect config after replay")
+}
+
+func TestFileRepoForFailedWrite(t *testing.T) {
+	repo := newTestFileRepo(t, tempDataDir(t), 100)
+	require.NoError(t, repo.wal.Close(), "Unexpected close wal error")
+	defer repo.Close() //nolint:errcheck
+
+	_, err := repo.Create(dc1Item)
+
+	assert.Error(t, err, "Missing create config error")
+	_, err = repo.Get(model.DefaultNamespace, "datacenter-1")
+	assert.True(t, errors.Is(err, ErrNotFound), "Config was applied although journaling it failed")
+}
+
+// failingWAL is a write-ahead log whose writes or truncations fail, or whose next sync fails
+type failingWAL struct {
+	walFile
+	failWrite, failSync, failTruncate bool
+}
+
+var errDisk = errors.New("disk failure")
+
+// Write writes half of b before failing, as a full disk would
+func (w *failingWAL) Write(b []byte) (int, error) {
+	if w.failWrite {
+		n, _ := w.walFile.Write(b[:len(b)/2])
+		return n, errDisk
+	}
+	return w.walFile.Write(b)
+}
+
+func (w *failingWAL) Sync() error {
+	if w.failSync {
+		w.failSync = false
+		return errDisk
+	}
+	return w.walFile.Sync()
+}
+
+func (w *failingWAL) Truncate(size int64) error {
+	if w.failTruncate {
+		return errDisk
+	}
+	return w.walFile.Truncate(size)
+}
+
+func TestFileRepoDiscardsFailedWALEntry(t *testing.T) {
+	dc3 := model.Config{Name: "datacenter-3", Metadata: dc1}
+	for name, wal := range map[string]*failingWAL{
+		"partial write": {failWrite: true},
+		"failed sync":   {failSync: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := tempDataDir(t)
+			repo := newTestFileRepo(t, dir, 100)
+			_, err := repo.Create(dc1Item)
+			require.NoError(t, err, "Unexpected create config error")
+			wal.walFile = repo.wal
+			repo.wal = wal
+
+			_, err = repo.Create(dc2Item)
+			assert.True(t, errors.Is(err, errDisk), "Incorrect create config error: %v", err)
+			repo.wal = wal.walFile
+			_, err = repo.Create(dc3)
+			require.NoError(t, err, "Unexpected create config error after a failed write")
+			require.NoError(t, repo.Close(), "Unexpected close error")
+
+			reopened := newTestFileRepo(t, dir, 100)
+			defer reopened.Close() //nolint:errcheck
+			configs, err := reopened.GetAll(model.DefaultNamespace)
+			require.NoError(t, err, "Unexpected get all configs error")
+			assert.Equal(t, []model.Config{*stored(dc1Item, 1), *stored(dc3, 1)}, configs, "Incorrect replayed configs")
+		})
+	}
+}
+
+func TestFileRepoForUndiscardableWALEntry(t *testing.T) {
+	repo := newTestFileRepo(t, tempDataDir(t), 100)
+	defer repo.Close() //nolint:errcheck
+	wal := &failingWAL{walFile: repo.wal, failSync: true, failTruncate: true}
+	repo.wal = wal
+
+	_, err := repo.Create(dc1Item)
+	assert.True(t, errors.Is(err, errDisk), "Incorrect create config error: %v", err)
+	repo.wal = wal.walFile
+	_, err = repo.Create(dc2Item)
+
+	assert.Error(t, err, "Write was accepted by an unusable wal")
+	_, err = repo.Get(model.DefaultNamespace, "datacenter-2")
+	assert.True(t, errors.Is(err, ErrNotFound), "Config was applied although the wal is unusable")
+}
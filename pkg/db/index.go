@@ -0,0 +1,283 @@
+package db
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/tidwall/gjson"
+
+	"jsonstore/pkg/model"
+	"jsonstore/pkg/query"
+)
+
+// indexSet holds the secondary indexes of the store by path. It is updated while the shard of
+// the changed config is locked, so an index always reflects the latest applied change of each
+// config.
+type indexSet struct {
+	sync.RWMutex
+	byPath map[string]*index
+}
+
+// index maps each scalar value found at a path to the names of the configs holding it. Arrays
+// are indexed by their elements, mirroring how conditions are evaluated against them.
+type index struct {
+	entries map[query.Value]map[string]struct{}
+}
+
+func newIndexSet() *indexSet {
+	return &indexSet{byPath: map[string]*index{}}
+}
+
+func (c *configRepo) CreateIndex(path string) error {
+	c.writers.Lock()
+	defer c.writers.Unlock()
+
+	c.indexes.Lock()
+	defer c.indexes.Unlock()
+
+	if _, ok := c.indexes.byPath[path]; ok {
+		return ErrIndexExists
+	}
+	if c.saveIndexes != nil {
+		if err := c.saveIndexes(append(c.indexes.paths(), path)); err != nil {
+			return err
+		}
+	}
+
+	idx := &index{entries: map[query.Value]map[string]struct{}{}}
+	for _, s := range c.shards {
+		s.RLock()
+		for name, doc := range s.docs {
+			idx.add(name, indexKeys(doc, path))
+		}
+		s.RUnlock()
+	}
+	c.indexes.byPath[path] = idx
+
+	return nil
+}
+
+func (c *configRepo) DropIndex(path string) error {
+	c.indexes.Lock()
+	defer c.indexes.Unlock()
+
+	if _, ok := c.indexes.byPath[path]; !ok {
+		return ErrIndexNotFound
+	}
+	if c.saveIndexes != nil {
+		paths := c.indexes.paths()
+		for i, p := range paths {
+			if p == path {
+				paths = append(paths[:i], paths[i+1:]...)
+				break
+			}
+		}
+		if err := c.saveIndexes(paths); err != nil {
+			return err
+		}
+	}
+	delete(c.indexes.byPath, path)
+
+	return nil
+}
+
+// Indexes describes every index ordered by path
+func (c *configRepo) Indexes() ([]model.Index, error) {
+	c.indexes.RLock()
+	defer c.indexes.RUnlock()
+
+	indexes := []model.Index{}
+	for _, path := range c.indexes.paths() {
+		stats := model.Index{Path: path, Values: len(c.indexes.byPath[path].entries)}
+		for _, names := range c.indexes.byPath[path].entries {
+			stats.Entries += len(names)
+		}
+		indexes = append(indexes, stats)
+	}
+
+	return indexes, nil
+}
+
+// paths returns the indexed paths in order
+func (set *indexSet) paths() []string {
+	paths := make([]string, 0, len(set.byPath))
+	for path := range set.byPath {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+	return paths
+}
+
+// update moves a config from the entries of its previous JSON representation to those of the
+// next one; either is empty when the config did not or no longer exists
+func (set *indexSet) update(name, previous, next string) {
+	set.Lock()
+	defer set.Unlock()
+
+	for path, idx := range set.byPath {
+		idx.remove(name, indexKeys(previous, path))
+		idx.add(name, indexKeys(next, path))
+	}
+}
+
+// candidates returns the names of the configs that may match expr according to the indexes, or
+// false when the indexes cannot narrow it down and every config has to be evaluated
+func (set *indexSet) candidates(expr query.Expr) (map[string]struct{}, bool) {
+	set.RLock()
+	defer set.RUnlock()
+
+	if len(set.byPath) == 0 {
+		return nil, false
+	}
+	return set.plan(expr)
+}
+
+func (set *indexSet) plan(expr query.Expr) (map[string]struct{}, bool) {
+	switch e := expr.(type) {
+	case query.And:
+		left, leftOK := set.plan(e.Left)
+		right, rightOK := set.plan(e.Right)
+		switch {
+		case leftOK && rightOK:
+			return intersect(left, right), true
+		case leftOK:
+			return left, true
+		default:
+			return right, rightOK
+		}
+	case query.Or:
+		left, leftOK := set.plan(e.Left)
+		right, rightOK := set.plan(e.Right)
+		if !leftOK || !rightOK {
+			return nil, false
+		}
+		return union(left, right), true
+	case query.In:
+		idx, ok := set.byPath[e.Path]
+		if !ok {
+			return nil, false
+		}
+		names := map[string]struct{}{}
+		for _, value := range e.Values {
+			names = union(names, idx.entries[value])
+		}
+		return names, true
+	case query.Comparison:
+		idx, ok := set.byPath[e.Path]
+		if !ok || e.Op == query.OpNe {
+			return nil, false
+		}
+		if e.Op == query.OpEq {
+			return union(map[string]struct{}{}, idx.entries[e.Value]), true
+		}
+		// Other operators are answered by testing every distinct value of the index, which is
+		// still far cheaper than evaluating every config
+		names := map[string]struct{}{}
+		for value, entries := range idx.entries {
+			if compare(toResult(value), e) {
+				names = union(names, entries)
+			}
+		}
+		return names, true
+	default:
+		// NOT and EXISTS also match configs that have no indexed value
+		return nil, false
+	}
+}
+
+func (idx *index) add(name string, keys []query.Value) {
+	for _, key := range keys {
+		names, ok := idx.entries[key]
+		if !ok {
+			names = map[string]struct{}{}
+			idx.entries[key] = names
+		}
+		names[name] = struct{}{}
+	}
+}
+
+func (idx *index) remove(name string, keys []query.Value) {
+	for _, key := range keys {
+		delete(idx.entries[key], name)
+		if len(idx.entries[key]) == 0 {
+			delete(idx.entries, key)
+		}
+	}
+}
+
+// indexKeys returns the scalar values found at path in a JSON document
+func indexKeys(doc, path string) []query.Value {
+	if doc == "" {
+		return nil
+	}
+	result := gjson.Get(doc, path)
+	if !result.IsArray() {
+		if key, ok := toValue(result); ok {
+			return []query.Value{key}
+		}
+		return nil
+	}
+
+	var keys []query.Value
+	result.ForEach(func(_, element gjson.Result) bool {
+		if key, ok := toValue(element); ok {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	return keys
+}
+
+func toValue(result gjson.Result) (query.Value, bool) {
+	switch result.Type {
+	case gjson.String:
+		return query.Value{Kind: query.String, Str: result.Str}, true
+	case gjson.Number:
+		return query.Value{Kind: query.Number, Num: result.Num}, true
+	case gjson.True, gjson.False:
+		return query.Value{Kind: query.Bool, Bool: result.Bool()}, true
+	case gjson.Null:
+		if result.Exists() {
+			return query.Value{Kind: query.Null}, true
+		}
+	}
+
+	return query.Value{}, false
+}
+
+func toResult(value query.Value) gjson.Result {
+	switch value.Kind {
+	case query.String:
+		return gjson.Result{Type: gjson.String, Str: value.Str}
+	case query.Number:
+		return gjson.Result{Type: gjson.Number, Num: value.Num}
+	case query.Bool:
+		if value.Bool {
+			return gjson.Result{Type: gjson.True}
+		}
+		return gjson.Result{Type: gjson.False}
+	default:
+		return gjson.Result{Type: gjson.Null, Raw: "null"}
+	}
+}
+
+func union(a, b map[string]struct{}) map[string]struct{} {
+	for name := range b {
+		a[name] = struct{}{}
+	}
+	return a
+}
+
+func intersect(a, b map[string]struct{}) map[string]struct{} {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	names := map[string]struct{}{}
+	for name := range a {
+		if _, ok := b[name]; ok {
+			names[name] = struct{}{}
+		}
+	}
+	return names
+}
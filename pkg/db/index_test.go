@@ -0,0 +1,147 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/model"
+	"jsonstore/pkg/query"
+)
+
+func regionConfig(name string, metadata map[string]interface{}) model.Config {
+	return model.Config{Name: name, Metadata: metadata}
+}
+
+func newIndexedTestRepo(t *testing.T, paths ...string) *configRepo {
+	repo := newTestConfigRepo()
+	configs := []model.Config{
+		regionConfig("dc-1", map[string]interface{}{"region": "eu", "replicas": 1, "tags": []interface{}{"prod"}}),
+		regionConfig("dc-2", map[string]interface{}{"region": "eu", "replicas": 3, "tags": []interface{}{"gpu", "prod"}}),
+		regionConfig("dc-3", map[string]interface{}{"region": "us", "replicas": 5}),
+		regionConfig("dc-4", map[string]interface{}{"replicas": 5, "beta": true}),
+	}
+	for _, config := range configs {
+		_, err := repo.Upsert(config, Precondition{})
+		require.NoError(t, err, "Unexpected upsert config error")
+	}
+	for _, path := range paths {
+		require.NoError(t, repo.CreateIndex(path), "Unexpected create index error")
+	}
+	return repo
+}
+
+func names(configs []model.Config) []string {
+	names := []string{}
+	for _, config := range configs {
+		names = append(names, config.Name)
+	}
+	return names
+}
+
+func TestSearchWithIndexes(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected []string
+		indexed  bool
+	}{
+		{`metadata.region = "eu"`, []string{"dc-1", "dc-2"}, true},
+		{`metadata.region in ("us", "ap")`, []string{"dc-3"}, true},
+		{`metadata.replicas >= 3`, []string{"dc-2", "dc-3", "dc-4"}, true},
+		{`metadata.tags = "gpu"`, []string{"dc-2"}, true},
+		{`metadata.region = "eu" AND metadata.replicas > 1`, []string{"dc-2"}, true},
+		{`metadata.region = "us" OR metadata.tags = "gpu"`, []string{"dc-2", "dc-3"}, true},
+		{`metadata.region ^= "e" AND metadata.beta exists`, []string{}, true},
+		{`metadata.region = "eu" OR metadata.beta = true`, []string{"dc-1", "dc-2", "dc-4"}, false},
+		{`metadata.region != "eu"`, []string{"dc-3", "dc-4"}, false},
+		{`NOT metadata.region = "eu"`, []string{"dc-3", "dc-4"}, false},
+	}
+
+	repo := newIndexedTestRepo(t, "metadata.region", "metadata.replicas", "metadata.tags")
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := query.Parse(tt.expr)
+			require.NoError(t, err, "Unexpected parse query error")
+
+			configs, err := repo.Search(model.DefaultNamespace, expr)
+
+			assert.NoError(t, err, "Unexpected search config error")
+			assert.Equal(t, tt.expected, names(configs), "Incorrect configs")
+			_, indexed := repo.indexes.candidates(expr)
+			assert.Equal(t, tt.indexed, indexed, "Incorrect use of indexes")
+		})
+	}
+}
+
+func TestIndexIsMaintainedOnWrite(t *testing.T) {
+	repo := newIndexedTestRepo(t, "metadata.region")
+	expr, err := query.Parse(`metadata.region = "eu"`)
+	require.NoError(t, err, "Unexpected parse query error")
+
+	_, err = repo.Upsert(regionConfig("dc-1", map[string]interface{}{"region": "us"}), Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+	_, err = repo.Upsert(regionConfig("dc-5", map[string]interface{}{"region": "eu"}), Precondition{})
+	require.NoError(t, err, "Unexpected upsert config error")
+	_, err = repo.Delete(model.DefaultNamespace, "dc-2", Precondition{}, "")
+	require.NoError(t, err, "Unexpected delete config error")
+
+	candidates, ok := repo.indexes.candidates(expr)
+	assert.True(t, ok, "Index was not used")
+	assert.Equal(t, map[string]struct{}{"dc-5": {}}, candidates, "Incorrect index entries")
+}
+
+func TestIndexes(t *testing.T) {
+	repo := newIndexedTestRepo(t, "metadata.tags", "metadata.region")
+
+	indexes, err := repo.Indexes()
+
+	assert.NoError(t, err, "Unexpected indexes error")
+	assert.Equal(t, []model.Index{
+		{Path: "metadata.region", Values: 2, Entries: 3},
+		{Path: "metadata.tags", Values: 2, Entries: 3},
+	}, indexes, "Incorrect indexes")
+}
+
+func TestCreateIndexForExistingIndex(t *testing.T) {
+	repo := newIndexedTestRepo(t, "metadata.region")
+
+	err := repo.CreateIndex("metadata.region")
+
+	assert.True(t, errors.Is(err, ErrIndexExists), "Incorrect create index error")
+	assert.True(t, errors.Is(err, ErrConflict), "Incorrect create index error")
+}
+
+func TestDropIndex(t *testing.T) {
+	repo := newIndexedTestRepo(t, "metadata.region")
+
+	err := repo.DropIndex("metadata.region")
+
+	assert.NoError(t, err, "Unexpected drop index error")
+	indexes, err := repo.Indexes()
+	require.NoError(t, err, "Unexpected indexes error")
+	assert.Empty(t, indexes, "Index was not dropped")
+}
+
+func TestDropIndexForMissingIndex(t *testing.T) {
+	repo := newIndexedTestRepo(t)
+
+	err := repo.DropIndex("metadata.region")
+
+	assert.True(t, errors.Is(err, ErrIndexNotFound), "Incorrect drop index error")
+	assert.True(t, errors.Is(err, ErrNotFound), "Incorrect drop index error")
+}
+
+func TestCreateIndexForFailedSave(t *testing.T) {
+	repo := newIndexedTestRepo(t)
+	repo.saveIndexes = func([]string) error { return fmt.Errorf("some error") }
+
+	err := repo.CreateIndex("metadata.region")
+
+	assert.Error(t, err, "Missing create index error")
+	indexes, err := repo.Indexes()
+	require.NoError(t, err, "Unexpected indexes error")
+	assert.Empty(t, indexes, "Index was created although saving it failed")
+}
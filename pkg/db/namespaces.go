@@ -0,0 +1,163 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"jsonstore/pkg/model"
+)
+
+// namespaceSet holds the namespaces created in the store. The default namespace is implicit and
+// never part of the set.
+type namespaceSet struct {
+	sync.RWMutex
+	byName map[string]model.Namespace
+}
+
+func newNamespaceSet() *namespaceSet {
+	return &namespaceSet{byName: map[string]model.Namespace{}}
+}
+
+// canonical returns the name of a namespace, where an empty name stands for the default one
+func canonical(namespace string) string {
+	if namespace == "" {
+		return model.DefaultNamespace
+	}
+	return namespace
+}
+
+// key identifies a config across namespaces. Config names cannot contain a slash, and configs of
+// the default namespace are keyed by their bare name as they were before namespaces existed.
+func key(namespace, name string) string {
+	if namespace = canonical(namespace); namespace == model.DefaultNamespace {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+func (c *configRepo) Namespace(name string) (*model.Namespace, error) {
+	if canonical(name) == model.DefaultNamespace {
+		return &model.Namespace{Name: model.DefaultNamespace}, nil
+	}
+
+	c.namespaces.RLock()
+	defer c.namespaces.RUnlock()
+
+	namespace, ok := c.namespaces.byName[name]
+	if !ok {
+		return nil, ErrNamespaceNotFound
+	}
+
+	return &namespace, nil
+}
+
+// Namespaces returns every namespace ordered by name, including the default one
+func (c *configRepo) Namespaces() ([]model.Namespace, error) {
+	c.namespaces.RLock()
+	defer c.namespaces.RUnlock()
+
+	namespaces := append(c.namespaces.sorted(), model.Namespace{Name: model.DefaultNamespace})
+	sort.Slice(namespaces, func(i, j int) bool { return namespaces[i].Name < namespaces[j].Name })
+	return namespaces, nil
+}
+
+func (c *configRepo) CreateNamespace(name string) (*model.Namespace, error) {
+	c.namespaces.Lock()
+	defer c.namespaces.Unlock()
+
+	if _, ok := c.namespaces.byName[name]; ok || canonical(name) == model.DefaultNamespace {
+		return nil, ErrNamespaceExists
+	}
+
+	namespace := model.Namespace{Name: name, CreatedAt: c.now().UTC()}
+	if c.saveNamespaces != nil {
+		if err := c.saveNamespaces(append(c.namespaces.sorted(), namespace)); err != nil {
+			return nil, err
+		}
+	}
+	c.namespaces.byName[name] = namespace
+
+	return &namespace, nil
+}
+
+// DeleteNamespace removes a namespace. A namespace holding configs is only removed with cascade,
+// which deletes each of its configs on behalf of author and returns the revisions recording their
+// deletion; their history is kept like that of any deleted config. The removal of the namespace is
+// persisted first and the deletions are then journaled at once, the namespace being persisted
+// again should that fail, so that it is left either intact or removed along with its configs.
+// Writers are paused meanwhile so that no config is created in the namespace while it is emptied.
+func (c *configRepo) DeleteNamespace(name string, cascade bool, author string) ([]model.Revision, error) {
+	c.writers.Lock()
+	defer c.writers.Unlock()
+
+	c.namespaces.Lock()
+	defer c.namespaces.Unlock()
+
+	if _, ok := c.namespaces.byName[name]; !ok {
+		return nil, ErrNamespaceNotFound
+	}
+
+	configs := c.valuesIn(name)
+	if len(configs) > 0 && !cascade {
+		return nil, fmt.Errorf("%w: %d configs such as %s are left", ErrNamespaceNotEmpty, len(configs), configs[0].Name)
+	}
+	changes := make([]change, 0, len(configs))
+	for _, config := range configs {
+		config := config
+		_, latest := c.current(name, config.Name)
+		changes = append(changes, c.revise(name, config.Name, &config, nil, latest, author))
+	}
+
+	if c.saveNamespaces != nil {
+		namespaces := c.namespaces.sorted()
+		remaining := make([]model.Namespace, 0, len(namespaces)-1)
+		for _, namespace := range namespaces {
+			if namespace.Name != name {
+				remaining = append(remaining, namespace)
+			}
+		}
+		if err := c.saveNamespaces(remaining); err != nil {
+			return nil, err
+		}
+		if err := c.commitAll(changes); err != nil {
+			if restoreErr := c.saveNamespaces(namespaces); restoreErr != nil {
+				return nil, fmt.Errorf("delete configs: %w, and restore namespace: %v", err, restoreErr)
+			}
+			return nil, fmt.Errorf("delete configs: %w", err)
+		}
+	} else if err := c.commitAll(changes); err != nil {
+		return nil, fmt.Errorf("delete configs: %w", err)
+	}
+	delete(c.namespaces.byName, name)
+
+	deleted := make([]model.Revision, 0, len(changes))
+	for _, ch := range changes {
+		deleted = append(deleted, *ch.Revision)
+	}
+	return deleted, nil
+}
+
+// exists tells whether a namespace can hold configs
+func (set *namespaceSet) exists(name string) bool {
+	if canonical(name) == model.DefaultNamespace {
+		return true
+	}
+
+	set.RLock()
+	defer set.RUnlock()
+
+	_, ok := set.byName[name]
+	return ok
+}
+
+// sorted returns the created namespaces ordered by name
+func (set *namespaceSet) sorted() []model.Namespace {
+	namespaces := make([]model.Namespace, 0, len(set.byName))
+	for _, namespace := range set.byName {
+		namespaces = append(namespaces, namespace)
+	}
+
+	sort.Slice(namespaces, func(i, j int) bool { return namespaces[i].Name < namespaces[j].Name })
+	return namespaces
+}
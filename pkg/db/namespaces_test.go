@@ -0,0 +1,193 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/model"
+	"jsonstore/pkg/query"
+)
+
+func inNamespace(item model.Config, namespace string) model.Config {
+	item.Namespace = namespace
+	return item
+}
+
+func TestNamespacesScopeConfigNames(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.CreateNamespace("team-a")
+	require.NoError(t, err, "Unexpected create namespace error")
+	_, err = repo.Create(dc1Item)
+	require.NoError(t, err, "Unexpected create config error")
+
+	created, err := repo.Create(inNamespace(model.Config{Name: "datacenter-1", Metadata: dc2}, "team-a"))
+
+	assert.NoError(t, err, "Unexpected create config error")
+	assert.Equal(t, int64(1), created.Version, "Incorrect version")
+	config, err := repo.Get(model.DefaultNamespace, "datacenter-1")
+	require.NoError(t, err, "Unexpected get config error")
+	assert.Equal(t, dc1, config.Metadata, "Config of the default namespace was overwritten")
+	configs, err := repo.GetAll("team-a")
+	require.NoError(t, err, "Unexpected get all configs error")
+	assert.Equal(t, []model.Config{*stored(inNamespace(model.Config{Name: "datacenter-1", Metadata: dc2}, "team-a"), 1)},
+		configs, "Incorrect configs")
+	all, err := repo.Search("team-a", query.Exists{Path: "name"})
+	require.NoError(t, err, "Unexpected search error")
+	assert.Len(t, all, 1, "Search is not scoped to the namespace")
+}
+
+func TestNamespacesForMissingNamespace(t *testing.T) {
+	repo := newTestConfigRepo()
+
+	_, err := repo.Create(inNamespace(dc1Item, "team-a"))
+	assert.True(t, errors.Is(err, ErrNamespaceNotFound), "Incorrect create config error")
+	_, err = repo.Get("team-a", "datacenter-1")
+	assert.True(t, errors.Is(err, ErrNamespaceNotFound), "Incorrect get config error")
+	_, err = repo.GetAll("team-a")
+	assert.True(t, errors.Is(err, ErrNotFound), "Incorrect get all configs error")
+}
+
+func TestNamespacesListsDefaultNamespace(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.CreateNamespace("team-b")
+	require.NoError(t, err, "Unexpected create namespace error")
+	_, err = repo.CreateNamespace("alpha")
+	require.NoError(t, err, "Unexpected create namespace error")
+
+	namespaces, err := repo.Namespaces()
+
+	assert.NoError(t, err, "Unexpected namespaces error")
+	assert.Equal(t, []model.Namespace{
+		{Name: "alpha", CreatedAt: testTime},
+		{Name: model.DefaultNamespace},
+		{Name: "team-b", CreatedAt: testTime},
+	}, namespaces, "Incorrect namespaces")
+}
+
+func TestCreateNamespaceForExistingNamespace(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.CreateNamespace("team-a")
+	require.NoError(t, err, "Unexpected create namespace error")
+
+	_, err = repo.CreateNamespace("team-a")
+	assert.True(t, errors.Is(err, ErrNamespaceExists), "Incorrect create namespace error")
+	_, err = repo.CreateNamespace(model.DefaultNamespace)
+	assert.True(t, errors.Is(err, ErrNamespaceExists), "Incorrect create namespace error")
+}
+
+func TestDeleteNamespaceWithoutCascade(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.CreateNamespace("team-a")
+	require.NoError(t, err, "Unexpected create namespace error")
+	_, err = repo.Create(inNamespace(dc1Item, "team-a"))
+	require.NoError(t, err, "Unexpected create config error")
+
+	_, err = repo.DeleteNamespace("team-a", false, "alice")
+
+	assert.True(t, errors.Is(err, ErrNamespaceNotEmpty), "Incorrect delete namespace error")
+	assert.True(t, errors.Is(err, ErrConflict), "Incorrect delete namespace error")
+	_, err = repo.Get("team-a", "datacenter-1")
+	assert.NoError(t, err, "Config was deleted")
+}
+
+func TestDeleteNamespaceWithCascade(t *testing.T) {
+	repo := newTestConfigRepo()
+	_, err := repo.CreateNamespace("team-a")
+	require.NoError(t, err, "Unexpected create namespace error")
+	_, err = repo.Create(inNamespace(dc1Item, "team-a"))
+	require.NoError(t, err, "Unexpected create config error")
+	_, err = repo.Create(dc1Item)
+	require.NoError(t, err, "Unexpected create config error")
+
+	deleted, err := repo.DeleteNamespace("team-a", true, "alice")
+
+	assert.NoError(t, err, "Unexpected delete namespace error")
+	assert.Equal(t, []model.Revision{{Namespace: "team-a", Name: "datacenter-1", Version: 2, Timestamp: testTime,
+		Author: "alice", Deleted: true}}, deleted, "Incorrect deletion revisions")
+	_, err = repo.Namespace("team-a")
+	assert.True(t, errors.Is(err, ErrNamespaceNotFound), "Namespace was not deleted")
+	_, err = repo.Get(model.DefaultNamespace, "datacenter-1")
+	assert.NoError(t, err, "Config of another namespace was deleted")
+
+	_, err = repo.CreateNamespace("team-a")
+	require.NoError(t, err, "Unexpected create namespace error")
+	configs, err := repo.GetAll("team-a")
+	require.NoError(t, err, "Unexpected get all configs error")
+	assert.Empty(t, configs, "Configs survived the namespace")
+	history, err := repo.History("team-a", "datacenter-1")
+	require.NoError(t, err, "Unexpected history error")
+	assert.Equal(t, model.Revision{Namespace: "team-a", Name: "datacenter-1", Version: 2, Timestamp: testTime,
+		Author: "alice", Deleted: true}, history[len(history)-1], "Incorrect deletion revision")
+}
+
+func TestDeleteNamespaceForFailedWrite(t *testing.T) {
+	errDisk := errors.New("disk failure")
+	tests := []struct {
+		name    string
+		save    func([]model.Namespace) error
+		journal func(...change) error
+	}{
+		{"namespaces", func([]model.Namespace) error { return errDisk }, nil},
+		{"deletions", func([]model.Namespace) error { return nil }, func(...change) error { return errDisk }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestConfigRepo()
+			_, err := repo.CreateNamespace("team-a")
+			require.NoError(t, err, "Unexpected create namespace error")
+			_, err = repo.Create(inNamespace(dc1Item, "team-a"))
+			require.NoError(t, err, "Unexpected create config error")
+			saved := []model.Namespace{{Name: "team-a", CreatedAt: testTime}}
+			repo.saveNamespaces = func(namespaces []model.Namespace) error {
+				if err := tt.save(namespaces); err != nil {
+					return err
+				}
+				saved = namespaces
+				return nil
+			}
+			repo.journal = tt.journal
+
+			deleted, err := repo.DeleteNamespace("team-a", true, "alice")
+
+			assert.True(t, errors.Is(err, errDisk), "Incorrect delete namespace error: %v", err)
+			assert.Empty(t, deleted, "Incorrect deletion revisions")
+			assert.Equal(t, []model.Namespace{{Name: "team-a", CreatedAt: testTime}}, saved, "Namespace removal was persisted")
+			_, err = repo.Get("team-a", "datacenter-1")
+			assert.NoError(t, err, "Config was deleted")
+		})
+	}
+}
+
+func TestFileRepoPersistsNamespaces(t *testing.T) {
+	dir := tempDataDir(t)
+	repo := newTestFileRepo(t, dir, 2)
+	_, err := repo.CreateNamespace("team-a")
+	require.NoError(t, err, "Unexpected create namespace error")
+	_, err = repo.CreateNamespace("team-b")
+	require.NoError(t, err, "Unexpected create namespace error")
+	_, err = repo.Create(inNamespace(dc1Item, "team-a"))
+	require.NoError(t, err, "Unexpected create config error")
+	_, err = repo.Create(inNamespace(dc1Item, "team-b"))
+	require.NoError(t, err, "Unexpected create config error")
+	require.NoError(t, repo.compact(), "Unexpected compact error")
+	_, err = repo.DeleteNamespace("team-b", true, "")
+	require.NoError(t, err, "Unexpected delete namespace error")
+	require.NoError(t, repo.Close(), "Unexpected close error")
+
+	reopened := newTestFileRepo(t, dir, 2)
+	defer reopened.Close()
+
+	namespaces, err := reopened.Namespaces()
+	assert.NoError(t, err, "Unexpected namespaces error")
+	assert.Equal(t, []model.Namespace{{Name: model.DefaultNamespace}, {Name: "team-a", CreatedAt: testTime}}, namespaces,
+		"Incorrect namespaces after replay")
+	config, err := reopened.Get("team-a", "datacenter-1")
+	assert.NoError(t, err, "Unexpected get config error")
+	assert.Equal(t, stored(inNamespace(dc1Item, "team-a"), 1), config, "Incorrect config after replay")
+	_, err = reopened.Get(model.DefaultNamespace, "datacenter-1")
+	assert.True(t, errors.Is(err, ErrNotFound), "Config leaked into the default namespace")
+}
@@ -0,0 +1,45 @@
+package db
+
+import "jsonstore/pkg/model"
+
+// Precondition restricts a write to a particular state of the stored config, mirroring the
+// semantics of the HTTP If-Match and If-None-Match headers. The zero value matches any state.
+type Precondition struct {
+	// The stored version must be one of these
+	Match []int64
+	// The config must exist
+	MatchAny bool
+	// The stored version must not be one of these
+	NoneMatch []int64
+	// The config must not exist
+	NoneMatchAny bool
+}
+
+// check returns ErrPreconditionFailed unless current, which is nil for a missing config,
+// satisfies the precondition
+func (p Precondition) check(current *model.Config) error {
+	if (p.MatchAny || len(p.Match) > 0) && current == nil {
+		return ErrPreconditionFailed
+	}
+	if len(p.Match) > 0 && !containsVersion(p.Match, current.Version) {
+		return ErrPreconditionFailed
+	}
+	if p.NoneMatchAny && current != nil {
+		return ErrPreconditionFailed
+	}
+	if current != nil && containsVersion(p.NoneMatch, current.Version) {
+		return ErrPreconditionFailed
+	}
+
+	return nil
+}
+
+func containsVersion(versions []int64, version int64) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+
+	return false
+}
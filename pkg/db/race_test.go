@@ -0,0 +1,95 @@
+package db
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/model"
+	"jsonstore/pkg/query"
+)
+
+const (
+	stressWorkers    = 8
+	stressIterations = 200
+)
+
+// stress hammers the repo with concurrent writers and readers; run with -race to detect
+// unsynchronized access to the underlying store.
+func stress(t *testing.T, repo Config) {
+	expr, err := query.Parse(`metadata.monitoring.enabled = "true"`)
+	require.NoError(t, err, "Unexpected parse query error")
+
+	var wg sync.WaitGroup
+	for w := 0; w < stressWorkers; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < stressIterations; i++ {
+				name := fmt.Sprintf("datacenter-%d-%d", w, i%10)
+				config := model.Config{
+					Name:     name,
+					Metadata: map[string]interface{}{"monitoring": map[string]interface{}{"enabled": "true"}},
+				}
+				_, err := repo.Upsert(config, Precondition{})
+				assert.NoError(t, err, "Unexpected upsert config error")
+				// The last round of writes leaves every name in place
+				if i < stressIterations-10 && i%3 == 0 {
+					_, _ = repo.Delete(model.DefaultNamespace, name, Precondition{}, "")
+				}
+			}
+		}(w)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < stressIterations; i++ {
+				_, _ = repo.Get(model.DefaultNamespace, fmt.Sprintf("datacenter-%d-%d", w, i%10))
+				_, _ = repo.GetAll(model.DefaultNamespace)
+				_, err := repo.Search(model.DefaultNamespace, expr)
+				assert.NoError(t, err, "Unexpected search config error")
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	repo := NewConfigRepo()
+
+	stress(t, repo)
+
+	configs, err := repo.GetAll(model.DefaultNamespace)
+	require.NoError(t, err, "Unexpected get all configs error")
+	assert.Len(t, configs, stressWorkers*10, "Incorrect number of configs after concurrent writes")
+}
+
+func TestConcurrentAccessWithIndexes(t *testing.T) {
+	repo := NewConfigRepo()
+	require.NoError(t, repo.CreateIndex("metadata.monitoring.enabled"), "Unexpected create index error")
+
+	stress(t, repo)
+
+	expr, err := query.Parse(`metadata.monitoring.enabled = "true"`)
+	require.NoError(t, err, "Unexpected parse query error")
+	configs, err := repo.Search(model.DefaultNamespace, expr)
+	require.NoError(t, err, "Unexpected search config error")
+	assert.Len(t, configs, stressWorkers*10, "Incorrect number of indexed configs after concurrent writes")
+}
+
+func TestFileRepoConcurrentAccess(t *testing.T) {
+	dir := tempDataDir(t)
+	repo := newTestFileRepo(t, dir, 50)
+
+	stress(t, repo)
+
+	configs, err := repo.GetAll(model.DefaultNamespace)
+	require.NoError(t, err, "Unexpected get all configs error")
+	require.NoError(t, repo.Close(), "Unexpected close error")
+
+	reopened := newTestFileRepo(t, dir, 50)
+	replayed, err := reopened.GetAll(model.DefaultNamespace)
+	assert.NoError(t, err, "Unexpected get all configs error")
+	assert.Equal(t, configs, replayed, "Incorrect configs after replay")
+}
@@ -0,0 +1,160 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"jsonstore/pkg/model"
+)
+
+// Schema stores the JSON Schemas that configs are validated against. Writes assign the version
+// and timestamps of the stored schema.
+type Schema interface {
+	Get(string) (*model.Schema, error)
+	GetAll() ([]model.Schema, error)
+	Upsert(model.Schema) (*model.Schema, error)
+	Delete(string) error
+}
+
+// schemaRepo is an in-memory store that is safe for concurrent use. Schemas change rarely and are
+// few, so every write persists the whole set through save.
+type schemaRepo struct {
+	sync.RWMutex
+	schemas map[string]model.Schema
+	// Invoked with every schema once a write is validated and before it is applied; a failure
+	// aborts the write
+	save func([]model.Schema) error
+	now  func() time.Time
+}
+
+func NewSchemaRepo() Schema {
+	return newSchemaRepo()
+}
+
+func newSchemaRepo() *schemaRepo {
+	return &schemaRepo{schemas: map[string]model.Schema{}, now: time.Now}
+}
+
+// NewFileSchemaRepo returns a schema store persisted to a file in dataDir, which is replaced
+// atomically on every write
+func NewFileSchemaRepo(dataDir string) (Schema, error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+
+	repo := newSchemaRepo()
+	data, err := ioutil.ReadFile(filepath.Join(dataDir, schemasFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read schemas: %w", err)
+	}
+	if err == nil {
+		var schemas []model.Schema
+		if err := json.Unmarshal(data, &schemas); err != nil {
+			return nil, fmt.Errorf("decode schemas: %w", err)
+		}
+		for _, schema := range schemas {
+			repo.schemas[schema.Name] = schema
+		}
+	}
+
+	repo.save = func(schemas []model.Schema) error {
+		data, err := json.Marshal(schemas)
+		if err != nil {
+			return fmt.Errorf("encode schemas: %w", err)
+		}
+		if err := replaceFile(dataDir, schemasFileName, data); err != nil {
+			return fmt.Errorf("write schemas: %w", err)
+		}
+		return nil
+	}
+	return repo, nil
+}
+
+func (s *schemaRepo) Get(name string) (*model.Schema, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	schema, ok := s.schemas[name]
+	if !ok {
+		return nil, ErrSchemaNotFound
+	}
+
+	return &schema, nil
+}
+
+func (s *schemaRepo) GetAll() ([]model.Schema, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	return sortedSchemas(s.schemas), nil
+}
+
+func (s *schemaRepo) Upsert(schema model.Schema) (*model.Schema, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	now := s.now().UTC()
+	schema.Version, schema.CreatedAt, schema.UpdatedAt = 1, now, now
+	if current, ok := s.schemas[schema.Name]; ok {
+		schema.Version, schema.CreatedAt = current.Version+1, current.CreatedAt
+	}
+
+	next := copySchemas(s.schemas)
+	next[schema.Name] = schema
+	if err := s.persist(next); err != nil {
+		return nil, err
+	}
+
+	s.schemas = next
+	return &schema, nil
+}
+
+func (s *schemaRepo) Delete(name string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.schemas[name]; !ok {
+		return ErrSchemaNotFound
+	}
+
+	next := copySchemas(s.schemas)
+	delete(next, name)
+	if err := s.persist(next); err != nil {
+		return err
+	}
+
+	s.schemas = next
+	return nil
+}
+
+func (s *schemaRepo) persist(schemas map[string]model.Schema) error {
+	if s.save == nil {
+		return nil
+	}
+
+	return s.save(sortedSchemas(schemas))
+}
+
+func copySchemas(schemas map[string]model.Schema) map[string]model.Schema {
+	copied := make(map[string]model.Schema, len(schemas))
+	for name, schema := range schemas {
+		copied[name] = schema
+	}
+	return copied
+}
+
+func sortedSchemas(schemas map[string]model.Schema) []model.Schema {
+	sorted := make([]model.Schema, 0, len(schemas))
+	for _, schema := range schemas {
+		sorted = append(sorted, schema)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/model"
+)
+
+var datacenterSchema = model.Schema{
+	Name:    "datacenter",
+	Pattern: "datacenter-*",
+	Schema:  json.RawMessage(`{"type":"object"}`),
+}
+
+func TestSchemaRepoUpsert(t *testing.T) {
+	repo := newSchemaRepo()
+	repo.now = func() time.Time { return testTime }
+
+	created, err := repo.Upsert(datacenterSchema)
+	require.NoError(t, err, "Unexpected upsert schema error")
+	updated, err := repo.Upsert(model.Schema{Name: "datacenter", Schema: json.RawMessage(`true`)})
+	require.NoError(t, err, "Unexpected upsert schema error")
+	stored, err := repo.Get("datacenter")
+	require.NoError(t, err, "Unexpected get schema error")
+
+	assert.Equal(t, int64(1), created.Version, "Incorrect version of the created schema")
+	assert.Equal(t, int64(2), updated.Version, "Incorrect version of the updated schema")
+	assert.Equal(t, updated, stored, "Incorrect stored schema")
+}
+
+func TestSchemaRepoDelete(t *testing.T) {
+	repo := newSchemaRepo()
+	_, err := repo.Upsert(datacenterSchema)
+	require.NoError(t, err, "Unexpected upsert schema error")
+
+	require.NoError(t, repo.Delete("datacenter"), "Unexpected delete schema error")
+	_, err = repo.Get("datacenter")
+	assert.True(t, errors.Is(err, ErrSchemaNotFound), "Incorrect get schema error")
+	assert.True(t, errors.Is(repo.Delete("datacenter"), ErrNotFound), "Incorrect delete schema error")
+}
+
+func TestSchemaRepoForFailedSave(t *testing.T) {
+	repo := newSchemaRepo()
+	repo.save = func([]model.Schema) error { return errors.New("disk full") }
+
+	_, err := repo.Upsert(datacenterSchema)
+
+	assert.EqualError(t, err, "disk full", "Incorrect upsert schema error")
+	all, err := repo.GetAll()
+	require.NoError(t, err, "Unexpected get all schemas error")
+	assert.Empty(t, all, "Unexpected schema stored after failed save")
+}
+
+func TestFileSchemaRepoPersistsSchemas(t *testing.T) {
+	dir := tempDataDir(t)
+	repo, err := NewFileSchemaRepo(dir)
+	require.NoError(t, err, "Unexpected open file schema repo error")
+	_, err = repo.Upsert(datacenterSchema)
+	require.NoError(t, err, "Unexpected upsert schema error")
+	_, err = repo.Upsert(model.Schema{Name: "cluster", Schema: json.RawMessage(`{"required":["zone"]}`)})
+	require.NoError(t, err, "Unexpected upsert schema error")
+	require.NoError(t, repo.Delete("cluster"), "Unexpected delete schema error")
+
+	reopened, err := NewFileSchemaRepo(dir)
+	require.NoError(t, err, "Unexpected open file schema repo error")
+	all, err := reopened.GetAll()
+	require.NoError(t, err, "Unexpected get all schemas error")
+	require.Len(t, all, 1, "Incorrect number of schemas")
+	assert.Equal(t, "datacenter-*", all[0].Pattern, "Incorrect schema pattern")
+	assert.JSONEq(t, `{"type":"object"}`, string(all[0].Schema), "Incorrect schema")
+}
+
+func TestFileSchemaRepoForCorruptFile(t *testing.T) {
+	dir := tempDataDir(t)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, schemasFileName), []byte(`[{`), 0o644),
+		"Unexpected write error")
+
+	_, err := NewFileSchemaRepo(dir)
+
+	assert.Error(t, err, "Missing open file schema repo error")
+}
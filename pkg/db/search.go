@@ -0,0 +1,126 @@
+package db
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+
+	"jsonstore/pkg/query"
+)
+
+// Matches tells whether the JSON representation of a config matches a filter expression, as
+// Search does
+func Matches(expr query.Expr, doc []byte) bool {
+	return matches(expr, gjson.ParseBytes(doc))
+}
+
+// matches evaluates a filter expression against the JSON representation of a config. When the
+// value at a path is an array, a condition holds if it holds for any of its elements.
+func matches(expr query.Expr, doc gjson.Result) bool {
+	switch e := expr.(type) {
+	case query.And:
+		return matches(e.Left, doc) && matches(e.Right, doc)
+	case query.Or:
+		return matches(e.Left, doc) || matches(e.Right, doc)
+	case query.Not:
+		return !matches(e.Expr, doc)
+	case query.Exists:
+		return doc.Get(e.Path).Exists()
+	case query.In:
+		return anyValue(doc.Get(e.Path), func(v gjson.Result) bool {
+			for _, value := range e.Values {
+				if equal(v, value) {
+					return true
+				}
+			}
+			return false
+		})
+	case query.Comparison:
+		if e.Op == query.OpNe {
+			return !anyValue(doc.Get(e.Path), func(v gjson.Result) bool { return equal(v, e.Value) })
+		}
+		return anyValue(doc.Get(e.Path), func(v gjson.Result) bool { return compare(v, e) })
+	default:
+		return false
+	}
+}
+
+// anyValue applies fn to result, or to each of its elements when it is an array
+func anyValue(result gjson.Result, fn func(gjson.Result) bool) bool {
+	if !result.Exists() {
+		return false
+	}
+	if fn(result) {
+		return true
+	}
+	if !result.IsArray() {
+		return false
+	}
+
+	found := false
+	result.ForEach(func(_, v gjson.Result) bool {
+		found = fn(v)
+		return !found
+	})
+	return found
+}
+
+func equal(v gjson.Result, value query.Value) bool {
+	switch value.Kind {
+	case query.String:
+		return v.Type == gjson.String && v.Str == value.Str
+	case query.Number:
+		return v.Type == gjson.Number && v.Num == value.Num
+	case query.Bool:
+		return (v.Type == gjson.True || v.Type == gjson.False) && v.Bool() == value.Bool
+	case query.Null:
+		return v.Type == gjson.Null
+	default:
+		return false
+	}
+}
+
+func compare(v gjson.Result, cmp query.Comparison) bool {
+	switch cmp.Op {
+	case query.OpEq:
+		return equal(v, cmp.Value)
+	case query.OpMatch:
+		return v.Type == gjson.String && cmp.Regexp.MatchString(v.Str)
+	case query.OpPrefix:
+		return v.Type == gjson.String && strings.HasPrefix(v.Str, cmp.Value.Str)
+	}
+
+	var order int
+	switch {
+	case cmp.Value.Kind == query.Number && v.Type == gjson.Number:
+		order = compareFloat(v.Num, cmp.Value.Num)
+	case cmp.Value.Kind == query.String && v.Type == gjson.String:
+		order = strings.Compare(v.Str, cmp.Value.Str)
+	default:
+		return false
+	}
+
+	switch cmp.Op {
+	case query.OpLt:
+		return order < 0
+	case query.OpLe:
+		return order <= 0
+	case query.OpGt:
+		return order > 0
+	case query.OpGe:
+		return order >= 0
+	default:
+		return false
+	}
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+
+	"jsonstore/pkg/model"
+	"jsonstore/pkg/query"
+)
+
+const benchConfigs = 20000
+
+var benchRegions = []string{"eu-west", "eu-central", "us-east", "us-west", "ap-south"}
+
+// newBenchRepo returns a repo holding benchConfigs configs spread over regions, with an index on
+// each of the given paths
+func newBenchRepo(b *testing.B, paths ...string) Config {
+	repo := NewConfigRepo()
+	for i := 0; i < benchConfigs; i++ {
+		_, err := repo.Upsert(benchConfig(i, 0), Precondition{})
+		if err != nil {
+			b.Fatalf("Unexpected upsert config error: %v", err)
+		}
+	}
+	for _, path := range paths {
+		if err := repo.CreateIndex(path); err != nil {
+			b.Fatalf("Unexpected create index error: %v", err)
+		}
+	}
+	return repo
+}
+
+func benchConfig(i, revision int) model.Config {
+	return model.Config{
+		Name: fmt.Sprintf("datacenter-%05d", i),
+		Metadata: map[string]interface{}{
+			"region":   benchRegions[i%len(benchRegions)],
+			"replicas": i % 100,
+			"revision": revision,
+			"limits":   map[string]interface{}{"cpu": "300m", "memory": "1Gi"},
+			"tags":     []interface{}{"prod", fmt.Sprintf("team-%d", i%50)},
+		},
+	}
+}
+
+func benchmarkSearch(b *testing.B, repo Config, input string) {
+	expr, err := query.Parse(input)
+	if err != nil {
+		b.Fatalf("Unexpected parse query error: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.Search(model.DefaultNamespace, expr); err != nil {
+			b.Fatalf("Unexpected search config error: %v", err)
+		}
+	}
+}
+
+func BenchmarkSearchEqualityScan(b *testing.B) {
+	benchmarkSearch(b, newBenchRepo(b), `metadata.tags = "team-7"`)
+}
+
+func BenchmarkSearchEqualityIndexed(b *testing.B) {
+	benchmarkSearch(b, newBenchRepo(b, "metadata.tags"), `metadata.tags = "team-7"`)
+}
+
+func BenchmarkSearchRangeScan(b *testing.B) {
+	benchmarkSearch(b, newBenchRepo(b), `metadata.replicas >= 98`)
+}
+
+func BenchmarkSearchRangeIndexed(b *testing.B) {
+	benchmarkSearch(b, newBenchRepo(b, "metadata.replicas"), `metadata.replicas >= 98`)
+}
+
+func BenchmarkSearchConjunctionIndexed(b *testing.B) {
+	benchmarkSearch(b, newBenchRepo(b, "metadata.region", "metadata.tags"),
+		`metadata.region = "eu-west" AND metadata.tags = "team-5"`)
+}
+
+func benchmarkUpsert(b *testing.B, repo Config) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.Upsert(benchConfig(i%benchConfigs, i), Precondition{}); err != nil {
+			b.Fatalf("Unexpected upsert config error: %v", err)
+		}
+	}
+}
+
+func BenchmarkUpsert(b *testing.B) {
+	benchmarkUpsert(b, newBenchRepo(b))
+}
+
+func BenchmarkUpsertIndexed(b *testing.B) {
+	benchmarkUpsert(b, newBenchRepo(b, "metadata.region", "metadata.replicas", "metadata.tags"))
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"jsonstore/pkg/query"
+)
+
+const searchDoc = `{
+  "name": "datacenter-1",
+  "metadata": {
+    "replicas": 3,
+    "ratio": 0.75,
+    "enabled": true,
+    "owner": null,
+    "region": "eu-west",
+    "tags": ["prod", "gpu"],
+    "zones": [{"name": "a", "nodes": 4}, {"name": "b", "nodes": 12}],
+    "monitoring": {"enabled": "true"}
+  }
+}`
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`metadata.replicas = 3`, true},
+		{`metadata.replicas = "3"`, false},
+		{`metadata.replicas > 2 AND metadata.replicas <= 3`, true},
+		{`metadata.replicas < 3`, false},
+		{`metadata.ratio >= 0.75`, true},
+		{`metadata.enabled = true`, true},
+		{`metadata.enabled = "true"`, false},
+		{`metadata.monitoring.enabled = "true"`, true},
+		{`metadata.monitoring.enabled = true`, false},
+		{`metadata.owner = null`, true},
+		{`metadata.owner exists`, true},
+		{`metadata.missing exists`, false},
+		{`NOT metadata.missing exists`, true},
+		{`metadata.missing != 1`, true},
+		{`metadata.replicas != 3`, false},
+		{`metadata.region in ("us-east", "eu-west")`, true},
+		{`metadata.region in ("us-east", 3)`, false},
+		{`metadata.region ^= "eu-"`, true},
+		{`metadata.region ~ "^(eu|us)-[a-z]+$"`, true},
+		{`metadata.region ~ "^us"`, false},
+		{`metadata.region > "eu"`, true},
+		{`metadata.region > 1`, false},
+		{`metadata.tags = "gpu"`, true},
+		{`metadata.tags != "gpu"`, false},
+		{`metadata.tags in ("cpu", "prod")`, true},
+		{`metadata.tags.# = 2`, true},
+		{`metadata.zones.#.nodes > 10`, true},
+		{`metadata.zones.#.nodes > 20`, false},
+		{`metadata.zones.0.name = "a"`, true},
+		{`name = "datacenter-2" OR metadata.replicas = 3`, true},
+		{`name = "datacenter-2" OR (metadata.replicas = 3 AND metadata.enabled = false)`, false},
+	}
+
+	doc := gjson.Parse(searchDoc)
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := query.Parse(tt.expr)
+			require.NoError(t, err, "Unexpected parse query error")
+
+			assert.Equal(t, tt.expected, matches(expr, doc), "Incorrect match")
+		})
+	}
+}
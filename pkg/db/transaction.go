@@ -0,0 +1,91 @@
+package db
+
+import (
+	"fmt"
+
+	"jsonstore/pkg/model"
+)
+
+// TxOp is an operation of a transaction on a single config, made once its conditions hold
+type TxOp struct {
+	Name string
+	// Config to store, attributed to its UpdatedBy; the config is left as is when nil, unless it
+	// is deleted
+	Config *model.Config
+	// Deletes the config, which must exist, on behalf of Author
+	Delete bool
+	Author string
+	// Condition on the version of the config
+	Precondition Precondition
+	// Test checks any further condition on the config, which is nil when missing, and fails the
+	// transaction with its error. It is invoked while writers are paused, so it must not call back
+	// into the store.
+	Test func(*model.Config) error
+}
+
+// Transact makes the operations of a transaction on configs of a namespace in order, all or
+// nothing: the conditions of each operation are checked against the configs as left by the
+// operations before it, and the first failing one aborts the transaction with an error naming
+// the index of the operation. Every change is journaled at once before any is applied. The
+// results follow the order of ops, those of the operations leaving a config as is holding it
+// unchanged.
+func (c *configRepo) Transact(namespace string, ops []TxOp) ([]BatchResult, error) {
+	c.writers.Lock()
+	defer c.writers.Unlock()
+
+	if !c.namespaces.exists(namespace) {
+		return nil, ErrNamespaceNotFound
+	}
+
+	// Writers are paused, so the stored configs cannot change until the transaction is committed
+	namespace = canonical(namespace)
+	results := make([]BatchResult, 0, len(ops))
+	var changes []change
+	// Configs as left by the operations so far, nil once deleted, along with their latest version
+	written := map[string]*model.Config{}
+	latest := map[string]int64{}
+	for i, op := range ops {
+		current, ok := written[op.Name]
+		if !ok {
+			current, latest[op.Name] = c.current(namespace, op.Name)
+		}
+		if err := op.check(current); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		if op.Config == nil && !op.Delete {
+			results = append(results, BatchResult{Name: op.Name, Config: current, Previous: current})
+			continue
+		}
+
+		next, author := op.Config, op.Author
+		if op.Delete {
+			next = nil
+		} else {
+			author = next.UpdatedBy
+		}
+		ch := c.revise(namespace, op.Name, current, next, latest[op.Name], author)
+		written[op.Name], latest[op.Name] = ch.Config, ch.Revision.Version
+		changes = append(changes, ch)
+		results = append(results, BatchResult{Name: op.Name, Config: ch.Config, Revision: ch.Revision, Previous: current})
+	}
+
+	if err := c.commitAll(changes); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// check returns the error of the first condition of the operation that current, which is nil for
+// a missing config, does not satisfy
+func (op TxOp) check(current *model.Config) error {
+	if err := op.Precondition.check(current); err != nil {
+		return err
+	}
+	if op.Delete && current == nil {
+		return ErrNotFound
+	}
+	if op.Test != nil {
+		return op.Test(current)
+	}
+	return nil
+}
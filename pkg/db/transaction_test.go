@@ -0,0 +1,118 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/model"
+)
+
+func TestTransact(t *testing.T) {
+	repo := newTestConfigRepo()
+	for _, item := range []model.Config{dc1Item, dc2Item} {
+		_, err := repo.Create(item)
+		require.NoError(t, err, "Unexpected create config error")
+	}
+	dc1Updated := model.Config{Name: "datacenter-1", Metadata: dc2, UpdatedBy: "alice"}
+	dc3 := model.Config{Name: "datacenter-3", Metadata: dc1, UpdatedBy: "alice"}
+
+	results, err := repo.Transact(model.DefaultNamespace, []TxOp{
+		{Name: "datacenter-1", Config: &dc1Updated, Precondition: Precondition{Match: []int64{1}}},
+		{Name: "datacenter-2", Delete: true, Author: "alice", Precondition: Precondition{MatchAny: true}},
+		{Name: "datacenter-3", Config: &dc3, Precondition: Precondition{NoneMatchAny: true}},
+		{Name: "datacenter-2", Precondition: Precondition{NoneMatchAny: true}},
+	})
+
+	assert.NoError(t, err, "Unexpected transaction error")
+	require.Len(t, results, 4, "Incorrect number of results")
+	assert.Equal(t, stored(dc1Updated, 2), results[0].Config, "Incorrect updated config")
+	assert.Equal(t, stored(dc1Item, 1), results[0].Previous, "Incorrect previous config")
+	assert.True(t, results[1].Revision.Deleted, "Config was not deleted")
+	assert.Equal(t, "alice", results[1].Revision.Author, "Incorrect author of the deletion")
+	assert.Equal(t, stored(dc3, 1), results[2].Config, "Incorrect created config")
+	assert.Equal(t, BatchResult{Name: "datacenter-2"}, results[3], "Incorrect result of the check")
+	configs, err := repo.GetAll(model.DefaultNamespace)
+	require.NoError(t, err, "Unexpected get all configs error")
+	assert.Equal(t, []model.Config{*stored(dc1Updated, 2), *stored(dc3, 1)}, configs, "Incorrect stored configs")
+}
+
+func TestTransactWritesConfigTwice(t *testing.T) {
+	repo := newTestConfigRepo()
+	dc1Updated := model.Config{Name: "datacenter-1", Metadata: dc2, UpdatedBy: "alice"}
+
+	results, err := repo.Transact(model.DefaultNamespace, []TxOp{
+		{Name: "datacenter-1", Config: &dc1Item},
+		{Name: "datacenter-1", Config: &dc1Updated, Precondition: Precondition{Match: []int64{1}}},
+	})
+
+	assert.NoError(t, err, "Unexpected transaction error")
+	assert.Equal(t, stored(dc1Item, 1), results[1].Previous, "Operation did not see the previous one")
+	history, err := repo.History(model.DefaultNamespace, "datacenter-1")
+	require.NoError(t, err, "Unexpected history error")
+	assert.Len(t, history, 2, "Incorrect number of revisions")
+}
+
+func TestTransactForFailedCondition(t *testing.T) {
+	errRegion := fmt.Errorf("%w: wrong region", ErrPreconditionFailed)
+	tests := []struct {
+		name     string
+		op       TxOp
+		expected error
+	}{
+		{"exists", TxOp{Name: "datacenter-3", Precondition: Precondition{MatchAny: true}}, ErrPreconditionFailed},
+		{"version", TxOp{Name: "datacenter-2", Precondition: Precondition{Match: []int64{2}}}, ErrPreconditionFailed},
+		{"test", TxOp{Name: "datacenter-2", Test: func(*model.Config) error { return errRegion }}, errRegion},
+		{"missing deletion", TxOp{Name: "datacenter-3", Delete: true}, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestConfigRepo()
+			_, err := repo.Create(dc2Item)
+			require.NoError(t, err, "Unexpected create config error")
+
+			_, err = repo.Transact(model.DefaultNamespace, []TxOp{{Name: "datacenter-1", Config: &dc1Item}, tt.op})
+
+			assert.True(t, errors.Is(err, tt.expected), "Incorrect transaction error: %v", err)
+			assert.Contains(t, err.Error(), "operation 1", "Failed operation is not named")
+			_, err = repo.Get(model.DefaultNamespace, "datacenter-1")
+			assert.True(t, errors.Is(err, ErrNotFound), "Part of the transaction was applied")
+		})
+	}
+}
+
+func TestTransactForMissingNamespace(t *testing.T) {
+	repo := newTestConfigRepo()
+
+	_, err := repo.Transact("team-a", []TxOp{{Name: "datacenter-1", Config: &dc1Item}})
+
+	assert.True(t, errors.Is(err, ErrNamespaceNotFound), "Incorrect transaction error")
+}
+
+func TestFileRepoReplaysTransaction(t *testing.T) {
+	dir := tempDataDir(t)
+	repo := newTestFileRepo(t, dir, 100)
+	dc1Updated := model.Config{Name: "datacenter-1", Metadata: dc2, UpdatedBy: "alice"}
+	_, err := repo.Transact(model.DefaultNamespace, []TxOp{
+		{Name: "datacenter-1", Config: &dc1Item},
+		{Name: "datacenter-2", Config: &dc2Item},
+		{Name: "datacenter-1", Config: &dc1Updated},
+		{Name: "datacenter-2", Delete: true, Author: "alice"},
+	})
+	require.NoError(t, err, "Unexpected transaction error")
+	require.NoError(t, repo.Close(), "Unexpected close error")
+
+	reopened := newTestFileRepo(t, dir, 100)
+	defer reopened.Close() //nolint:errcheck
+
+	configs, err := reopened.GetAll(model.DefaultNamespace)
+	require.NoError(t, err, "Unexpected get all configs error")
+	assert.Equal(t, []model.Config{*stored(dc1Updated, 2)}, configs, "Incorrect replayed configs")
+	history, err := reopened.History(model.DefaultNamespace, "datacenter-2")
+	require.NoError(t, err, "Unexpected history error")
+	assert.Len(t, history, 2, "Deletion was not replayed")
+}
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"jsonstore/pkg/model"
+)
+
+// Webhook stores the subscriptions to config changes. Writes assign the creation time of the
+// stored webhook.
+type Webhook interface {
+	Get(string) (*model.Webhook, error)
+	GetAll() ([]model.Webhook, error)
+	Create(model.Webhook) (*model.Webhook, error)
+	Delete(string) error
+}
+
+// webhookRepo is an in-memory store that is safe for concurrent use. Like schemas, webhooks are
+// few and every write persists the whole set through save.
+type webhookRepo struct {
+	sync.RWMutex
+	webhooks map[string]model.Webhook
+	// Invoked with every webhook once a write is validated and before it is applied; a failure
+	// aborts the write
+	save func([]model.Webhook) error
+	now  func() time.Time
+}
+
+func NewWebhookRepo() Webhook {
+	return newWebhookRepo()
+}
+
+func newWebhookRepo() *webhookRepo {
+	return &webhookRepo{webhooks: map[string]model.Webhook{}, now: time.Now}
+}
+
+// NewFileWebhookRepo returns a webhook store persisted to a file in dataDir, which is replaced
+// atomically on every write
+func NewFileWebhookRepo(dataDir string) (Webhook, error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+
+	repo := newWebhookRepo()
+	data, err := ioutil.ReadFile(filepath.Join(dataDir, webhooksFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read webhooks: %w", err)
+	}
+	if err == nil {
+		var webhooks []model.Webhook
+		if err := json.Unmarshal(data, &webhooks); err != nil {
+			return nil, fmt.Errorf("decode webhooks: %w", err)
+		}
+		for _, webhook := range webhooks {
+			repo.webhooks[webhook.ID] = webhook
+		}
+	}
+
+	repo.save = func(webhooks []model.Webhook) error {
+		data, err := json.Marshal(webhooks)
+		if err != nil {
+			return fmt.Errorf("encode webhooks: %w", err)
+		}
+		if err := replaceFile(dataDir, webhooksFileName, data); err != nil {
+			return fmt.Errorf("write webhooks: %w", err)
+		}
+		return nil
+	}
+	return repo, nil
+}
+
+func (s *webhookRepo) Get(id string) (*model.Webhook, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	webhook, ok := s.webhooks[id]
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+
+	return &webhook, nil
+}
+
+// GetAll returns the webhooks in the order they were created
+func (s *webhookRepo) GetAll() ([]model.Webhook, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	return sortedWebhooks(s.webhooks), nil
+}
+
+// Create stores a webhook under its ID, which must not be taken
+func (s *webhookRepo) Create(webhook model.Webhook) (*model.Webhook, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.webhooks[webhook.ID]; ok {
+		return nil, fmt.Errorf("webhook %s: %w", webhook.ID, ErrConflict)
+	}
+	webhook.CreatedAt = s.now().UTC()
+
+	next := copyWebhooks(s.webhooks)
+	next[webhook.ID] = webhook
+	if err := s.persist(next); err != nil {
+		return nil, err
+	}
+
+	s.webhooks = next
+	return &webhook, nil
+}
+
+func (s *webhookRepo) Delete(id string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.webhooks[id]; !ok {
+		return ErrWebhookNotFound
+	}
+
+	next := copyWebhooks(s.webhooks)
+	delete(next, id)
+	if err := s.persist(next); err != nil {
+		return err
+	}
+
+	s.webhooks = next
+	return nil
+}
+
+func (s *webhookRepo) persist(webhooks map[string]model.Webhook) error {
+	if s.save == nil {
+		return nil
+	}
+
+	return s.save(sortedWebhooks(webhooks))
+}
+
+func copyWebhooks(webhooks map[string]model.Webhook) map[string]model.Webhook {
+	copied := make(map[string]model.Webhook, len(webhooks))
+	for id, webhook := range webhooks {
+		copied[id] = webhook
+	}
+	return copied
+}
+
+func sortedWebhooks(webhooks map[string]model.Webhook) []model.Webhook {
+	sorted := make([]model.Webhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		sorted = append(sorted, webhook)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
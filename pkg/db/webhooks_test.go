@@ -0,0 +1,93 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/model"
+)
+
+var datacenterWebhook = model.Webhook{
+	ID:        "b1946ac92492d2347c6235b4d2611184",
+	Namespace: model.DefaultNamespace,
+	URL:       "https://example.com/hooks/datacenters",
+	Prefix:    "datacenter-",
+	Secret:    "s3cret",
+}
+
+func TestWebhookRepoCreate(t *testing.T) {
+	repo := newWebhookRepo()
+	repo.now = func() time.Time { return testTime }
+
+	created, err := repo.Create(datacenterWebhook)
+	require.NoError(t, err, "Unexpected create webhook error")
+	stored, err := repo.Get(datacenterWebhook.ID)
+	require.NoError(t, err, "Unexpected get webhook error")
+
+	assert.Equal(t, testTime, created.CreatedAt, "Incorrect creation time")
+	assert.Equal(t, created, stored, "Incorrect stored webhook")
+	_, err = repo.Create(datacenterWebhook)
+	assert.True(t, errors.Is(err, ErrConflict), "Incorrect create webhook error for a taken id")
+}
+
+func TestWebhookRepoGetAll(t *testing.T) {
+	repo := newWebhookRepo()
+	now := testTime
+	repo.now = func() time.Time { now = now.Add(time.Second); return now }
+	_, err := repo.Create(model.Webhook{ID: "b", URL: "https://example.com/b"})
+	require.NoError(t, err, "Unexpected create webhook error")
+	_, err = repo.Create(model.Webhook{ID: "a", URL: "https://example.com/a"})
+	require.NoError(t, err, "Unexpected create webhook error")
+
+	all, err := repo.GetAll()
+
+	assert.NoError(t, err, "Unexpected get all webhooks error")
+	require.Len(t, all, 2, "Incorrect number of webhooks")
+	assert.Equal(t, "b", all[0].ID, "Webhooks are not in creation order")
+}
+
+func TestWebhookRepoDelete(t *testing.T) {
+	repo := newWebhookRepo()
+	_, err := repo.Create(datacenterWebhook)
+	require.NoError(t, err, "Unexpected create webhook error")
+
+	require.NoError(t, repo.Delete(datacenterWebhook.ID), "Unexpected delete webhook error")
+	_, err = repo.Get(datacenterWebhook.ID)
+	assert.True(t, errors.Is(err, ErrWebhookNotFound), "Incorrect get webhook error")
+	assert.True(t, errors.Is(repo.Delete(datacenterWebhook.ID), ErrNotFound), "Incorrect delete webhook error")
+}
+
+func TestWebhookRepoForFailedSave(t *testing.T) {
+	repo := newWebhookRepo()
+	repo.save = func([]model.Webhook) error { return errors.New("disk full") }
+
+	_, err := repo.Create(datacenterWebhook)
+
+	assert.EqualError(t, err, "disk full", "Incorrect create webhook error")
+	all, err := repo.GetAll()
+	require.NoError(t, err, "Unexpected get all webhooks error")
+	assert.Empty(t, all, "Unexpected webhook stored after failed save")
+}
+
+func TestFileWebhookRepoPersistsWebhooks(t *testing.T) {
+	dir := tempDataDir(t)
+	repo, err := NewFileWebhookRepo(dir)
+	require.NoError(t, err, "Unexpected open file webhook repo error")
+	_, err = repo.Create(datacenterWebhook)
+	require.NoError(t, err, "Unexpected create webhook error")
+	_, err = repo.Create(model.Webhook{ID: "c4ca4238a0b923820dcc509a6f75849b", URL: "https://example.com/all"})
+	require.NoError(t, err, "Unexpected create webhook error")
+	require.NoError(t, repo.Delete("c4ca4238a0b923820dcc509a6f75849b"), "Unexpected delete webhook error")
+
+	reopened, err := NewFileWebhookRepo(dir)
+	require.NoError(t, err, "Unexpected open file webhook repo error")
+	all, err := reopened.GetAll()
+	require.NoError(t, err, "Unexpected get all webhooks error")
+	require.Len(t, all, 1, "Incorrect number of webhooks")
+	assert.Equal(t, datacenterWebhook.URL, all[0].URL, "Incorrect webhook url")
+	assert.Equal(t, datacenterWebhook.Secret, all[0].Secret, "Incorrect webhook secret")
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/lib"
+	"jsonstore/pkg/service"
+)
+
+// ApplyConfigs makes the configs of the namespace those of the body, given as a JSON array or as
+// application/x-ndjson, in a single atomic batch. The prune parameter also deletes the configs
+// that are not given, and the dryRun parameter returns the plan without applying it. The reply
+// holds the diff of every change.
+func ApplyConfigs(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := contract.ApplyRequest{Namespace: namespace(r), Author: author(r)}
+		for name, param := range map[string]*bool{"dryRun": &req.DryRun, "prune": &req.Prune} {
+			value := r.URL.Query().Get(name)
+			if value == "" {
+				continue
+			}
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				zap.S().Errorf("Apply configs: invalid %s %q", name, value)
+				lib.WriteError(w, httperr.InvalidApply.WithMessage("Apply configs: %s %q is not a boolean", name, value))
+				return
+			}
+			*param = parsed
+		}
+
+		var ok bool
+		if req.Configs, ok = decodeConfigs(w, r, "Apply configs"); !ok {
+			return
+		}
+
+		res, err := mgr.Apply(req)
+		if err != nil {
+			writeError(w, err, "Apply configs")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, res)
+	}
+}
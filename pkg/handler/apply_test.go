@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/patch"
+	"jsonstore/pkg/service"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+func TestApplyConfigs(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/configs/apply?dryRun=true&prune=true",
+		strings.NewReader(`[{"name": "datacenter-1", "metadata": {"region": "us"}}]`))
+	require.NoError(t, err, "Unexpected apply request error")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(authorHeader, "alice")
+	resp := &contract.ApplyResponse{DryRun: true, Prune: true, Updated: 1, Deleted: 1, Changes: []contract.ApplyChange{
+		{Name: "datacenter-1", Action: contract.ApplyUpdate, Version: 3, Diff: []patch.Operation{
+			{Op: "replace", Path: "/metadata/region", Value: json.RawMessage(`"us"`)}}},
+		{Name: "datacenter-2", Action: contract.ApplyDelete, Version: 1, Diff: []patch.Operation{
+			{Op: "remove", Path: "/metadata"}}},
+	}}
+
+	manager.On("Apply", contract.ApplyRequest{
+		Namespace: service.DefaultNamespace,
+		Prune:     true,
+		DryRun:    true,
+		Author:    "alice",
+		Configs:   []contract.Config{{Name: "datacenter-1", Metadata: map[string]interface{}{"region": "us"}}},
+	}).Return(resp, nil)
+
+	ApplyConfigs(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `{"dryRun": true, "prune": true, "created": 0, "updated": 1, "deleted": 1, "unchanged": 0,
+		"changes": [
+			{"name": "datacenter-1", "action": "update", "version": 3,
+				"diff": [{"op": "replace", "path": "/metadata/region", "value": "us"}]},
+			{"name": "datacenter-2", "action": "delete", "version": 1, "diff": [{"op": "remove", "path": "/metadata"}]}
+		]}`, rr.Body.String(), "Incorrect response")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestApplyConfigsForInvalidPrune(t *testing.T) {
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/configs/apply?prune=all", strings.NewReader(`[]`))
+	require.NoError(t, err, "Unexpected apply request error")
+
+	ApplyConfigs(new(mocks.Manager)).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+	assert.Contains(t, rr.Body.String(), "INVALID_APPLY", "Incorrect error code")
+}
+
+func TestApplyConfigsForInvalidConfig(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/configs/apply", strings.NewReader(`[{"name": "apply"}]`))
+	require.NoError(t, err, "Unexpected apply request error")
+
+	manager.On("Apply", mock.Anything).Return(nil, service.ErrInvalidConfig)
+
+	ApplyConfigs(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+	assert.Contains(t, rr.Body.String(), "INVALID_CONFIG", "Incorrect error code")
+}
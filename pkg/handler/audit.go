@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"jsonstore/pkg/audit"
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/lib"
+	"jsonstore/pkg/patch"
+	"jsonstore/pkg/service"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// bodyRecorder keeps a copy of the body of a response along with its status
+type bodyRecorder struct {
+	*lib.RecordingWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.RecordingWriter.Write(b)
+}
+
+// Audited records every request that next handles by writing a config, whether it succeeds or
+// not. The metadata before and after a successful write is read back from the history of the
+// config by the version the write produced, so that concurrent writes do not blur the record.
+func Audited(auditLog audit.Log, mgr service.Manager, operation string, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entry := audit.Entry{
+			Principal:  author(r),
+			RemoteAddr: r.RemoteAddr,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Operation:  operation,
+			Namespace:  namespace(r),
+			Name:       mux.Vars(r)["name"],
+		}
+		if entry.Name == "" {
+			entry.Name = bodyName(r)
+		}
+
+		rw := &bodyRecorder{RecordingWriter: lib.NewRecordingWriter(w)}
+		next.ServeHTTP(rw, r)
+
+		entry.Time = time.Now().UTC()
+		entry.Outcome = audit.Outcome{Status: rw.Status, Code: rw.Err.Code}
+		if rw.Status >= http.StatusOK && rw.Status < http.StatusMultipleChoices {
+			recordChange(&entry, mgr, rw.body.Bytes())
+		}
+		if err := auditLog.Record(entry); err != nil {
+			zap.S().Errorf("Audit %s %s: %v", r.Method, r.URL.Path, err)
+		}
+	}
+}
+
+// bodyName returns the name of the config in the body of a request, which is left for the handler
+// to read again
+func bodyName(r *http.Request) string {
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var named struct {
+		Name string `json:"name"`
+	}
+	_ = json.Unmarshal(body, &named)
+	return named.Name
+}
+
+// recordChange adds to an entry the version produced by a write, as found in the response, and
+// the metadata before and after it
+func recordChange(entry *audit.Entry, mgr service.Manager, response []byte) {
+	var written struct {
+		Name    string `json:"name"`
+		Version int64  `json:"version"`
+	}
+	// Writes to several configs, such as imports, are recorded without the changes they made
+	if err := json.Unmarshal(response, &written); err != nil || written.Version == 0 {
+		return
+	}
+	entry.Name, entry.Version = written.Name, written.Version
+
+	var err error
+	if entry.After, err = revisionMetadata(mgr, entry.Namespace, entry.Name, entry.Version); err != nil {
+		zap.S().Errorf("Audit %s: %v", entry.Path, err)
+		return
+	}
+	if entry.Version > 1 {
+		if entry.Before, err = revisionMetadata(mgr, entry.Namespace, entry.Name, entry.Version-1); err != nil {
+			zap.S().Errorf("Audit %s: %v", entry.Path, err)
+			return
+		}
+	}
+	entry.Diff = patch.Diff(entry.Before, entry.After)
+}
+
+// revisionMetadata returns the metadata of a config at a version, or nil when it was deleted
+func revisionMetadata(mgr service.Manager, namespace, name string, version int64) (interface{}, error) {
+	revision, err := mgr.Revision(namespace, name, version)
+	if err != nil {
+		return nil, err
+	}
+	if revision.Deleted {
+		return nil, nil
+	}
+
+	// Decoded into generic values for the diff
+	data, err := json.Marshal(revision.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	var metadata interface{}
+	err = json.Unmarshal(data, &metadata)
+	return metadata, err
+}
+
+// GetAuditEntries lists the latest audit entries, the oldest first, optionally selected by the
+// namespace and name of their config and recorded at or after the RFC 3339 time of the since
+// parameter
+func GetAuditEntries(auditLog audit.Log) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		queries := r.URL.Query()
+		filter := audit.Filter{Namespace: queries.Get("namespace"), Name: queries.Get("name"), Limit: defaultAuditLimit}
+		if since := queries.Get("since"); since != "" {
+			t, err := time.Parse(time.RFC3339Nano, since)
+			if err != nil {
+				zap.S().Errorf("Get audit entries: invalid since %q", since)
+				lib.WriteError(w, httperr.InvalidAuditQuery.WithMessage("Get audit entries: since %q is not an RFC 3339 time",
+					since))
+				return
+			}
+			filter.Since = t
+		}
+		if limit := queries.Get(limitParam); limit != "" {
+			n, err := strconv.Atoi(limit)
+			if err != nil || n < 1 || n > maxAuditLimit {
+				zap.S().Errorf("Get audit entries: invalid limit %q", limit)
+				lib.WriteError(w, httperr.InvalidAuditQuery.WithMessage("Get audit entries: limit %q is not an integer between 1 and %d",
+					limit, maxAuditLimit))
+				return
+			}
+			filter.Limit = n
+		}
+
+		entries, err := auditLog.Query(filter)
+		if err != nil {
+			zap.S().Errorf("Get audit entries: %v", err)
+			lib.WriteError(w, httperr.InternalError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, entries)
+	}
+}
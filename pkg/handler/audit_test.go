@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/audit"
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/patch"
+	"jsonstore/pkg/service"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+func auditedEntries(t *testing.T, auditLog audit.Log) []audit.Entry {
+	entries, err := auditLog.Query(audit.Filter{})
+	require.NoError(t, err, "Unexpected query audit log error")
+	for i := range entries {
+		assert.False(t, entries[i].Time.IsZero(), "Missing audit entry time")
+		entries[i].Time = time.Time{}
+	}
+	return entries
+}
+
+func TestAuditedUpdate(t *testing.T) {
+	manager := new(mocks.Manager)
+	auditLog := audit.NewMemoryLog(10)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPut, "/configs/datacenter-1", strings.NewReader(`{"metadata":{"cpu":"400m"}}`))
+	require.NoError(t, err, "Unexpected create request error")
+	req.RemoteAddr = "10.0.0.1:4242"
+	req.Header.Set("X-Author", "alice")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	manager.On("Upsert", mock.Anything, contract.Precondition{}).Return(
+		&contract.GetConfigResponse{Config: contract.Config{Name: "datacenter-1"}, Version: 3}, nil)
+	manager.On("Revision", service.DefaultNamespace, "datacenter-1", int64(3)).Return(
+		&contract.GetRevisionResponse{Name: "datacenter-1", Version: 3, Metadata: map[string]interface{}{"cpu": "400m"}}, nil)
+	manager.On("Revision", service.DefaultNamespace, "datacenter-1", int64(2)).Return(
+		&contract.GetRevisionResponse{Name: "datacenter-1", Version: 2, Metadata: map[string]interface{}{"cpu": "300m"}}, nil)
+
+	Audited(auditLog, manager, "update", UpdateConfig(manager)).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.Equal(t, []audit.Entry{{
+		Principal:  "alice",
+		RemoteAddr: "10.0.0.1:4242",
+		Method:     http.MethodPut,
+		Path:       "/configs/datacenter-1",
+		Operation:  "update",
+		Namespace:  service.DefaultNamespace,
+		Name:       "datacenter-1",
+		Outcome:    audit.Outcome{Status: http.StatusOK},
+		Version:    3,
+		Before:     map[string]interface{}{"cpu": "300m"},
+		After:      map[string]interface{}{"cpu": "400m"},
+		Diff:       []patch.Operation{{Op: "replace", Path: "/cpu", Value: json.RawMessage(`"400m"`)}},
+	}}, auditedEntries(t, auditLog), "Incorrect audit entries")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestAuditedCreate(t *testing.T) {
+	manager := new(mocks.Manager)
+	auditLog := audit.NewMemoryLog(10)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/configs", strings.NewReader(`{"name":"datacenter-1","metadata":{"cpu":"300m"}}`))
+	require.NoError(t, err, "Unexpected create request error")
+
+	manager.On("Create", mock.Anything).Return(
+		&contract.GetConfigResponse{Config: contract.Config{Name: "datacenter-1"}, Version: 1}, nil)
+	manager.On("Revision", service.DefaultNamespace, "datacenter-1", int64(1)).Return(
+		&contract.GetRevisionResponse{Name: "datacenter-1", Version: 1, Metadata: map[string]interface{}{"cpu": "300m"}}, nil)
+
+	Audited(auditLog, manager, "create", CreateConfig(manager)).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, "Incorrect http status code")
+	entries := auditedEntries(t, auditLog)
+	require.Len(t, entries, 1, "Incorrect number of audit entries")
+	assert.Equal(t, "datacenter-1", entries[0].Name, "Incorrect audited name")
+	assert.Nil(t, entries[0].Before, "Unexpected metadata before creation")
+	assert.Equal(t, map[string]interface{}{"cpu": "300m"}, entries[0].After, "Incorrect metadata after creation")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestAuditedDelete(t *testing.T) {
+	manager := new(mocks.Manager)
+	auditLog := audit.NewMemoryLog(10)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodDelete, "/namespaces/team-a/configs/datacenter-1", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"ns": "team-a", "name": "datacenter-1"})
+
+	manager.On("Delete", contract.DeleteConfigRequest{Namespace: "team-a", Name: "datacenter-1"}, contract.Precondition{}).Return(
+		&contract.GetRevisionResponse{Namespace: "team-a", Name: "datacenter-1", Version: 2, Deleted: true}, nil)
+	manager.On("Revision", "team-a", "datacenter-1", int64(2)).Return(
+		&contract.GetRevisionResponse{Namespace: "team-a", Name: "datacenter-1", Version: 2, Deleted: true}, nil)
+	manager.On("Revision", "team-a", "datacenter-1", int64(1)).Return(
+		&contract.GetRevisionResponse{Namespace: "team-a", Name: "datacenter-1", Version: 1, Metadata: "on"}, nil)
+
+	Audited(auditLog, manager, "delete", DeleteConfig(manager)).ServeHTTP(rr, req)
+
+	entries := auditedEntries(t, auditLog)
+	require.Len(t, entries, 1, "Incorrect number of audit entries")
+	assert.Equal(t, "on", entries[0].Before, "Incorrect metadata before deletion")
+	assert.Nil(t, entries[0].After, "Unexpected metadata after deletion")
+	assert.Equal(t, []patch.Operation{{Op: "replace", Path: "", Value: json.RawMessage(`null`)}}, entries[0].Diff,
+		"Incorrect diff")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestAuditedFailure(t *testing.T) {
+	manager := new(mocks.Manager)
+	auditLog := audit.NewMemoryLog(10)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodDelete, "/configs/datacenter-1", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	manager.On("Delete", contract.DeleteConfigRequest{Namespace: service.DefaultNamespace, Name: "datacenter-1"},
+		contract.Precondition{}).Return(nil, fmt.Errorf("delete: %w", service.ErrNotFound))
+
+	Audited(auditLog, manager, "delete", DeleteConfig(manager)).ServeHTTP(rr, req)
+
+	entries := auditedEntries(t, auditLog)
+	require.Len(t, entries, 1, "Incorrect number of audit entries")
+	assert.Equal(t, audit.Outcome{Status: http.StatusNotFound, Code: "CONFIG_NOT_FOUND"}, entries[0].Outcome,
+		"Incorrect audited outcome")
+	assert.Zero(t, entries[0].Version, "Unexpected audited version")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestGetAuditEntries(t *testing.T) {
+	auditLog := audit.NewMemoryLog(10)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, name := range []string{"dc-1", "dc-2", "dc-1"} {
+		require.NoError(t, auditLog.Record(audit.Entry{Time: start.Add(time.Duration(i) * time.Minute), Name: name,
+			Namespace: service.DefaultNamespace, Operation: "update", Outcome: audit.Outcome{Status: http.StatusOK}}),
+			"Unexpected record error")
+	}
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/audit?name=dc-1&since=2024-05-01T12:01:00Z", nil)
+	require.NoError(t, err, "Unexpected create request error")
+
+	GetAuditEntries(auditLog).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `[{"time":"2024-05-01T12:02:00Z","remoteAddr":"","method":"","path":"","operation":"update",
+		"namespace":"default","name":"dc-1","outcome":{"status":200}}]`, rr.Body.String(), "Incorrect http response body")
+}
+
+func TestGetAuditEntriesForInvalidQuery(t *testing.T) {
+	for _, query := range []string{"since=yesterday", "limit=0", "limit=1001"} {
+		t.Run(query, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, "/audit?"+query, nil)
+			require.NoError(t, err, "Unexpected create request error")
+
+			GetAuditEntries(audit.NewMemoryLog(10)).ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+			assertErrorCode(t, rr, "INVALID_AUDIT_QUERY")
+		})
+	}
+}
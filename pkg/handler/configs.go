@@ -2,27 +2,51 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
+	"io/ioutil"
+	"mime"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 
+	"jsonstore/pkg/auth"
 	"jsonstore/pkg/contract"
+	"jsonstore/pkg/httperr"
 	"jsonstore/pkg/lib"
+	"jsonstore/pkg/patch"
 	"jsonstore/pkg/service"
 )
 
+// Header naming who makes a change, recorded as the author of the revision it produces
+const authorHeader = "X-Author"
+
+// author returns who makes a request: the authenticated principal, or else the author header
+func author(r *http.Request) string {
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		return principal.Name
+	}
+	return r.Header.Get(authorHeader)
+}
+
+// GetAllConfigs lists the configs ordered by name, or by the value at the path given by the sort
+// parameter. The limit, cursor and fields parameters paginate and project the list.
 func GetAllConfigs(mgr service.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		res, err := mgr.GetAll()
+		opts, err := listOptions(r)
 		if err != nil {
 			zap.S().Errorf("Get all configs: %v", err)
-			http.Error(w, fmt.Sprintf("Get all configs: %v", err), http.StatusInternalServerError)
+			lib.WriteError(w, httperr.InvalidListOptions.WithMessage("Get all configs: %v", err))
 			return
 		}
-		w.WriteHeader(http.StatusOK)
-		lib.WriteResponseJSON(w, res)
+
+		res, err := mgr.GetAll(namespace(r), opts)
+		if err != nil {
+			writeError(w, err, "Get all configs")
+			return
+		}
+		writePage(w, r, res)
 	}
 }
 
@@ -31,109 +55,210 @@ func GetConfig(mgr service.Manager) http.HandlerFunc {
 		name, ok := mux.Vars(r)["name"]
 		if !ok {
 			zap.S().Errorf("Config name was not provided")
-			http.Error(w, "Missing config name", http.StatusBadRequest)
+			lib.WriteError(w, httperr.MissingConfigName)
 			return
 		}
 
-		res, err := mgr.Get(name)
+		res, err := mgr.Get(namespace(r), name)
 		if err != nil {
-			zap.S().Errorf("Get config %s: %v", name, err)
-			http.Error(w, fmt.Sprintf("Get config %s: %v", name, err), http.StatusInternalServerError)
+			writeError(w, err, "Get config %s", name)
 			return
 		}
 
+		w.Header().Set("ETag", etag(res.Version))
+		if notModified(r, res.Version) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		lib.WriteResponseJSON(w, res)
 	}
 }
 
+// SearchConfigs lists the configs matching the filter expression given by the q parameter. A
+// single path=value parameter is also accepted and matches configs whose value at the gjson path
+// is that string. The list is ordered, paginated and projected like GetAllConfigs.
 func SearchConfigs(mgr service.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := listOptions(r)
+		if err != nil {
+			zap.S().Errorf("Search configs: %v", err)
+			lib.WriteError(w, httperr.InvalidListOptions.WithMessage("Search configs: %v", err))
+			return
+		}
+
 		queries := r.URL.Query()
+		if expr, ok := queries["q"]; ok {
+			res, err := mgr.Search(namespace(r), expr[0], opts)
+			if err != nil {
+				writeError(w, err, "Search configs %s", expr[0])
+				return
+			}
+			writePage(w, r, res)
+			return
+		}
+
+		for _, param := range listParams {
+			delete(queries, param)
+		}
 		if len(queries) != 1 {
 			zap.S().Errorf("Search configs: invalid query expression")
-			http.Error(w, "Search configs: invalid query expression", http.StatusBadRequest)
+			lib.WriteError(w, httperr.InvalidQuery.WithMessage("Search configs: invalid query expression"))
 			return
 		}
 		var path, value string
-		for k, v := range queries {
-			path = k
-			if len(v) == 0 {
-				v = []string{""}
-			}
-			value = v[0]
+		for path = range queries {
+			value = queries.Get(path)
 		}
 
-		res, err := mgr.Search(path, value)
+		res, err := mgr.SearchValue(namespace(r), path, value, opts)
 		if err != nil {
-			zap.S().Errorf("Search configs %s=%s: %v", path, value, err)
-			http.Error(w, fmt.Sprintf("Search configs %s=%s: %v", path, value, err), http.StatusInternalServerError)
+			writeError(w, err, "Search configs %s=%s", path, value)
 			return
 		}
-
-		w.WriteHeader(http.StatusOK)
-		lib.WriteResponseJSON(w, res)
+		writePage(w, r, res)
 	}
 }
 
+// CreateConfig stores a new config and replies with 201 and its location, or with 409 when a
+// config with the same name already exists
 func CreateConfig(mgr service.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var request contract.UpsertConfigRequest
 		err := json.NewDecoder(r.Body).Decode(&request)
 		if err != nil {
 			zap.S().Errorf("Malformed request body: %v", err)
-			http.Error(w, "Malformed request body", http.StatusBadRequest)
+			lib.WriteError(w, httperr.MalformedBody)
 			return
 		}
 
-		err = mgr.Upsert(request)
+		request.Namespace, request.Author = namespace(r), author(r)
+		res, err := mgr.Create(request)
 		if err != nil {
-			zap.S().Errorf("Upsert config: %v", err)
-			http.Error(w, fmt.Sprintf("Create config: %v", err), http.StatusInternalServerError)
+			writeError(w, err, "Create config")
 			return
 		}
 
-		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+url.PathEscape(res.Name))
+		w.Header().Set("ETag", etag(res.Version))
+		w.WriteHeader(http.StatusCreated)
+		lib.WriteResponseJSON(w, res)
 	}
 }
 
+// UpdateConfig creates or replaces the config named in the path. The name in the body may be
+// omitted, but must match the path when present. If-Match and If-None-Match are honoured with
+// 412 on mismatch.
 func UpdateConfig(mgr service.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		name, ok := mux.Vars(r)["name"]
+		if !ok {
+			zap.S().Errorf("Config name was not provided")
+			lib.WriteError(w, httperr.MissingConfigName)
+			return
+		}
+
 		var request contract.UpsertConfigRequest
 		err := json.NewDecoder(r.Body).Decode(&request)
 		if err != nil {
 			zap.S().Errorf("Malformed request body: %v", err)
-			http.Error(w, "Malformed request body", http.StatusBadRequest)
+			lib.WriteError(w, httperr.MalformedBody)
+			return
+		}
+		if request.Name == "" {
+			request.Name = name
+		}
+		request.Namespace, request.Author = namespace(r), author(r)
+		if request.Name != name {
+			zap.S().Errorf("Update config %s: body name %s does not match", name, request.Name)
+			lib.WriteError(w, httperr.NameMismatch.WithMessage("Update config %s: name %q in body does not match the path",
+				name, request.Name))
 			return
 		}
 
-		err = mgr.Upsert(request)
+		res, err := mgr.Upsert(request, precondition(r))
 		if err != nil {
-			zap.S().Errorf("Upsert config: %v", err)
-			http.Error(w, fmt.Sprintf("Update config: %v", err), http.StatusInternalServerError)
+			writeError(w, err, "Update config")
 			return
 		}
 
+		w.Header().Set("ETag", etag(res.Version))
 		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, res)
 	}
 }
 
+// PatchConfig applies a JSON Merge Patch or a JSON Patch to a config depending on the request
+// content type. Plain JSON bodies are treated as merge patches.
+func PatchConfig(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, ok := mux.Vars(r)["name"]
+		if !ok {
+			zap.S().Errorf("Config name was not provided")
+			lib.WriteError(w, httperr.MissingConfigName)
+			return
+		}
+
+		contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			contentType = ""
+		}
+		switch contentType {
+		case patch.MergePatchType, patch.JSONPatchType:
+		case "application/json":
+			contentType = patch.MergePatchType
+		default:
+			zap.S().Errorf("Unsupported patch content type %q", contentType)
+			lib.WriteError(w, httperr.UnsupportedMedia.WithMessage("Patch config %s: content type must be %s or %s",
+				name, patch.MergePatchType, patch.JSONPatchType))
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil || !json.Valid(body) {
+			zap.S().Errorf("Malformed request body: %v", err)
+			lib.WriteError(w, httperr.MalformedBody)
+			return
+		}
+
+		res, err := mgr.Patch(namespace(r), name, contract.PatchConfigRequest{
+			ContentType: contentType,
+			Patch:       body,
+			Author:      author(r),
+		}, precondition(r))
+		if err != nil {
+			writeError(w, err, "Patch config %s", name)
+			return
+		}
+
+		w.Header().Set("ETag", etag(res.Version))
+		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, res)
+	}
+}
+
+// DeleteConfig removes the config named in the path and replies with the revision recording its
+// deletion
 func DeleteConfig(mgr service.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		name, ok := mux.Vars(r)["name"]
 		if !ok {
 			zap.S().Errorf("Config name was not provided")
-			http.Error(w, "Missing config name", http.StatusBadRequest)
+			lib.WriteError(w, httperr.MissingConfigName)
 			return
 		}
 
-		err := mgr.Delete(name)
+		res, err := mgr.Delete(contract.DeleteConfigRequest{
+			Namespace: namespace(r),
+			Name:      name,
+			Author:    author(r),
+		}, precondition(r))
 		if err != nil {
-			zap.S().Errorf("Delete config %s: %v", name, err)
-			http.Error(w, fmt.Sprintf("Delete config %s: %v", name, err), http.StatusInternalServerError)
+			writeError(w, err, "Delete config %s", name)
 			return
 		}
 
 		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, res)
 	}
 }
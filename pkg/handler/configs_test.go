@@ -3,9 +3,11 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -14,7 +16,10 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"jsonstore/pkg/auth"
 	"jsonstore/pkg/contract"
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/service"
 	"jsonstore/pkg/testlib/mocks"
 )
 
@@ -89,7 +94,7 @@ func TestGetConfigs(t *testing.T) {
 	err = json.NewDecoder(strings.NewReader(dc1)).Decode(&dc1Data)
 	require.NoError(t, err, "Unexpected json decode error")
 
-	manager.On("Get", "datacenter-1").Return(&dc1Data, nil)
+	manager.On("Get", service.DefaultNamespace, "datacenter-1").Return(&dc1Data, nil)
 
 	GetConfig(manager).ServeHTTP(rr, req)
 
@@ -100,6 +105,48 @@ func TestGetConfigs(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, manager)
 }
 
+func TestGetConfigsWithVersion(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req.Header.Set("If-None-Match", `"1"`)
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	manager.On("Get", service.DefaultNamespace, "datacenter-1").Return(&contract.GetConfigResponse{
+		Config:  contract.Config{Name: "datacenter-1"},
+		Version: 2,
+	}, nil)
+
+	GetConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"), "Incorrect ETag header")
+	assert.JSONEq(t, `{"name":"datacenter-1","metadata":null,"version":2}`, rr.Body.String(), "Incorrect config values")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestGetConfigsForMatchingIfNoneMatch(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req.Header.Set("If-None-Match", `"1", W/"2"`)
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	manager.On("Get", service.DefaultNamespace, "datacenter-1").Return(&contract.GetConfigResponse{
+		Config:  contract.Config{Name: "datacenter-1"},
+		Version: 2,
+	}, nil)
+
+	GetConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotModified, rr.Code, "Incorrect http status code")
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"), "Incorrect ETag header")
+	assert.Empty(t, rr.Body.String(), "Incorrect response")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
 func TestGetConfigsForMissingConfigNameError(t *testing.T) {
 	manager := new(mocks.Manager)
 	rr := httptest.NewRecorder()
@@ -122,7 +169,7 @@ func TestGetConfigsForConfigManagerError(t *testing.T) {
 	require.NoError(t, err, "Unexpected create request error")
 	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
 
-	manager.On("Get", "datacenter-1").Return(nil, errors.New("some error"))
+	manager.On("Get", service.DefaultNamespace, "datacenter-1").Return(nil, errors.New("some error"))
 
 	GetConfig(manager).ServeHTTP(rr, req)
 
@@ -133,6 +180,22 @@ func TestGetConfigsForConfigManagerError(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, manager)
 }
 
+func TestGetConfigsForMissingConfig(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	manager.On("Get", service.DefaultNamespace, "datacenter-1").Return(nil, fmt.Errorf("select: %w", service.ErrNotFound))
+
+	GetConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "CONFIG_NOT_FOUND")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
 func TestGetAllConfigs(t *testing.T) {
 	manager := new(mocks.Manager)
 	rr := httptest.NewRecorder()
@@ -145,7 +208,8 @@ func TestGetAllConfigs(t *testing.T) {
 	err = json.NewDecoder(strings.NewReader(dc2)).Decode(&dc2Data)
 	require.NoError(t, err, "Unexpected json decode error")
 
-	manager.On("GetAll").Return([]contract.GetConfigResponse{dc1Data, dc2Data}, nil)
+	manager.On("GetAll", service.DefaultNamespace, contract.ListOptions{}).
+		Return(&contract.ListConfigsResponse{Configs: []contract.GetConfigResponse{dc1Data, dc2Data}}, nil)
 
 	GetAllConfigs(manager).ServeHTTP(rr, req)
 
@@ -156,13 +220,31 @@ func TestGetAllConfigs(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, manager)
 }
 
+func TestGetAllConfigsForNoConfigs(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs", nil)
+	require.NoError(t, err, "Unexpected create request error")
+
+	manager.On("GetAll", service.DefaultNamespace, contract.ListOptions{}).
+		Return(&contract.ListConfigsResponse{Configs: []contract.GetConfigResponse{}}, nil)
+
+	GetAllConfigs(manager).ServeHTTP(rr, req)
+
+	response, err := ioutil.ReadAll(rr.Body)
+	require.NoError(t, err, "Unexpected error while reading response body")
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `[]`, string(response), "Incorrect config values")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
 func TestGetAllConfigsForServiceManagerError(t *testing.T) {
 	manager := new(mocks.Manager)
 	rr := httptest.NewRecorder()
 	req, err := http.NewRequest(http.MethodGet, "/configs", nil)
 	require.NoError(t, err, "Unexpected create request error")
 
-	manager.On("GetAll").Return(nil, errors.New("some error"))
+	manager.On("GetAll", service.DefaultNamespace, contract.ListOptions{}).Return(nil, errors.New("some error"))
 
 	GetAllConfigs(manager).ServeHTTP(rr, req)
 
@@ -182,7 +264,8 @@ func TestSearchConfigs(t *testing.T) {
 	err = json.NewDecoder(strings.NewReader(all)).Decode(&data)
 	require.NoError(t, err, "Unexpected json decode error")
 
-	manager.On("Search", "metadata.monitoring.enabled", "true").Return(data, nil)
+	manager.On("SearchValue", service.DefaultNamespace, "metadata.monitoring.enabled", "true", contract.ListOptions{}).
+		Return(&contract.ListConfigsResponse{Configs: data}, nil)
 
 	SearchConfigs(manager).ServeHTTP(rr, req)
 
@@ -193,6 +276,57 @@ func TestSearchConfigs(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, manager)
 }
 
+func TestSearchConfigsForGJSONPath(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	path := `metadata.hosts.#(zone=="a")#.name|@reverse`
+	req, err := http.NewRequest(http.MethodGet, "/configs/search?limit=1&"+url.QueryEscape(path)+"=db-1", nil)
+	require.NoError(t, err, "Unexpected create request error")
+
+	manager.On("SearchValue", service.DefaultNamespace, path, "db-1", contract.ListOptions{Limit: 1}).
+		Return(&contract.ListConfigsResponse{Configs: []contract.GetConfigResponse{}}, nil)
+
+	SearchConfigs(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `[]`, rr.Body.String(), "Incorrect config values")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestSearchConfigsWithExpression(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	expr := `metadata.replicas >= 3 AND metadata.region in ("eu", "us")`
+	req, err := http.NewRequest(http.MethodGet, "/configs/search?q="+url.QueryEscape(expr), nil)
+	require.NoError(t, err, "Unexpected create request error")
+
+	manager.On("Search", service.DefaultNamespace, expr, contract.ListOptions{}).
+		Return(&contract.ListConfigsResponse{Configs: []contract.GetConfigResponse{}}, nil)
+
+	SearchConfigs(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `[]`, rr.Body.String(), "Incorrect config values")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestSearchConfigsForSyntaxError(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs/search?q="+url.QueryEscape("metadata.replicas >="), nil)
+	require.NoError(t, err, "Unexpected create request error")
+
+	manager.On("Search", service.DefaultNamespace, "metadata.replicas >=", contract.ListOptions{}).
+		Return(nil, fmt.Errorf("search: %w: syntax error at position 21: expected a value", service.ErrInvalidQuery))
+
+	SearchConfigs(manager).ServeHTTP(rr, req)
+
+	assertErrorCode(t, rr, "INVALID_QUERY")
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+	assert.Contains(t, rr.Body.String(), "syntax error at position 21", "Incorrect response")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
 func TestSearchConfigsForMissingQueryParamError(t *testing.T) {
 	manager := new(mocks.Manager)
 	rr := httptest.NewRecorder()
@@ -201,6 +335,7 @@ func TestSearchConfigsForMissingQueryParamError(t *testing.T) {
 
 	SearchConfigs(manager).ServeHTTP(rr, req)
 
+	assertErrorCode(t, rr, "INVALID_QUERY")
 	response, err := ioutil.ReadAll(rr.Body)
 	require.NoError(t, err, "Unexpected error while reading response body")
 	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
@@ -215,7 +350,8 @@ func TestSearchConfigsForConfigManagerError(t *testing.T) {
 	require.NoError(t, err, "Unexpected create request error")
 	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
 
-	manager.On("Search", "metadata.monitoring.enabled", "true").Return(nil, errors.New("some error"))
+	manager.On("SearchValue", service.DefaultNamespace, "metadata.monitoring.enabled", "true", contract.ListOptions{}).
+		Return(nil, errors.New("some error"))
 
 	SearchConfigs(manager).ServeHTTP(rr, req)
 
@@ -234,13 +370,58 @@ func TestCreateConfig(t *testing.T) {
 	var dc1Data contract.UpsertConfigRequest
 	err = json.NewDecoder(strings.NewReader(dc1)).Decode(&dc1Data)
 	require.NoError(t, err, "Unexpected json decode error")
+	dc1Data.Namespace = service.DefaultNamespace
 
-	manager.On("Upsert", dc1Data).Return(nil)
+	manager.On("Create", dc1Data).Return(&contract.GetConfigResponse{Config: dc1Data.Config}, nil)
 
 	CreateConfig(manager).ServeHTTP(rr, req)
 
-	require.NoError(t, err, "Unexpected error while reading response body")
-	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.Equal(t, http.StatusCreated, rr.Code, "Incorrect http status code")
+	assert.Equal(t, "/configs/datacenter-1", rr.Header().Get("Location"), "Incorrect location header")
+	assert.JSONEq(t, dc1, rr.Body.String(), "Incorrect config values")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestCreateConfigForExistingConfig(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/configs", strings.NewReader(dc1))
+	require.NoError(t, err, "Unexpected create request error")
+
+	manager.On("Create", mock.Anything).Return(nil, fmt.Errorf("create: %w", service.ErrAlreadyExists))
+
+	CreateConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "CONFIG_ALREADY_EXISTS")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestCreateConfigForInvalidInput(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/configs", strings.NewReader(`{"metadata":{}}`))
+	require.NoError(t, err, "Unexpected create request error")
+
+	manager.On("Create", mock.Anything).Return(nil, fmt.Errorf("create: %w", service.ErrInvalidConfig))
+
+	CreateConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "INVALID_CONFIG")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestCreateConfigForMalformedBody(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/configs", strings.NewReader(`{"name":`))
+	require.NoError(t, err, "Unexpected create request error")
+
+	CreateConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "MALFORMED_BODY")
 	mock.AssertExpectationsForObjects(t, manager)
 }
 
@@ -252,8 +433,9 @@ func TestCreateConfigForServiceManagerError(t *testing.T) {
 	var dc1Data contract.UpsertConfigRequest
 	err = json.NewDecoder(strings.NewReader(dc1)).Decode(&dc1Data)
 	require.NoError(t, err, "Unexpected json decode error")
+	dc1Data.Namespace = service.DefaultNamespace
 
-	manager.On("Upsert", dc1Data).Return(errors.New("some error"))
+	manager.On("Create", dc1Data).Return(nil, errors.New("some error"))
 
 	CreateConfig(manager).ServeHTTP(rr, req)
 
@@ -267,31 +449,90 @@ func TestCreateConfigForServiceManagerError(t *testing.T) {
 func TestUpdateConfig(t *testing.T) {
 	manager := new(mocks.Manager)
 	rr := httptest.NewRecorder()
-	req, err := http.NewRequest(http.MethodPatch, "/configs", strings.NewReader(dc1))
+	req, err := http.NewRequest(http.MethodPut, "/configs/datacenter-1", strings.NewReader(dc1))
 	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
 	var dc1Data contract.UpsertConfigRequest
 	err = json.NewDecoder(strings.NewReader(dc1)).Decode(&dc1Data)
 	require.NoError(t, err, "Unexpected json decode error")
+	dc1Data.Namespace = service.DefaultNamespace
 
-	manager.On("Upsert", dc1Data).Return(nil)
+	manager.On("Upsert", dc1Data, contract.Precondition{}).Return(&contract.GetConfigResponse{Config: dc1Data.Config, Version: 1}, nil)
 
 	UpdateConfig(manager).ServeHTTP(rr, req)
 
 	require.NoError(t, err, "Unexpected error while reading response body")
 	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.Equal(t, `"1"`, rr.Header().Get("ETag"), "Incorrect ETag header")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestUpdateConfigWithIfMatch(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPut, "/configs/datacenter-1", strings.NewReader(dc1))
+	require.NoError(t, err, "Unexpected create request error")
+	req.Header.Set("If-Match", `"2", "3"`)
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+	var dc1Data contract.UpsertConfigRequest
+	err = json.NewDecoder(strings.NewReader(dc1)).Decode(&dc1Data)
+	require.NoError(t, err, "Unexpected json decode error")
+	dc1Data.Namespace = service.DefaultNamespace
+
+	manager.On("Upsert", dc1Data, contract.Precondition{IfMatch: []int64{2, 3}}).
+		Return(nil, fmt.Errorf("insert: %w", service.ErrPreconditionFailed))
+
+	UpdateConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "PRECONDITION_FAILED")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestUpdateConfigUsesPathName(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPut, "/configs/datacenter-1", strings.NewReader(`{"metadata":{"a":"b"}}`))
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	manager.On("Upsert", contract.UpsertConfigRequest{Namespace: service.DefaultNamespace, Config: contract.Config{
+		Name:     "datacenter-1",
+		Metadata: map[string]interface{}{"a": "b"},
+	}}, contract.Precondition{}).Return(&contract.GetConfigResponse{}, nil)
+
+	UpdateConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestUpdateConfigForMismatchedName(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPut, "/configs/datacenter-2", strings.NewReader(dc1))
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-2"})
+
+	UpdateConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "NAME_MISMATCH")
 	mock.AssertExpectationsForObjects(t, manager)
 }
 
 func TestUpdateConfigForServiceManagerError(t *testing.T) {
 	manager := new(mocks.Manager)
 	rr := httptest.NewRecorder()
-	req, err := http.NewRequest(http.MethodPatch, "/configs", strings.NewReader(dc1))
+	req, err := http.NewRequest(http.MethodPut, "/configs/datacenter-1", strings.NewReader(dc1))
 	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
 	var dc1Data contract.UpsertConfigRequest
 	err = json.NewDecoder(strings.NewReader(dc1)).Decode(&dc1Data)
 	require.NoError(t, err, "Unexpected json decode error")
+	dc1Data.Namespace = service.DefaultNamespace
 
-	manager.On("Upsert", dc1Data).Return(errors.New("some error"))
+	manager.On("Upsert", dc1Data, contract.Precondition{}).Return(nil, errors.New("some error"))
 
 	UpdateConfig(manager).ServeHTTP(rr, req)
 
@@ -302,6 +543,98 @@ func TestUpdateConfigForServiceManagerError(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, manager)
 }
 
+func TestPatchConfig(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	body := `[{"op":"replace","path":"/metadata/monitoring/enabled","value":"false"}]`
+	req, err := http.NewRequest(http.MethodPatch, "/configs/datacenter-1", strings.NewReader(body))
+	require.NoError(t, err, "Unexpected create request error")
+	req.Header.Set("Content-Type", "application/json-patch+json")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+	var dc1Data contract.GetConfigResponse
+	err = json.NewDecoder(strings.NewReader(dc1)).Decode(&dc1Data)
+	require.NoError(t, err, "Unexpected json decode error")
+
+	manager.On("Patch", service.DefaultNamespace, "datacenter-1", contract.PatchConfigRequest{
+		ContentType: "application/json-patch+json",
+		Patch:       []byte(body),
+	}, contract.Precondition{}).Return(&dc1Data, nil)
+
+	PatchConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, dc1, rr.Body.String(), "Incorrect config values")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestPatchConfigTreatsJSONAsMergePatch(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	body := `{"metadata":{"monitoring":null}}`
+	req, err := http.NewRequest(http.MethodPatch, "/configs/datacenter-1", strings.NewReader(body))
+	require.NoError(t, err, "Unexpected create request error")
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	manager.On("Patch", service.DefaultNamespace, "datacenter-1", contract.PatchConfigRequest{
+		ContentType: "application/merge-patch+json",
+		Patch:       []byte(body),
+	}, contract.Precondition{}).Return(&contract.GetConfigResponse{}, nil)
+
+	PatchConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestPatchConfigForUnsupportedContentType(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPatch, "/configs/datacenter-1", strings.NewReader(`{}`))
+	require.NoError(t, err, "Unexpected create request error")
+	req.Header.Set("Content-Type", "text/plain")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	PatchConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "UNSUPPORTED_MEDIA_TYPE")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestPatchConfigForFailedTestOperation(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	body := `[{"op":"test","path":"/metadata/monitoring/enabled","value":"false"}]`
+	req, err := http.NewRequest(http.MethodPatch, "/configs/datacenter-1", strings.NewReader(body))
+	require.NoError(t, err, "Unexpected create request error")
+	req.Header.Set("Content-Type", "application/json-patch+json")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	manager.On("Patch", service.DefaultNamespace, "datacenter-1", mock.Anything, contract.Precondition{}).Return(nil, fmt.Errorf("patch: %w", service.ErrPatchTestFailed))
+
+	PatchConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "PATCH_TEST_FAILED")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestPatchConfigForMalformedBody(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPatch, "/configs/datacenter-1", strings.NewReader(`[{"op":`))
+	require.NoError(t, err, "Unexpected create request error")
+	req.Header.Set("Content-Type", "application/json-patch+json")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	PatchConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "MALFORMED_BODY")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
 func TestDeleteConfig(t *testing.T) {
 	manager := new(mocks.Manager)
 	rr := httptest.NewRecorder()
@@ -309,11 +642,49 @@ func TestDeleteConfig(t *testing.T) {
 	require.NoError(t, err, "Unexpected create request error")
 	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
 
-	manager.On("Delete", "datacenter-1").Return(nil)
+	manager.On("Delete", contract.DeleteConfigRequest{Namespace: service.DefaultNamespace, Name: "datacenter-1"}, contract.Precondition{}).Return(
+		&contract.GetRevisionResponse{Name: "datacenter-1", Version: 3, Deleted: true}, nil)
+
+	DeleteConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `{"name":"datacenter-1","version":3,"timestamp":"0001-01-01T00:00:00Z","deleted":true}`, rr.Body.String(),
+		"Incorrect http response body")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestDeleteConfigWithIfMatch(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodDelete, "/configs", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req.Header.Set("If-Match", "*")
+	req.Header.Set("X-Author", "alice")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	manager.On("Delete", contract.DeleteConfigRequest{Namespace: service.DefaultNamespace, Name: "datacenter-1", Author: "alice"},
+		contract.Precondition{IfMatchAny: true}).Return(&contract.GetRevisionResponse{Name: "datacenter-1", Version: 2, Deleted: true}, nil)
+
+	DeleteConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestDeleteConfigByAuthenticatedPrincipal(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodDelete, "/configs", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req.Header.Set("X-Author", "mallory")
+	req = req.WithContext(auth.NewContext(req.Context(), &auth.Principal{Name: "ci", Method: auth.MethodAPIKey}))
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	manager.On("Delete", contract.DeleteConfigRequest{Namespace: service.DefaultNamespace, Name: "datacenter-1", Author: "ci"},
+		contract.Precondition{}).Return(&contract.GetRevisionResponse{Name: "datacenter-1", Version: 2, Deleted: true}, nil)
 
 	DeleteConfig(manager).ServeHTTP(rr, req)
 
-	require.NoError(t, err, "Unexpected error while reading response body")
 	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
 	mock.AssertExpectationsForObjects(t, manager)
 }
@@ -333,6 +704,22 @@ func TestDeleteConfigForMissingConfigNameError(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, manager)
 }
 
+func TestDeleteConfigForMissingConfig(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodDelete, "/configs", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	manager.On("Delete", contract.DeleteConfigRequest{Namespace: service.DefaultNamespace, Name: "datacenter-1"}, contract.Precondition{}).Return(nil, fmt.Errorf("delete: %w", service.ErrNotFound))
+
+	DeleteConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "CONFIG_NOT_FOUND")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
 func TestDeleteConfigForServiceManagerError(t *testing.T) {
 	manager := new(mocks.Manager)
 	rr := httptest.NewRecorder()
@@ -340,13 +727,23 @@ func TestDeleteConfigForServiceManagerError(t *testing.T) {
 	require.NoError(t, err, "Unexpected create request error")
 	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
 
-	manager.On("Delete", "datacenter-1").Return(errors.New("some error"))
+	manager.On("Delete", contract.DeleteConfigRequest{Namespace: service.DefaultNamespace, Name: "datacenter-1"}, contract.Precondition{}).Return(nil, errors.New("some error"))
 
 	DeleteConfig(manager).ServeHTTP(rr, req)
 
+	assertErrorCode(t, rr, "INTERNAL_ERROR")
 	response, err := ioutil.ReadAll(rr.Body)
 	require.NoError(t, err, "Unexpected error while reading response body")
 	assert.Equal(t, http.StatusInternalServerError, rr.Code, "Incorrect http status code")
 	assert.Contains(t, string(response), "Delete config datacenter-1:", "Incorrect response")
 	mock.AssertExpectationsForObjects(t, manager)
 }
+
+func assertErrorCode(t *testing.T, rr *httptest.ResponseRecorder, code string) {
+	var body httperr.Error
+	err := json.Unmarshal(rr.Body.Bytes(), &body)
+	require.NoError(t, err, "Error response is not a JSON error object")
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"), "Incorrect content type")
+	assert.Equal(t, rr.Code, body.HTTPStatus, "Incorrect http status in error body")
+	assert.Equal(t, code, body.Code, "Incorrect error code")
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/lib"
+	"jsonstore/pkg/service"
+)
+
+// writeError logs err and replies with the catalog error matching it, prefixing its message
+// with a description of the failed operation
+func writeError(w http.ResponseWriter, err error, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	zap.S().Errorf("%s: %v", message, err)
+	httpErr := toHTTPError(err).WithMessage("%s: %v", message, err)
+
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		for _, v := range validationErr.Violations {
+			httpErr.Violations = append(httpErr.Violations, httperr.Violation{Path: v.Path, Message: v.Message})
+		}
+	}
+	lib.WriteError(w, httpErr)
+}
+
+// toHTTPError maps an error returned by the service layer to its entry in the error catalog
+func toHTTPError(err error) httperr.Error {
+	switch {
+	case errors.Is(err, service.ErrRevisionNotFound):
+		return httperr.RevisionNotFound
+	case errors.Is(err, service.ErrSchemaNotFound):
+		return httperr.SchemaNotFound
+	case errors.Is(err, service.ErrNamespaceNotFound):
+		return httperr.NamespaceNotFound
+	case errors.Is(err, service.ErrIndexNotFound):
+		return httperr.IndexNotFound
+	case errors.Is(err, service.ErrWebhookNotFound):
+		return httperr.WebhookNotFound
+	case errors.Is(err, service.ErrNotFound):
+		return httperr.ConfigNotFound
+	case errors.Is(err, service.ErrAlreadyExists):
+		return httperr.ConfigExists
+	case errors.Is(err, service.ErrNamespaceExists):
+		return httperr.NamespaceExists
+	case errors.Is(err, service.ErrNamespaceNotEmpty):
+		return httperr.NamespaceNotEmpty
+	case errors.Is(err, service.ErrIndexExists):
+		return httperr.IndexExists
+	case errors.Is(err, service.ErrSchemaInUse):
+		return httperr.SchemaInUse
+	case errors.Is(err, service.ErrPreconditionFailed):
+		return httperr.PreconditionFailed
+	case errors.Is(err, service.ErrConflict):
+		return httperr.VersionConflict
+	case errors.Is(err, service.ErrInvalidQuery):
+		return httperr.InvalidQuery
+	case errors.Is(err, service.ErrInvalidListOptions):
+		return httperr.InvalidListOptions
+	case errors.Is(err, service.ErrInvalidIndex):
+		return httperr.InvalidIndex
+	case errors.Is(err, service.ErrInvalidSchema):
+		return httperr.InvalidSchema
+	case errors.Is(err, service.ErrSchemaViolation):
+		return httperr.SchemaViolation
+	case errors.Is(err, service.ErrInvalidNamespace):
+		return httperr.InvalidNamespace
+	case errors.Is(err, service.ErrInvalidConfig):
+		return httperr.InvalidConfig
+	case errors.Is(err, service.ErrInvalidPatch):
+		return httperr.InvalidPatch
+	case errors.Is(err, service.ErrPatchTestFailed):
+		return httperr.PatchTestFailed
+	case errors.Is(err, service.ErrInvalidRollback):
+		return httperr.InvalidRollback
+	case errors.Is(err, service.ErrWatchExpired):
+		return httperr.WatchExpired
+	case errors.Is(err, service.ErrInvalidWatch):
+		return httperr.InvalidWatch
+	case errors.Is(err, service.ErrInvalidImport):
+		return httperr.InvalidImport
+	case errors.Is(err, service.ErrInvalidTransaction):
+		return httperr.InvalidTransaction
+	case errors.Is(err, service.ErrInvalidWebhook):
+		return httperr.InvalidWebhook
+	default:
+		return httperr.InternalError
+	}
+}
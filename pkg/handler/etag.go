@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"jsonstore/pkg/contract"
+)
+
+// etag returns the strong entity tag of a config version
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// precondition reads the If-Match and If-None-Match headers of r. Tags that are not a version
+// issued by etag are kept as version 0, which never matches a stored config.
+func precondition(r *http.Request) contract.Precondition {
+	var cond contract.Precondition
+	cond.IfMatch, cond.IfMatchAny = parseETags(r.Header.Get("If-Match"), false)
+	cond.IfNoneMatch, cond.IfNoneMatchAny = parseETags(r.Header.Get("If-None-Match"), true)
+	return cond
+}
+
+// parseETags parses a list of entity tags, or "*". Weak tags are only accepted when weak is
+// set, as If-Match requires the strong comparison.
+func parseETags(header string, weak bool) ([]int64, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil, false
+	}
+	if header == "*" {
+		return nil, true
+	}
+
+	var versions []int64
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				versions = append(versions, 0)
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		value, err := strconv.Unquote(tag)
+		if err != nil {
+			versions = append(versions, 0)
+			continue
+		}
+		version, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || version < 1 {
+			version = 0
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, false
+}
+
+// notModified tells whether the If-None-Match header of a GET request matches the version
+func notModified(r *http.Request, version int64) bool {
+	versions, matchAny := parseETags(r.Header.Get("If-None-Match"), true)
+	if matchAny {
+		return true
+	}
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+)
+
+func TestPrecondition(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifMatch     string
+		ifNoneMatch string
+		expected    contract.Precondition
+	}{
+		{name: "no headers"},
+		{name: "if match", ifMatch: `"3"`, expected: contract.Precondition{IfMatch: []int64{3}}},
+		{name: "if match list", ifMatch: `"3", "4"`, expected: contract.Precondition{IfMatch: []int64{3, 4}}},
+		{name: "if match any", ifMatch: "*", expected: contract.Precondition{IfMatchAny: true}},
+		{name: "if match weak", ifMatch: `W/"3"`, expected: contract.Precondition{IfMatch: []int64{0}}},
+		{name: "if match unknown tag", ifMatch: `"abc"`, expected: contract.Precondition{IfMatch: []int64{0}}},
+		{name: "if none match weak", ifNoneMatch: `W/"3"`, expected: contract.Precondition{IfNoneMatch: []int64{3}}},
+		{name: "if none match any", ifNoneMatch: "*", expected: contract.Precondition{IfNoneMatchAny: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPut, "/configs/datacenter-1", nil)
+			require.NoError(t, err, "Unexpected create request error")
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+
+			assert.Equal(t, tt.expected, precondition(req), "Incorrect precondition")
+		})
+	}
+}
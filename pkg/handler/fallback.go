@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"net/http"
+
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/lib"
+)
+
+func NotFound() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lib.WriteError(w, httperr.RouteNotFound.WithMessage("No route matches %s", r.URL.Path))
+	}
+}
+
+func MethodNotAllowed() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lib.WriteError(w, httperr.MethodNotAllowed.WithMessage("Method %s is not allowed on %s", r.Method, r.URL.Path))
+	}
+}
@@ -0,0 +1,33 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"jsonstore/pkg/handler"
+)
+
+func TestNotFound(t *testing.T) {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+
+	handler.NotFound().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `{"httpStatus":404,"code":"ROUTE_NOT_FOUND","message":"No route matches /unknown"}`,
+		rr.Body.String(), "Incorrect response")
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/health", nil)
+
+	handler.MethodNotAllowed().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `{"httpStatus":405,"code":"METHOD_NOT_ALLOWED","message":"Method POST is not allowed on /health"}`,
+		rr.Body.String(), "Incorrect response")
+}
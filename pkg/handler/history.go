@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/lib"
+	"jsonstore/pkg/service"
+)
+
+// GetConfigHistory lists every revision of a config, including those from before it was deleted
+func GetConfigHistory(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, ok := mux.Vars(r)["name"]
+		if !ok {
+			zap.S().Errorf("Config name was not provided")
+			lib.WriteError(w, httperr.MissingConfigName)
+			return
+		}
+
+		res, err := mgr.History(namespace(r), name)
+		if err != nil {
+			writeError(w, err, "Get config history %s", name)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, res)
+	}
+}
+
+func GetConfigRevision(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, ok := mux.Vars(r)["name"]
+		if !ok {
+			zap.S().Errorf("Config name was not provided")
+			lib.WriteError(w, httperr.MissingConfigName)
+			return
+		}
+		version, err := parseVersion(mux.Vars(r)["version"])
+		if err != nil {
+			zap.S().Errorf("Get config revision %s: %v", name, err)
+			lib.WriteError(w, httperr.InvalidVersion.WithMessage("Get config revision %s: %v", name, err))
+			return
+		}
+
+		res, err := mgr.Revision(namespace(r), name, version)
+		if err != nil {
+			writeError(w, err, "Get config revision %s@%d", name, version)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, res)
+	}
+}
+
+// RollbackConfig restores a config to the metadata of the revision given by the "to" query
+// parameter, recording the rollback as a new revision
+func RollbackConfig(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, ok := mux.Vars(r)["name"]
+		if !ok {
+			zap.S().Errorf("Config name was not provided")
+			lib.WriteError(w, httperr.MissingConfigName)
+			return
+		}
+		version, err := parseVersion(r.URL.Query().Get("to"))
+		if err != nil {
+			zap.S().Errorf("Rollback config %s: %v", name, err)
+			lib.WriteError(w, httperr.InvalidVersion.WithMessage("Rollback config %s: %v", name, err))
+			return
+		}
+
+		res, err := mgr.Rollback(contract.RollbackConfigRequest{
+			Namespace: namespace(r),
+			Name:      name,
+			Version:   version,
+			Author:    author(r),
+		}, precondition(r))
+		if err != nil {
+			writeError(w, err, "Rollback config %s to %d", name, version)
+			return
+		}
+
+		w.Header().Set("ETag", etag(res.Version))
+		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, res)
+	}
+}
+
+func parseVersion(value string) (int64, error) {
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("version %q is not a positive integer", value)
+	}
+
+	return version, nil
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/service"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+func TestGetConfigHistory(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs/datacenter-1/history", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+	timestamp := time.Date(2021, time.September, 1, 10, 0, 0, 0, time.UTC)
+
+	manager.On("History", service.DefaultNamespace, "datacenter-1").Return([]contract.GetRevisionResponse{
+		{Name: "datacenter-1", Version: 1, Timestamp: timestamp, Author: "alice", Metadata: map[string]interface{}{"a": "b"}},
+		{Name: "datacenter-1", Version: 2, Timestamp: timestamp, Deleted: true},
+	}, nil)
+
+	GetConfigHistory(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `[
+  {"name":"datacenter-1","version":1,"timestamp":"2021-09-01T10:00:00Z","author":"alice","metadata":{"a":"b"}},
+  {"name":"datacenter-1","version":2,"timestamp":"2021-09-01T10:00:00Z","deleted":true}
+]`, rr.Body.String(), "Incorrect history")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestGetConfigHistoryForMissingConfig(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs/datacenter-1/history", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	manager.On("History", service.DefaultNamespace, "datacenter-1").Return(nil, fmt.Errorf("history: %w", service.ErrNotFound))
+
+	GetConfigHistory(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "CONFIG_NOT_FOUND")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestGetConfigRevision(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs/datacenter-1/history/2", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1", "version": "2"})
+
+	manager.On("Revision", service.DefaultNamespace, "datacenter-1", int64(2)).
+		Return(&contract.GetRevisionResponse{Name: "datacenter-1", Version: 2}, nil)
+
+	GetConfigRevision(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `{"name":"datacenter-1","version":2,"timestamp":"0001-01-01T00:00:00Z"}`, rr.Body.String(),
+		"Incorrect revision")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestGetConfigRevisionForMissingRevision(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs/datacenter-1/history/9", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1", "version": "9"})
+
+	manager.On("Revision", service.DefaultNamespace, "datacenter-1", int64(9)).Return(nil, fmt.Errorf("revision: %w", service.ErrRevisionNotFound))
+
+	GetConfigRevision(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "REVISION_NOT_FOUND")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestGetConfigRevisionForInvalidVersion(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs/datacenter-1/history/latest", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1", "version": "latest"})
+
+	GetConfigRevision(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "INVALID_VERSION")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestRollbackConfig(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/configs/datacenter-1/rollback?to=1", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req.Header.Set("X-Author", "alice")
+	req.Header.Set("If-Match", `"3"`)
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	manager.On("Rollback", contract.RollbackConfigRequest{Namespace: service.DefaultNamespace, Name: "datacenter-1", Version: 1, Author: "alice"},
+		contract.Precondition{IfMatch: []int64{3}}).
+		Return(&contract.GetConfigResponse{Config: contract.Config{Name: "datacenter-1"}, Version: 4}, nil)
+
+	RollbackConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.Equal(t, `"4"`, rr.Header().Get("ETag"), "Incorrect ETag header")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestRollbackConfigForMissingVersion(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/configs/datacenter-1/rollback", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	RollbackConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "INVALID_VERSION")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestRollbackConfigToDeletion(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/configs/datacenter-1/rollback?to=2", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	manager.On("Rollback", contract.RollbackConfigRequest{Namespace: service.DefaultNamespace, Name: "datacenter-1", Version: 2}, contract.Precondition{}).
+		Return(nil, fmt.Errorf("rollback: %w", service.ErrInvalidRollback))
+
+	RollbackConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "INVALID_ROLLBACK")
+	mock.AssertExpectationsForObjects(t, manager)
+}
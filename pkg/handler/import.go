@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/lib"
+	"jsonstore/pkg/service"
+)
+
+// Newline delimited JSON, one config per line
+const ndjsonType = "application/x-ndjson"
+
+// ExportConfigs streams every config of the namespace ordered by name, as a JSON array or, when
+// the format parameter is ndjson or the client accepts application/x-ndjson, as one config per
+// line. Either can be imported back as is.
+func ExportConfigs(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		switch {
+		case format == "" && strings.Contains(r.Header.Get("Accept"), ndjsonType):
+			format = "ndjson"
+		case format == "", format == "json", format == "ndjson":
+		default:
+			zap.S().Errorf("Export configs: invalid format %q", format)
+			lib.WriteError(w, httperr.InvalidImport.WithMessage("Export configs: format %q is neither json nor ndjson",
+				format))
+			return
+		}
+
+		res, err := mgr.Export(namespace(r))
+		if err != nil {
+			writeError(w, err, "Export configs")
+			return
+		}
+
+		// Configs are encoded one at a time, so that the encoded export is never buffered as a whole
+		contentType, open, separator, end := "application/json", "[\n", ",\n", "\n]\n"
+		if format == "ndjson" {
+			contentType, open, separator, end = ndjsonType, "", "\n", "\n"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, open)
+		for i, config := range res {
+			if i > 0 {
+				io.WriteString(w, separator)
+			}
+			data, err := json.Marshal(config)
+			if err != nil {
+				zap.S().Errorf("Export configs: %v", err)
+				return
+			}
+			w.Write(data)
+		}
+		if len(res) == 0 {
+			end = strings.TrimPrefix(end, "\n")
+		}
+		io.WriteString(w, end)
+	}
+}
+
+// ImportConfigs writes the configs of the body, given as a JSON array or as application/x-ndjson,
+// to the namespace as the mode parameter says: merge, the default, replace-all or dry-run. The
+// dryRun parameter previews any mode. The reply reports the outcome of each config.
+func ImportConfigs(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		queries := r.URL.Query()
+		req := contract.ImportRequest{Namespace: namespace(r), Mode: queries.Get("mode"), Author: author(r)}
+		if value := queries.Get("dryRun"); value != "" {
+			dryRun, err := strconv.ParseBool(value)
+			if err != nil {
+				zap.S().Errorf("Import configs: invalid dryRun %q", value)
+				lib.WriteError(w, httperr.InvalidImport.WithMessage("Import configs: dryRun %q is not a boolean", value))
+				return
+			}
+			req.DryRun = dryRun
+		}
+
+		var ok bool
+		if req.Configs, ok = decodeConfigs(w, r, "Import configs"); !ok {
+			return
+		}
+
+		res, err := mgr.Import(req)
+		if err != nil {
+			writeError(w, err, "Import configs")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, res)
+	}
+}
+
+// decodeConfigs decodes the configs of a body given as a JSON array or as application/x-ndjson,
+// replying with an error when it cannot
+func decodeConfigs(w http.ResponseWriter, r *http.Request, operation string) ([]contract.Config, bool) {
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		contentType = "application/json"
+	}
+
+	var configs []contract.Config
+	switch contentType {
+	case ndjsonType:
+		configs, err = decodeNDJSON(r)
+	case "application/json":
+		err = json.NewDecoder(r.Body).Decode(&configs)
+	default:
+		zap.S().Errorf("%s: unsupported content type %q", operation, contentType)
+		lib.WriteError(w, httperr.UnsupportedMedia.WithMessage("%s: content type must be application/json or %s",
+			operation, ndjsonType))
+		return nil, false
+	}
+	if err != nil {
+		zap.S().Errorf("Malformed request body: %v", err)
+		lib.WriteError(w, httperr.MalformedBody.WithMessage("%s: %v", operation, err))
+		return nil, false
+	}
+	return configs, true
+}
+
+// decodeNDJSON decodes the configs of a body holding one per line, skipping blank lines
+func decodeNDJSON(r *http.Request) ([]contract.Config, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := []contract.Config{}
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(nil, len(body)+1)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var config contract.Config
+		if err := json.Unmarshal(scanner.Bytes(), &config); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		configs = append(configs, config)
+	}
+	return configs, scanner.Err()
+}
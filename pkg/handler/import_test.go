@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/service"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+var exported = []contract.GetConfigResponse{
+	{Namespace: "team-a", Config: contract.Config{Name: "datacenter-1", Metadata: map[string]interface{}{"a": 1.0}}, Version: 3},
+	{Namespace: "team-a", Config: contract.Config{Name: "datacenter-2", Metadata: "b"}, Version: 1},
+}
+
+func TestExportConfigs(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/namespaces/team-a/configs/export", nil)
+	require.NoError(t, err, "Unexpected export request error")
+	req = mux.SetURLVars(req, map[string]string{"ns": "team-a"})
+
+	manager.On("Export", "team-a").Return(exported, nil)
+
+	ExportConfigs(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"), "Incorrect content type")
+	var configs []contract.GetConfigResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &configs), "Export is not a JSON document")
+	assert.Equal(t, exported, configs, "Incorrect configs")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestExportConfigsAsNDJSON(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs/export", nil)
+	require.NoError(t, err, "Unexpected export request error")
+	req.Header.Set("Accept", ndjsonType)
+
+	manager.On("Export", service.DefaultNamespace).Return(exported, nil)
+
+	ExportConfigs(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.Equal(t, ndjsonType, rr.Header().Get("Content-Type"), "Incorrect content type")
+	assert.Equal(t, `{"namespace":"team-a","name":"datacenter-1","metadata":{"a":1},"version":3}`+"\n"+
+		`{"namespace":"team-a","name":"datacenter-2","metadata":"b","version":1}`+"\n", rr.Body.String(),
+		"Incorrect export")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestExportConfigsForInvalidFormat(t *testing.T) {
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs/export?format=yaml", nil)
+	require.NoError(t, err, "Unexpected export request error")
+
+	ExportConfigs(new(mocks.Manager)).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+}
+
+func TestImportConfigs(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/configs/import?mode=replace-all&dryRun=true",
+		strings.NewReader(`[{"name": "datacenter-1", "metadata": "a"}]`))
+	require.NoError(t, err, "Unexpected import request error")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(authorHeader, "alice")
+	resp := &contract.ImportResponse{Mode: contract.ImportReplaceAll, DryRun: true, Created: 1,
+		Results: []contract.ImportResult{{Name: "datacenter-1", Result: contract.ImportCreated}}}
+
+	manager.On("Import", contract.ImportRequest{
+		Namespace: service.DefaultNamespace,
+		Mode:      contract.ImportReplaceAll,
+		DryRun:    true,
+		Author:    "alice",
+		Configs:   []contract.Config{{Name: "datacenter-1", Metadata: "a"}},
+	}).Return(resp, nil)
+
+	ImportConfigs(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `{"mode": "replace-all", "dryRun": true, "created": 1, "updated": 0, "unchanged": 0,
+		"deleted": 0, "invalid": 0, "failed": 0, "results": [{"name": "datacenter-1", "result": "created"}]}`,
+		rr.Body.String(), "Incorrect response")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestImportConfigsAsNDJSON(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/configs/import",
+		strings.NewReader(`{"name": "datacenter-1", "metadata": "a"}`+"\n\n"+`{"name": "datacenter-2"}`+"\n"))
+	require.NoError(t, err, "Unexpected import request error")
+	req.Header.Set("Content-Type", ndjsonType)
+
+	manager.On("Import", contract.ImportRequest{
+		Namespace: service.DefaultNamespace,
+		Configs:   []contract.Config{{Name: "datacenter-1", Metadata: "a"}, {Name: "datacenter-2"}},
+	}).Return(&contract.ImportResponse{}, nil)
+
+	ImportConfigs(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestImportConfigsForMalformedLine(t *testing.T) {
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/configs/import",
+		strings.NewReader(`{"name": "datacenter-1"}`+"\n"+`{"name": `+"\n"))
+	require.NoError(t, err, "Unexpected import request error")
+	req.Header.Set("Content-Type", ndjsonType)
+
+	ImportConfigs(new(mocks.Manager)).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+	assert.Contains(t, rr.Body.String(), "line 2", "Incorrect error message")
+}
+
+func TestImportConfigsForInvalidMode(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/configs/import?mode=overwrite", strings.NewReader(`[]`))
+	require.NoError(t, err, "Unexpected import request error")
+
+	manager.On("Import", mock.Anything).Return(nil, service.ErrInvalidImport)
+
+	ImportConfigs(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+	assert.Contains(t, rr.Body.String(), "INVALID_IMPORT", "Incorrect error code")
+}
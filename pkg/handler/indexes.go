@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/lib"
+	"jsonstore/pkg/service"
+)
+
+func GetIndexes(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, err := mgr.Indexes()
+		if err != nil {
+			writeError(w, err, "Get indexes")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, res)
+	}
+}
+
+// CreateIndex indexes the configs on the path given in the body and replies with 201, or with
+// 409 when the path is already indexed
+func CreateIndex(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request contract.CreateIndexRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			zap.S().Errorf("Malformed request body: %v", err)
+			lib.WriteError(w, httperr.MalformedBody)
+			return
+		}
+
+		if err := mgr.CreateIndex(request); err != nil {
+			writeError(w, err, "Create index %s", request.Path)
+			return
+		}
+
+		w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+url.PathEscape(request.Path))
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+func DeleteIndex(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path, ok := mux.Vars(r)["path"]
+		if !ok {
+			zap.S().Errorf("Index path was not provided")
+			lib.WriteError(w, httperr.InvalidIndex)
+			return
+		}
+
+		if err := mgr.DropIndex(path); err != nil {
+			writeError(w, err, "Delete index %s", path)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
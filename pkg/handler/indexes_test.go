@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/service"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+func TestGetIndexes(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/indexes", nil)
+	require.NoError(t, err, "Unexpected create request error")
+
+	manager.On("Indexes").Return([]contract.GetIndexResponse{{Path: "metadata.region", Values: 2, Entries: 3}}, nil)
+
+	GetIndexes(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `[{"path":"metadata.region","values":2,"entries":3}]`, rr.Body.String(), "Incorrect indexes")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestCreateIndex(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/indexes", strings.NewReader(`{"path":"metadata.region"}`))
+	require.NoError(t, err, "Unexpected create request error")
+
+	manager.On("CreateIndex", contract.CreateIndexRequest{Path: "metadata.region"}).Return(nil)
+
+	CreateIndex(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, "Incorrect http status code")
+	assert.Equal(t, "/indexes/metadata.region", rr.Header().Get("Location"), "Incorrect location")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestCreateIndexForErrors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{service.ErrIndexExists, http.StatusConflict, "INDEX_ALREADY_EXISTS"},
+		{service.ErrInvalidIndex, http.StatusBadRequest, "INVALID_INDEX"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			manager := new(mocks.Manager)
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/indexes", strings.NewReader(`{"path":"metadata.region"}`))
+			require.NoError(t, err, "Unexpected create request error")
+
+			manager.On("CreateIndex", contract.CreateIndexRequest{Path: "metadata.region"}).
+				Return(fmt.Errorf("create index: %w", tt.err))
+
+			CreateIndex(manager).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code, "Incorrect http status code")
+			assertErrorCode(t, rr, tt.code)
+			mock.AssertExpectationsForObjects(t, manager)
+		})
+	}
+}
+
+func TestCreateIndexForMalformedBody(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/indexes", strings.NewReader(`{"path":`))
+	require.NoError(t, err, "Unexpected create request error")
+
+	CreateIndex(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "MALFORMED_BODY")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestDeleteIndex(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodDelete, "/indexes/metadata.region", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"path": "metadata.region"})
+
+	manager.On("DropIndex", "metadata.region").Return(nil)
+
+	DeleteIndex(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestDeleteIndexForMissingIndex(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodDelete, "/indexes/metadata.region", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"path": "metadata.region"})
+
+	manager.On("DropIndex", "metadata.region").Return(fmt.Errorf("drop index: %w", service.ErrIndexNotFound))
+
+	DeleteIndex(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "INDEX_NOT_FOUND")
+	mock.AssertExpectationsForObjects(t, manager)
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/lib"
+)
+
+// Query parameters controlling the pagination, sorting and projection of lists
+const (
+	limitParam  = "limit"
+	cursorParam = "cursor"
+	sortParam   = "sort"
+	fieldsParam = "fields"
+)
+
+var listParams = []string{limitParam, cursorParam, sortParam, fieldsParam}
+
+// listOptions reads the list options from the query parameters. Fields are given as a comma
+// separated list, in one or more fields parameters.
+func listOptions(r *http.Request) (contract.ListOptions, error) {
+	queries := r.URL.Query()
+	opts := contract.ListOptions{Cursor: queries.Get(cursorParam), Sort: queries.Get(sortParam)}
+	if limit := queries.Get(limitParam); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return opts, fmt.Errorf("limit %q is not a positive integer", limit)
+		}
+		opts.Limit = n
+	}
+	for _, fields := range queries[fieldsParam] {
+		for _, field := range strings.Split(fields, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				opts.Fields = append(opts.Fields, field)
+			}
+		}
+	}
+
+	return opts, nil
+}
+
+// writePage replies with the configs of a page and, unless it is the last one, a Link header
+// pointing to the next page
+func writePage(w http.ResponseWriter, r *http.Request, page *contract.ListConfigsResponse) {
+	if page.Next != "" {
+		next := *r.URL
+		queries := next.Query()
+		queries.Set(cursorParam, page.Next)
+		next.RawQuery = queries.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if page.Projected != nil {
+		lib.WriteResponseJSON(w, page.Projected)
+		return
+	}
+	lib.WriteResponseJSON(w, page.Configs)
+}
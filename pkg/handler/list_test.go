@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/service"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+func TestGetAllConfigsPaginated(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs?limit=1&sort=-metadata.region&fields=metadata.region,version", nil)
+	require.NoError(t, err, "Unexpected create request error")
+
+	manager.On("GetAll", service.DefaultNamespace, contract.ListOptions{Limit: 1, Sort: "-metadata.region", Fields: []string{"metadata.region", "version"}}).
+		Return(&contract.ListConfigsResponse{
+			Projected: []map[string]interface{}{
+				{"name": "dc-2", "metadata": map[string]interface{}{"region": "us"}, "version": 3},
+			},
+			Next: "abc",
+		}, nil)
+
+	GetAllConfigs(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `[{"name":"dc-2","metadata":{"region":"us"},"version":3}]`, rr.Body.String(), "Incorrect configs")
+	assert.Equal(t, `</configs?cursor=abc&fields=metadata.region%2Cversion&limit=1&sort=-metadata.region>; rel="next"`,
+		rr.Header().Get("Link"), "Incorrect link to the next page")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestGetAllConfigsForInvalidLimit(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs?limit=0", nil)
+	require.NoError(t, err, "Unexpected create request error")
+
+	GetAllConfigs(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "INVALID_LIST_OPTIONS")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestSearchConfigsPaginated(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs/search?metadata.region=eu&limit=2&cursor=abc", nil)
+	require.NoError(t, err, "Unexpected create request error")
+
+	manager.On("SearchValue", service.DefaultNamespace, "metadata.region", "eu", contract.ListOptions{Limit: 2, Cursor: "abc"}).
+		Return(&contract.ListConfigsResponse{Configs: []contract.GetConfigResponse{}}, nil)
+
+	SearchConfigs(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `[]`, rr.Body.String(), "Incorrect configs")
+	assert.Empty(t, rr.Header().Get("Link"), "Unexpected link to the next page")
+	mock.AssertExpectationsForObjects(t, manager)
+}
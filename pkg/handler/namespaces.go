@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/lib"
+	"jsonstore/pkg/service"
+)
+
+// namespace returns the namespace named in the path of a request, which is the default one for
+// the routes under /configs
+func namespace(r *http.Request) string {
+	if ns, ok := mux.Vars(r)["ns"]; ok {
+		return ns
+	}
+	return service.DefaultNamespace
+}
+
+func GetNamespaces(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, err := mgr.Namespaces()
+		if err != nil {
+			writeError(w, err, "Get namespaces")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, res)
+	}
+}
+
+func GetNamespace(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ns := namespace(r)
+		res, err := mgr.Namespace(ns)
+		if err != nil {
+			writeError(w, err, "Get namespace %s", ns)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, res)
+	}
+}
+
+// CreateNamespace creates an empty namespace and replies with 201 and its location, or with 409
+// when it already exists
+func CreateNamespace(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request contract.CreateNamespaceRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			zap.S().Errorf("Malformed request body: %v", err)
+			lib.WriteError(w, httperr.MalformedBody)
+			return
+		}
+
+		res, err := mgr.CreateNamespace(request)
+		if err != nil {
+			writeError(w, err, "Create namespace")
+			return
+		}
+
+		w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+url.PathEscape(res.Name))
+		w.WriteHeader(http.StatusCreated)
+		lib.WriteResponseJSON(w, res)
+	}
+}
+
+// DeleteNamespace removes a namespace, along with its configs when the cascade query parameter
+// is true. Without it, a namespace holding configs is answered with 409.
+func DeleteNamespace(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ns := namespace(r)
+		var cascade bool
+		if value := r.URL.Query().Get("cascade"); value != "" {
+			var err error
+			if cascade, err = strconv.ParseBool(value); err != nil {
+				zap.S().Errorf("Delete namespace %s: invalid cascade %q", ns, value)
+				lib.WriteError(w, httperr.InvalidNamespace.WithMessage("Delete namespace %s: cascade %q is not a boolean",
+					ns, value))
+				return
+			}
+		}
+
+		err := mgr.DeleteNamespace(contract.DeleteNamespaceRequest{
+			Name:    ns,
+			Cascade: cascade,
+			Author:  author(r),
+		})
+		if err != nil {
+			writeError(w, err, "Delete namespace %s", ns)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/service"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+func TestGetNamespaces(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/namespaces", nil)
+	require.NoError(t, err, "Unexpected create request error")
+
+	manager.On("Namespaces").Return([]contract.GetNamespaceResponse{{Name: "default"}, {Name: "team-a"}}, nil)
+
+	GetNamespaces(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `[{"name":"default"},{"name":"team-a"}]`, rr.Body.String(), "Incorrect namespaces")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestGetNamespaceForMissingNamespace(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/namespaces/team-a", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"ns": "team-a"})
+
+	manager.On("Namespace", "team-a").Return(nil, fmt.Errorf("namespace: %w", service.ErrNamespaceNotFound))
+
+	GetNamespace(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "NAMESPACE_NOT_FOUND")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestCreateNamespace(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/namespaces", strings.NewReader(`{"name":"team-a"}`))
+	require.NoError(t, err, "Unexpected create request error")
+
+	manager.On("CreateNamespace", contract.CreateNamespaceRequest{Name: "team-a"}).
+		Return(&contract.GetNamespaceResponse{Name: "team-a"}, nil)
+
+	CreateNamespace(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, "Incorrect http status code")
+	assert.Equal(t, "/namespaces/team-a", rr.Header().Get("Location"), "Incorrect location")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestCreateNamespaceForErrors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{service.ErrNamespaceExists, http.StatusConflict, "NAMESPACE_ALREADY_EXISTS"},
+		{service.ErrInvalidNamespace, http.StatusBadRequest, "INVALID_NAMESPACE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			manager := new(mocks.Manager)
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/namespaces", strings.NewReader(`{"name":"team-a"}`))
+			require.NoError(t, err, "Unexpected create request error")
+
+			manager.On("CreateNamespace", contract.CreateNamespaceRequest{Name: "team-a"}).
+				Return(nil, fmt.Errorf("create namespace: %w", tt.err))
+
+			CreateNamespace(manager).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code, "Incorrect http status code")
+			assertErrorCode(t, rr, tt.code)
+			mock.AssertExpectationsForObjects(t, manager)
+		})
+	}
+}
+
+func TestDeleteNamespace(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodDelete, "/namespaces/team-a?cascade=true", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req.Header.Set("X-Author", "alice")
+	req = mux.SetURLVars(req, map[string]string{"ns": "team-a"})
+
+	manager.On("DeleteNamespace", contract.DeleteNamespaceRequest{Name: "team-a", Cascade: true, Author: "alice"}).
+		Return(nil)
+
+	DeleteNamespace(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestDeleteNamespaceForNotEmptyNamespace(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodDelete, "/namespaces/team-a", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"ns": "team-a"})
+
+	manager.On("DeleteNamespace", contract.DeleteNamespaceRequest{Name: "team-a"}).
+		Return(fmt.Errorf("delete namespace: %w", service.ErrNamespaceNotEmpty))
+
+	DeleteNamespace(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "NAMESPACE_NOT_EMPTY")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestDeleteNamespaceForInvalidCascade(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodDelete, "/namespaces/team-a?cascade=maybe", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"ns": "team-a"})
+
+	DeleteNamespace(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "INVALID_NAMESPACE")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestGetConfigInNamespace(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/namespaces/team-a/configs/datacenter-1", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"ns": "team-a", "name": "datacenter-1"})
+
+	manager.On("Get", "team-a", "datacenter-1").
+		Return(&contract.GetConfigResponse{Namespace: "team-a", Config: contract.Config{Name: "datacenter-1"}, Version: 1}, nil)
+
+	GetConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `{"namespace":"team-a","name":"datacenter-1","metadata":null,"version":1}`, rr.Body.String(),
+		"Incorrect config")
+	mock.AssertExpectationsForObjects(t, manager)
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/lib"
+	"jsonstore/pkg/service"
+)
+
+func GetSchemas(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, err := mgr.Schemas()
+		if err != nil {
+			writeError(w, err, "Get schemas")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, res)
+	}
+}
+
+func GetSchema(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+		res, err := mgr.Schema(name)
+		if err != nil {
+			writeError(w, err, "Get schema %s", name)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, res)
+	}
+}
+
+// PutSchema registers or replaces the schema named in the path. The name in the body may be
+// omitted, but must match the path when present.
+func PutSchema(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+
+		var request contract.PutSchemaRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			zap.S().Errorf("Malformed request body: %v", err)
+			lib.WriteError(w, httperr.MalformedBody)
+			return
+		}
+		if request.Name == "" {
+			request.Name = name
+		}
+		if request.Name != name {
+			zap.S().Errorf("Put schema %s: body name %s does not match", name, request.Name)
+			lib.WriteError(w, httperr.NameMismatch.WithMessage("Put schema %s: name %q in body does not match the path",
+				name, request.Name))
+			return
+		}
+
+		res, err := mgr.PutSchema(request)
+		if err != nil {
+			writeError(w, err, "Put schema %s", name)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, res)
+	}
+}
+
+func DeleteSchema(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+		if err := mgr.DeleteSchema(name); err != nil {
+			writeError(w, err, "Delete schema %s", name)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/schema"
+	"jsonstore/pkg/service"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+func TestPutSchema(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPut, "/schemas/datacenter",
+		strings.NewReader(`{"pattern":"datacenter-*","schema":{"type":"object"}}`))
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter"})
+	timestamp := time.Date(2021, time.September, 1, 10, 0, 0, 0, time.UTC)
+
+	manager.On("PutSchema", contract.PutSchemaRequest{
+		Name:    "datacenter",
+		Pattern: "datacenter-*",
+		Schema:  json.RawMessage(`{"type":"object"}`),
+	}).Return(&contract.GetSchemaResponse{
+		Name:      "datacenter",
+		Pattern:   "datacenter-*",
+		Schema:    json.RawMessage(`{"type":"object"}`),
+		Version:   1,
+		CreatedAt: timestamp,
+		UpdatedAt: timestamp,
+	}, nil)
+
+	PutSchema(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `{"name":"datacenter","pattern":"datacenter-*","schema":{"type":"object"},"version":1,`+
+		`"createdAt":"2021-09-01T10:00:00Z","updatedAt":"2021-09-01T10:00:00Z"}`, rr.Body.String(), "Incorrect schema")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestPutSchemaForNameMismatch(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPut, "/schemas/datacenter", strings.NewReader(`{"name":"cluster","schema":true}`))
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter"})
+
+	PutSchema(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "NAME_MISMATCH")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestPutSchemaForInvalidSchema(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPut, "/schemas/datacenter", strings.NewReader(`{"schema":{"type":1}}`))
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter"})
+
+	manager.On("PutSchema", mock.Anything).Return(nil, fmt.Errorf("put schema: %w: /type: must be an array of strings",
+		service.ErrInvalidSchema))
+
+	PutSchema(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "INVALID_SCHEMA")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestUpdateConfigForSchemaViolation(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPut, "/configs/datacenter-1", strings.NewReader(`{"metadata":{"replicas":"3"}}`))
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	manager.On("Upsert", mock.Anything, contract.Precondition{}).Return(nil, fmt.Errorf("insert: %w", &service.ValidationError{
+		Violations: []schema.Violation{
+			{Path: "/metadata/region", Message: "property is required"},
+			{Path: "/metadata/replicas", Message: "expected integer, got string"},
+		},
+	}))
+
+	UpdateConfig(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "SCHEMA_VIOLATION")
+	var body httperr.Error
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body), "Unexpected json decode error")
+	assert.Equal(t, []httperr.Violation{
+		{Path: "/metadata/region", Message: "property is required"},
+		{Path: "/metadata/replicas", Message: "expected integer, got string"},
+	}, body.Violations, "Incorrect violations")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestDeleteSchemaForSchemaInUse(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodDelete, "/schemas/datacenter", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter"})
+
+	manager.On("DeleteSchema", "datacenter").Return(fmt.Errorf("delete schema: %w", service.ErrSchemaInUse))
+
+	DeleteSchema(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "SCHEMA_IN_USE")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestGetSchemaForMissingSchema(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/schemas/datacenter", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter"})
+
+	manager.On("Schema", "datacenter").Return(nil, fmt.Errorf("schema: %w", service.ErrSchemaNotFound))
+
+	GetSchema(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "SCHEMA_NOT_FOUND")
+	mock.AssertExpectationsForObjects(t, manager)
+}
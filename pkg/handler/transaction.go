@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/lib"
+	"jsonstore/pkg/service"
+)
+
+// Transact makes the operations of the body on configs of the namespace all or nothing. A failing
+// condition aborts the transaction with 412, naming the operation it belongs to.
+func Transact(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request contract.TransactionRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			zap.S().Errorf("Malformed request body: %v", err)
+			lib.WriteError(w, httperr.MalformedBody)
+			return
+		}
+
+		request.Namespace, request.Author = namespace(r), author(r)
+		res, err := mgr.Transact(request)
+		if err != nil {
+			writeError(w, err, "Transact configs")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, res)
+	}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/service"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+func TestTransact(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/namespaces/team-a/transactions", strings.NewReader(`{"operations": [
+		{"op": "put", "name": "datacenter-1", "metadata": {"region": "us"}, "if": [{"version": 3}]},
+		{"op": "check", "name": "datacenter-1-limits", "if": [{"path": "/metadata/cpu", "value": "300m"}]}]}`))
+	require.NoError(t, err, "Unexpected transaction request error")
+	req = mux.SetURLVars(req, map[string]string{"ns": "team-a"})
+	req.Header.Set(authorHeader, "alice")
+
+	manager.On("Transact", contract.TransactionRequest{
+		Namespace: "team-a",
+		Author:    "alice",
+		Operations: []contract.TransactionOperation{
+			{Op: contract.TransactionPut, Name: "datacenter-1", Metadata: map[string]interface{}{"region": "us"},
+				If: []contract.TransactionCondition{{Version: 3}}},
+			{Op: contract.TransactionCheck, Name: "datacenter-1-limits",
+				If: []contract.TransactionCondition{{Path: "/metadata/cpu", Value: json.RawMessage(`"300m"`)}}},
+		},
+	}).Return(&contract.TransactionResponse{Results: []contract.TransactionResult{
+		{Op: contract.TransactionPut, Name: "datacenter-1", Version: 4},
+		{Op: contract.TransactionCheck, Name: "datacenter-1-limits", Version: 2},
+	}}, nil)
+
+	Transact(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `{"results": [{"op": "put", "name": "datacenter-1", "version": 4},
+		{"op": "check", "name": "datacenter-1-limits", "version": 2}]}`, rr.Body.String(), "Incorrect response")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestTransactForErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		status int
+		code   string
+	}{
+		{"malformed body", `{"operations": {}}`, nil, http.StatusBadRequest, "MALFORMED_BODY"},
+		{"invalid operation", `{"operations": []}`, service.ErrInvalidTransaction, http.StatusBadRequest, "INVALID_TRANSACTION"},
+		{"failed condition", `{"operations": [{"op": "check", "name": "datacenter-1", "if": [{"version": 2}]}]}`,
+			fmt.Errorf("transaction: operation 0: %w", service.ErrPreconditionFailed), http.StatusPreconditionFailed,
+			"PRECONDITION_FAILED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := new(mocks.Manager)
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/transactions", strings.NewReader(tt.body))
+			require.NoError(t, err, "Unexpected transaction request error")
+
+			manager.On("Transact", mock.Anything).Return(nil, tt.err)
+
+			Transact(manager).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code, "Incorrect http status code")
+			assert.Contains(t, rr.Body.String(), tt.code, "Incorrect error code")
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/lib"
+	"jsonstore/pkg/service"
+)
+
+const (
+	eventStreamType = "text/event-stream"
+	// Header of the id of the last event received, sent by event stream clients when reconnecting
+	lastEventIDHeader = "Last-Event-ID"
+
+	// How long a long poll waits for changes, unless given a timeout
+	defaultWatchTimeout = 30 * time.Second
+	maxWatchTimeout     = 5 * time.Minute
+)
+
+// How often an idle event stream is written to, so that proxies keep the connection open
+var heartbeatInterval = 15 * time.Second
+
+// WatchConfig reports the changes to a config, as WatchConfigs does for a prefix
+func WatchConfig(mgr service.Manager, stop <-chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, ok := mux.Vars(r)["name"]
+		if !ok {
+			zap.S().Errorf("Config name was not provided")
+			lib.WriteError(w, httperr.MissingConfigName)
+			return
+		}
+
+		watch(w, r, mgr, stop, contract.WatchRequest{Namespace: namespace(r), Name: name}, "Watch config "+name)
+	}
+}
+
+// WatchConfigs reports the changes to the configs whose name starts with the prefix parameter,
+// after the revision given by the revision parameter or the Last-Event-ID header. Clients
+// accepting text/event-stream are streamed the changes as Server-Sent Events. Others are
+// long-polled: the reply lists the changes as soon as there are any, or none once the timeout
+// parameter elapses, along with the revision to watch from next. Watches end once stop is closed,
+// as the server shuts down, since they would otherwise hold it up until they time out.
+func WatchConfigs(mgr service.Manager, stop <-chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		prefix := r.URL.Query().Get("prefix")
+		watch(w, r, mgr, stop, contract.WatchRequest{Namespace: namespace(r), Prefix: prefix}, "Watch configs")
+	}
+}
+
+func watch(w http.ResponseWriter, r *http.Request, mgr service.Manager, stop <-chan struct{}, req contract.WatchRequest,
+	op string) {
+	value := r.URL.Query().Get("revision")
+	if value == "" {
+		value = r.Header.Get(lastEventIDHeader)
+	}
+	if value != "" {
+		revision, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			zap.S().Errorf("%s: invalid revision %q", op, value)
+			lib.WriteError(w, httperr.InvalidWatch.WithMessage("%s: revision %q is not an integer", op, value))
+			return
+		}
+		req.Revision = revision
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), eventStreamType) {
+		streamChanges(w, r, mgr, stop, req, op)
+		return
+	}
+	pollChanges(w, r, mgr, stop, req, op)
+}
+
+// pollChanges replies once there are changes matching the request, the timeout elapses or the
+// watch is stopped
+func pollChanges(w http.ResponseWriter, r *http.Request, mgr service.Manager, stop <-chan struct{},
+	req contract.WatchRequest, op string) {
+	timeout := defaultWatchTimeout
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		var err error
+		if timeout, err = time.ParseDuration(value); err != nil || timeout < 0 || timeout > maxWatchTimeout {
+			zap.S().Errorf("%s: invalid timeout %q", op, value)
+			lib.WriteError(w, httperr.InvalidWatch.WithMessage("%s: timeout %q is not a duration of at most %s",
+				op, value, maxWatchTimeout))
+			return
+		}
+	}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		res, err := mgr.Watch(req)
+		if err != nil {
+			writeError(w, err, op)
+			return
+		}
+		if len(res.Events) > 0 {
+			w.WriteHeader(http.StatusOK)
+			lib.WriteResponseJSON(w, res)
+			return
+		}
+
+		req.Revision = res.Revision
+		select {
+		case <-res.Changed:
+		case <-deadline.C:
+			w.WriteHeader(http.StatusOK)
+			lib.WriteResponseJSON(w, res)
+			return
+		case <-stop:
+			w.WriteHeader(http.StatusOK)
+			lib.WriteResponseJSON(w, res)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// streamChanges sends the changes matching the request as they are made, until the client goes
+// away or the watch is stopped. Each event has its revision as id, for clients to resume from after reconnecting.
+// Heartbeats carry the current revision as id, so that clients watching rarely changed configs
+// do not fall behind the changes kept by the server.
+func streamChanges(w http.ResponseWriter, r *http.Request, mgr service.Manager, stop <-chan struct{},
+	req contract.WatchRequest, op string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		zap.S().Errorf("%s: response writer does not support streaming", op)
+		lib.WriteError(w, httperr.InternalError)
+		return
+	}
+
+	// Watch before replying, so that an invalid request is answered with its status
+	res, err := mgr.Watch(req)
+	if err != nil {
+		writeError(w, err, op)
+		return
+	}
+	w.Header().Set("Content-Type", eventStreamType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		for _, event := range res.Events {
+			if err := writeEvent(w, event.Revision, event.Type, event); err != nil {
+				zap.S().Errorf("%s: write event: %v", op, err)
+				return
+			}
+		}
+		flusher.Flush()
+		req.Revision = res.Revision
+
+	wait:
+		for {
+			select {
+			case <-res.Changed:
+				break wait
+			case <-heartbeat.C:
+				if _, err := fmt.Fprintf(w, ": heartbeat\nid: %d\n\n", req.Revision); err != nil {
+					zap.S().Errorf("%s: write heartbeat: %v", op, err)
+					return
+				}
+				flusher.Flush()
+			case <-stop:
+				return
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		if res, err = mgr.Watch(req); err != nil {
+			zap.S().Errorf("%s: %v", op, err)
+			httpErr := toHTTPError(err).WithMessage("%s: %v", op, err)
+			if err := writeEvent(w, req.Revision, "error", httpErr); err != nil {
+				zap.S().Errorf("%s: write event: %v", op, err)
+			}
+			flusher.Flush()
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, id int64, eventType string, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, eventType, encoded)
+	return err
+}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/service"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+var (
+	watchTime = time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	putEvent  = contract.Event{Revision: 12, Type: contract.EventPut, Namespace: service.DefaultNamespace,
+		Name: "datacenter-1", Version: 2, Timestamp: watchTime, Config: &contract.GetConfigResponse{
+			Config: contract.Config{Name: "datacenter-1", Metadata: map[string]interface{}{"region": "eu"}}, Version: 2,
+		}}
+	deleteEvent = contract.Event{Revision: 13, Type: contract.EventDelete, Namespace: service.DefaultNamespace,
+		Name: "datacenter-2", Version: 3, Timestamp: watchTime}
+)
+
+func closedChannel() <-chan struct{} {
+	changed := make(chan struct{})
+	close(changed)
+	return changed
+}
+
+func TestWatchConfigsLongPoll(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs/watch?prefix=datacenter-&revision=10", nil)
+	require.NoError(t, err, "Unexpected create request error")
+
+	manager.On("Watch", contract.WatchRequest{Namespace: service.DefaultNamespace, Prefix: "datacenter-", Revision: 10}).
+		Return(&contract.WatchResponse{Revision: 11, Events: []contract.Event{}, Changed: closedChannel()}, nil).Once()
+	manager.On("Watch", contract.WatchRequest{Namespace: service.DefaultNamespace, Prefix: "datacenter-", Revision: 11}).
+		Return(&contract.WatchResponse{Revision: 13, Events: []contract.Event{putEvent, deleteEvent}}, nil).Once()
+
+	WatchConfigs(manager, nil).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `{"revision":13,"events":[
+		{"revision":12,"type":"put","namespace":"default","name":"datacenter-1","version":2,
+			"timestamp":"2021-03-04T05:06:07Z","config":{"name":"datacenter-1","metadata":{"region":"eu"},"version":2}},
+		{"revision":13,"type":"delete","namespace":"default","name":"datacenter-2","version":3,
+			"timestamp":"2021-03-04T05:06:07Z"}]}`, rr.Body.String(), "Incorrect watch response")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestWatchConfigLongPollForTimeout(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs/datacenter-1/watch?timeout=10ms", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+
+	manager.On("Watch", contract.WatchRequest{Namespace: service.DefaultNamespace, Name: "datacenter-1"}).
+		Return(&contract.WatchResponse{Revision: 11, Events: []contract.Event{}, Changed: make(chan struct{})}, nil).Once()
+
+	WatchConfig(manager, nil).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `{"revision":11,"events":[]}`, rr.Body.String(), "Incorrect watch response")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestWatchConfigsLongPollForStop(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs/watch?timeout=1m", nil)
+	require.NoError(t, err, "Unexpected create request error")
+
+	manager.On("Watch", contract.WatchRequest{Namespace: service.DefaultNamespace}).
+		Return(&contract.WatchResponse{Revision: 11, Events: []contract.Event{}, Changed: make(chan struct{})}, nil).Once()
+
+	WatchConfigs(manager, closedChannel()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `{"revision":11,"events":[]}`, rr.Body.String(), "Incorrect watch response")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestWatchConfigsForInvalidRequest(t *testing.T) {
+	tests := []struct {
+		target string
+		header string
+	}{
+		{target: "/configs/watch?revision=latest"},
+		{target: "/configs/watch", header: "abc"},
+		{target: "/configs/watch?timeout=10"},
+		{target: "/configs/watch?timeout=1h"},
+	}
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			manager := new(mocks.Manager)
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, test.target, nil)
+			require.NoError(t, err, "Unexpected create request error")
+			req.Header.Set(lastEventIDHeader, test.header)
+
+			WatchConfigs(manager, nil).ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, "Incorrect http status code")
+			assertErrorCode(t, rr, "INVALID_WATCH")
+			mock.AssertExpectationsForObjects(t, manager)
+		})
+	}
+}
+
+func TestWatchConfigsForExpiredRevision(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs/watch", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req.Header.Set("Accept", eventStreamType)
+	req.Header.Set(lastEventIDHeader, "3")
+
+	manager.On("Watch", contract.WatchRequest{Namespace: service.DefaultNamespace, Revision: 3}).
+		Return(nil, fmt.Errorf("watch: %w: revision 3", service.ErrWatchExpired)).Once()
+
+	WatchConfigs(manager, nil).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusGone, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "WATCH_EXPIRED")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestWatchConfigsEventStream(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/namespaces/team-a/configs/watch", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"ns": "team-a"})
+	req.Header.Set("Accept", eventStreamType)
+	req.Header.Set(lastEventIDHeader, "11")
+
+	manager.On("Watch", contract.WatchRequest{Namespace: "team-a", Revision: 11}).
+		Return(&contract.WatchResponse{Revision: 12, Events: []contract.Event{putEvent}, Changed: closedChannel()}, nil).Once()
+	manager.On("Watch", contract.WatchRequest{Namespace: "team-a", Revision: 12}).
+		Return(&contract.WatchResponse{Revision: 13, Events: []contract.Event{deleteEvent}, Changed: make(chan struct{})}, nil).
+		Run(func(mock.Arguments) { cancel() }).Once()
+
+	WatchConfigs(manager, nil).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.Equal(t, eventStreamType, rr.Header().Get("Content-Type"), "Incorrect content type")
+	assert.Equal(t, `id: 12
+event: put
+data: {"revision":12,"type":"put","namespace":"default","name":"datacenter-1","version":2,`+
+		`"timestamp":"2021-03-04T05:06:07Z","config":{"name":"datacenter-1","metadata":{"region":"eu"},"version":2}}
+
+id: 13
+event: delete
+data: {"revision":13,"type":"delete","namespace":"default","name":"datacenter-2","version":3,`+
+		`"timestamp":"2021-03-04T05:06:07Z"}
+
+`, rr.Body.String(), "Incorrect event stream")
+	assert.True(t, rr.Flushed, "Events were not flushed")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestWatchConfigEventStreamForHeartbeat(t *testing.T) {
+	defer func(interval time.Duration) { heartbeatInterval = interval }(heartbeatInterval)
+	heartbeatInterval = 5 * time.Millisecond
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/configs/datacenter-1/watch", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"name": "datacenter-1"})
+	req.Header.Set("Accept", eventStreamType)
+
+	manager.On("Watch", contract.WatchRequest{Namespace: service.DefaultNamespace, Name: "datacenter-1"}).
+		Return(&contract.WatchResponse{Revision: 20, Events: []contract.Event{}, Changed: make(chan struct{})}, nil).Once()
+
+	WatchConfig(manager, nil).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.Contains(t, rr.Body.String(), ": heartbeat\nid: 20\n\n", "Missing heartbeat")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestWatchConfigsEventStreamForStop(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs/watch", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req.Header.Set("Accept", eventStreamType)
+
+	manager.On("Watch", contract.WatchRequest{Namespace: service.DefaultNamespace}).
+		Return(&contract.WatchResponse{Revision: 12, Events: []contract.Event{putEvent}, Changed: make(chan struct{})}, nil).Once()
+
+	WatchConfigs(manager, closedChannel()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.Contains(t, rr.Body.String(), "id: 12\nevent: put\n", "Events before the stop were not sent")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestWatchConfigsEventStreamForExpiredRevision(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/configs/watch", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req.Header.Set("Accept", eventStreamType)
+
+	manager.On("Watch", contract.WatchRequest{Namespace: service.DefaultNamespace}).
+		Return(&contract.WatchResponse{Revision: 20, Events: []contract.Event{}, Changed: closedChannel()}, nil).Once()
+	manager.On("Watch", contract.WatchRequest{Namespace: service.DefaultNamespace, Revision: 20}).
+		Return(nil, fmt.Errorf("watch: %w: revision 20", service.ErrWatchExpired)).Once()
+
+	WatchConfigs(manager, nil).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.Equal(t, `id: 20
+event: error
+data: {"httpStatus":410,"code":"WATCH_EXPIRED","message":"Watch configs: watch: watch expired: revision 20"}
+
+`, rr.Body.String(), "Incorrect event stream")
+	mock.AssertExpectationsForObjects(t, manager)
+}
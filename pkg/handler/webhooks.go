@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/lib"
+	"jsonstore/pkg/service"
+)
+
+func GetWebhooks(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, err := mgr.Webhooks()
+		if err != nil {
+			writeError(w, err, "Get webhooks")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, res)
+	}
+}
+
+// GetWebhook returns a webhook along with the outcome of the deliveries to it
+func GetWebhook(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		res, err := mgr.Webhook(id)
+		if err != nil {
+			writeError(w, err, "Get webhook %s", id)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		lib.WriteResponseJSON(w, res)
+	}
+}
+
+// CreateWebhook subscribes a URL to config changes and replies with 201 and the location of the
+// webhook, whose id is generated
+func CreateWebhook(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request contract.CreateWebhookRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			zap.S().Errorf("Malformed request body: %v", err)
+			lib.WriteError(w, httperr.MalformedBody)
+			return
+		}
+
+		res, err := mgr.CreateWebhook(request)
+		if err != nil {
+			writeError(w, err, "Create webhook")
+			return
+		}
+
+		w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+url.PathEscape(res.ID))
+		w.WriteHeader(http.StatusCreated)
+		lib.WriteResponseJSON(w, res)
+	}
+}
+
+func DeleteWebhook(mgr service.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		if err := mgr.DeleteWebhook(id); err != nil {
+			writeError(w, err, "Delete webhook %s", id)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
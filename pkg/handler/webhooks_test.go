@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/service"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+var webhookResp = contract.GetWebhookResponse{
+	ID:         "b1946ac92492d2347c6235b4d2611184",
+	Namespace:  service.DefaultNamespace,
+	URL:        "https://example.com/hooks",
+	Prefix:     "datacenter-",
+	Deliveries: contract.WebhookDeliveries{Delivered: 3, LastStatus: http.StatusOK},
+}
+
+func TestGetWebhooks(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/webhooks", nil)
+	require.NoError(t, err, "Unexpected create request error")
+
+	manager.On("Webhooks").Return([]contract.GetWebhookResponse{webhookResp}, nil)
+
+	GetWebhooks(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `[{"id":"b1946ac92492d2347c6235b4d2611184","namespace":"default","url":"https://example.com/hooks",
+		"prefix":"datacenter-","deliveries":{"delivered":3,"failed":0,"lastStatus":200}}]`, rr.Body.String(),
+		"Incorrect webhooks")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestGetWebhookForMissingWebhook(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/webhooks/abc", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"id": "abc"})
+
+	manager.On("Webhook", "abc").Return(nil, fmt.Errorf("webhook: %w", service.ErrWebhookNotFound))
+
+	GetWebhook(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code, "Incorrect http status code")
+	assertErrorCode(t, rr, "WEBHOOK_NOT_FOUND")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestCreateWebhook(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/webhooks",
+		strings.NewReader(`{"url":"https://example.com/hooks","prefix":"datacenter-","secret":"s3cret"}`))
+	require.NoError(t, err, "Unexpected create request error")
+
+	manager.On("CreateWebhook", contract.CreateWebhookRequest{URL: "https://example.com/hooks", Prefix: "datacenter-",
+		Secret: "s3cret"}).Return(&webhookResp, nil)
+
+	CreateWebhook(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, "Incorrect http status code")
+	assert.Equal(t, "/webhooks/b1946ac92492d2347c6235b4d2611184", rr.Header().Get("Location"), "Incorrect location")
+	assert.NotContains(t, rr.Body.String(), "s3cret", "Secret was returned")
+	mock.AssertExpectationsForObjects(t, manager)
+}
+
+func TestCreateWebhookForErrors(t *testing.T) {
+	tests := []struct {
+		body   string
+		err    error
+		status int
+		code   string
+	}{
+		{`{"url":`, nil, http.StatusBadRequest, "MALFORMED_BODY"},
+		{`{"url":"/hooks"}`, service.ErrInvalidWebhook, http.StatusBadRequest, "INVALID_WEBHOOK"},
+		{`{"url":"https://example.com","query":"name ="}`, service.ErrInvalidQuery, http.StatusBadRequest, "INVALID_QUERY"},
+	}
+	for _, test := range tests {
+		t.Run(test.code, func(t *testing.T) {
+			manager := new(mocks.Manager)
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(test.body))
+			require.NoError(t, err, "Unexpected create request error")
+
+			if test.err != nil {
+				manager.On("CreateWebhook", mock.Anything).Return(nil, fmt.Errorf("create webhook: %w", test.err))
+			}
+
+			CreateWebhook(manager).ServeHTTP(rr, req)
+
+			assert.Equal(t, test.status, rr.Code, "Incorrect http status code")
+			assertErrorCode(t, rr, test.code)
+			mock.AssertExpectationsForObjects(t, manager)
+		})
+	}
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	manager := new(mocks.Manager)
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodDelete, "/webhooks/abc", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = mux.SetURLVars(req, map[string]string{"id": "abc"})
+
+	manager.On("DeleteWebhook", "abc").Return(nil)
+
+	DeleteWebhook(manager).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	mock.AssertExpectationsForObjects(t, manager)
+}
@@ -1,5 +1,10 @@
 package httperr
 
+import (
+	"fmt"
+	"net/http"
+)
+
 const (
 	StatusUnknown = 520
 )
@@ -8,11 +13,71 @@ type Error struct {
 	HTTPStatus int    `json:"httpStatus"`
 	Code       string `json:"code"`
 	Message    string `json:"message"`
+	// Every location of the request payload that failed validation, if any
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// Violation locates a value of the request payload that failed validation by its JSON Pointer
+type Violation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
 }
 
 func (err Error) Error() string {
 	return err.Message
 }
 
-//Future extension: The contract maybe extended to have more specific error objects for server errors
+// WithMessage returns a copy of the error with a request specific message
+func (err Error) WithMessage(format string, args ...interface{}) Error {
+	err.Message = fmt.Sprintf(format, args...)
+	return err
+}
+
+// Future extension: The contract maybe extended to have more specific error objects for server errors
 var UnknownError = Error{HTTPStatus: StatusUnknown, Code: "520", Message: "UNKNOWN_ERROR"}
+
+// Catalog of the errors returned by the API. The code is stable and meant for clients to act
+// upon, whereas the message is human-readable and may change between releases.
+var (
+	ConfigNotFound     = Error{HTTPStatus: http.StatusNotFound, Code: "CONFIG_NOT_FOUND", Message: "Config not found"}
+	VersionConflict    = Error{HTTPStatus: http.StatusConflict, Code: "VERSION_CONFLICT", Message: "Config was modified concurrently"}
+	RevisionNotFound   = Error{HTTPStatus: http.StatusNotFound, Code: "REVISION_NOT_FOUND", Message: "Config revision not found"}
+	InvalidVersion     = Error{HTTPStatus: http.StatusBadRequest, Code: "INVALID_VERSION", Message: "Invalid config version"}
+	InvalidRollback    = Error{HTTPStatus: http.StatusUnprocessableEntity, Code: "INVALID_ROLLBACK", Message: "Config cannot be rolled back to the revision"}
+	IndexNotFound      = Error{HTTPStatus: http.StatusNotFound, Code: "INDEX_NOT_FOUND", Message: "Index not found"}
+	IndexExists        = Error{HTTPStatus: http.StatusConflict, Code: "INDEX_ALREADY_EXISTS", Message: "Index already exists"}
+	InvalidIndex       = Error{HTTPStatus: http.StatusBadRequest, Code: "INVALID_INDEX", Message: "Invalid index path"}
+	PreconditionFailed = Error{HTTPStatus: http.StatusPreconditionFailed, Code: "PRECONDITION_FAILED", Message: "Config version does not match the precondition"}
+	SchemaNotFound     = Error{HTTPStatus: http.StatusNotFound, Code: "SCHEMA_NOT_FOUND", Message: "Schema not found"}
+	SchemaInUse        = Error{HTTPStatus: http.StatusConflict, Code: "SCHEMA_IN_USE", Message: "Schema is used by configs"}
+	InvalidSchema      = Error{HTTPStatus: http.StatusBadRequest, Code: "INVALID_SCHEMA", Message: "Invalid JSON Schema"}
+	SchemaViolation    = Error{HTTPStatus: http.StatusUnprocessableEntity, Code: "SCHEMA_VIOLATION", Message: "Config does not match its schema"}
+	NamespaceNotFound  = Error{HTTPStatus: http.StatusNotFound, Code: "NAMESPACE_NOT_FOUND", Message: "Namespace not found"}
+	NamespaceExists    = Error{HTTPStatus: http.StatusConflict, Code: "NAMESPACE_ALREADY_EXISTS", Message: "Namespace already exists"}
+	NamespaceNotEmpty  = Error{HTTPStatus: http.StatusConflict, Code: "NAMESPACE_NOT_EMPTY", Message: "Namespace still holds configs"}
+	InvalidNamespace   = Error{HTTPStatus: http.StatusBadRequest, Code: "INVALID_NAMESPACE", Message: "Invalid namespace"}
+	WatchExpired       = Error{HTTPStatus: http.StatusGone, Code: "WATCH_EXPIRED", Message: "Changes since the revision are no longer available"}
+	InvalidWatch       = Error{HTTPStatus: http.StatusBadRequest, Code: "INVALID_WATCH", Message: "Invalid watch revision or timeout"}
+	WebhookNotFound    = Error{HTTPStatus: http.StatusNotFound, Code: "WEBHOOK_NOT_FOUND", Message: "Webhook not found"}
+	InvalidWebhook     = Error{HTTPStatus: http.StatusBadRequest, Code: "INVALID_WEBHOOK", Message: "Invalid webhook"}
+	ConfigExists       = Error{HTTPStatus: http.StatusConflict, Code: "CONFIG_ALREADY_EXISTS", Message: "Config already exists"}
+	NameMismatch       = Error{HTTPStatus: http.StatusBadRequest, Code: "NAME_MISMATCH", Message: "Config name does not match the path"}
+	InvalidQuery       = Error{HTTPStatus: http.StatusBadRequest, Code: "INVALID_QUERY", Message: "Invalid query expression"}
+	InvalidListOptions = Error{HTTPStatus: http.StatusBadRequest, Code: "INVALID_LIST_OPTIONS", Message: "Invalid pagination, sorting or projection"}
+	InvalidAuditQuery  = Error{HTTPStatus: http.StatusBadRequest, Code: "INVALID_AUDIT_QUERY", Message: "Invalid audit query"}
+	InvalidImport      = Error{HTTPStatus: http.StatusBadRequest, Code: "INVALID_IMPORT", Message: "Invalid import or export parameters"}
+	InvalidApply       = Error{HTTPStatus: http.StatusBadRequest, Code: "INVALID_APPLY", Message: "Invalid apply parameters"}
+	InvalidTransaction = Error{HTTPStatus: http.StatusBadRequest, Code: "INVALID_TRANSACTION", Message: "Invalid transaction"}
+	InvalidConfig      = Error{HTTPStatus: http.StatusBadRequest, Code: "INVALID_CONFIG", Message: "Invalid config"}
+	MalformedBody      = Error{HTTPStatus: http.StatusBadRequest, Code: "MALFORMED_BODY", Message: "Malformed request body"}
+	InvalidPatch       = Error{HTTPStatus: http.StatusUnprocessableEntity, Code: "INVALID_PATCH", Message: "Patch cannot be applied"}
+	PatchTestFailed    = Error{HTTPStatus: http.StatusConflict, Code: "PATCH_TEST_FAILED", Message: "Patch test operation failed"}
+	UnsupportedMedia   = Error{HTTPStatus: http.StatusUnsupportedMediaType, Code: "UNSUPPORTED_MEDIA_TYPE", Message: "Unsupported content type"}
+	MissingConfigName  = Error{HTTPStatus: http.StatusBadRequest, Code: "MISSING_CONFIG_NAME", Message: "Missing config name"}
+	RouteNotFound      = Error{HTTPStatus: http.StatusNotFound, Code: "ROUTE_NOT_FOUND", Message: "Route not found"}
+	MethodNotAllowed   = Error{HTTPStatus: http.StatusMethodNotAllowed, Code: "METHOD_NOT_ALLOWED", Message: "Method not allowed"}
+	Unauthorized       = Error{HTTPStatus: http.StatusUnauthorized, Code: "UNAUTHORIZED", Message: "Missing or invalid credentials"}
+	Forbidden          = Error{HTTPStatus: http.StatusForbidden, Code: "FORBIDDEN", Message: "Not allowed to act upon the resource"}
+	Timeout            = Error{HTTPStatus: http.StatusServiceUnavailable, Code: "TIMEOUT", Message: "Request timed out"}
+	InternalError      = Error{HTTPStatus: http.StatusInternalServerError, Code: "INTERNAL_ERROR", Message: "There was an internal server error"}
+)
@@ -14,3 +14,12 @@ func TestError(t *testing.T) {
 
 	assert.Equal(t, "NOT_FOUND", err.Error(), "Incorrect error")
 }
+
+func TestErrorWithMessage(t *testing.T) {
+	err := httperr.ConfigNotFound.WithMessage("Get config %s: not found", "datacenter-1")
+
+	assert.Equal(t, "Get config datacenter-1: not found", err.Error(), "Incorrect error message")
+	assert.Equal(t, "CONFIG_NOT_FOUND", err.Code, "Incorrect error code")
+	assert.Equal(t, http.StatusNotFound, err.HTTPStatus, "Incorrect error status")
+	assert.Equal(t, "Config not found", httperr.ConfigNotFound.Message, "Catalog error was modified")
+}
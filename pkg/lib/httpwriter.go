@@ -19,7 +19,7 @@ func NewRecordingWriter(w http.ResponseWriter) *RecordingWriter {
 }
 
 func (rw *RecordingWriter) Write(b []byte) (int, error) {
-	if rw.Status != http.StatusOK {
+	if rw.Status >= http.StatusBadRequest {
 		err := httperr.Error{}
 		if unmarshalErr := json.Unmarshal(b, &err); unmarshalErr != nil {
 			rw.Err = httperr.UnknownError
@@ -41,3 +41,10 @@ func (rw *RecordingWriter) WriteHeader(status int) {
 func (rw *RecordingWriter) Header() http.Header {
 	return rw.ResponseWriter.Header()
 }
+
+// Flush sends buffered data to the client, when the underlying writer supports it
+func (rw *RecordingWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -3,6 +3,7 @@ package lib_test
 import (
 	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -83,3 +84,38 @@ func TestHeader(t *testing.T) {
 
 	mock.AssertExpectationsForObjects(t, mockWriter)
 }
+
+func TestWriteForSuccessStatus(t *testing.T) {
+	mockWriter := new(mocks.ResponseWriter)
+	recordingWriter := lib.NewRecordingWriter(mockWriter)
+	bytes := []byte(`{"name":"datacenter-1"}`)
+
+	mockWriter.On("Write", bytes).Return(len(bytes), nil).Once()
+	mockWriter.On("WriteHeader", http.StatusCreated).Return().Once()
+
+	recordingWriter.WriteHeader(http.StatusCreated)
+	_, err := recordingWriter.Write(bytes)
+
+	assert.NoError(t, err, "Unexpected response write error")
+	assert.Equal(t, httperr.Error{}, recordingWriter.Err, "Unexpected error recorded in writer")
+
+	mock.AssertExpectationsForObjects(t, mockWriter)
+}
+
+func TestFlush(t *testing.T) {
+	rr := httptest.NewRecorder()
+	recordingWriter := lib.NewRecordingWriter(rr)
+
+	recordingWriter.Flush()
+
+	assert.True(t, rr.Flushed, "Response was not flushed")
+}
+
+func TestFlushForUnsupportedWriter(t *testing.T) {
+	mockWriter := new(mocks.ResponseWriter)
+	recordingWriter := lib.NewRecordingWriter(mockWriter)
+
+	recordingWriter.Flush()
+
+	mock.AssertExpectationsForObjects(t, mockWriter)
+}
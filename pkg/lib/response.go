@@ -3,8 +3,11 @@ package lib
 import (
 	"encoding/json"
 	"io"
+	"net/http"
 
 	"go.uber.org/zap"
+
+	"jsonstore/pkg/httperr"
 )
 
 func WriteResponseJSON(w io.Writer, response interface{}) {
@@ -15,3 +18,10 @@ func WriteResponseJSON(w io.Writer, response interface{}) {
 		zap.S().Errorf("write JSON response: %v", err)
 	}
 }
+
+// WriteError replies to the request with err serialized as a JSON body and its HTTP status code
+func WriteError(w http.ResponseWriter, err httperr.Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.HTTPStatus)
+	WriteResponseJSON(w, err)
+}
@@ -2,10 +2,13 @@ package lib_test
 
 import (
 	"bytes"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"jsonstore/pkg/httperr"
 	"jsonstore/pkg/lib"
 )
 
@@ -26,3 +29,14 @@ func TestWriteResponseJSONWhenError(t *testing.T) {
 
 	assert.Empty(t, w.String(), "Unexpected response written")
 }
+
+func TestWriteError(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	lib.WriteError(w, httperr.ConfigNotFound.WithMessage("Get config datacenter-1"))
+
+	assert.Equal(t, http.StatusNotFound, w.Code, "Incorrect http status code")
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"), "Incorrect content type")
+	assert.JSONEq(t, `{"httpStatus":404,"code":"CONFIG_NOT_FOUND","message":"Get config datacenter-1"}`,
+		w.Body.String(), "Incorrect response written")
+}
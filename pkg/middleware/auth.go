@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"jsonstore/pkg/auth"
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/lib"
+)
+
+// Challenge sent along with 401 replies
+const authChallenge = `Bearer realm="jsonstore"`
+
+// Authenticate replies with 401 to requests that none of the authenticators accept, and adds the
+// authenticated principal to the context of the others. Authenticators are tried in order, and
+// the first one finding credentials in the request decides. It does nothing without
+// authenticators.
+func Authenticate(authenticators ...auth.Authenticator) Middleware {
+	return func(next http.Handler) http.Handler {
+		if len(authenticators) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, authenticator := range authenticators {
+				principal, err := authenticator.Authenticate(r)
+				if errors.Is(err, auth.ErrNoCredentials) {
+					continue
+				}
+				if err != nil {
+					zap.S().Warnf("Authenticate %s %s: %v", r.Method, r.URL.Path, err)
+					unauthorized(w, httperr.Unauthorized.WithMessage("%v", err))
+					return
+				}
+
+				next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+				return
+			}
+
+			unauthorized(w, httperr.Unauthorized.WithMessage("%v", auth.ErrNoCredentials))
+		})
+	}
+}
+
+func unauthorized(w http.ResponseWriter, err httperr.Error) {
+	w.Header().Set("WWW-Authenticate", authChallenge)
+	lib.WriteError(w, err)
+}
@@ -0,0 +1,96 @@
+package middleware_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/auth"
+	"jsonstore/pkg/middleware"
+)
+
+type authenticatorFunc func(*http.Request) (*auth.Principal, error)
+
+func (f authenticatorFunc) Authenticate(r *http.Request) (*auth.Principal, error) {
+	return f(r)
+}
+
+func noCredentials(*http.Request) (*auth.Principal, error) {
+	return nil, auth.ErrNoCredentials
+}
+
+func TestAuthenticate(t *testing.T) {
+	req, err := http.NewRequest("GET", "/configs", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	rr := httptest.NewRecorder()
+	var principal *auth.Principal
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = auth.FromContext(r.Context())
+	})
+	ci := &auth.Principal{Name: "ci", Method: auth.MethodJWT}
+
+	middleware.Authenticate(authenticatorFunc(noCredentials), authenticatorFunc(func(*http.Request) (*auth.Principal, error) {
+		return ci, nil
+	}))(nextHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.Equal(t, ci, principal, "Incorrect principal")
+}
+
+func TestAuthenticateForMissingCredentials(t *testing.T) {
+	req, err := http.NewRequest("GET", "/configs", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	rr := httptest.NewRecorder()
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected call to the next handler")
+	})
+
+	middleware.Authenticate(authenticatorFunc(noCredentials))(nextHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "Incorrect http status code")
+	assert.Equal(t, `Bearer realm="jsonstore"`, rr.Header().Get("WWW-Authenticate"), "Incorrect challenge")
+	assert.JSONEq(t, `{"httpStatus":401,"code":"UNAUTHORIZED","message":"no credentials"}`, rr.Body.String(),
+		"Incorrect http response body")
+}
+
+func TestAuthenticateForInvalidCredentials(t *testing.T) {
+	req, err := http.NewRequest("GET", "/configs", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	rr := httptest.NewRecorder()
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected call to the next handler")
+	})
+	tried := false
+
+	middleware.Authenticate(authenticatorFunc(func(*http.Request) (*auth.Principal, error) {
+		return nil, fmt.Errorf("%w: token expired", auth.ErrInvalidCredentials)
+	}), authenticatorFunc(func(*http.Request) (*auth.Principal, error) {
+		tried = true
+		return nil, errors.New("unexpected")
+	}))(nextHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "Incorrect http status code")
+	assert.False(t, tried, "Authenticators were tried after invalid credentials")
+	assert.JSONEq(t, `{"httpStatus":401,"code":"UNAUTHORIZED","message":"invalid credentials: token expired"}`,
+		rr.Body.String(), "Incorrect http response body")
+}
+
+func TestAuthenticateWithoutAuthenticators(t *testing.T) {
+	req, err := http.NewRequest("GET", "/configs", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	rr := httptest.NewRecorder()
+	called := false
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	middleware.Authenticate()(nextHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.True(t, called, "Request was not passed on")
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"jsonstore/pkg/auth"
+	"jsonstore/pkg/httperr"
+	"jsonstore/pkg/lib"
+)
+
+// Authorize replies with 403 to requests whose principal the authorizer does not allow to take
+// an action upon the resource the request names. It does nothing without an authorizer.
+func Authorize(authorizer auth.Authorizer, action auth.Action, resource func(*http.Request) auth.Resource) Middleware {
+	return func(next http.Handler) http.Handler {
+		if authorizer == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, _ := auth.FromContext(r.Context())
+			if err := authorizer.Authorize(principal, action, resource(r)); err != nil {
+				zap.S().Warnf("Authorize %s %s: %v", r.Method, r.URL.Path, err)
+				lib.WriteError(w, httperr.Forbidden.WithMessage("%v", err))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/auth"
+	"jsonstore/pkg/middleware"
+)
+
+func configResource(*http.Request) auth.Resource {
+	return auth.Resource{Namespace: "team-a", Name: "dc-1"}
+}
+
+func TestAuthorize(t *testing.T) {
+	policy, err := auth.ParsePolicy([]byte(`{"bindings": [{"principal": "ci", "role": "reader"}]}`))
+	require.NoError(t, err, "Unexpected parse policy error")
+	req, err := http.NewRequest("GET", "/namespaces/team-a/configs/dc-1", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = req.WithContext(auth.NewContext(req.Context(), &auth.Principal{Name: "ci", Method: auth.MethodAPIKey}))
+	rr := httptest.NewRecorder()
+	called := false
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	middleware.Authorize(policy, auth.ActionRead, configResource)(nextHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.True(t, called, "Request was not passed on")
+}
+
+func TestAuthorizeForForbiddenAction(t *testing.T) {
+	policy, err := auth.ParsePolicy([]byte(`{"bindings": [{"principal": "ci", "role": "reader"}]}`))
+	require.NoError(t, err, "Unexpected parse policy error")
+	req, err := http.NewRequest("PUT", "/namespaces/team-a/configs/dc-1", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	req = req.WithContext(auth.NewContext(req.Context(), &auth.Principal{Name: "ci", Method: auth.MethodAPIKey}))
+	rr := httptest.NewRecorder()
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected call to the next handler")
+	})
+
+	middleware.Authorize(policy, auth.ActionWrite, configResource)(nextHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code, "Incorrect http status code")
+	assert.JSONEq(t, `{"httpStatus":403,"code":"FORBIDDEN",
+		"message":"forbidden: ci may not write config dc-1 of namespace team-a"}`, rr.Body.String(),
+		"Incorrect http response body")
+}
+
+func TestAuthorizeWithoutAuthorizer(t *testing.T) {
+	req, err := http.NewRequest("DELETE", "/namespaces/team-a/configs/dc-1", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	rr := httptest.NewRecorder()
+	called := false
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	middleware.Authorize(nil, auth.ActionWrite, configResource)(nextHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect http status code")
+	assert.True(t, called, "Request was not passed on")
+}
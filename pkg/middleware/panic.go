@@ -5,6 +5,7 @@ import (
 
 	"go.uber.org/zap"
 
+	"jsonstore/pkg/httperr"
 	"jsonstore/pkg/lib"
 	"jsonstore/pkg/prometheus"
 )
@@ -17,9 +18,7 @@ func Recovery(p *prometheus.Prometheus, serviceName string) Middleware {
 				if err != nil {
 					p.PanicCounter().WithLabelValues(serviceName).Inc()
 					zap.S().Errorf("panic recovery: %v", err)
-					w.Header().Set("Content-Type", "application/json")
-					w.WriteHeader(http.StatusInternalServerError)
-					lib.WriteResponseJSON(w, map[string]string{"error": "There was an internal server error"})
+					lib.WriteError(w, httperr.InternalError)
 				}
 			}()
 
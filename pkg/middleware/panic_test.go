@@ -30,5 +30,5 @@ func TestRecovery(t *testing.T) {
 	recoverMiddleware(nextHandler).ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusInternalServerError, rr.Code, "Incorrect http status code")
-	assert.JSONEq(t, `{"error":"There was an internal server error"}`, rr.Body.String(), "Incorrect http response body")
+	assert.JSONEq(t, `{"httpStatus":500,"code":"INTERNAL_ERROR","message":"There was an internal server error"}`, rr.Body.String(), "Incorrect http response body")
 }
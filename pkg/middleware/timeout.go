@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"jsonstore/pkg/httperr"
+)
+
+// Timeout replies with 503 to requests not handled within d, discarding whatever the handler
+// writes afterwards. The response is buffered until the handler returns, so streaming handlers
+// must not be wrapped. It does nothing when d is 0.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+
+		body, _ := json.Marshal(httperr.Timeout)
+		timeout := http.TimeoutHandler(next, d, string(body))
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Replaced by the headers of the handler, unless it times out
+			w.Header().Set("Content-Type", "application/json")
+			timeout.ServeHTTP(w, r)
+		})
+	}
+}
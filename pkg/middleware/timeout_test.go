@@ -0,0 +1,60 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/middleware"
+)
+
+func TestTimeout(t *testing.T) {
+	req, err := http.NewRequest("GET", "/configs", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	rr := httptest.NewRecorder()
+
+	middleware.Timeout(10*time.Millisecond)(nextHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code, "Incorrect http status code")
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"), "Incorrect content type")
+	assert.JSONEq(t, `{"httpStatus":503,"code":"TIMEOUT","message":"Request timed out"}`, rr.Body.String(),
+		"Incorrect http response body")
+}
+
+func TestTimeoutForFastHandler(t *testing.T) {
+	req, err := http.NewRequest("GET", "/configs", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created"))
+	})
+	rr := httptest.NewRecorder()
+
+	middleware.Timeout(time.Second)(nextHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, "Incorrect http status code")
+	assert.Equal(t, "text/plain", rr.Header().Get("Content-Type"), "Incorrect content type")
+	assert.Equal(t, "created", rr.Body.String(), "Incorrect http response body")
+}
+
+func TestTimeoutForZeroDuration(t *testing.T) {
+	req, err := http.NewRequest("GET", "/configs/watch", nil)
+	require.NoError(t, err, "Unexpected create request error")
+	rr := httptest.NewRecorder()
+	var written http.ResponseWriter
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		written = w
+	})
+
+	middleware.Timeout(0)(nextHandler).ServeHTTP(rr, req)
+
+	assert.Same(t, rr, written, "Response was buffered")
+}
@@ -1,7 +1,79 @@
 package model
 
+import (
+	"encoding/json"
+	"time"
+)
+
+// Namespace of the configs that are not explicitly placed in one; it always exists
+const DefaultNamespace = "default"
+
 // Represents a config object in persistence
 type Config struct {
-	Name     string      `json:"name"`
-	Metadata interface{} `json:"metadata"`
+	Namespace string      `json:"namespace,omitempty"`
+	Name      string      `json:"name"`
+	Metadata  interface{} `json:"metadata"`
+	// Name of the schema the metadata is validated against, if any
+	Schema string `json:"schema,omitempty"`
+
+	// Incremented on every write, starting from 1 when the config is created
+	Version   int64     `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	UpdatedBy string    `json:"updatedBy,omitempty"`
+}
+
+// Represents an immutable record of a write to a config. Revisions are numbered like the config
+// versions they produced, and a deletion is recorded as a revision of its own.
+type Revision struct {
+	Namespace string      `json:"namespace,omitempty"`
+	Name      string      `json:"name"`
+	Version   int64       `json:"version"`
+	Timestamp time.Time   `json:"timestamp"`
+	Author    string      `json:"author,omitempty"`
+	Deleted   bool        `json:"deleted,omitempty"`
+	Metadata  interface{} `json:"metadata,omitempty"`
+	Schema    string      `json:"schema,omitempty"`
+}
+
+// Represents a secondary index on a JSON path of the configs
+type Index struct {
+	Path string `json:"path"`
+	// Number of distinct values found at the path
+	Values int `json:"values"`
+	// Number of pairs of a value and a config holding it
+	Entries int `json:"entries"`
+}
+
+// Represents a JSON Schema that the metadata of configs is validated against
+type Schema struct {
+	Name string `json:"name"`
+	// Pattern of the names of the configs the schema applies to, in the syntax of path.Match
+	Pattern string          `json:"pattern,omitempty"`
+	Schema  json.RawMessage `json:"schema"`
+
+	Version   int64     `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Represents a namespace, which scopes the names of the configs it holds
+type Namespace struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Represents a subscription to the changes of the configs of a namespace, which are posted to its
+// URL
+type Webhook struct {
+	ID        string `json:"id"`
+	Namespace string `json:"namespace"`
+	URL       string `json:"url"`
+	// Changes are only posted for configs whose name starts with Prefix and, unless deleted, that
+	// match the filter expression Query
+	Prefix string `json:"prefix,omitempty"`
+	Query  string `json:"query,omitempty"`
+	// Key of the HMAC signing the payloads
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"createdAt"`
 }
@@ -0,0 +1,64 @@
+package patch
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Diff returns the RFC 6902 operations turning a document into another. Objects are compared
+// member by member in key order, whereas any other changed value, arrays included, is replaced
+// as a whole.
+func Diff(from, to interface{}) []Operation {
+	ops := []Operation{}
+	return diff(ops, "", from, to)
+}
+
+func diff(ops []Operation, pointer string, from, to interface{}) []Operation {
+	if reflect.DeepEqual(from, to) {
+		return ops
+	}
+
+	fromObj, fromIsObj := from.(map[string]interface{})
+	toObj, toIsObj := to.(map[string]interface{})
+	if !fromIsObj || !toIsObj {
+		return append(ops, operation("replace", pointer, to))
+	}
+
+	keys := make([]string, 0, len(fromObj)+len(toObj))
+	for k := range fromObj {
+		keys = append(keys, k)
+	}
+	for k := range toObj {
+		if _, ok := fromObj[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		path := pointer + "/" + escapeToken(k)
+		fromValue, inFrom := fromObj[k]
+		toValue, inTo := toObj[k]
+		switch {
+		case !inTo:
+			ops = append(ops, Operation{Op: "remove", Path: path})
+		case !inFrom:
+			ops = append(ops, operation("add", path, toValue))
+		default:
+			ops = diff(ops, path, fromValue, toValue)
+		}
+	}
+	return ops
+}
+
+func operation(op, path string, value interface{}) Operation {
+	// Generic values decoded by encoding/json always encode back
+	encoded, _ := json.Marshal(value)
+	return Operation{Op: op, Path: path, Value: encoded}
+}
+
+func escapeToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
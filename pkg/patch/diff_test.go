@@ -0,0 +1,48 @@
+package patch_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/patch"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name, from, to, expected string
+	}{
+		{"equal documents", `{"a":{"b":[1,2]}}`, `{"a":{"b":[1,2]}}`, `[]`},
+		{"add member", `{"a":1}`, `{"a":1,"b":{"c":2}}`, `[{"op":"add","path":"/b","value":{"c":2}}]`},
+		{"remove member", `{"a":1,"b":2}`, `{"a":1}`, `[{"op":"remove","path":"/b"}]`},
+		{"replace nested value", `{"a":{"b":"c","d":1}}`, `{"a":{"b":"e","d":1}}`,
+			`[{"op":"replace","path":"/a/b","value":"e"}]`},
+		{"replace array", `{"a":[1,2]}`, `{"a":[1,3]}`, `[{"op":"replace","path":"/a","value":[1,3]}]`},
+		{"replace with null", `{"a":1}`, `{"a":null}`, `[{"op":"replace","path":"/a","value":null}]`},
+		{"replace root", `{"a":1}`, `[1]`, `[{"op":"replace","path":"","value":[1]}]`},
+		{"from null", `null`, `{"a":1}`, `[{"op":"replace","path":"","value":{"a":1}}]`},
+		{"escaped keys", `{"a/b":1,"c~d":1}`, `{"a/b":2}`,
+			`[{"op":"replace","path":"/a~1b","value":2},{"op":"remove","path":"/c~0d"}]`},
+		{"keys in order", `{"b":1,"a":1}`, `{"b":2,"a":2}`,
+			`[{"op":"replace","path":"/a","value":2},{"op":"replace","path":"/b","value":2}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := patch.Diff(decode(t, tt.from), decode(t, tt.to))
+
+			assert.JSONEq(t, tt.expected, encode(t, ops), "Incorrect diff")
+		})
+	}
+}
+
+func TestDiffApplies(t *testing.T) {
+	from := `{"a":{"b":"c","d":[1,2],"e":{"f":1}},"g":true}`
+	to := `{"a":{"b":"x","d":[2],"h":null},"i":"j"}`
+
+	result, err := patch.Apply(decode(t, from), patch.Diff(decode(t, from), decode(t, to)))
+
+	require.NoError(t, err, "Unexpected apply patch error")
+	assert.JSONEq(t, to, encode(t, result), "Incorrect patched document")
+}
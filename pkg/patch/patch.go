@@ -0,0 +1,182 @@
+// Package patch implements JSON Merge Patch (RFC 7396) and JSON Patch (RFC 6902) on documents
+// decoded by encoding/json into generic values.
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	// Returned when a patch document is not a valid patch
+	ErrInvalidPatch = errors.New("invalid patch")
+	// Returned when an operation references a location that does not exist in the document
+	ErrPathNotFound = errors.New("path not found")
+	// Returned when a test operation does not match the document
+	ErrTestFailed = errors.New("test operation failed")
+)
+
+// Represents a single JSON Patch operation
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Merge applies an RFC 7396 merge patch to doc. Members of the patch set to null are removed
+// from the document and any non-object patch replaces the target entirely.
+func Merge(doc, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	docObj, ok := doc.(map[string]interface{})
+	if !ok {
+		docObj = map[string]interface{}{}
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(docObj, k)
+			continue
+		}
+		docObj[k] = Merge(docObj[k], v)
+	}
+
+	return docObj
+}
+
+// Apply applies the RFC 6902 operations in order and returns the patched document. Operations
+// modify doc in place, so callers needing atomicity must pass a copy and discard it on error.
+func Apply(doc interface{}, ops []Operation) (interface{}, error) {
+	for i, op := range ops {
+		var err error
+		doc, err = applyOperation(doc, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	return doc, nil
+}
+
+func applyOperation(doc interface{}, op Operation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add":
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "remove":
+		return remove(doc, path)
+	case "replace":
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := get(doc, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		if doc, err = remove(doc, path); err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, fmt.Errorf("%w: cannot move a value into one of its children", ErrInvalidPatch)
+			}
+			if doc, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			value = deepCopy(value)
+		}
+		return add(doc, path, value)
+	case "test":
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		actual, err := get(doc, path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrTestFailed, err)
+		}
+		if !reflect.DeepEqual(actual, value) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+func (op Operation) value() (interface{}, error) {
+	if len(op.Value) == 0 {
+		return nil, fmt.Errorf("%w: missing value", ErrInvalidPatch)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(op.Value, &value); err != nil {
+		return nil, fmt.Errorf("%w: malformed value: %v", ErrInvalidPatch, err)
+	}
+
+	return value, nil
+}
+
+func isPrefix(prefix, tokens []string) bool {
+	if len(prefix) > len(tokens) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != tokens[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			c[k] = deepCopy(child)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, child := range v {
+			c[i] = deepCopy(child)
+		}
+		return c
+	default:
+		return v
+	}
+}
@@ -0,0 +1,123 @@
+package patch_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/patch"
+)
+
+func decode(t *testing.T, doc string) interface{} {
+	var v interface{}
+	require.NoError(t, json.Unmarshal([]byte(doc), &v), "Unexpected json decode error")
+	return v
+}
+
+func encode(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	require.NoError(t, err, "Unexpected json encode error")
+	return string(b)
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		doc, patch, expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.doc+" "+tt.patch, func(t *testing.T) {
+			result := patch.Merge(decode(t, tt.doc), decode(t, tt.patch))
+
+			assert.JSONEq(t, tt.expected, encode(t, result), "Incorrect merged document")
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name, doc, patch, expected string
+	}{
+		{"add member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"append array element", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc"]}]`, `{"foo":["bar",["abc"]]}`},
+		{"add null value", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":null}]`, `{"foo":"bar","baz":null}`},
+		{"remove member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace value", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"move value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			`{"foo":["all","cows","eat","grass"]}`},
+		{"copy value", `{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"}]`, `{"foo":{"bar":1},"baz":{"bar":1}}`},
+		{"test value", `{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`},
+		{"escaped pointer", `{"/":9,"~1":10}`, `[{"op":"replace","path":"/~01","value":11},{"op":"remove","path":"/~1"}]`, `{"~1":11}`},
+		{"replace root", `{"foo":"bar"}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ops []patch.Operation
+			require.NoError(t, json.Unmarshal([]byte(tt.patch), &ops), "Unexpected json decode error")
+
+			result, err := patch.Apply(decode(t, tt.doc), ops)
+
+			assert.NoError(t, err, "Unexpected apply patch error")
+			assert.JSONEq(t, tt.expected, encode(t, result), "Incorrect patched document")
+		})
+	}
+}
+
+func TestApplyForInvalidOperations(t *testing.T) {
+	tests := []struct {
+		name, doc, patch string
+		expected         error
+	}{
+		{"failed test", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, patch.ErrTestFailed},
+		{"test of missing member", `{"baz":"qux"}`, `[{"op":"test","path":"/foo","value":"bar"}]`, patch.ErrTestFailed},
+		{"test type mismatch", `{"baz":"1"}`, `[{"op":"test","path":"/baz","value":1}]`, patch.ErrTestFailed},
+		{"add to missing parent", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, patch.ErrPathNotFound},
+		{"remove missing member", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, patch.ErrPathNotFound},
+		{"replace missing member", `{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":1}]`, patch.ErrPathNotFound},
+		{"array index out of range", `{"foo":[1]}`, `[{"op":"add","path":"/foo/2","value":1}]`, patch.ErrPathNotFound},
+		{"leading zero array index", `{"foo":[1,2]}`, `[{"op":"remove","path":"/foo/01"}]`, patch.ErrInvalidPatch},
+		{"unknown operation", `{"foo":"bar"}`, `[{"op":"frobnicate","path":"/foo"}]`, patch.ErrInvalidPatch},
+		{"missing value", `{"foo":"bar"}`, `[{"op":"add","path":"/baz"}]`, patch.ErrInvalidPatch},
+		{"relative pointer", `{"foo":"bar"}`, `[{"op":"remove","path":"foo"}]`, patch.ErrInvalidPatch},
+		{"move into child", `{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`, patch.ErrInvalidPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ops []patch.Operation
+			require.NoError(t, json.Unmarshal([]byte(tt.patch), &ops), "Unexpected json decode error")
+
+			_, err := patch.Apply(decode(t, tt.doc), ops)
+
+			assert.Error(t, err, "Missing apply patch error")
+			assert.True(t, errors.Is(err, tt.expected), "Incorrect apply patch error: %v", err)
+		})
+	}
+}
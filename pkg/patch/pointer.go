@@ -0,0 +1,157 @@
+package patch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parsePointer splits an RFC 6901 JSON Pointer into its unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+
+	return tokens, nil
+}
+
+// arrayIndex resolves a reference token against an array of the given length. The "-" token
+// refers to the position past the last element and is only valid when allowEnd is set.
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+
+	upper := length - 1
+	if allowEnd {
+		upper = length
+	}
+	if idx > upper {
+		return 0, fmt.Errorf("%w: array index %d out of range", ErrPathNotFound, idx)
+	}
+
+	return idx, nil
+}
+
+func get(node interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: member %q does not exist", ErrPathNotFound, token)
+			}
+			node = child
+		case []interface{}:
+			idx, err := arrayIndex(token, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			node = n[idx]
+		default:
+			return nil, fmt.Errorf("%w: cannot traverse a scalar at %q", ErrPathNotFound, token)
+		}
+	}
+
+	return node, nil
+}
+
+// add inserts value at the location referenced by tokens and returns the updated node
+func add(node interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	token, last := tokens[0], len(tokens) == 1
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if last {
+			n[token] = value
+			return n, nil
+		}
+		child, ok := n[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: member %q does not exist", ErrPathNotFound, token)
+		}
+		updated, err := add(child, tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[token] = updated
+		return n, nil
+	case []interface{}:
+		idx, err := arrayIndex(token, len(n), last)
+		if err != nil {
+			return nil, err
+		}
+		if last {
+			n = append(n, nil)
+			copy(n[idx+1:], n[idx:])
+			n[idx] = value
+			return n, nil
+		}
+		updated, err := add(n[idx], tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[idx] = updated
+		return n, nil
+	default:
+		return nil, fmt.Errorf("%w: cannot traverse a scalar at %q", ErrPathNotFound, token)
+	}
+}
+
+// remove deletes the value referenced by tokens and returns the updated node
+func remove(node interface{}, tokens []string) (interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the root document", ErrInvalidPatch)
+	}
+
+	token, last := tokens[0], len(tokens) == 1
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: member %q does not exist", ErrPathNotFound, token)
+		}
+		if last {
+			delete(n, token)
+			return n, nil
+		}
+		updated, err := remove(child, tokens[1:])
+		if err != nil {
+			return nil, err
+		}
+		n[token] = updated
+		return n, nil
+	case []interface{}:
+		idx, err := arrayIndex(token, len(n), false)
+		if err != nil {
+			return nil, err
+		}
+		if last {
+			return append(n[:idx], n[idx+1:]...), nil
+		}
+		updated, err := remove(n[idx], tokens[1:])
+		if err != nil {
+			return nil, err
+		}
+		n[idx] = updated
+		return n, nil
+	default:
+		return nil, fmt.Errorf("%w: cannot traverse a scalar at %q", ErrPathNotFound, token)
+	}
+}
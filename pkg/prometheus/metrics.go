@@ -27,4 +27,17 @@ var (
 		Name:      "panic_count",
 		Help:      "count of server panics by service name",
 	}, []string{"service"})
+
+	WebhookDeliveryCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "jsonstore",
+		Name:      "webhook_delivery_count",
+		Help:      "count of webhook delivery attempts partitioned by service and result (delivered, retried, failed or dropped)",
+	}, []string{"service", "result"})
+
+	WebhookDeliveryLatencyHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "jsonstore",
+		Name:      "webhook_delivery_latency",
+		Help:      "webhook delivery attempt latency histogram partitioned by service",
+		Buckets:   Buckets,
+	}, []string{"service"})
 )
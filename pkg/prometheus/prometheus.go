@@ -24,6 +24,14 @@ func (p *Prometheus) PanicCounter() *prometheus.CounterVec {
 	return registerCounter(p.registry, PanicCounter)
 }
 
+func (p *Prometheus) WebhookDeliveryCounter() *prometheus.CounterVec {
+	return registerCounter(p.registry, WebhookDeliveryCounter)
+}
+
+func (p *Prometheus) WebhookDeliveryLatencyHistogram() *prometheus.HistogramVec {
+	return registerHistogram(p.registry, WebhookDeliveryLatencyHistogram)
+}
+
 func registerHistogram(registry *prometheus.Registry, histogram *prometheus.HistogramVec) *prometheus.HistogramVec {
 	if err := registry.Register(histogram); err != nil {
 		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
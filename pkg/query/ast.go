@@ -0,0 +1,121 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Expr is a node of a parsed filter expression. Paths use the gjson path syntax and are resolved
+// against the JSON representation of a config.
+type Expr interface {
+	fmt.Stringer
+	expr()
+}
+
+// And matches documents matched by both of its operands
+type And struct {
+	Left, Right Expr
+}
+
+// Or matches documents matched by either of its operands
+type Or struct {
+	Left, Right Expr
+}
+
+// Not matches documents that its operand does not match
+type Not struct {
+	Expr Expr
+}
+
+// Comparison matches documents whose value at Path compares to Value as given by Op. Regexp is
+// set for OpMatch and holds the compiled pattern.
+type Comparison struct {
+	Path   string
+	Op     Operator
+	Value  Value
+	Regexp *regexp.Regexp
+}
+
+// In matches documents whose value at Path equals any of Values
+type In struct {
+	Path   string
+	Values []Value
+}
+
+// Exists matches documents that have a value at Path
+type Exists struct {
+	Path string
+}
+
+func (And) expr()        {}
+func (Or) expr()         {}
+func (Not) expr()        {}
+func (Comparison) expr() {}
+func (In) expr()         {}
+func (Exists) expr()     {}
+
+func (e And) String() string { return fmt.Sprintf("(%s AND %s)", e.Left, e.Right) }
+func (e Or) String() string  { return fmt.Sprintf("(%s OR %s)", e.Left, e.Right) }
+func (e Not) String() string { return fmt.Sprintf("NOT %s", e.Expr) }
+
+func (e Comparison) String() string {
+	return fmt.Sprintf("%s %s %s", e.Path, e.Op, e.Value)
+}
+
+func (e In) String() string {
+	values := make([]string, 0, len(e.Values))
+	for _, v := range e.Values {
+		values = append(values, v.String())
+	}
+	return fmt.Sprintf("%s IN (%s)", e.Path, strings.Join(values, ", "))
+}
+
+func (e Exists) String() string { return fmt.Sprintf("%s EXISTS", e.Path) }
+
+// Operator of a Comparison
+type Operator string
+
+const (
+	OpEq     Operator = "="
+	OpNe     Operator = "!="
+	OpLt     Operator = "<"
+	OpLe     Operator = "<="
+	OpGt     Operator = ">"
+	OpGe     Operator = ">="
+	OpMatch  Operator = "~"
+	OpPrefix Operator = "^="
+)
+
+// Kind of a literal Value
+type Kind int
+
+const (
+	String Kind = iota
+	Number
+	Bool
+	Null
+)
+
+// Value is a literal of an expression. Comparisons are type-aware: a value only ever equals or
+// orders against a JSON value of the same kind.
+type Value struct {
+	Kind Kind
+	Str  string
+	Num  float64
+	Bool bool
+}
+
+func (v Value) String() string {
+	switch v.Kind {
+	case Number:
+		return strconv.FormatFloat(v.Num, 'g', -1, 64)
+	case Bool:
+		return strconv.FormatBool(v.Bool)
+	case Null:
+		return "null"
+	default:
+		return strconv.Quote(v.Str)
+	}
+}
@@ -0,0 +1,407 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// Longest expression that is parsed
+	maxLength = 4096
+	// Deepest nesting of parentheses and NOT that is parsed, which bounds the recursion of the
+	// parser
+	maxDepth = 100
+)
+
+// SyntaxError reports an expression that cannot be parsed. Pos is the 1-based offset of the
+// offending token in the expression.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", e.Pos, e.Msg)
+}
+
+// Parse parses a filter expression such as
+//
+//	metadata.limits.cpu.enabled = true AND (metadata.region in ("eu", "us") OR NOT metadata.beta exists)
+//
+// Conditions compare the value at a path using =, !=, <, <=, >, >=, ~ (regular expression) and
+// ^= (prefix), or test it with IN and EXISTS. They are combined with NOT, AND and OR, in
+// decreasing order of precedence, and parentheses. Keywords are case-insensitive and literals are
+// double or single quoted strings, numbers, true, false and null. Expressions are at most 4096
+// bytes long and nested at most 100 levels deep.
+func Parse(input string) (Expr, error) {
+	if len(input) > maxLength {
+		return nil, &SyntaxError{Pos: maxLength + 1,
+			Msg: fmt.Sprintf("expression is longer than %d characters", maxLength)}
+	}
+
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok, "unexpected %s", tok)
+	}
+
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// Decoded value of string literals
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// keyword tells whether the token is the given case-insensitive keyword
+func (t token) keyword(word string) bool {
+	return t.kind == tokenIdent && strings.EqualFold(t.text, word)
+}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		r, size := utf8.DecodeRuneInString(input[i:])
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+			continue
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: start + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: start + 1})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: start + 1})
+			i++
+		case r == '"' || r == '\'':
+			end, value, err := lexString(input, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: input[i:end], value: value, pos: start + 1})
+			i = end
+		case strings.ContainsRune("=!<>~^", r):
+			op := lexOperator(input[i:])
+			if op == "" {
+				return nil, &SyntaxError{Pos: start + 1, Msg: fmt.Sprintf("unknown operator %q", string(r))}
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: start + 1})
+			i += len(op)
+		case isNumberStart(input[i:]):
+			for i++; i < len(input) && strings.ContainsRune("0123456789.eE+-", rune(input[i])); i++ {
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: input[start:i], pos: start + 1})
+		case isPathRune(r):
+			for i += size; i < len(input); i += size {
+				r, size = utf8.DecodeRuneInString(input[i:])
+				if !isPathRune(r) {
+					break
+				}
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: input[start:i], pos: start + 1})
+		default:
+			return nil, &SyntaxError{Pos: start + 1, Msg: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(input) + 1}), nil
+}
+
+// lexString scans the quoted string starting at input[start] and returns the offset past its
+// closing quote along with its value. Double quoted strings follow the Go syntax, whereas single
+// quoted ones only support escaping the quote and the backslash.
+func lexString(input string, start int) (int, string, error) {
+	quote := input[start]
+	var value strings.Builder
+	for i := start + 1; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			if i+1 == len(input) {
+				break
+			}
+			if quote == '\'' {
+				i++
+				value.WriteByte(input[i])
+				continue
+			}
+			i++
+		case quote:
+			if quote == '\'' {
+				return i + 1, value.String(), nil
+			}
+			unquoted, err := strconv.Unquote(input[start : i+1])
+			if err != nil {
+				return 0, "", &SyntaxError{Pos: start + 1, Msg: fmt.Sprintf("invalid string %s", input[start:i+1])}
+			}
+			return i + 1, unquoted, nil
+		default:
+			value.WriteByte(input[i])
+		}
+	}
+
+	return 0, "", &SyntaxError{Pos: start + 1, Msg: "unterminated string"}
+}
+
+func lexOperator(input string) string {
+	for _, op := range []Operator{OpNe, OpLe, OpGe, OpPrefix, OpEq, OpLt, OpGt, OpMatch} {
+		if strings.HasPrefix(input, string(op)) {
+			return string(op)
+		}
+	}
+
+	return ""
+}
+
+func isNumberStart(input string) bool {
+	if strings.HasPrefix(input, "-") {
+		input = input[1:]
+	}
+	return input != "" && input[0] >= '0' && input[0] <= '9'
+}
+
+// isPathRune tells whether r may appear in a path, which allows the gjson wildcards and array
+// queries such as tags.# or items.#.name
+func isPathRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.-#*?@\\", r)
+}
+
+type parser struct {
+	tokens []token
+	next   int
+	// Nesting of the expression being parsed
+	depth int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokenEOF {
+		p.next++
+	}
+	return tok
+}
+
+// nest enters a nested expression starting at tok, which fails past maxDepth. The returned
+// function leaves it.
+func (p *parser) nest(tok token) (func(), error) {
+	if p.depth == maxDepth {
+		return nil, p.errorf(tok, "expression is nested more than %d levels deep", maxDepth)
+	}
+	p.depth++
+	return func() { p.depth-- }, nil
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword("or") {
+		p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword("and") {
+		p.advance()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if tok := p.peek(); tok.keyword("not") {
+		p.advance()
+		leave, err := p.nest(tok)
+		if err != nil {
+			return nil, err
+		}
+		defer leave()
+
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Expr: expr}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.advance()
+	switch {
+	case tok.kind == tokenLParen:
+		leave, err := p.nest(tok)
+		if err != nil {
+			return nil, err
+		}
+		defer leave()
+
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.advance(); closing.kind != tokenRParen {
+			return nil, p.errorf(closing, "expected \")\", got %s", closing)
+		}
+		return expr, nil
+	case tok.kind == tokenIdent:
+		return p.parseCondition(tok.text)
+	default:
+		return nil, p.errorf(tok, "expected path or \"(\", got %s", tok)
+	}
+}
+
+func (p *parser) parseCondition(path string) (Expr, error) {
+	tok := p.advance()
+	switch {
+	case tok.keyword("exists"):
+		return Exists{Path: path}, nil
+	case tok.keyword("in"):
+		return p.parseIn(path)
+	case tok.kind == tokenOperator:
+		valueTok := p.peek()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		cmp := Comparison{Path: path, Op: Operator(tok.text), Value: value}
+		switch cmp.Op {
+		case OpMatch, OpPrefix:
+			if value.Kind != String {
+				return nil, p.errorf(valueTok, "operator %s requires a string, got %s", cmp.Op, valueTok)
+			}
+		}
+		if cmp.Op == OpMatch {
+			cmp.Regexp, err = regexp.Compile(value.Str)
+			if err != nil {
+				return nil, p.errorf(valueTok, "invalid regular expression: %v", err)
+			}
+		}
+		return cmp, nil
+	default:
+		return nil, p.errorf(tok, "expected operator, IN or EXISTS after %q, got %s", path, tok)
+	}
+}
+
+func (p *parser) parseIn(path string) (Expr, error) {
+	if tok := p.advance(); tok.kind != tokenLParen {
+		return nil, p.errorf(tok, "expected \"(\" after IN, got %s", tok)
+	}
+
+	in := In{Path: path}
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		in.Values = append(in.Values, value)
+
+		tok := p.advance()
+		if tok.kind == tokenRParen {
+			return in, nil
+		}
+		if tok.kind != tokenComma {
+			return nil, p.errorf(tok, "expected \",\" or \")\", got %s", tok)
+		}
+	}
+}
+
+func (p *parser) parseValue() (Value, error) {
+	tok := p.advance()
+	switch {
+	case tok.kind == tokenString:
+		return Value{Kind: String, Str: tok.value}, nil
+	case tok.kind == tokenNumber:
+		num, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return Value{}, p.errorf(tok, "invalid number %s", tok)
+		}
+		return Value{Kind: Number, Num: num}, nil
+	case tok.keyword("true"), tok.keyword("false"):
+		return Value{Kind: Bool, Bool: strings.EqualFold(tok.text, "true")}, nil
+	case tok.keyword("null"):
+		return Value{Kind: Null}, nil
+	default:
+		return Value{}, p.errorf(tok, "expected a string, number, true, false or null, got %s", tok)
+	}
+}
+
+// IsPath tells whether s can be used as a path in an expression
+func IsPath(s string) bool {
+	tokens, err := lex(s)
+	return err == nil && len(tokens) == 2 && tokens[0].kind == tokenIdent && tokens[0].text == s &&
+		!isKeyword(s)
+}
+
+func isKeyword(s string) bool {
+	for _, keyword := range []string{"and", "or", "not", "in", "exists", "true", "false", "null"} {
+		if strings.EqualFold(s, keyword) {
+			return true
+		}
+	}
+	return false
+}
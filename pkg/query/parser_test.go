@@ -0,0 +1,123 @@
+package query
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`metadata.monitoring.enabled = "true"`, `metadata.monitoring.enabled = "true"`},
+		{`metadata.replicas >= 3`, `metadata.replicas >= 3`},
+		{`metadata.ratio < -0.5`, `metadata.ratio < -0.5`},
+		{`metadata.enabled != false`, `metadata.enabled != false`},
+		{`metadata.owner = null`, `metadata.owner = null`},
+		{`name ^= 'datacenter-'`, `name ^= "datacenter-"`},
+		{`name ~ "^dc-[0-9]+$"`, `name ~ "^dc-[0-9]+$"`},
+		{`metadata.region in ("eu", 'us', 3)`, `metadata.region IN ("eu", "us", 3)`},
+		{`metadata.beta EXISTS`, `metadata.beta EXISTS`},
+		{`metadata.tags.# > 1`, `metadata.tags.# > 1`},
+		{`a = 1 and b = 2 or c = 3`, `((a = 1 AND b = 2) OR c = 3)`},
+		{`a = 1 or b = 2 and c = 3`, `(a = 1 OR (b = 2 AND c = 3))`},
+		{`a = 1 AND (b = 2 OR c = 3)`, `(a = 1 AND (b = 2 OR c = 3))`},
+		{`not a exists and b = 'it\'s'`, `(NOT a EXISTS AND b = "it's")`},
+		{`NOT NOT a = "x\ty"`, `NOT NOT a = "x\ty"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+
+			require.NoError(t, err, "Unexpected parse error")
+			assert.Equal(t, tt.expected, expr.String(), "Incorrect expression")
+		})
+	}
+}
+
+func TestParseBuildsAST(t *testing.T) {
+	expr, err := Parse(`a = "x" AND NOT b in (true, null)`)
+
+	require.NoError(t, err, "Unexpected parse error")
+	assert.Equal(t, And{
+		Left: Comparison{Path: "a", Op: OpEq, Value: Value{Kind: String, Str: "x"}},
+		Right: Not{Expr: In{Path: "b", Values: []Value{
+			{Kind: Bool, Bool: true},
+			{Kind: Null},
+		}}},
+	}, expr, "Incorrect expression")
+}
+
+func TestParseForSyntaxError(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+		msg   string
+	}{
+		{``, 1, `expected path or "(", got end of expression`},
+		{`a`, 2, `expected operator, IN or EXISTS after "a", got end of expression`},
+		{`a = `, 5, `expected a string, number, true, false or null, got end of expression`},
+		{`a = b`, 5, `expected a string, number, true, false or null, got "b"`},
+		{`a = 1 b = 2`, 7, `unexpected "b"`},
+		{`(a = 1`, 7, `expected ")", got end of expression`},
+		{`a = "x`, 5, `unterminated string`},
+		{`a in ("x" "y")`, 11, `expected "," or ")", got "\"y\""`},
+		{`a in "x"`, 6, `expected "(" after IN, got "\"x\""`},
+		{`a ~ "["`, 5, "invalid regular expression: error parsing regexp: missing closing ]: `[`"},
+		{`a ^= 1`, 6, `operator ^= requires a string, got "1"`},
+		{`a ! 1`, 3, `unknown operator "!"`},
+		{`a = 1 & b = 2`, 7, `unexpected character '&'`},
+		{`a = 1..2`, 5, `invalid number "1..2"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input)
+
+			var syntaxErr *SyntaxError
+			require.True(t, errors.As(err, &syntaxErr), "Missing syntax error: %v", err)
+			assert.Equal(t, tt.pos, syntaxErr.Pos, "Incorrect error position")
+			assert.Equal(t, tt.msg, syntaxErr.Msg, "Incorrect error message")
+		})
+	}
+}
+
+func TestParseForDeepNesting(t *testing.T) {
+	nested := func(depth int) string {
+		return strings.Repeat("(", depth) + "a = 1" + strings.Repeat(")", depth)
+	}
+
+	_, err := Parse(nested(maxDepth))
+	assert.NoError(t, err, "Unexpected parse error at the maximum depth")
+	_, err = Parse(strings.Repeat("NOT ", maxDepth) + "a = 1")
+	assert.NoError(t, err, "Unexpected parse error at the maximum depth")
+
+	for name, input := range map[string]string{
+		"parentheses": nested(maxDepth + 1),
+		"not":         strings.Repeat("NOT ", maxDepth+1) + "a = 1",
+		"mixed":       strings.Repeat("NOT (", maxDepth) + "a = 1" + strings.Repeat(")", maxDepth),
+		"too long":    strings.Repeat("(", 900000),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(input)
+
+			var syntaxErr *SyntaxError
+			assert.True(t, errors.As(err, &syntaxErr), "Missing syntax error: %v", err)
+		})
+	}
+}
+
+func TestIsPath(t *testing.T) {
+	assert.True(t, IsPath("metadata.region"), "Incorrect path check")
+	assert.True(t, IsPath("metadata.zones.#.name"), "Incorrect path check")
+	assert.False(t, IsPath(""), "Incorrect path check")
+	assert.False(t, IsPath("metadata region"), "Incorrect path check")
+	assert.False(t, IsPath("1.name"), "Incorrect path check")
+	assert.False(t, IsPath("exists"), "Incorrect path check")
+}
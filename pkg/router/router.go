@@ -2,11 +2,14 @@ package router
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	promlib "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"jsonstore/pkg/audit"
+	"jsonstore/pkg/auth"
 	"jsonstore/pkg/handler"
 	"jsonstore/pkg/middleware"
 	"jsonstore/pkg/prometheus"
@@ -17,10 +20,28 @@ const (
 	metricsPath = "/metrics"
 	healthPath  = "/health"
 	configsPath = "/configs"
+	indexesPath = "/indexes"
+	schemasPath = "/schemas"
+
+	namespacesPath   = "/namespaces"
+	webhooksPath     = "/webhooks"
+	auditPath        = "/audit"
+	transactionsPath = "/transactions"
 )
 
 type Context struct {
 	Manager service.Manager
+	// Time given to handle a request, except for watches which last as long as the client wants;
+	// unlimited when 0
+	Timeout time.Duration
+	// Authenticators of the requests to the API, which is open when there are none
+	Authenticators []auth.Authenticator
+	// Policy deciding what authenticated principals may do, which may do anything when nil
+	Authorizer auth.Authorizer
+	// Log of the writes to configs, which are not audited when nil
+	AuditLog audit.Log
+	// Closed as the server shuts down, to end the watches that would otherwise keep it waiting
+	StopWatches <-chan struct{}
 }
 
 func (ctx Context) New() http.Handler {
@@ -28,32 +49,148 @@ func (ctx Context) New() http.Handler {
 	prom := prometheus.NewPrometheus(promRegistry)
 
 	metrics := promhttp.HandlerFor(promRegistry, promhttp.HandlerOpts{})
+	// Recorded by the manager as it posts changes to webhooks
+	prom.WebhookDeliveryCounter()
+	prom.WebhookDeliveryLatencyHistogram()
 
+	// Health and metrics are probed by the infrastructure, so they do not require credentials
+	public := []middleware.Middleware{
+		middleware.HTTPMetrics(prom, "jsonstore"),
+		middleware.Recovery(prom, "jsonstore"),
+		middleware.Timeout(ctx.Timeout),
+	}
 	middlewares := []middleware.Middleware{
 		middleware.HTTPMetrics(prom, "jsonstore"),
 		middleware.Recovery(prom, "jsonstore"),
+		middleware.Authenticate(ctx.Authenticators...),
+		middleware.Timeout(ctx.Timeout),
 	}
+	// Watches and exports stream their response for as long as it takes, so they are not timed out
+	streaming := middlewares[:len(middlewares)-1]
 
 	router := mux.NewRouter()
+	router.NotFoundHandler = middleware.Wrap(handler.NotFound(), middlewares...)
+	router.MethodNotAllowedHandler = middleware.Wrap(handler.MethodNotAllowed(), middlewares...)
 
-	//Future extension: An auth middleware can be added here to protect the APIs
-	router.Handle(healthPath, middleware.Wrap(handler.Health(), middlewares...)).Methods(http.MethodGet)
-	router.Handle(metricsPath, middleware.Wrap(metrics, middlewares...)).Methods(http.MethodGet)
+	router.Handle(healthPath, middleware.Wrap(handler.Health(), public...)).Methods(http.MethodGet)
+	router.Handle(metricsPath, middleware.Wrap(metrics, public...)).Methods(http.MethodGet)
 
-	router.Handle(configsPath+"/search", middleware.Wrap(handler.SearchConfigs(ctx.Manager),
-		middlewares...)).Methods(http.MethodGet)
-	router.Handle(configsPath+"/{name}", middleware.Wrap(handler.GetConfig(ctx.Manager),
+	// allow checks that the principal of a request may take an action upon the resource it names
+	allow := func(action auth.Action, resource func(*http.Request) auth.Resource, h http.Handler) http.Handler {
+		return middleware.Authorize(ctx.Authorizer, action, resource)(h)
+	}
+	read, write, admin := auth.ActionRead, auth.ActionWrite, auth.ActionAdmin
+	// audited records the writes to configs, including those the policy denies
+	audited := func(operation string, h http.Handler) http.Handler {
+		if ctx.AuditLog == nil {
+			return h
+		}
+		return handler.Audited(ctx.AuditLog, ctx.Manager, operation, h)
+	}
+
+	// Configs of the default namespace are served under /configs, and those of any namespace under
+	// /namespaces/{ns}/configs
+	for _, prefix := range []string{configsPath, namespacesPath + "/{ns}" + configsPath} {
+		router.Handle(prefix+"/search", middleware.Wrap(allow(read, configResource, handler.SearchConfigs(ctx.Manager)),
+			middlewares...)).Methods(http.MethodGet)
+		router.Handle(prefix+"/watch", middleware.Wrap(allow(read, configResource, handler.WatchConfigs(ctx.Manager, ctx.StopWatches)),
+			streaming...)).Methods(http.MethodGet)
+		router.Handle(prefix+"/export", middleware.Wrap(allow(read, configResource, handler.ExportConfigs(ctx.Manager)),
+			streaming...)).Methods(http.MethodGet)
+		router.Handle(prefix+"/import", middleware.Wrap(audited("import", allow(write, configResource,
+			handler.ImportConfigs(ctx.Manager))), middlewares...)).Methods(http.MethodPost)
+		router.Handle(prefix+"/apply", middleware.Wrap(audited("apply", allow(write, configResource,
+			handler.ApplyConfigs(ctx.Manager))), middlewares...)).Methods(http.MethodPost)
+		router.Handle(prefix+"/{name}", middleware.Wrap(allow(read, configResource, handler.GetConfig(ctx.Manager)),
+			middlewares...)).Methods(http.MethodGet)
+		router.Handle(prefix, middleware.Wrap(allow(read, configResource, handler.GetAllConfigs(ctx.Manager)),
+			middlewares...)).Methods(http.MethodGet)
+		router.Handle(prefix+"/{name}/history", middleware.Wrap(allow(read, configResource,
+			handler.GetConfigHistory(ctx.Manager)), middlewares...)).Methods(http.MethodGet)
+		router.Handle(prefix+"/{name}/history/{version}", middleware.Wrap(allow(read, configResource,
+			handler.GetConfigRevision(ctx.Manager)), middlewares...)).Methods(http.MethodGet)
+		router.Handle(prefix+"/{name}/watch", middleware.Wrap(allow(read, configResource, handler.WatchConfig(ctx.Manager, ctx.StopWatches)),
+			streaming...)).Methods(http.MethodGet)
+
+		// The name of a created config is in the body, so creating requires access to the namespace
+		router.Handle(prefix, middleware.Wrap(audited("create", allow(write, configResource,
+			handler.CreateConfig(ctx.Manager))), middlewares...)).Methods(http.MethodPost)
+		router.Handle(prefix+"/{name}", middleware.Wrap(audited("update", allow(write, configResource,
+			handler.UpdateConfig(ctx.Manager))), middlewares...)).Methods(http.MethodPut)
+		router.Handle(prefix+"/{name}", middleware.Wrap(audited("patch", allow(write, configResource,
+			handler.PatchConfig(ctx.Manager))), middlewares...)).Methods(http.MethodPatch)
+		router.Handle(prefix+"/{name}/rollback", middleware.Wrap(audited("rollback", allow(write, configResource,
+			handler.RollbackConfig(ctx.Manager))), middlewares...)).Methods(http.MethodPost)
+
+		router.Handle(prefix+"/{name}", middleware.Wrap(audited("delete", allow(write, configResource,
+			handler.DeleteConfig(ctx.Manager))), middlewares...)).Methods(http.MethodDelete)
+	}
+
+	// Transactions act upon configs of the default namespace under /transactions, and upon those of
+	// any namespace under /namespaces/{ns}/transactions
+	for _, prefix := range []string{"", namespacesPath + "/{ns}"} {
+		router.Handle(prefix+transactionsPath, middleware.Wrap(audited("transaction", allow(write, configResource,
+			handler.Transact(ctx.Manager))), middlewares...)).Methods(http.MethodPost)
+	}
+
+	router.Handle(namespacesPath, middleware.Wrap(allow(read, storeResource, handler.GetNamespaces(ctx.Manager)),
 		middlewares...)).Methods(http.MethodGet)
-	router.Handle(configsPath, middleware.Wrap(handler.GetAllConfigs(ctx.Manager),
+	router.Handle(namespacesPath, middleware.Wrap(allow(admin, storeResource, handler.CreateNamespace(ctx.Manager)),
+		middlewares...)).Methods(http.MethodPost)
+	router.Handle(namespacesPath+"/{ns}", middleware.Wrap(allow(read, namespaceResource, handler.GetNamespace(ctx.Manager)),
 		middlewares...)).Methods(http.MethodGet)
+	router.Handle(namespacesPath+"/{ns}", middleware.Wrap(allow(admin, namespaceResource,
+		handler.DeleteNamespace(ctx.Manager)), middlewares...)).Methods(http.MethodDelete)
 
-	router.Handle(configsPath, middleware.Wrap(handler.CreateConfig(ctx.Manager),
+	router.Handle(indexesPath, middleware.Wrap(allow(read, storeResource, handler.GetIndexes(ctx.Manager)),
+		middlewares...)).Methods(http.MethodGet)
+	router.Handle(indexesPath, middleware.Wrap(allow(admin, storeResource, handler.CreateIndex(ctx.Manager)),
 		middlewares...)).Methods(http.MethodPost)
-	router.Handle(configsPath+"/{name}", middleware.Wrap(handler.UpdateConfig(ctx.Manager),
-		middlewares...)).Methods(http.MethodPut, http.MethodPatch)
+	router.Handle(indexesPath+"/{path}", middleware.Wrap(allow(admin, storeResource, handler.DeleteIndex(ctx.Manager)),
+		middlewares...)).Methods(http.MethodDelete)
 
-	router.Handle(configsPath+"/{name}", middleware.Wrap(handler.DeleteConfig(ctx.Manager),
+	router.Handle(schemasPath, middleware.Wrap(allow(read, storeResource, handler.GetSchemas(ctx.Manager)),
+		middlewares...)).Methods(http.MethodGet)
+	router.Handle(schemasPath+"/{name}", middleware.Wrap(allow(read, storeResource, handler.GetSchema(ctx.Manager)),
+		middlewares...)).Methods(http.MethodGet)
+	router.Handle(schemasPath+"/{name}", middleware.Wrap(allow(admin, storeResource, handler.PutSchema(ctx.Manager)),
+		middlewares...)).Methods(http.MethodPut)
+	router.Handle(schemasPath+"/{name}", middleware.Wrap(allow(admin, storeResource, handler.DeleteSchema(ctx.Manager)),
+		middlewares...)).Methods(http.MethodDelete)
+
+	// Webhooks hold secrets and are notified of every namespace, so they are managed by admins only
+	router.Handle(webhooksPath, middleware.Wrap(allow(admin, storeResource, handler.GetWebhooks(ctx.Manager)),
+		middlewares...)).Methods(http.MethodGet)
+	router.Handle(webhooksPath, middleware.Wrap(allow(admin, storeResource, handler.CreateWebhook(ctx.Manager)),
+		middlewares...)).Methods(http.MethodPost)
+	router.Handle(webhooksPath+"/{id}", middleware.Wrap(allow(admin, storeResource, handler.GetWebhook(ctx.Manager)),
+		middlewares...)).Methods(http.MethodGet)
+	router.Handle(webhooksPath+"/{id}", middleware.Wrap(allow(admin, storeResource, handler.DeleteWebhook(ctx.Manager)),
 		middlewares...)).Methods(http.MethodDelete)
 
+	if ctx.AuditLog != nil {
+		router.Handle(auditPath, middleware.Wrap(allow(admin, storeResource, handler.GetAuditEntries(ctx.AuditLog)),
+			middlewares...)).Methods(http.MethodGet)
+	}
+
 	return router
 }
+
+// configResource is the config named in the path of a request, or every config of its namespace
+// for the routes acting upon several configs
+func configResource(r *http.Request) auth.Resource {
+	vars := mux.Vars(r)
+	ns, ok := vars["ns"]
+	if !ok {
+		ns = service.DefaultNamespace
+	}
+	return auth.Resource{Namespace: ns, Name: vars["name"]}
+}
+
+func namespaceResource(r *http.Request) auth.Resource {
+	return auth.Resource{Namespace: mux.Vars(r)["ns"]}
+}
+
+func storeResource(*http.Request) auth.Resource {
+	return auth.Resource{}
+}
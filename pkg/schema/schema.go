@@ -0,0 +1,485 @@
+// Package schema validates documents decoded by encoding/json against JSON Schemas. It supports
+// the subset of draft 2020-12 made of the applicator and validation vocabularies, with $ref
+// limited to pointers into the same schema. Annotations such as title or format are ignored.
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Returned when a schema cannot be compiled
+var ErrInvalidSchema = errors.New("invalid schema")
+
+// Keywords that are part of draft 2020-12 but not implemented, rejected so that a schema is
+// never silently weaker than its author intended
+var unsupported = []string{
+	"$dynamicRef", "$dynamicAnchor", "$recursiveRef", "unevaluatedItems", "unevaluatedProperties",
+}
+
+// Schema is a compiled JSON Schema
+type Schema struct {
+	// Set for the boolean schemas true and false
+	always *bool
+
+	ref *Schema
+
+	types    []string
+	enum     []interface{}
+	constant interface{}
+	hasConst bool
+
+	minimum, maximum                   *float64
+	exclusiveMinimum, exclusiveMaximum *float64
+	multipleOf                         *float64
+
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+
+	items                  *Schema
+	prefixItems            []*Schema
+	contains               *Schema
+	minContains            *int
+	maxContains            *int
+	minItems, maxItems     *int
+	uniqueItems            bool
+	properties             map[string]*Schema
+	patternProperties      []patternSchema
+	additionalProperties   *Schema
+	propertyNames          *Schema
+	required               []string
+	dependentRequired      map[string][]string
+	dependentSchemas       map[string]*Schema
+	minProperties          *int
+	maxProperties          *int
+	allOf, anyOf, oneOf    []*Schema
+	not                    *Schema
+	ifSchema, then, orElse *Schema
+}
+
+type patternSchema struct {
+	pattern *regexp.Regexp
+	schema  *Schema
+}
+
+// Compile parses a JSON Schema document
+func Compile(data []byte) (*Schema, error) {
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+
+	c := compiler{root: root, compiled: map[string]*Schema{}}
+	s, err := c.compile(root, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkCycles(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// compiler compiles the schemas of a document, sharing the schemas referenced by $ref
+type compiler struct {
+	root interface{}
+	// Schemas by JSON Pointer, registered before their keywords are compiled so that recursive
+	// references resolve to them
+	compiled map[string]*Schema
+}
+
+func (c *compiler) compile(node interface{}, pointer string) (*Schema, error) {
+	if s, ok := c.compiled[pointer]; ok {
+		return s, nil
+	}
+
+	s := &Schema{}
+	c.compiled[pointer] = s
+	switch n := node.(type) {
+	case bool:
+		s.always = &n
+		return s, nil
+	case map[string]interface{}:
+		if err := c.compileKeywords(s, n, pointer); err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, c.errorf(pointer, "schema must be an object or a boolean")
+	}
+}
+
+func (c *compiler) compileKeywords(s *Schema, n map[string]interface{}, pointer string) error {
+	for _, keyword := range unsupported {
+		if _, ok := n[keyword]; ok {
+			return c.errorf(pointer, "keyword %s is not supported", keyword)
+		}
+	}
+
+	var err error
+	if v, ok := n["$ref"]; ok {
+		ref, isString := v.(string)
+		if !isString || (ref != "#" && !strings.HasPrefix(ref, "#/")) {
+			return c.errorf(pointer+"/$ref", "only references within the schema are supported")
+		}
+		if s.ref, err = c.resolve(ref[1:], pointer+"/$ref"); err != nil {
+			return err
+		}
+	}
+	if defs, ok := n["$defs"].(map[string]interface{}); ok {
+		for name, def := range defs {
+			if _, err := c.compile(def, pointer+"/$defs/"+escape(name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if v, ok := n["type"]; ok {
+		if s.types, err = c.typeList(v, pointer+"/type"); err != nil {
+			return err
+		}
+	}
+	if v, ok := n["enum"]; ok {
+		enum, isArray := v.([]interface{})
+		if !isArray {
+			return c.errorf(pointer+"/enum", "must be an array")
+		}
+		s.enum = enum
+	}
+	s.constant, s.hasConst = n["const"]
+
+	numbers := []struct {
+		keyword string
+		target  **float64
+	}{
+		{"minimum", &s.minimum}, {"maximum", &s.maximum},
+		{"exclusiveMinimum", &s.exclusiveMinimum}, {"exclusiveMaximum", &s.exclusiveMaximum},
+		{"multipleOf", &s.multipleOf},
+	}
+	for _, number := range numbers {
+		if *number.target, err = c.number(n, number.keyword, pointer); err != nil {
+			return err
+		}
+	}
+	if s.multipleOf != nil && *s.multipleOf <= 0 {
+		return c.errorf(pointer+"/multipleOf", "must be greater than 0")
+	}
+
+	counts := []struct {
+		keyword string
+		target  **int
+	}{
+		{"minLength", &s.minLength}, {"maxLength", &s.maxLength},
+		{"minItems", &s.minItems}, {"maxItems", &s.maxItems},
+		{"minContains", &s.minContains}, {"maxContains", &s.maxContains},
+		{"minProperties", &s.minProperties}, {"maxProperties", &s.maxProperties},
+	}
+	for _, count := range counts {
+		if *count.target, err = c.count(n, count.keyword, pointer); err != nil {
+			return err
+		}
+	}
+
+	if v, ok := n["pattern"]; ok {
+		if s.pattern, err = c.regexp(v, pointer+"/pattern"); err != nil {
+			return err
+		}
+	}
+	if v, ok := n["uniqueItems"]; ok {
+		if s.uniqueItems, ok = v.(bool); !ok {
+			return c.errorf(pointer+"/uniqueItems", "must be a boolean")
+		}
+	}
+	if v, ok := n["required"]; ok {
+		if s.required, err = c.stringList(v, pointer+"/required"); err != nil {
+			return err
+		}
+	}
+	if v, ok := n["dependentRequired"]; ok {
+		deps, isObject := v.(map[string]interface{})
+		if !isObject {
+			return c.errorf(pointer+"/dependentRequired", "must be an object")
+		}
+		s.dependentRequired = map[string][]string{}
+		for name, list := range deps {
+			if s.dependentRequired[name], err = c.stringList(list, pointer+"/dependentRequired/"+escape(name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	subschemas := []struct {
+		keyword string
+		target  **Schema
+	}{
+		{"items", &s.items}, {"contains", &s.contains}, {"additionalProperties", &s.additionalProperties},
+		{"propertyNames", &s.propertyNames}, {"not", &s.not},
+		{"if", &s.ifSchema}, {"then", &s.then}, {"else", &s.orElse},
+	}
+	for _, sub := range subschemas {
+		if v, ok := n[sub.keyword]; ok {
+			if *sub.target, err = c.compile(v, pointer+"/"+sub.keyword); err != nil {
+				return err
+			}
+		}
+	}
+
+	lists := []struct {
+		keyword string
+		target  *[]*Schema
+	}{
+		{"prefixItems", &s.prefixItems}, {"allOf", &s.allOf}, {"anyOf", &s.anyOf}, {"oneOf", &s.oneOf},
+	}
+	for _, list := range lists {
+		if v, ok := n[list.keyword]; ok {
+			if *list.target, err = c.schemaList(v, pointer+"/"+list.keyword); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.properties, err = c.schemaMap(n, "properties", pointer); err != nil {
+		return err
+	}
+	if s.dependentSchemas, err = c.schemaMap(n, "dependentSchemas", pointer); err != nil {
+		return err
+	}
+	patterns, err := c.schemaMap(n, "patternProperties", pointer)
+	if err != nil {
+		return err
+	}
+	for _, expr := range sortedKeys(patterns) {
+		re, err := c.regexp(expr, pointer+"/patternProperties/"+escape(expr))
+		if err != nil {
+			return err
+		}
+		s.patternProperties = append(s.patternProperties, patternSchema{pattern: re, schema: patterns[expr]})
+	}
+
+	return nil
+}
+
+// checkCycles rejects the schemas that apply to a value through a chain of references leading
+// back to themselves, whose validation would never end. Chains going through a keyword that
+// applies to an item or a member of the value are fine, as they end with the value.
+func (c *compiler) checkCycles() error {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	states := map[*Schema]int{}
+	pointers := make(map[*Schema]string, len(c.compiled))
+	for pointer, s := range c.compiled {
+		pointers[s] = pointer
+	}
+
+	var visit func(*Schema) error
+	visit = func(s *Schema) error {
+		switch states[s] {
+		case visiting:
+			return c.errorf(pointers[s], "$ref applies the schema to the same value again")
+		case visited:
+			return nil
+		}
+		states[s] = visiting
+		for _, sub := range s.inPlace() {
+			if err := visit(sub); err != nil {
+				return err
+			}
+		}
+		states[s] = visited
+		return nil
+	}
+
+	for _, pointer := range sortedKeys(c.compiled) {
+		if err := visit(c.compiled[pointer]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inPlace returns the subschemas that apply to the same value as s
+func (s *Schema) inPlace() []*Schema {
+	var schemas []*Schema
+	for _, sub := range []*Schema{s.ref, s.not, s.ifSchema, s.then, s.orElse} {
+		if sub != nil {
+			schemas = append(schemas, sub)
+		}
+	}
+	schemas = append(schemas, s.allOf...)
+	schemas = append(schemas, s.anyOf...)
+	schemas = append(schemas, s.oneOf...)
+	for _, name := range sortedKeys(s.dependentSchemas) {
+		schemas = append(schemas, s.dependentSchemas[name])
+	}
+	return schemas
+}
+
+// resolve compiles the schema at a JSON Pointer into the root schema
+func (c *compiler) resolve(pointer, from string) (*Schema, error) {
+	node := c.root
+	if pointer != "" {
+		for _, token := range strings.Split(pointer[1:], "/") {
+			token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+			switch n := node.(type) {
+			case map[string]interface{}:
+				child, ok := n[token]
+				if !ok {
+					return nil, c.errorf(from, "reference #%s does not exist", pointer)
+				}
+				node = child
+			case []interface{}:
+				idx, err := strconv.Atoi(token)
+				if err != nil || idx < 0 || idx >= len(n) {
+					return nil, c.errorf(from, "reference #%s does not exist", pointer)
+				}
+				node = n[idx]
+			default:
+				return nil, c.errorf(from, "reference #%s does not exist", pointer)
+			}
+		}
+	}
+
+	return c.compile(node, pointer)
+}
+
+func (c *compiler) typeList(v interface{}, pointer string) ([]string, error) {
+	types := []string{}
+	if name, ok := v.(string); ok {
+		types = append(types, name)
+	} else {
+		var err error
+		if types, err = c.stringList(v, pointer); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range types {
+		switch name {
+		case "null", "boolean", "object", "array", "number", "string", "integer":
+		default:
+			return nil, c.errorf(pointer, "unknown type %q", name)
+		}
+	}
+	return types, nil
+}
+
+func (c *compiler) stringList(v interface{}, pointer string) ([]string, error) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, c.errorf(pointer, "must be an array of strings")
+	}
+
+	strs := make([]string, 0, len(list))
+	for _, item := range list {
+		str, ok := item.(string)
+		if !ok {
+			return nil, c.errorf(pointer, "must be an array of strings")
+		}
+		strs = append(strs, str)
+	}
+	return strs, nil
+}
+
+func (c *compiler) schemaList(v interface{}, pointer string) ([]*Schema, error) {
+	list, ok := v.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, c.errorf(pointer, "must be a non-empty array of schemas")
+	}
+
+	schemas := make([]*Schema, 0, len(list))
+	for i, item := range list {
+		s, err := c.compile(item, fmt.Sprintf("%s/%d", pointer, i))
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, s)
+	}
+	return schemas, nil
+}
+
+func (c *compiler) schemaMap(n map[string]interface{}, keyword, pointer string) (map[string]*Schema, error) {
+	v, ok := n[keyword]
+	if !ok {
+		return nil, nil
+	}
+	members, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, c.errorf(pointer+"/"+keyword, "must be an object")
+	}
+
+	schemas := map[string]*Schema{}
+	for name, member := range members {
+		s, err := c.compile(member, pointer+"/"+keyword+"/"+escape(name))
+		if err != nil {
+			return nil, err
+		}
+		schemas[name] = s
+	}
+	return schemas, nil
+}
+
+func (c *compiler) number(n map[string]interface{}, keyword, pointer string) (*float64, error) {
+	v, ok := n[keyword]
+	if !ok {
+		return nil, nil
+	}
+	num, ok := v.(float64)
+	if !ok {
+		return nil, c.errorf(pointer+"/"+keyword, "must be a number")
+	}
+	return &num, nil
+}
+
+func (c *compiler) count(n map[string]interface{}, keyword, pointer string) (*int, error) {
+	v, ok := n[keyword]
+	if !ok {
+		return nil, nil
+	}
+	num, ok := v.(float64)
+	if !ok || num < 0 || num != math.Trunc(num) {
+		return nil, c.errorf(pointer+"/"+keyword, "must be a non-negative integer")
+	}
+	count := int(num)
+	return &count, nil
+}
+
+func (c *compiler) regexp(v interface{}, pointer string) (*regexp.Regexp, error) {
+	expr, ok := v.(string)
+	if !ok {
+		return nil, c.errorf(pointer, "must be a regular expression")
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, c.errorf(pointer, "invalid regular expression: %v", err)
+	}
+	return re, nil
+}
+
+func (c *compiler) errorf(pointer, format string, args ...interface{}) error {
+	if pointer == "" {
+		pointer = "/"
+	}
+	return fmt.Errorf("%w: %s: %s", ErrInvalidSchema, pointer, fmt.Sprintf(format, args...))
+}
+
+// escape encodes a reference token of a JSON Pointer
+func escape(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+func sortedKeys(m map[string]*Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
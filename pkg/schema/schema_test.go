@@ -0,0 +1,160 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const datacenterSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "Datacenter",
+  "type": "object",
+  "required": ["region", "replicas"],
+  "properties": {
+    "region": {"enum": ["eu", "us", "ap"]},
+    "replicas": {"type": "integer", "minimum": 1, "maximum": 9},
+    "owner": {"type": "string", "pattern": "^[a-z]+$", "maxLength": 8},
+    "zones": {"type": "array", "items": {"$ref": "#/$defs/zone"}, "uniqueItems": true, "minItems": 1},
+    "limits": {
+      "type": "object",
+      "patternProperties": {"^(cpu|memory)$": {"type": "string"}},
+      "additionalProperties": false
+    }
+  },
+  "$defs": {
+    "zone": {"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}}
+  }
+}`
+
+func decode(t *testing.T, doc string) interface{} {
+	var v interface{}
+	require.NoError(t, json.Unmarshal([]byte(doc), &v), "Unexpected json decode error")
+	return v
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		expected []Violation
+	}{
+		{
+			name: "valid",
+			doc:  `{"region":"eu","replicas":3,"owner":"ops","zones":[{"name":"a"}],"limits":{"cpu":"300m"}}`,
+		},
+		{
+			name:     "null",
+			doc:      `null`,
+			expected: []Violation{{Path: "", Message: "expected object, got null"}},
+		},
+		{
+			name: "every violation",
+			doc: `{"region":"mars","replicas":2.5,"owner":"Ops-Team-1","zones":[{"name":"a"},{"name":"a"},{}],` +
+				`"limits":{"cpu":1,"disk":"1G"}}`,
+			expected: []Violation{
+				{Path: "/limits/cpu", Message: "expected string, got integer"},
+				{Path: "/limits/disk", Message: "property is not allowed"},
+				{Path: "/owner", Message: "must be at most 8 characters long"},
+				{Path: "/owner", Message: `must match the pattern "^[a-z]+$"`},
+				{Path: "/region", Message: "value is not one of the allowed values"},
+				{Path: "/replicas", Message: "expected integer, got number"},
+				{Path: "/zones", Message: "items 0 and 1 are equal"},
+				{Path: "/zones/2/name", Message: "property is required"},
+			},
+		},
+		{
+			name: "missing properties",
+			doc:  `{"replicas":0}`,
+			expected: []Violation{
+				{Path: "/region", Message: "property is required"},
+				{Path: "/replicas", Message: "must be greater than or equal to 1"},
+			},
+		},
+	}
+
+	s, err := Compile([]byte(datacenterSchema))
+	require.NoError(t, err, "Unexpected compile error")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, s.Validate(decode(t, tt.doc)), "Incorrect violations")
+		})
+	}
+}
+
+func TestValidateCombinators(t *testing.T) {
+	tests := []struct {
+		schema string
+		doc    string
+		valid  bool
+	}{
+		{`{"anyOf":[{"type":"string"},{"type":"number"}]}`, `1`, true},
+		{`{"anyOf":[{"type":"string"},{"type":"number"}]}`, `true`, false},
+		{`{"oneOf":[{"type":"integer"},{"type":"number"}]}`, `1`, false},
+		{`{"oneOf":[{"type":"integer"},{"type":"string"}]}`, `1`, true},
+		{`{"allOf":[{"minimum":1},{"maximum":2}]}`, `3`, false},
+		{`{"not":{"type":"null"}}`, `null`, false},
+		{`{"if":{"required":["tls"]},"then":{"required":["cert"]}}`, `{"tls":true}`, false},
+		{`{"if":{"required":["tls"]},"then":{"required":["cert"]},"else":{"maxProperties":0}}`, `{"a":1}`, false},
+		{`{"contains":{"const":"eu"},"maxContains":1}`, `["us","eu"]`, true},
+		{`{"contains":{"const":"eu"},"maxContains":1}`, `["eu","eu"]`, false},
+		{`{"prefixItems":[{"type":"string"}],"items":{"type":"number"}}`, `["a",1,2]`, true},
+		{`{"prefixItems":[{"type":"string"}],"items":{"type":"number"}}`, `["a","b"]`, false},
+		{`{"propertyNames":{"pattern":"^[a-z]+$"}}`, `{"Abc":1}`, false},
+		{`{"dependentRequired":{"tls":["cert"]}}`, `{"tls":true}`, false},
+		{`{"multipleOf":0.1}`, `0.3`, true},
+		{`{"exclusiveMaximum":3}`, `3`, false},
+		{`{"$ref":"#/$defs/node","$defs":{"node":{"type":"object","properties":{"next":{"$ref":"#/$defs/node"}}}}}`,
+			`{"next":{"next":{"next":1}}}`, false},
+		{`false`, `{}`, false},
+		{`true`, `{}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schema+" "+tt.doc, func(t *testing.T) {
+			s, err := Compile([]byte(tt.schema))
+			require.NoError(t, err, "Unexpected compile error")
+
+			violations := s.Validate(decode(t, tt.doc))
+
+			assert.Equal(t, tt.valid, len(violations) == 0, "Incorrect validation: %v", violations)
+		})
+	}
+}
+
+func TestCompileForInvalidSchema(t *testing.T) {
+	tests := []struct {
+		schema string
+		msg    string
+	}{
+		{`{`, "invalid schema: unexpected end of JSON input"},
+		{`"object"`, "invalid schema: /: schema must be an object or a boolean"},
+		{`{"type":"decimal"}`, `invalid schema: /type: unknown type "decimal"`},
+		{`{"minLength":-1}`, "invalid schema: /minLength: must be a non-negative integer"},
+		{`{"properties":{"a":{"pattern":"["}}}`,
+			"invalid schema: /properties/a/pattern: invalid regular expression: error parsing regexp: missing closing ]: `[`"},
+		{`{"$ref":"https://example.com/schema"}`, "invalid schema: /$ref: only references within the schema are supported"},
+		{`{"$ref":"#/$defs/missing"}`, "invalid schema: /$ref: reference #/$defs/missing does not exist"},
+		{`{"$ref":"#"}`, "invalid schema: /: $ref applies the schema to the same value again"},
+		{`{"$defs":{"a":{"$ref":"#/$defs/b"},"b":{"$ref":"#/$defs/a"}}}`,
+			"invalid schema: /$defs/a: $ref applies the schema to the same value again"},
+		{`{"anyOf":[{"type":"string"},{"not":{"$ref":"#"}}]}`,
+			"invalid schema: /: $ref applies the schema to the same value again"},
+		{`{"unevaluatedProperties":false}`, "invalid schema: /: keyword unevaluatedProperties is not supported"},
+		{`{"allOf":[]}`, "invalid schema: /allOf: must be a non-empty array of schemas"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schema, func(t *testing.T) {
+			_, err := Compile([]byte(tt.schema))
+
+			require.Error(t, err, "Missing compile error")
+			assert.True(t, errors.Is(err, ErrInvalidSchema), "Incorrect compile error")
+			assert.Equal(t, tt.msg, err.Error(), "Incorrect compile error message")
+		})
+	}
+}
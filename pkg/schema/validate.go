@@ -0,0 +1,306 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Violation is a failed assertion of a schema, located by the JSON Pointer to the offending value
+type Violation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s", path, v.Message)
+}
+
+// Validate returns every violation of the schema by doc, which is a value as decoded by
+// encoding/json into an interface{}. It returns nil when doc is valid.
+func (s *Schema) Validate(doc interface{}) []Violation {
+	v := validator{}
+	v.validate(s, doc, "")
+	return v.violations
+}
+
+type validator struct {
+	violations []Violation
+}
+
+func (v *validator) addf(path, format string, args ...interface{}) {
+	v.violations = append(v.violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// valid tells whether doc satisfies s, without reporting its violations
+func valid(s *Schema, doc interface{}, path string) bool {
+	sub := validator{}
+	sub.validate(s, doc, path)
+	return len(sub.violations) == 0
+}
+
+func (v *validator) validate(s *Schema, doc interface{}, path string) {
+	if s.always != nil {
+		if !*s.always {
+			v.addf(path, "no value is allowed")
+		}
+		return
+	}
+
+	if s.ref != nil {
+		v.validate(s.ref, doc, path)
+	}
+	if len(s.types) > 0 && !hasType(doc, s.types) {
+		v.addf(path, "expected %s, got %s", strings.Join(s.types, " or "), typeOf(doc))
+	}
+	if s.enum != nil && !containsValue(s.enum, doc) {
+		v.addf(path, "value is not one of the allowed values")
+	}
+	if s.hasConst && !equal(s.constant, doc) {
+		v.addf(path, "value does not equal the constant")
+	}
+
+	switch value := doc.(type) {
+	case float64:
+		v.validateNumber(s, value, path)
+	case string:
+		v.validateString(s, value, path)
+	case []interface{}:
+		v.validateArray(s, value, path)
+	case map[string]interface{}:
+		v.validateObject(s, value, path)
+	}
+
+	v.validateCombinators(s, doc, path)
+}
+
+func (v *validator) validateNumber(s *Schema, num float64, path string) {
+	if s.minimum != nil && num < *s.minimum {
+		v.addf(path, "must be greater than or equal to %v", *s.minimum)
+	}
+	if s.maximum != nil && num > *s.maximum {
+		v.addf(path, "must be less than or equal to %v", *s.maximum)
+	}
+	if s.exclusiveMinimum != nil && num <= *s.exclusiveMinimum {
+		v.addf(path, "must be greater than %v", *s.exclusiveMinimum)
+	}
+	if s.exclusiveMaximum != nil && num >= *s.exclusiveMaximum {
+		v.addf(path, "must be less than %v", *s.exclusiveMaximum)
+	}
+	if s.multipleOf != nil {
+		if q := num / *s.multipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.addf(path, "must be a multiple of %v", *s.multipleOf)
+		}
+	}
+}
+
+func (v *validator) validateString(s *Schema, str string, path string) {
+	length := utf8.RuneCountInString(str)
+	if s.minLength != nil && length < *s.minLength {
+		v.addf(path, "must be at least %d characters long", *s.minLength)
+	}
+	if s.maxLength != nil && length > *s.maxLength {
+		v.addf(path, "must be at most %d characters long", *s.maxLength)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		v.addf(path, "must match the pattern %q", s.pattern)
+	}
+}
+
+func (v *validator) validateArray(s *Schema, items []interface{}, path string) {
+	if s.minItems != nil && len(items) < *s.minItems {
+		v.addf(path, "must have at least %d items", *s.minItems)
+	}
+	if s.maxItems != nil && len(items) > *s.maxItems {
+		v.addf(path, "must have at most %d items", *s.maxItems)
+	}
+	if s.uniqueItems {
+	unique:
+		for i := range items {
+			for j := 0; j < i; j++ {
+				if equal(items[i], items[j]) {
+					v.addf(path, "items %d and %d are equal", j, i)
+					break unique
+				}
+			}
+		}
+	}
+
+	for i, item := range items {
+		itemPath := fmt.Sprintf("%s/%d", path, i)
+		if i < len(s.prefixItems) {
+			v.validate(s.prefixItems[i], item, itemPath)
+		} else if s.items != nil {
+			v.validate(s.items, item, itemPath)
+		}
+	}
+
+	if s.contains != nil {
+		matched := 0
+		for i, item := range items {
+			if valid(s.contains, item, fmt.Sprintf("%s/%d", path, i)) {
+				matched++
+			}
+		}
+		minContains := 1
+		if s.minContains != nil {
+			minContains = *s.minContains
+		}
+		if matched < minContains {
+			v.addf(path, "must contain at least %d matching items", minContains)
+		}
+		if s.maxContains != nil && matched > *s.maxContains {
+			v.addf(path, "must contain at most %d matching items", *s.maxContains)
+		}
+	}
+}
+
+func (v *validator) validateObject(s *Schema, obj map[string]interface{}, path string) {
+	if s.minProperties != nil && len(obj) < *s.minProperties {
+		v.addf(path, "must have at least %d properties", *s.minProperties)
+	}
+	if s.maxProperties != nil && len(obj) > *s.maxProperties {
+		v.addf(path, "must have at most %d properties", *s.maxProperties)
+	}
+	for _, name := range s.required {
+		if _, ok := obj[name]; !ok {
+			v.addf(path+"/"+escape(name), "property is required")
+		}
+	}
+
+	// Members are visited in order so that violations are reported deterministically
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, memberPath := obj[name], path+"/"+escape(name)
+		if s.propertyNames != nil && !valid(s.propertyNames, name, memberPath) {
+			v.addf(memberPath, "property name is not allowed")
+		}
+		for _, dependency := range s.dependentRequired[name] {
+			if _, ok := obj[dependency]; !ok {
+				v.addf(path+"/"+escape(dependency), "property is required when %q is present", name)
+			}
+		}
+		if dependent, ok := s.dependentSchemas[name]; ok {
+			v.validate(dependent, obj, path)
+		}
+
+		evaluated := false
+		if property, ok := s.properties[name]; ok {
+			v.validate(property, value, memberPath)
+			evaluated = true
+		}
+		for _, pattern := range s.patternProperties {
+			if pattern.pattern.MatchString(name) {
+				v.validate(pattern.schema, value, memberPath)
+				evaluated = true
+			}
+		}
+		if !evaluated && s.additionalProperties != nil {
+			if s.additionalProperties.always != nil && !*s.additionalProperties.always {
+				v.addf(memberPath, "property is not allowed")
+				continue
+			}
+			v.validate(s.additionalProperties, value, memberPath)
+		}
+	}
+}
+
+func (v *validator) validateCombinators(s *Schema, doc interface{}, path string) {
+	for _, sub := range s.allOf {
+		v.validate(sub, doc, path)
+	}
+	if len(s.anyOf) > 0 {
+		matched := false
+		for _, sub := range s.anyOf {
+			if valid(sub, doc, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.addf(path, "must match at least one schema of anyOf")
+		}
+	}
+	if len(s.oneOf) > 0 {
+		matched := 0
+		for _, sub := range s.oneOf {
+			if valid(sub, doc, path) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.addf(path, "must match exactly one schema of oneOf, matched %d", matched)
+		}
+	}
+	if s.not != nil && valid(s.not, doc, path) {
+		v.addf(path, "must not match the schema of not")
+	}
+	if s.ifSchema != nil {
+		if valid(s.ifSchema, doc, path) {
+			if s.then != nil {
+				v.validate(s.then, doc, path)
+			}
+		} else if s.orElse != nil {
+			v.validate(s.orElse, doc, path)
+		}
+	}
+}
+
+func hasType(doc interface{}, types []string) bool {
+	actual := typeOf(doc)
+	for _, name := range types {
+		if name == actual || (name == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(doc interface{}) string {
+	switch value := doc.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if value == math.Trunc(value) && !math.IsInf(value, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", doc)
+	}
+}
+
+func containsValue(values []interface{}, doc interface{}) bool {
+	for _, value := range values {
+		if equal(value, doc) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal compares JSON values, which as decoded by encoding/json are deeply equal exactly when
+// they are the same JSON value
+func equal(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"fmt"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/model"
+	"jsonstore/pkg/patch"
+	"jsonstore/pkg/schema"
+)
+
+// Apply makes the configs of a namespace those of the request in a single atomic batch: configs
+// whose content differs from the stored one are created or replaced and, when pruning, the others
+// are deleted. Nothing is written when any config is invalid, on a dry run, or when the store
+// fails. The response holds the diff of every change, which is the plan on a dry run.
+func (c configManager) Apply(req contract.ApplyRequest) (*contract.ApplyResponse, error) {
+	items := make([]model.Config, 0, len(req.Configs))
+	seen := make(map[string]bool, len(req.Configs))
+	for i, config := range req.Configs {
+		item := model.Config{
+			Namespace: req.Namespace,
+			Name:      config.Name,
+			Metadata:  config.Metadata,
+			Schema:    config.Schema,
+			UpdatedBy: req.Author,
+		}
+		if err := c.validateApply(item, seen); err != nil {
+			return nil, fmt.Errorf("apply: config %d: %w", i, atPath(err, fmt.Sprintf("/%d", i)))
+		}
+		seen[item.Name] = true
+		items = append(items, item)
+	}
+
+	resp := &contract.ApplyResponse{DryRun: req.DryRun, Prune: req.Prune, Changes: make([]contract.ApplyChange, 0, len(items))}
+	if req.DryRun {
+		current, err := c.configRepo.GetAll(req.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("apply: %w", err)
+		}
+		plan(resp, current, items, req.Prune)
+		return resp, nil
+	}
+
+	results, err := c.configRepo.ApplyAll(req.Namespace, items, req.Prune, req.Author)
+	if err != nil {
+		return nil, fmt.Errorf("apply: %w", err)
+	}
+	for _, result := range results {
+		ch := diffConfigs(result.Name, result.Previous, result.Config)
+		switch {
+		case result.Revision == nil:
+			ch.Version = result.Config.Version
+		case result.Config == nil:
+			c.events.delete(*result.Revision)
+			ch.Version = result.Revision.Version
+		default:
+			c.events.put(*result.Config)
+			ch.Version = result.Config.Version
+		}
+		resp.Changes = append(resp.Changes, ch)
+	}
+
+	countChanges(resp)
+	return resp, nil
+}
+
+// validateApply checks an applied config like a single write would, and that its name is not
+// among those seen before
+func (c configManager) validateApply(item model.Config, seen map[string]bool) error {
+	if err := validateName(item.Name); err != nil {
+		return err
+	}
+	if seen[item.Name] {
+		return fmt.Errorf("%w: config %s is applied more than once", ErrInvalidConfig, item.Name)
+	}
+	return c.validateMetadata(item)
+}
+
+// atPath locates the violations of a validation error within the request, by prefixing their path
+// with the JSON pointer of the config
+func atPath(err error, pointer string) error {
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		return err
+	}
+
+	violations := make([]schema.Violation, 0, len(validationErr.Violations))
+	for _, v := range validationErr.Violations {
+		v.Path = pointer + v.Path
+		violations = append(violations, v)
+	}
+	return &ValidationError{Violations: violations}
+}
+
+// plan sets the changes an apply would make, given the current configs of the namespace
+func plan(resp *contract.ApplyResponse, current, items []model.Config, prune bool) {
+	stored := make(map[string]model.Config, len(current))
+	for _, config := range current {
+		stored[config.Name] = config
+	}
+
+	desired := make(map[string]bool, len(items))
+	for _, item := range items {
+		item := item
+		desired[item.Name] = true
+		var previous *model.Config
+		if existing, ok := stored[item.Name]; ok {
+			previous = &existing
+		}
+		resp.Changes = append(resp.Changes, diffConfigs(item.Name, previous, &item))
+	}
+
+	if prune {
+		for _, config := range current {
+			config := config
+			if !desired[config.Name] {
+				resp.Changes = append(resp.Changes, diffConfigs(config.Name, &config, nil))
+			}
+		}
+	}
+
+	countChanges(resp)
+}
+
+// diffConfigs returns the change turning a config into another, where nil stands for a missing
+// config, along with the stored version it applies to
+func diffConfigs(name string, from, to *model.Config) contract.ApplyChange {
+	ch := contract.ApplyChange{Name: name, Diff: patch.Diff(document(from), document(to))}
+	if from != nil {
+		ch.Version = from.Version
+	}
+	switch {
+	case from == nil:
+		ch.Action = contract.ApplyCreate
+	case to == nil:
+		ch.Action = contract.ApplyDelete
+	case db.SameContent(*from, *to):
+		ch.Action, ch.Diff = contract.ApplyUnchanged, nil
+	default:
+		ch.Action = contract.ApplyUpdate
+	}
+	return ch
+}
+
+// document returns the generic representation of the metadata and schema of a config, or an empty
+// object for a missing config
+func document(config *model.Config) interface{} {
+	doc := map[string]interface{}{}
+	if config == nil {
+		return doc
+	}
+
+	var metadata interface{}
+	if err := remarshal(config.Metadata, &metadata); err == nil {
+		doc["metadata"] = metadata
+	}
+	if config.Schema != "" {
+		doc["schema"] = config.Schema
+	}
+	return doc
+}
+
+// countChanges sets the number of configs of each action
+func countChanges(resp *contract.ApplyResponse) {
+	for _, ch := range resp.Changes {
+		switch ch.Action {
+		case contract.ApplyCreate:
+			resp.Created++
+		case contract.ApplyUpdate:
+			resp.Updated++
+		case contract.ApplyDelete:
+			resp.Deleted++
+		case contract.ApplyUnchanged:
+			resp.Unchanged++
+		}
+	}
+}
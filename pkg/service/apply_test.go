@@ -0,0 +1,146 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/model"
+	"jsonstore/pkg/patch"
+	"jsonstore/pkg/schema"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+var (
+	euConfig = contract.Config{Name: "datacenter-1", Metadata: map[string]interface{}{"region": "eu"}}
+	usConfig = contract.Config{Name: "datacenter-2", Metadata: map[string]interface{}{"region": "us"}}
+)
+
+func newApplyManager(t *testing.T, configs ...contract.Config) Manager {
+	manager := NewConfigManager(db.NewConfigRepo(), newSchemaRepo(t, limitsSchema), db.NewWebhookRepo())
+	for _, config := range configs {
+		_, err := manager.Create(contract.UpsertConfigRequest{Config: config})
+		require.NoError(t, err, "Unexpected create error")
+	}
+	return manager
+}
+
+func TestApply(t *testing.T) {
+	manager := newApplyManager(t, euConfig, usConfig, contract.Config{Name: "datacenter-3"})
+
+	resp, err := manager.Apply(contract.ApplyRequest{
+		Namespace: DefaultNamespace,
+		Prune:     true,
+		Author:    "alice",
+		Configs: []contract.Config{
+			{Name: "datacenter-1", Metadata: map[string]interface{}{"region": "us"}},
+			usConfig,
+			{Name: "datacenter-4", Metadata: map[string]interface{}{"region": "ap"}},
+		},
+	})
+
+	assert.NoError(t, err, "Unexpected apply error")
+	assert.Equal(t, &contract.ApplyResponse{
+		Prune:     true,
+		Created:   1,
+		Updated:   1,
+		Deleted:   1,
+		Unchanged: 1,
+		Changes: []contract.ApplyChange{
+			{Name: "datacenter-1", Action: contract.ApplyUpdate, Version: 2, Diff: []patch.Operation{
+				{Op: "replace", Path: "/metadata/region", Value: json.RawMessage(`"us"`)}}},
+			{Name: "datacenter-2", Action: contract.ApplyUnchanged, Version: 1},
+			{Name: "datacenter-4", Action: contract.ApplyCreate, Version: 1, Diff: []patch.Operation{
+				{Op: "add", Path: "/metadata", Value: json.RawMessage(`{"region":"ap"}`)}}},
+			{Name: "datacenter-3", Action: contract.ApplyDelete, Version: 2, Diff: []patch.Operation{
+				{Op: "remove", Path: "/metadata"}}},
+		},
+	}, resp, "Incorrect response")
+	config, err := manager.Get(DefaultNamespace, "datacenter-4")
+	require.NoError(t, err, "Unexpected get error")
+	assert.Equal(t, "alice", config.UpdatedBy, "Incorrect author")
+	_, err = manager.Get(DefaultNamespace, "datacenter-3")
+	assert.True(t, errors.Is(err, ErrNotFound), "Config left out of the apply was not pruned")
+}
+
+func TestApplyDryRun(t *testing.T) {
+	manager := newApplyManager(t, euConfig, usConfig)
+
+	resp, err := manager.Apply(contract.ApplyRequest{
+		Namespace: DefaultNamespace,
+		Prune:     true,
+		DryRun:    true,
+		Configs:   []contract.Config{{Name: "datacenter-1", Metadata: map[string]interface{}{"region": "us"}}},
+	})
+
+	assert.NoError(t, err, "Unexpected apply error")
+	assert.True(t, resp.DryRun, "Apply was not a dry run")
+	assert.Equal(t, []contract.ApplyChange{
+		{Name: "datacenter-1", Action: contract.ApplyUpdate, Version: 1, Diff: []patch.Operation{
+			{Op: "replace", Path: "/metadata/region", Value: json.RawMessage(`"us"`)}}},
+		{Name: "datacenter-2", Action: contract.ApplyDelete, Version: 1, Diff: []patch.Operation{
+			{Op: "remove", Path: "/metadata"}}},
+	}, resp.Changes, "Incorrect plan")
+	configs, err := manager.Export(DefaultNamespace)
+	require.NoError(t, err, "Unexpected export error")
+	assert.Len(t, configs, 2, "Dry run deleted a config")
+	assert.Equal(t, int64(1), configs[0].Version, "Dry run updated a config")
+}
+
+func TestApplyForInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs []contract.Config
+	}{
+		{"reserved name", []contract.Config{usConfig, {Name: "apply"}}},
+		{"repeated name", []contract.Config{usConfig, usConfig}},
+		{"schema violation", []contract.Config{usConfig, {Name: "datacenter-3", Metadata: map[string]interface{}{}, Schema: "limits"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := newApplyManager(t, euConfig)
+
+			_, err := manager.Apply(contract.ApplyRequest{Namespace: DefaultNamespace, Prune: true, Configs: tt.configs})
+
+			assert.True(t, errors.Is(err, ErrInvalidConfig) || errors.Is(err, ErrSchemaViolation),
+				"Incorrect apply error: %v", err)
+			configs, err := manager.Export(DefaultNamespace)
+			require.NoError(t, err, "Unexpected export error")
+			assert.Len(t, configs, 1, "Part of an invalid apply was written")
+		})
+	}
+}
+
+func TestApplyLocatesViolations(t *testing.T) {
+	manager := newApplyManager(t)
+
+	_, err := manager.Apply(contract.ApplyRequest{
+		Namespace: DefaultNamespace,
+		Configs:   []contract.Config{usConfig, {Name: "datacenter-3", Metadata: map[string]interface{}{}, Schema: "limits"}},
+	})
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr), "Incorrect apply error: %v", err)
+	assert.Equal(t, []schema.Violation{{Path: "/1/metadata/limits", Message: "property is required"}},
+		validationErr.Violations, "Incorrect violations")
+}
+
+func TestApplyForRepoError(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("ApplyAll", "team-a", []model.Config{{Namespace: "team-a", Name: "datacenter-2",
+		Metadata: usConfig.Metadata}}, false, "").Return(nil, db.ErrNamespaceNotFound)
+
+	_, err := manager.Apply(contract.ApplyRequest{Namespace: "team-a", Configs: []contract.Config{usConfig}})
+
+	assert.True(t, errors.Is(err, ErrNamespaceNotFound), "Incorrect apply error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
@@ -1,95 +1,392 @@
 package service
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"jsonstore/pkg/contract"
 	"jsonstore/pkg/db"
 	"jsonstore/pkg/model"
+	"jsonstore/pkg/patch"
+	"jsonstore/pkg/query"
 )
 
+// Manager operates on configs within namespaces. Methods taking a namespace and a name, or a
+// request carrying them, address the config of that name in that namespace.
 type Manager interface {
-	Get(string) (*contract.GetConfigResponse, error)
-	GetAll() ([]contract.GetConfigResponse, error)
-	Search(string, string) ([]contract.GetConfigResponse, error)
+	Get(string, string) (*contract.GetConfigResponse, error)
+	GetAll(string, contract.ListOptions) (*contract.ListConfigsResponse, error)
+	Search(string, string, contract.ListOptions) (*contract.ListConfigsResponse, error)
+	SearchValue(string, string, string, contract.ListOptions) (*contract.ListConfigsResponse, error)
 
-	Upsert(contract.UpsertConfigRequest) error
-	Delete(string) error
+	Create(contract.UpsertConfigRequest) (*contract.GetConfigResponse, error)
+	Upsert(contract.UpsertConfigRequest, contract.Precondition) (*contract.GetConfigResponse, error)
+	Patch(string, string, contract.PatchConfigRequest, contract.Precondition) (*contract.GetConfigResponse, error)
+	Delete(contract.DeleteConfigRequest, contract.Precondition) (*contract.GetRevisionResponse, error)
+	Export(string) ([]contract.GetConfigResponse, error)
+	Import(contract.ImportRequest) (*contract.ImportResponse, error)
+	Apply(contract.ApplyRequest) (*contract.ApplyResponse, error)
+	Transact(contract.TransactionRequest) (*contract.TransactionResponse, error)
+
+	History(string, string) ([]contract.GetRevisionResponse, error)
+	Revision(string, string, int64) (*contract.GetRevisionResponse, error)
+	Rollback(contract.RollbackConfigRequest, contract.Precondition) (*contract.GetConfigResponse, error)
+
+	Indexes() ([]contract.GetIndexResponse, error)
+	CreateIndex(contract.CreateIndexRequest) error
+	DropIndex(string) error
+
+	Schemas() ([]contract.GetSchemaResponse, error)
+	Schema(string) (*contract.GetSchemaResponse, error)
+	PutSchema(contract.PutSchemaRequest) (*contract.GetSchemaResponse, error)
+	DeleteSchema(string) error
+
+	Namespaces() ([]contract.GetNamespaceResponse, error)
+	Namespace(string) (*contract.GetNamespaceResponse, error)
+	CreateNamespace(contract.CreateNamespaceRequest) (*contract.GetNamespaceResponse, error)
+	DeleteNamespace(contract.DeleteNamespaceRequest) error
+
+	Watch(contract.WatchRequest) (*contract.WatchResponse, error)
+
+	Webhooks() ([]contract.GetWebhookResponse, error)
+	Webhook(string) (*contract.GetWebhookResponse, error)
+	CreateWebhook(contract.CreateWebhookRequest) (*contract.GetWebhookResponse, error)
+	DeleteWebhook(string) error
 }
 
 type configManager struct {
-	configRepo db.Config
+	configRepo  db.Config
+	schemaRepo  db.Schema
+	webhookRepo db.Webhook
+	// Compiled schemas of schemaRepo, shared by the copies of the manager
+	schemas *schemaCache
+	// Changes made through the manager, as reported to watchers and posted to webhooks
+	events     *eventBus
+	deliveries *dispatcher
 }
 
-func NewConfigManager(configRepo db.Config) Manager {
-	return configManager{configRepo}
+// NewConfigManager returns a manager of the configs of configRepo. Once webhooks are created, it
+// posts changes to them in the background until closed.
+func NewConfigManager(configRepo db.Config, schemaRepo db.Schema, webhookRepo db.Webhook) Manager {
+	events := newEventBus(time.Now())
+	manager := configManager{configRepo, schemaRepo, webhookRepo, newSchemaCache(), events, newDispatcher(events, webhookRepo)}
+	if webhooks, err := webhookRepo.GetAll(); err == nil && len(webhooks) > 0 {
+		manager.deliveries.start()
+	}
+
+	return manager
 }
 
-func (c configManager) Get(name string) (*contract.GetConfigResponse, error) {
-	item, err := c.configRepo.Get(name)
+// Close stops posting changes to webhooks, dropping the deliveries still pending
+func (c configManager) Close() error {
+	c.deliveries.close()
+	return nil
+}
+
+func (c configManager) Get(namespace, name string) (*contract.GetConfigResponse, error) {
+	item, err := c.configRepo.Get(namespace, name)
 	if err != nil {
 		return nil, fmt.Errorf("select: %w", err)
 	}
 
-	config := contract.Config{
-		Name:     item.Name,
-		Metadata: item.Metadata,
-	}
-	return &contract.GetConfigResponse{Config: config}, nil
+	resp := toResponse(*item)
+	return &resp, nil
 }
 
-func (c configManager) GetAll() ([]contract.GetConfigResponse, error) {
-	all, err := c.configRepo.GetAll()
+// GetAll returns a page of the configs of a namespace as given by the list options
+func (c configManager) GetAll(namespace string, opts contract.ListOptions) (*contract.ListConfigsResponse, error) {
+	l, err := newListing(opts)
 	if err != nil {
 		return nil, fmt.Errorf("select all: %w", err)
 	}
 
-	resp := make([]contract.GetConfigResponse, 0, len(all))
-	for _, item := range all {
-		config := contract.Config{
-			Name:     item.Name,
-			Metadata: item.Metadata,
-		}
-		resp = append(resp, contract.GetConfigResponse{Config: config})
+	all, err := c.configRepo.GetAll(namespace)
+	if err != nil {
+		return nil, fmt.Errorf("select all: %w", err)
+	}
+
+	resp, err := l.page(all)
+	if err != nil {
+		return nil, fmt.Errorf("select all: %w", err)
 	}
 	return resp, nil
 }
 
-func (c configManager) Search(path, value string) ([]contract.GetConfigResponse, error) {
-	all, err := c.configRepo.Search(path, value)
+// Search returns a page of the configs of a namespace matching a filter expression in the syntax
+// of query.Parse
+func (c configManager) Search(namespace, expr string, opts contract.ListOptions) (*contract.ListConfigsResponse, error) {
+	parsed, err := query.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w: %v", ErrInvalidQuery, err)
+	}
+	return c.search(namespace, parsed, opts)
+}
+
+// SearchValue returns a page of the configs of a namespace whose value at a path is a string. The
+// path takes the whole gjson syntax, including the wildcards, queries and modifiers that filter
+// expressions do not accept.
+func (c configManager) SearchValue(namespace, path, value string, opts contract.ListOptions) (*contract.ListConfigsResponse, error) {
+	if path == "" {
+		return nil, fmt.Errorf("search: %w: empty query path", ErrInvalidQuery)
+	}
+	return c.search(namespace, query.Comparison{Path: path, Op: query.OpEq, Value: query.Value{Kind: query.String, Str: value}}, opts)
+}
+
+func (c configManager) search(namespace string, expr query.Expr, opts contract.ListOptions) (*contract.ListConfigsResponse, error) {
+	l, err := newListing(opts)
 	if err != nil {
 		return nil, fmt.Errorf("search: %w", err)
 	}
 
-	resp := make([]contract.GetConfigResponse, 0, len(all))
-	for _, item := range all {
-		config := contract.Config{
-			Name:     item.Name,
-			Metadata: item.Metadata,
+	all, err := c.configRepo.Search(namespace, expr)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+
+	resp, err := l.page(all)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+	return resp, nil
+}
+
+// Create stores a new config and fails with ErrAlreadyExists when the name is already taken
+func (c configManager) Create(req contract.UpsertConfigRequest) (*contract.GetConfigResponse, error) {
+	if err := validateName(req.Config.Name); err != nil {
+		return nil, fmt.Errorf("create: %w", err)
+	}
+
+	item := model.Config{
+		Namespace: req.Namespace,
+		Name:      req.Config.Name,
+		Metadata:  req.Config.Metadata,
+		Schema:    req.Config.Schema,
+		UpdatedBy: req.Author,
+	}
+	if err := c.validateMetadata(item); err != nil {
+		return nil, fmt.Errorf("create: %w", err)
+	}
+	created, err := c.configRepo.Create(item)
+	if err != nil {
+		return nil, fmt.Errorf("create: %w", err)
+	}
+	c.events.put(*created)
+
+	resp := toResponse(*created)
+	return &resp, nil
+}
+
+// Upsert creates or replaces a config, whose metadata must be valid against its schemas
+func (c configManager) Upsert(req contract.UpsertConfigRequest, cond contract.Precondition) (*contract.GetConfigResponse, error) {
+	if err := validateName(req.Config.Name); err != nil {
+		return nil, fmt.Errorf("insert: %w", err)
+	}
+
+	item := model.Config{
+		Namespace: req.Namespace,
+		Name:      req.Config.Name,
+		Metadata:  req.Config.Metadata,
+		Schema:    req.Config.Schema,
+		UpdatedBy: req.Author,
+	}
+	if err := c.validateMetadata(item); err != nil {
+		return nil, fmt.Errorf("insert: %w", err)
+	}
+	stored, err := c.configRepo.Upsert(item, toPrecondition(cond))
+	if err != nil {
+		return nil, fmt.Errorf("insert: %w", err)
+	}
+	c.events.put(*stored)
+
+	resp := toResponse(*stored)
+	return &resp, nil
+}
+
+// Patch applies a JSON Merge Patch or JSON Patch to the representation of the stored config, as
+// returned by Get. The patch is applied atomically, so concurrent writes are never lost and a
+// failing operation leaves the config untouched.
+func (c configManager) Patch(namespace, name string, req contract.PatchConfigRequest, cond contract.Precondition) (*contract.GetConfigResponse, error) {
+	var apply func(interface{}) (interface{}, error)
+	switch req.ContentType {
+	case patch.MergePatchType:
+		var mergePatch interface{}
+		if err := json.Unmarshal(req.Patch, &mergePatch); err != nil {
+			return nil, fmt.Errorf("patch: %w: %v", ErrInvalidPatch, err)
 		}
-		resp = append(resp, contract.GetConfigResponse{Config: config})
+		apply = func(doc interface{}) (interface{}, error) { return patch.Merge(doc, mergePatch), nil }
+	case patch.JSONPatchType:
+		var ops []patch.Operation
+		if err := json.Unmarshal(req.Patch, &ops); err != nil {
+			return nil, fmt.Errorf("patch: %w: %v", ErrInvalidPatch, err)
+		}
+		apply = func(doc interface{}) (interface{}, error) { return patch.Apply(doc, ops) }
+	default:
+		return nil, fmt.Errorf("patch: %w: unsupported content type %q", ErrInvalidPatch, req.ContentType)
 	}
-	return resp, err
+
+	item, err := c.configRepo.Update(namespace, name, toPrecondition(cond), func(current model.Config) (model.Config, error) {
+		doc, err := toDocument(current)
+		if err != nil {
+			return model.Config{}, err
+		}
+		patched, err := apply(doc)
+		if errors.Is(err, patch.ErrTestFailed) {
+			return model.Config{}, fmt.Errorf("%w: %v", ErrPatchTestFailed, err)
+		}
+		if err != nil {
+			return model.Config{}, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+
+		var next contract.Config
+		if err := remarshal(patched, &next); err != nil {
+			return model.Config{}, fmt.Errorf("%w: patched document is not a config: %v", ErrInvalidPatch, err)
+		}
+		if next.Name != current.Name {
+			return model.Config{}, fmt.Errorf("%w: config name cannot be changed", ErrInvalidPatch)
+		}
+
+		current.Metadata, current.Schema, current.UpdatedBy = next.Metadata, next.Schema, req.Author
+		if err := c.validateMetadata(current); err != nil {
+			return model.Config{}, err
+		}
+		return current, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("patch: %w", err)
+	}
+	c.events.put(*item)
+
+	resp := toResponse(*item)
+	return &resp, nil
 }
 
-func (c configManager) Upsert(req contract.UpsertConfigRequest) error {
+// Delete removes a config and returns the revision recording its deletion
+func (c configManager) Delete(req contract.DeleteConfigRequest, cond contract.Precondition) (*contract.GetRevisionResponse, error) {
+	revision, err := c.configRepo.Delete(req.Namespace, req.Name, toPrecondition(cond), req.Author)
+	if err != nil {
+		return nil, fmt.Errorf("delete: %w", err)
+	}
+	c.events.delete(*revision)
+
+	resp := toRevisionResponse(*revision)
+	return &resp, nil
+}
+
+func (c configManager) History(namespace, name string) ([]contract.GetRevisionResponse, error) {
+	history, err := c.configRepo.History(namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("history: %w", err)
+	}
+
+	resp := make([]contract.GetRevisionResponse, 0, len(history))
+	for _, revision := range history {
+		resp = append(resp, toRevisionResponse(revision))
+	}
+	return resp, nil
+}
+
+func (c configManager) Revision(namespace, name string, version int64) (*contract.GetRevisionResponse, error) {
+	revision, err := c.configRepo.Revision(namespace, name, version)
+	if err != nil {
+		return nil, fmt.Errorf("revision: %w", err)
+	}
+
+	resp := toRevisionResponse(*revision)
+	return &resp, nil
+}
+
+// Rollback writes the metadata of a past revision as a new revision of the config, which also
+// restores a deleted config. Revisions recording a deletion cannot be rolled back to.
+func (c configManager) Rollback(req contract.RollbackConfigRequest, cond contract.Precondition) (*contract.GetConfigResponse, error) {
+	revision, err := c.configRepo.Revision(req.Namespace, req.Name, req.Version)
+	if err != nil {
+		return nil, fmt.Errorf("rollback: %w", err)
+	}
+	if revision.Deleted {
+		return nil, fmt.Errorf("rollback: %w: revision %d is a deletion", ErrInvalidRollback, req.Version)
+	}
+
 	item := model.Config{
-		Name:     req.Config.Name,
-		Metadata: req.Config.Metadata,
+		Namespace: req.Namespace,
+		Name:      revision.Name,
+		Metadata:  revision.Metadata,
+		Schema:    revision.Schema,
+		UpdatedBy: req.Author,
 	}
-	err := c.configRepo.Upsert(item)
+	if err := c.validateMetadata(item); err != nil {
+		return nil, fmt.Errorf("rollback: %w", err)
+	}
+	stored, err := c.configRepo.Upsert(item, toPrecondition(cond))
 	if err != nil {
-		return fmt.Errorf("insert: %w", err)
+		return nil, fmt.Errorf("rollback: %w", err)
 	}
+	c.events.put(*stored)
 
-	return nil
+	resp := toResponse(*stored)
+	return &resp, nil
+}
+
+func toResponse(item model.Config) contract.GetConfigResponse {
+	resp := contract.GetConfigResponse{
+		Namespace: item.Namespace,
+		Config: contract.Config{
+			Name:     item.Name,
+			Metadata: item.Metadata,
+			Schema:   item.Schema,
+		},
+		Version:   item.Version,
+		UpdatedBy: item.UpdatedBy,
+	}
+	if !item.CreatedAt.IsZero() {
+		resp.CreatedAt = &item.CreatedAt
+	}
+	if !item.UpdatedAt.IsZero() {
+		resp.UpdatedAt = &item.UpdatedAt
+	}
+
+	return resp
+}
+
+func toRevisionResponse(revision model.Revision) contract.GetRevisionResponse {
+	return contract.GetRevisionResponse{
+		Namespace: revision.Namespace,
+		Name:      revision.Name,
+		Version:   revision.Version,
+		Timestamp: revision.Timestamp,
+		Author:    revision.Author,
+		Deleted:   revision.Deleted,
+		Metadata:  revision.Metadata,
+		Schema:    revision.Schema,
+	}
+}
+
+func toPrecondition(cond contract.Precondition) db.Precondition {
+	return db.Precondition{
+		Match:        cond.IfMatch,
+		MatchAny:     cond.IfMatchAny,
+		NoneMatch:    cond.IfNoneMatch,
+		NoneMatchAny: cond.IfNoneMatchAny,
+	}
 }
 
-func (c configManager) Delete(name string) error {
-	err := c.configRepo.Delete(name)
+// toDocument returns a deep copy of the config representation as generic JSON values, so that it
+// can be patched without modifying the stored config
+func toDocument(config model.Config) (interface{}, error) {
+	var doc interface{}
+	if err := remarshal(contract.Config{Name: config.Name, Metadata: config.Metadata, Schema: config.Schema}, &doc); err != nil {
+		return nil, fmt.Errorf("parse stored data: %w", err)
+	}
+
+	return doc, nil
+}
+
+func remarshal(in, out interface{}) error {
+	data, err := json.Marshal(in)
 	if err != nil {
-		return fmt.Errorf("delete: %w", err)
+		return err
 	}
 
-	return nil
+	return json.Unmarshal(data, out)
 }
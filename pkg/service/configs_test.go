@@ -3,12 +3,17 @@ package service
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
 	"jsonstore/pkg/model"
+	"jsonstore/pkg/patch"
+	"jsonstore/pkg/query"
 	"jsonstore/pkg/testlib/mocks"
 )
 
@@ -52,11 +57,11 @@ var (
 
 func TestGet(t *testing.T) {
 	configRepo := new(mocks.Config)
-	manager := NewConfigManager(configRepo)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
 
-	configRepo.On("Get", "datacenter-1").Return(&dc1Item, nil)
+	configRepo.On("Get", model.DefaultNamespace, "datacenter-1").Return(&dc1Item, nil)
 
-	config, err := manager.Get("datacenter-1")
+	config, err := manager.Get(DefaultNamespace, "datacenter-1")
 
 	assert.NoError(t, err, "Unexpected get config error")
 	assert.Equal(t, &dc1GetResp, config, "Incorrect config value")
@@ -65,38 +70,52 @@ func TestGet(t *testing.T) {
 
 func TestGetForRepoError(t *testing.T) {
 	configRepo := new(mocks.Config)
-	manager := NewConfigManager(configRepo)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
 
-	configRepo.On("Get", "datacenter-1").Return(nil, errors.New("some error"))
+	configRepo.On("Get", model.DefaultNamespace, "datacenter-1").Return(nil, errors.New("some error"))
 
-	_, err := manager.Get("datacenter-1")
+	_, err := manager.Get(DefaultNamespace, "datacenter-1")
 
 	assert.Errorf(t, err, "Missing get config error")
 	assert.Contains(t, err.Error(), "select:", "Incorrect get config error")
 	mock.AssertExpectationsForObjects(t, configRepo)
 }
 
+func TestGetForMissingConfig(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("Get", model.DefaultNamespace, "datacenter-1").Return(nil, db.ErrNotFound)
+
+	_, err := manager.Get(DefaultNamespace, "datacenter-1")
+
+	assert.Errorf(t, err, "Missing get config error")
+	assert.True(t, errors.Is(err, ErrNotFound), "Incorrect get config error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
 func TestGetAll(t *testing.T) {
 	configRepo := new(mocks.Config)
-	manager := NewConfigManager(configRepo)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
 
 	all := []model.Config{dc1Item, dc2Item}
-	configRepo.On("GetAll").Return(all, nil)
+	configRepo.On("GetAll", model.DefaultNamespace).Return(all, nil)
 
-	configs, err := manager.GetAll()
+	configs, err := manager.GetAll(DefaultNamespace, contract.ListOptions{})
 
 	assert.NoError(t, err, "Unexpected get all configs error")
-	assert.Equal(t, []contract.GetConfigResponse{dc1GetResp, dc2GetResp}, configs, "Incorrect configs value")
+	assert.Equal(t, &contract.ListConfigsResponse{Configs: []contract.GetConfigResponse{dc1GetResp, dc2GetResp}}, configs,
+		"Incorrect configs value")
 	mock.AssertExpectationsForObjects(t, configRepo)
 }
 
 func TestGetAllForRepoError(t *testing.T) {
 	configRepo := new(mocks.Config)
-	manager := NewConfigManager(configRepo)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
 
-	configRepo.On("GetAll").Return(nil, errors.New("some error"))
+	configRepo.On("GetAll", model.DefaultNamespace).Return(nil, errors.New("some error"))
 
-	_, err := manager.GetAll()
+	_, err := manager.GetAll(DefaultNamespace, contract.ListOptions{})
 
 	assert.Errorf(t, err, "Missing get all configs error")
 	assert.Contains(t, err.Error(), "select all:", "Incorrect get all configs error")
@@ -105,77 +124,445 @@ func TestGetAllForRepoError(t *testing.T) {
 
 func TestSearch(t *testing.T) {
 	configRepo := new(mocks.Config)
-	manager := NewConfigManager(configRepo)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
 
 	all := []model.Config{dc1Item, dc2Item}
-	configRepo.On("Search", "metadata.monitoring.enabled", "true").Return(all, nil)
+	configRepo.On("Search", model.DefaultNamespace, query.Comparison{
+		Path:  "metadata.monitoring.enabled",
+		Op:    query.OpEq,
+		Value: query.Value{Kind: query.String, Str: "true"},
+	}).Return(all, nil)
 
-	configs, err := manager.Search("metadata.monitoring.enabled", "true")
+	configs, err := manager.Search(DefaultNamespace, `metadata.monitoring.enabled = "true"`, contract.ListOptions{})
 
 	assert.NoError(t, err, "Unexpected get all configs error")
-	assert.Equal(t, []contract.GetConfigResponse{dc1GetResp, dc2GetResp}, configs, "Incorrect configs value")
+	assert.Equal(t, &contract.ListConfigsResponse{Configs: []contract.GetConfigResponse{dc1GetResp, dc2GetResp}}, configs,
+		"Incorrect configs value")
 	mock.AssertExpectationsForObjects(t, configRepo)
 }
 
 func TestSearchForRepoError(t *testing.T) {
 	configRepo := new(mocks.Config)
-	manager := NewConfigManager(configRepo)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
 
-	configRepo.On("Search", "metadata.monitoring.enabled", "true").Return(nil, errors.New("some error"))
+	configRepo.On("Search", model.DefaultNamespace, mock.Anything).Return(nil, errors.New("some error"))
 
-	_, err := manager.Search("metadata.monitoring.enabled", "true")
+	_, err := manager.Search(DefaultNamespace, `metadata.monitoring.enabled = "true"`, contract.ListOptions{})
 
 	assert.Errorf(t, err, "Missing get all configs error")
 	assert.Contains(t, err.Error(), "search:", "Incorrect get all configs error")
 	mock.AssertExpectationsForObjects(t, configRepo)
 }
 
+func TestSearchForSyntaxError(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	_, err := manager.Search(DefaultNamespace, `metadata.monitoring.enabled = true AND`, contract.ListOptions{})
+
+	assert.Errorf(t, err, "Missing search configs error")
+	assert.True(t, errors.Is(err, ErrInvalidQuery), "Incorrect search configs error")
+	assert.Contains(t, err.Error(), "syntax error at position 39", "Incorrect search configs error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestSearchValueForGJSONPath(t *testing.T) {
+	manager := NewConfigManager(db.NewConfigRepo(), db.NewSchemaRepo(), db.NewWebhookRepo())
+	for name, metadata := range map[string]interface{}{
+		"datacenter-1": map[string]interface{}{"hosts": []interface{}{map[string]interface{}{"name": "db-1", "zone": "a"}},
+			"team.name": "storage"},
+		"datacenter-2": map[string]interface{}{"hosts": []interface{}{map[string]interface{}{"name": "db-1", "zone": "b"}},
+			"team.name": "compute"},
+	} {
+		_, err := manager.Create(contract.UpsertConfigRequest{Namespace: DefaultNamespace,
+			Config: contract.Config{Name: name, Metadata: metadata}})
+		require.NoError(t, err, "Unexpected create error")
+	}
+	tests := []struct {
+		path, value string
+	}{
+		{`metadata.hosts.#(zone=="a").name`, "db-1"},
+		{`metadata.hosts|@reverse|0.zone`, "a"},
+		{`metadata.team\.name`, "storage"},
+		{`metadata.t*`, "storage"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := manager.SearchValue(DefaultNamespace, tt.path, tt.value, contract.ListOptions{})
+
+			require.NoError(t, err, "Unexpected search configs error")
+			require.Len(t, resp.Configs, 1, "Incorrect number of configs")
+			assert.Equal(t, "datacenter-1", resp.Configs[0].Name, "Incorrect config")
+		})
+	}
+}
+
+func TestSearchValueForEmptyPath(t *testing.T) {
+	manager := NewConfigManager(new(mocks.Config), db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	_, err := manager.SearchValue(DefaultNamespace, "", "true", contract.ListOptions{})
+
+	assert.True(t, errors.Is(err, ErrInvalidQuery), "Incorrect search configs error")
+}
+
+func TestCreate(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("Create", dc1Item).Return(&dc1Item, nil)
+
+	config, err := manager.Create(dc1CreateReq)
+
+	assert.NoError(t, err, "Unexpected create config error")
+	assert.Equal(t, &dc1GetResp, config, "Incorrect config value")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestCreateForExistingConfig(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("Create", dc1Item).Return(nil, db.ErrAlreadyExists)
+
+	_, err := manager.Create(dc1CreateReq)
+
+	assert.Errorf(t, err, "Missing create config error")
+	assert.True(t, errors.Is(err, ErrAlreadyExists), "Incorrect create config error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestCreateForInvalidName(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	_, err := manager.Create(contract.UpsertConfigRequest{Config: contract.Config{Name: "data center"}})
+
+	assert.Errorf(t, err, "Missing create config error")
+	assert.True(t, errors.Is(err, ErrInvalidConfig), "Incorrect create config error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
 func TestUpsert(t *testing.T) {
 	configRepo := new(mocks.Config)
-	manager := NewConfigManager(configRepo)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
 
-	configRepo.On("Upsert", dc1Item).Return(nil)
+	stored := dc1Item
+	stored.Version = 3
+	stored.CreatedAt = time.Date(2021, time.September, 1, 10, 0, 0, 0, time.UTC)
+	stored.UpdatedAt = stored.CreatedAt.Add(time.Hour)
+	configRepo.On("Upsert", dc1Item, db.Precondition{Match: []int64{2}}).Return(&stored, nil)
 
-	err := manager.Upsert(dc1CreateReq)
+	config, err := manager.Upsert(dc1CreateReq, contract.Precondition{IfMatch: []int64{2}})
 
 	assert.NoError(t, err, "Unexpected upsert config error")
+	assert.Equal(t, &contract.GetConfigResponse{
+		Config:    dc1Data,
+		Version:   3,
+		CreatedAt: &stored.CreatedAt,
+		UpdatedAt: &stored.UpdatedAt,
+	}, config, "Incorrect config value")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestUpsertForFailedPrecondition(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("Upsert", dc1Item, db.Precondition{NoneMatchAny: true}).Return(nil, db.ErrPreconditionFailed)
+
+	_, err := manager.Upsert(dc1CreateReq, contract.Precondition{IfNoneMatchAny: true})
+
+	assert.Errorf(t, err, "Missing upsert config error")
+	assert.True(t, errors.Is(err, ErrPreconditionFailed), "Incorrect upsert config error")
 	mock.AssertExpectationsForObjects(t, configRepo)
 }
 
 func TestUpsertForRepoError(t *testing.T) {
 	configRepo := new(mocks.Config)
-	manager := NewConfigManager(configRepo)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
 
-	configRepo.On("Upsert", dc1Item).Return(errors.New("some error"))
+	configRepo.On("Upsert", dc1Item, db.Precondition{}).Return(nil, errors.New("some error"))
 
-	err := manager.Upsert(dc1CreateReq)
+	_, err := manager.Upsert(dc1CreateReq, contract.Precondition{})
 
 	assert.Errorf(t, err, "Missing upsert config error")
 	assert.Contains(t, err.Error(), "insert:", "Incorrect upsert config error")
 	mock.AssertExpectationsForObjects(t, configRepo)
 }
 
+func TestUpsertForMissingName(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	_, err := manager.Upsert(contract.UpsertConfigRequest{Config: contract.Config{Metadata: []byte(dc1)}}, contract.Precondition{})
+
+	assert.Errorf(t, err, "Missing upsert config error")
+	assert.True(t, errors.Is(err, ErrInvalidConfig), "Incorrect upsert config error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+// mockUpdate expects an Update of the named config and runs the update function against current
+func mockUpdate(configRepo *mocks.Config, name string, current model.Config) {
+	var next model.Config
+	var err error
+	configRepo.On("Update", model.DefaultNamespace, name, db.Precondition{}, mock.Anything).
+		Run(func(args mock.Arguments) { next, err = args.Get(3).(db.UpdateFunc)(current) }).
+		Return(
+			func(string, string, db.Precondition, db.UpdateFunc) *model.Config {
+				if err != nil {
+					return nil
+				}
+				return &next
+			},
+			func(string, string, db.Precondition, db.UpdateFunc) error { return err },
+		)
+}
+
+func TestPatchWithMergePatch(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+	current := model.Config{Name: "datacenter-1", Metadata: map[string]interface{}{
+		"monitoring": map[string]interface{}{"enabled": "true"},
+		"limits":     map[string]interface{}{"cpu": "300m"},
+	}}
+
+	mockUpdate(configRepo, "datacenter-1", current)
+
+	config, err := manager.Patch(DefaultNamespace, "datacenter-1", contract.PatchConfigRequest{
+		ContentType: patch.MergePatchType,
+		Patch:       []byte(`{"metadata":{"monitoring":{"enabled":"false"},"limits":null}}`),
+	}, contract.Precondition{})
+
+	assert.NoError(t, err, "Unexpected patch config error")
+	assert.Equal(t, &contract.GetConfigResponse{Config: contract.Config{
+		Name:     "datacenter-1",
+		Metadata: map[string]interface{}{"monitoring": map[string]interface{}{"enabled": "false"}},
+	}}, config, "Incorrect patched config")
+	assert.Equal(t, map[string]interface{}{"cpu": "300m"}, current.Metadata.(map[string]interface{})["limits"],
+		"Stored config was modified in place")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestPatchWithJSONPatch(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+	current := model.Config{Name: "datacenter-1", Metadata: map[string]interface{}{"regions": []interface{}{"eu"}}}
+
+	mockUpdate(configRepo, "datacenter-1", current)
+
+	config, err := manager.Patch(DefaultNamespace, "datacenter-1", contract.PatchConfigRequest{
+		ContentType: patch.JSONPatchType,
+		Patch: []byte(`[{"op":"test","path":"/metadata/regions/0","value":"eu"},
+			{"op":"add","path":"/metadata/regions/-","value":"us"}]`),
+	}, contract.Precondition{})
+
+	assert.NoError(t, err, "Unexpected patch config error")
+	assert.Equal(t, &contract.GetConfigResponse{Config: contract.Config{
+		Name:     "datacenter-1",
+		Metadata: map[string]interface{}{"regions": []interface{}{"eu", "us"}},
+	}}, config, "Incorrect patched config")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestPatchForFailedTestOperation(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+	current := model.Config{Name: "datacenter-1", Metadata: map[string]interface{}{"regions": []interface{}{"eu"}}}
+
+	mockUpdate(configRepo, "datacenter-1", current)
+
+	_, err := manager.Patch(DefaultNamespace, "datacenter-1", contract.PatchConfigRequest{
+		ContentType: patch.JSONPatchType,
+		Patch:       []byte(`[{"op":"test","path":"/metadata/regions/0","value":"us"}]`),
+	}, contract.Precondition{})
+
+	assert.Errorf(t, err, "Missing patch config error")
+	assert.True(t, errors.Is(err, ErrPatchTestFailed), "Incorrect patch config error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestPatchForRenamedConfig(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	mockUpdate(configRepo, "datacenter-1", dc1Item)
+
+	_, err := manager.Patch(DefaultNamespace, "datacenter-1", contract.PatchConfigRequest{
+		ContentType: patch.MergePatchType,
+		Patch:       []byte(`{"name":"datacenter-2"}`),
+	}, contract.Precondition{})
+
+	assert.Errorf(t, err, "Missing patch config error")
+	assert.True(t, errors.Is(err, ErrInvalidPatch), "Incorrect patch config error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestPatchForUnsupportedContentType(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	_, err := manager.Patch(DefaultNamespace, "datacenter-1", contract.PatchConfigRequest{ContentType: "text/plain", Patch: []byte(`{}`)}, contract.Precondition{})
+
+	assert.Errorf(t, err, "Missing patch config error")
+	assert.True(t, errors.Is(err, ErrInvalidPatch), "Incorrect patch config error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestPatchForMissingConfig(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("Update", model.DefaultNamespace, "datacenter-1", db.Precondition{}, mock.Anything).Return(nil, db.ErrNotFound)
+
+	_, err := manager.Patch(DefaultNamespace, "datacenter-1", contract.PatchConfigRequest{ContentType: patch.MergePatchType, Patch: []byte(`{}`)}, contract.Precondition{})
+
+	assert.Errorf(t, err, "Missing patch config error")
+	assert.True(t, errors.Is(err, ErrNotFound), "Incorrect patch config error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
 func TestDelete(t *testing.T) {
 	configRepo := new(mocks.Config)
-	manager := NewConfigManager(configRepo)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
 
-	configRepo.On("Delete", "datacenter-1").Return(nil)
+	configRepo.On("Delete", model.DefaultNamespace, "datacenter-1", db.Precondition{Match: []int64{1}}, "alice").Return(
+		&model.Revision{Name: "datacenter-1", Version: 2, Author: "alice", Deleted: true}, nil)
 
-	err := manager.Delete("datacenter-1")
+	revision, err := manager.Delete(contract.DeleteConfigRequest{Namespace: DefaultNamespace, Name: "datacenter-1", Author: "alice"},
+		contract.Precondition{IfMatch: []int64{1}})
 
 	assert.NoError(t, err, "Unexpected delete config error")
+	assert.Equal(t, &contract.GetRevisionResponse{Name: "datacenter-1", Version: 2, Author: "alice", Deleted: true}, revision,
+		"Incorrect deletion revision")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestDeleteForMissingConfig(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("Delete", model.DefaultNamespace, "datacenter-1", db.Precondition{}, "").Return(nil, db.ErrNotFound)
+
+	_, err := manager.Delete(contract.DeleteConfigRequest{Namespace: DefaultNamespace, Name: "datacenter-1"}, contract.Precondition{})
+
+	assert.Errorf(t, err, "Missing delete config error")
+	assert.True(t, errors.Is(err, ErrNotFound), "Incorrect delete config error")
 	mock.AssertExpectationsForObjects(t, configRepo)
 }
 
 func TestDeleteForRepoError(t *testing.T) {
 	configRepo := new(mocks.Config)
-	manager := NewConfigManager(configRepo)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
 
-	configRepo.On("Delete", "datacenter-1").Return(errors.New("some error"))
+	configRepo.On("Delete", model.DefaultNamespace, "datacenter-1", db.Precondition{}, "").Return(nil, errors.New("some error"))
 
-	err := manager.Delete("datacenter-1")
+	_, err := manager.Delete(contract.DeleteConfigRequest{Namespace: DefaultNamespace, Name: "datacenter-1"}, contract.Precondition{})
 
 	assert.Errorf(t, err, "Missing delete config error")
 	assert.Contains(t, err.Error(), "delete:", "Incorrect delete config error")
 	mock.AssertExpectationsForObjects(t, configRepo)
 }
+
+func TestHistory(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+	timestamp := time.Date(2021, time.September, 1, 10, 0, 0, 0, time.UTC)
+
+	configRepo.On("History", model.DefaultNamespace, "datacenter-1").Return([]model.Revision{
+		{Name: "datacenter-1", Version: 1, Timestamp: timestamp, Author: "alice", Metadata: []byte(dc1)},
+		{Name: "datacenter-1", Version: 2, Timestamp: timestamp, Author: "bob", Deleted: true},
+	}, nil)
+
+	history, err := manager.History(DefaultNamespace, "datacenter-1")
+
+	assert.NoError(t, err, "Unexpected history error")
+	assert.Equal(t, []contract.GetRevisionResponse{
+		{Name: "datacenter-1", Version: 1, Timestamp: timestamp, Author: "alice", Metadata: []byte(dc1)},
+		{Name: "datacenter-1", Version: 2, Timestamp: timestamp, Author: "bob", Deleted: true},
+	}, history, "Incorrect history")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestHistoryForMissingConfig(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("History", model.DefaultNamespace, "datacenter-1").Return(nil, db.ErrNotFound)
+
+	_, err := manager.History(DefaultNamespace, "datacenter-1")
+
+	assert.Errorf(t, err, "Missing history error")
+	assert.True(t, errors.Is(err, ErrNotFound), "Incorrect history error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestRevision(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("Revision", model.DefaultNamespace, "datacenter-1", int64(1)).
+		Return(&model.Revision{Name: "datacenter-1", Version: 1, Metadata: []byte(dc1)}, nil)
+
+	revision, err := manager.Revision(DefaultNamespace, "datacenter-1", 1)
+
+	assert.NoError(t, err, "Unexpected revision error")
+	assert.Equal(t, &contract.GetRevisionResponse{Name: "datacenter-1", Version: 1, Metadata: []byte(dc1)}, revision,
+		"Incorrect revision")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestRollback(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("Revision", model.DefaultNamespace, "datacenter-1", int64(1)).
+		Return(&model.Revision{Name: "datacenter-1", Version: 1, Author: "alice", Metadata: []byte(dc1)}, nil)
+	configRepo.On("Upsert", model.Config{
+		Namespace: model.DefaultNamespace,
+		Name:      "datacenter-1",
+		Metadata:  []byte(dc1),
+		UpdatedBy: "bob",
+	}, db.Precondition{Match: []int64{3}}).
+		Return(&model.Config{Name: "datacenter-1", Metadata: []byte(dc1), Version: 4, UpdatedBy: "bob"}, nil)
+
+	config, err := manager.Rollback(contract.RollbackConfigRequest{
+		Namespace: DefaultNamespace,
+		Name:      "datacenter-1",
+		Version:   1,
+		Author:    "bob",
+	}, contract.Precondition{IfMatch: []int64{3}})
+
+	assert.NoError(t, err, "Unexpected rollback error")
+	assert.Equal(t, &contract.GetConfigResponse{Config: dc1Data, Version: 4, UpdatedBy: "bob"}, config,
+		"Incorrect config value")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestRollbackToDeletion(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("Revision", model.DefaultNamespace, "datacenter-1", int64(2)).
+		Return(&model.Revision{Name: "datacenter-1", Version: 2, Deleted: true}, nil)
+
+	_, err := manager.Rollback(contract.RollbackConfigRequest{Namespace: DefaultNamespace, Name: "datacenter-1", Version: 2},
+		contract.Precondition{})
+
+	assert.Errorf(t, err, "Missing rollback error")
+	assert.True(t, errors.Is(err, ErrInvalidRollback), "Incorrect rollback error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestRollbackForMissingRevision(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("Revision", model.DefaultNamespace, "datacenter-1", int64(5)).Return(nil, db.ErrRevisionNotFound)
+
+	_, err := manager.Rollback(contract.RollbackConfigRequest{Namespace: DefaultNamespace, Name: "datacenter-1", Version: 5}, contract.Precondition{})
+
+	assert.Errorf(t, err, "Missing rollback error")
+	assert.True(t, errors.Is(err, ErrRevisionNotFound), "Incorrect rollback error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
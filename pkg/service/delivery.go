@@ -0,0 +1,306 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/model"
+	"jsonstore/pkg/prometheus"
+	"jsonstore/pkg/query"
+)
+
+// Headers of the requests posting an event to a webhook
+const (
+	WebhookIDHeader = "X-Jsonstore-Webhook"
+	EventTypeHeader = "X-Jsonstore-Event"
+	// Revision of the event, which identifies a delivery across its attempts
+	DeliveryHeader = "X-Jsonstore-Delivery"
+	// Hex encoded HMAC-SHA256 of the payload keyed by the secret of the webhook, prefixed by "sha256="
+	SignatureHeader = "X-Jsonstore-Signature"
+)
+
+const (
+	deliveryWorkers   = 4
+	deliveryQueueSize = 1000
+	deliveryAttempts  = 5
+	deliveryTimeout   = 10 * time.Second
+	// Delay before the second attempt of a delivery, doubled for every further attempt
+	deliveryBackoff = time.Second
+
+	// Service label of the delivery metrics
+	metricsService = "jsonstore"
+)
+
+type delivery struct {
+	webhook model.Webhook
+	event   contract.Event
+	// Number of attempts made so far
+	attempts int
+}
+
+// dispatcher posts the events of the bus to the webhooks they match. Deliveries are made by a
+// pool of workers and retried with an exponential backoff, so events may reach a webhook out of
+// order; receivers order them by revision. Deliveries are kept in memory and lost on restart.
+type dispatcher struct {
+	events   *eventBus
+	webhooks db.Webhook
+	client   *http.Client
+	backoff  time.Duration
+
+	queue   chan delivery
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started sync.Once
+	wg      sync.WaitGroup
+
+	mu       sync.Mutex
+	statuses map[string]contract.WebhookDeliveries
+}
+
+func newDispatcher(events *eventBus, webhooks db.Webhook) *dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &dispatcher{
+		events:   events,
+		webhooks: webhooks,
+		client:   &http.Client{Timeout: deliveryTimeout},
+		backoff:  deliveryBackoff,
+		queue:    make(chan delivery, deliveryQueueSize),
+		ctx:      ctx,
+		cancel:   cancel,
+		statuses: map[string]contract.WebhookDeliveries{},
+	}
+}
+
+// start follows the bus from its current revision, once and until close
+func (d *dispatcher) start() {
+	d.started.Do(func() {
+		_, revision, changed, _ := d.events.since(0)
+		d.wg.Add(deliveryWorkers + 1)
+		go d.follow(revision, changed)
+		for i := 0; i < deliveryWorkers; i++ {
+			go d.work()
+		}
+	})
+}
+
+// close stops following the bus and waits for the attempts in progress, dropping the pending
+// deliveries
+func (d *dispatcher) close() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+func (d *dispatcher) follow(revision int64, changed <-chan struct{}) {
+	defer d.wg.Done()
+
+	// Parsed filter expressions by their source, as webhooks are only created and deleted
+	parsed := map[string]query.Expr{}
+	for {
+		select {
+		case <-changed:
+		case <-d.ctx.Done():
+			return
+		}
+
+		events, head, next, err := d.events.since(revision)
+		if errors.Is(err, ErrWatchExpired) {
+			zap.S().Errorf("Webhooks: changes after revision %d were missed", revision)
+			prometheus.WebhookDeliveryCounter.WithLabelValues(metricsService, "dropped").Inc()
+			revision = 0
+			events, head, next, _ = d.events.since(revision)
+		}
+
+		// The revision is left as is, and changed closed, so that the events are fetched again
+		webhooks, err := d.webhooks.GetAll()
+		if err != nil {
+			zap.S().Errorf("Webhooks: get webhooks: %v", err)
+			select {
+			case <-time.After(d.backoff):
+			case <-d.ctx.Done():
+				return
+			}
+			continue
+		}
+		revision, changed = head, next
+		for _, event := range events {
+			for _, webhook := range webhooks {
+				if matchesWebhook(webhook, event, parsed) {
+					d.enqueue(delivery{webhook: webhook, event: event})
+				}
+			}
+		}
+	}
+}
+
+// matchesWebhook tells whether an event is to be posted to a webhook. The filter expression
+// cannot be evaluated for deletions, which are posted regardless.
+func matchesWebhook(webhook model.Webhook, event contract.Event, parsed map[string]query.Expr) bool {
+	if event.Namespace != canonicalNamespace(webhook.Namespace) || !strings.HasPrefix(event.Name, webhook.Prefix) {
+		return false
+	}
+	if webhook.Query == "" || event.Config == nil {
+		return true
+	}
+
+	expr, ok := parsed[webhook.Query]
+	if !ok {
+		var err error
+		if expr, err = query.Parse(webhook.Query); err != nil {
+			zap.S().Errorf("Webhook %s: parse query: %v", webhook.ID, err)
+			return false
+		}
+		parsed[webhook.Query] = expr
+	}
+	doc, err := json.Marshal(event.Config)
+	if err != nil {
+		zap.S().Errorf("Webhook %s: encode config %s: %v", webhook.ID, event.Name, err)
+		return false
+	}
+	return db.Matches(expr, doc)
+}
+
+// enqueue schedules a delivery, which is dropped when the queue is full or the dispatcher closed
+func (d *dispatcher) enqueue(del delivery) {
+	select {
+	case d.queue <- del:
+	case <-d.ctx.Done():
+	default:
+		zap.S().Errorf("Webhook %s: queue full, dropping event %d", del.webhook.ID, del.event.Revision)
+		prometheus.WebhookDeliveryCounter.WithLabelValues(metricsService, "dropped").Inc()
+		d.record(del.webhook.ID, func(status *contract.WebhookDeliveries) { status.Failed++ })
+	}
+}
+
+func (d *dispatcher) work() {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case del := <-d.queue:
+			d.deliver(del)
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver makes an attempt at a delivery and schedules the next one when it fails
+func (d *dispatcher) deliver(del delivery) {
+	// Deliveries outlive the webhooks deleted since they were scheduled
+	if _, err := d.webhooks.Get(del.webhook.ID); err != nil {
+		return
+	}
+
+	start := time.Now()
+	del.attempts++
+	status, err := d.post(del)
+	prometheus.WebhookDeliveryLatencyHistogram.WithLabelValues(metricsService).Observe(time.Since(start).Seconds())
+	if d.ctx.Err() != nil {
+		return
+	}
+
+	result := "delivered"
+	switch {
+	case err == nil:
+	case del.attempts < deliveryAttempts:
+		result = "retried"
+		zap.S().Warnf("Webhook %s: attempt %d at event %d: %v", del.webhook.ID, del.attempts, del.event.Revision, err)
+		time.AfterFunc(d.backoff<<(del.attempts-1), func() { d.enqueue(del) })
+	default:
+		result = "failed"
+		zap.S().Errorf("Webhook %s: giving up on event %d: %v", del.webhook.ID, del.event.Revision, err)
+	}
+	prometheus.WebhookDeliveryCounter.WithLabelValues(metricsService, result).Inc()
+
+	d.record(del.webhook.ID, func(s *contract.WebhookDeliveries) {
+		at := start.UTC()
+		s.LastAttemptAt, s.LastStatus, s.LastError = &at, status, ""
+		switch result {
+		case "delivered":
+			s.Delivered++
+		case "failed":
+			s.Failed++
+		}
+		if err != nil {
+			s.LastError = err.Error()
+		}
+	})
+}
+
+// post sends an event to a webhook and returns the status of the response, which must be 2xx
+func (d *dispatcher) post(del delivery) (int, error) {
+	payload, err := json.Marshal(del.event)
+	if err != nil {
+		return 0, fmt.Errorf("encode event: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, del.webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+	req = req.WithContext(d.ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookIDHeader, del.webhook.ID)
+	req.Header.Set(EventTypeHeader, del.event.Type)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(del.event.Revision, 10))
+	req.Header.Set(SignatureHeader, "sha256="+sign(del.webhook.Secret, payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drained so that the connection is reused
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook replied with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *dispatcher) record(id string, update func(*contract.WebhookDeliveries)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	status := d.statuses[id]
+	update(&status)
+	d.statuses[id] = status
+}
+
+func (d *dispatcher) status(id string) contract.WebhookDeliveries {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.statuses[id]
+}
+
+func (d *dispatcher) forget(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.statuses, id)
+}
+
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
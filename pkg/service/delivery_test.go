@@ -0,0 +1,191 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/model"
+)
+
+type receivedEvent struct {
+	header http.Header
+	body   []byte
+}
+
+// newReceiver returns a webhook receiver answering with the given statuses in turn, then with 200
+func newReceiver(t *testing.T, statuses ...int) (*httptest.Server, <-chan receivedEvent) {
+	received := make(chan receivedEvent, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err, "Unexpected read body error")
+		received <- receivedEvent{header: r.Header, body: body}
+		if len(statuses) > 0 {
+			w.WriteHeader(statuses[0])
+			statuses = statuses[1:]
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func newWebhookManager(t *testing.T) configManager {
+	manager := NewConfigManager(db.NewConfigRepo(), db.NewSchemaRepo(), db.NewWebhookRepo()).(configManager)
+	manager.deliveries.backoff = time.Millisecond
+	t.Cleanup(func() { _ = manager.Close() })
+	return manager
+}
+
+func receive(t *testing.T, received <-chan receivedEvent) receivedEvent {
+	select {
+	case event := <-received:
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "No event was delivered")
+		return receivedEvent{}
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	receiver, received := newReceiver(t)
+	manager := newWebhookManager(t)
+	webhook, err := manager.CreateWebhook(contract.CreateWebhookRequest{URL: receiver.URL, Prefix: "datacenter-",
+		Secret: "s3cret"})
+	require.NoError(t, err, "Unexpected create webhook error")
+
+	_, err = manager.Create(contract.UpsertConfigRequest{Namespace: DefaultNamespace,
+		Config: contract.Config{Name: "cluster-1", Metadata: map[string]interface{}{}}})
+	require.NoError(t, err, "Unexpected create config error")
+	_, err = manager.Create(contract.UpsertConfigRequest{Namespace: DefaultNamespace, Config: dc1Data, Author: "alice"})
+	require.NoError(t, err, "Unexpected create config error")
+
+	delivered := receive(t, received)
+	var event contract.Event
+	require.NoError(t, json.Unmarshal(delivered.body, &event), "Unexpected decode event error")
+	assert.Equal(t, "datacenter-1", event.Name, "Incorrect event name")
+	assert.Equal(t, contract.EventPut, event.Type, "Incorrect event type")
+	assert.Equal(t, "alice", event.Author, "Incorrect event author")
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(delivered.body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), delivered.header.Get(SignatureHeader),
+		"Incorrect signature")
+	assert.Equal(t, webhook.ID, delivered.header.Get(WebhookIDHeader), "Incorrect webhook id")
+	assert.Equal(t, contract.EventPut, delivered.header.Get(EventTypeHeader), "Incorrect event type header")
+	assert.Eventually(t, func() bool {
+		resp, err := manager.Webhook(webhook.ID)
+		return err == nil && resp.Deliveries.Delivered == 1
+	}, 5*time.Second, time.Millisecond, "Delivery was not recorded")
+}
+
+func TestWebhookDeliveryForQuery(t *testing.T) {
+	receiver, received := newReceiver(t)
+	manager := newWebhookManager(t)
+	_, err := manager.CreateWebhook(contract.CreateWebhookRequest{URL: receiver.URL, Query: `metadata.region = "eu"`,
+		Secret: "s3cret"})
+	require.NoError(t, err, "Unexpected create webhook error")
+
+	for _, region := range []string{"us", "eu"} {
+		_, err = manager.Create(contract.UpsertConfigRequest{Namespace: DefaultNamespace, Config: contract.Config{
+			Name: "dc-" + region, Metadata: map[string]interface{}{"region": region}}})
+		require.NoError(t, err, "Unexpected create config error")
+	}
+	_, err = manager.Delete(contract.DeleteConfigRequest{Namespace: DefaultNamespace, Name: "dc-us"}, contract.Precondition{})
+	require.NoError(t, err, "Unexpected delete config error")
+
+	var events []contract.Event
+	for len(events) < 2 {
+		var event contract.Event
+		require.NoError(t, json.Unmarshal(receive(t, received).body, &event), "Unexpected decode event error")
+		events = append(events, event)
+	}
+	types := map[string]string{events[0].Name: events[0].Type, events[1].Name: events[1].Type}
+	assert.Equal(t, map[string]string{"dc-eu": contract.EventPut, "dc-us": contract.EventDelete}, types,
+		"Incorrect events")
+}
+
+func TestWebhookDeliveryForFailingReceiver(t *testing.T) {
+	receiver, received := newReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+	manager := newWebhookManager(t)
+	webhook, err := manager.CreateWebhook(contract.CreateWebhookRequest{URL: receiver.URL, Secret: "s3cret"})
+	require.NoError(t, err, "Unexpected create webhook error")
+
+	_, err = manager.Create(contract.UpsertConfigRequest{Namespace: DefaultNamespace, Config: dc1Data})
+	require.NoError(t, err, "Unexpected create config error")
+
+	for i := 0; i < 3; i++ {
+		delivered := receive(t, received)
+		assert.Equal(t, "datacenter-1", eventName(t, delivered.body), "Incorrect retried event")
+	}
+	assert.Eventually(t, func() bool {
+		resp, err := manager.Webhook(webhook.ID)
+		return err == nil && resp.Deliveries.Delivered == 1 && resp.Deliveries.LastStatus == http.StatusOK
+	}, 5*time.Second, time.Millisecond, "Delivery was not recorded")
+}
+
+// failingWebhooks fails to list the webhooks as many times as given by failures
+type failingWebhooks struct {
+	db.Webhook
+	failures int32
+}
+
+func (w *failingWebhooks) GetAll() ([]model.Webhook, error) {
+	if atomic.AddInt32(&w.failures, -1) >= 0 {
+		return nil, errors.New("webhooks unavailable")
+	}
+	return w.Webhook.GetAll()
+}
+
+func TestWebhookDeliveryForFailingWebhookRepo(t *testing.T) {
+	receiver, received := newReceiver(t)
+	webhooks := &failingWebhooks{Webhook: db.NewWebhookRepo()}
+	manager := NewConfigManager(db.NewConfigRepo(), db.NewSchemaRepo(), webhooks).(configManager)
+	manager.deliveries.backoff = time.Millisecond
+	t.Cleanup(func() { _ = manager.Close() })
+	_, err := manager.CreateWebhook(contract.CreateWebhookRequest{URL: receiver.URL, Secret: "s3cret"})
+	require.NoError(t, err, "Unexpected create webhook error")
+	atomic.StoreInt32(&webhooks.failures, 2)
+
+	_, err = manager.Create(contract.UpsertConfigRequest{Namespace: DefaultNamespace, Config: dc1Data})
+	require.NoError(t, err, "Unexpected create config error")
+
+	assert.Equal(t, "datacenter-1", eventName(t, receive(t, received).body), "Incorrect delivered event")
+}
+
+func TestWebhookDeliveryForUnreachableReceiver(t *testing.T) {
+	receiver, _ := newReceiver(t)
+	receiver.Close()
+	manager := newWebhookManager(t)
+	webhook, err := manager.CreateWebhook(contract.CreateWebhookRequest{URL: receiver.URL, Secret: "s3cret"})
+	require.NoError(t, err, "Unexpected create webhook error")
+
+	_, err = manager.Create(contract.UpsertConfigRequest{Namespace: DefaultNamespace, Config: dc1Data})
+	require.NoError(t, err, "Unexpected create config error")
+
+	assert.Eventually(t, func() bool {
+		resp, err := manager.Webhook(webhook.ID)
+		return err == nil && resp.Deliveries.Failed == 1
+	}, 5*time.Second, time.Millisecond, "Failed delivery was not recorded")
+	resp, err := manager.Webhook(webhook.ID)
+	require.NoError(t, err, "Unexpected get webhook error")
+	assert.NotEmpty(t, resp.Deliveries.LastError, "Missing delivery error")
+	assert.Zero(t, resp.Deliveries.Delivered, "Incorrect number of deliveries")
+}
+
+func eventName(t *testing.T, body []byte) string {
+	var event contract.Event
+	require.NoError(t, json.Unmarshal(body, &event), "Unexpected decode event error")
+	return event.Name
+}
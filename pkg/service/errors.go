@@ -0,0 +1,71 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"jsonstore/pkg/db"
+)
+
+var (
+	// Returned when the requested config does not exist
+	ErrNotFound = db.ErrNotFound
+	// Returned when a write conflicts with the current state of a config
+	ErrConflict = db.ErrConflict
+	// Returned when creating a config whose name is already taken; it is also an ErrConflict
+	ErrAlreadyExists = db.ErrAlreadyExists
+	// Returned when the requested revision of a config does not exist; it is also an ErrNotFound
+	ErrRevisionNotFound = db.ErrRevisionNotFound
+	// Returned when the requested index does not exist; it is also an ErrNotFound
+	ErrIndexNotFound = db.ErrIndexNotFound
+	// Returned when creating an index on a path that is already indexed; it is also an ErrConflict
+	ErrIndexExists = db.ErrIndexExists
+	// Returned when the requested schema does not exist; it is also an ErrNotFound
+	ErrSchemaNotFound = db.ErrSchemaNotFound
+	// Returned when deleting a schema that configs name in their schema field; it is also an
+	// ErrConflict
+	ErrSchemaInUse = fmt.Errorf("schema in use: %w", ErrConflict)
+	// Returned when the requested namespace does not exist; it is also an ErrNotFound
+	ErrNamespaceNotFound = db.ErrNamespaceNotFound
+	// Returned when creating a namespace whose name is already taken; it is also an ErrConflict
+	ErrNamespaceExists = db.ErrNamespaceExists
+	// Returned when deleting a namespace that still holds configs without cascading; it is also an
+	// ErrConflict
+	ErrNamespaceNotEmpty = db.ErrNamespaceNotEmpty
+	// Returned when the stored config does not satisfy the precondition of a write
+	ErrPreconditionFailed = db.ErrPreconditionFailed
+	// Returned when a search query is not a valid expression
+	ErrInvalidQuery = errors.New("invalid query")
+	// Returned when the pagination, sorting or projection of a list is invalid
+	ErrInvalidListOptions = errors.New("invalid list options")
+	// Returned when an index is requested on something that is not a path
+	ErrInvalidIndex = errors.New("invalid index")
+	// Returned when a schema is not a valid JSON Schema or cannot be registered under its name
+	ErrInvalidSchema = errors.New("invalid schema")
+	// Returned when the metadata of a config does not match its schemas, wrapped by a
+	// ValidationError listing the violations
+	ErrSchemaViolation = errors.New("schema violation")
+	// Returned when a namespace cannot be created or deleted under its name
+	ErrInvalidNamespace = errors.New("invalid namespace")
+	// Returned when a config fails validation before reaching the store
+	ErrInvalidConfig = errors.New("invalid config")
+	// Returned when a patch document cannot be applied to a config
+	ErrInvalidPatch = errors.New("invalid patch")
+	// Returned when a test operation of a JSON Patch does not match the stored config
+	ErrPatchTestFailed = errors.New("patch test failed")
+	// Returned when a config cannot be rolled back to the requested revision
+	ErrInvalidRollback = errors.New("invalid rollback")
+	// Returned when a watch cannot resume from the requested revision, as the changes since are no
+	// longer known
+	ErrWatchExpired = errors.New("watch expired")
+	// Returned when a watch request is invalid
+	ErrInvalidWatch = errors.New("invalid watch")
+	// Returned when an import request is invalid
+	ErrInvalidImport = errors.New("invalid import")
+	// Returned when an operation of a transaction is invalid
+	ErrInvalidTransaction = errors.New("invalid transaction")
+	// Returned when the requested webhook does not exist; it is also an ErrNotFound
+	ErrWebhookNotFound = db.ErrWebhookNotFound
+	// Returned when a webhook cannot be created from a request
+	ErrInvalidWebhook = errors.New("invalid webhook")
+)
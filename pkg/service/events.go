@@ -0,0 +1,110 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/model"
+)
+
+// Number of the latest changes kept for watchers to resume from
+const eventBufferSize = 1000
+
+// eventBus numbers the changes made to configs and keeps the latest of them, closing a channel to
+// wake up watchers on every change. Revisions start at the time the bus is created, so that
+// revisions handed out before a restart are older than any kept change and cannot be resumed
+// from by mistake.
+type eventBus struct {
+	sync.Mutex
+	// Contiguous changes ending with the one at revision head
+	events []contract.Event
+	head   int64
+	// Version of the latest change published for each config, by namespace and name, as long as
+	// the change is kept
+	latest  map[[2]string]int64
+	changed chan struct{}
+}
+
+func newEventBus(start time.Time) *eventBus {
+	return &eventBus{
+		head:    start.UnixNano() / int64(time.Microsecond),
+		latest:  map[[2]string]int64{},
+		changed: make(chan struct{}),
+	}
+}
+
+func (b *eventBus) put(config model.Config) {
+	resp := toResponse(config)
+	resp.Namespace = canonicalNamespace(config.Namespace)
+	b.publish(contract.Event{
+		Type:      contract.EventPut,
+		Namespace: resp.Namespace,
+		Name:      config.Name,
+		Version:   config.Version,
+		Timestamp: config.UpdatedAt,
+		Author:    config.UpdatedBy,
+		Config:    &resp,
+	})
+}
+
+func (b *eventBus) delete(revision model.Revision) {
+	b.publish(contract.Event{
+		Type:      contract.EventDelete,
+		Namespace: canonicalNamespace(revision.Namespace),
+		Name:      revision.Name,
+		Version:   revision.Version,
+		Timestamp: revision.Timestamp,
+		Author:    revision.Author,
+	})
+}
+
+// publish numbers an event and wakes up watchers. Concurrent writes to a config may be published
+// out of order, in which case the older change is dropped as it is superseded by the newer one.
+// The version of a config is forgotten once its latest change is no longer kept, long after any
+// write racing with it was published.
+func (b *eventBus) publish(event contract.Event) {
+	b.Lock()
+	defer b.Unlock()
+
+	k := [2]string{event.Namespace, event.Name}
+	if event.Version <= b.latest[k] {
+		return
+	}
+	b.latest[k] = event.Version
+
+	b.head++
+	event.Revision = b.head
+	b.events = append(b.events, event)
+	if len(b.events) > eventBufferSize {
+		for _, dropped := range b.events[:len(b.events)-eventBufferSize] {
+			if k := [2]string{dropped.Namespace, dropped.Name}; b.latest[k] == dropped.Version {
+				delete(b.latest, k)
+			}
+		}
+		b.events = b.events[len(b.events)-eventBufferSize:]
+	}
+
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// since returns the changes after a revision along with the current revision, and a channel
+// closed on the next change. It fails with ErrWatchExpired when changes after the revision are no
+// longer kept, or when the revision was never handed out.
+func (b *eventBus) since(revision int64) ([]contract.Event, int64, <-chan struct{}, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if revision == 0 {
+		return nil, b.head, b.changed, nil
+	}
+	oldest := b.head - int64(len(b.events))
+	if revision < oldest || revision > b.head {
+		return nil, 0, nil, ErrWatchExpired
+	}
+
+	events := make([]contract.Event, b.head-revision)
+	copy(events, b.events[revision-oldest:])
+	return events, b.head, b.changed, nil
+}
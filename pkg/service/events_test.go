@@ -0,0 +1,93 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/model"
+)
+
+var busStart = time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+func TestEventBusSince(t *testing.T) {
+	bus := newEventBus(busStart)
+	_, head, changed, err := bus.since(0)
+	require.NoError(t, err, "Unexpected since error")
+
+	bus.put(model.Config{Name: "datacenter-1", Version: 1, UpdatedBy: "alice"})
+	bus.delete(model.Revision{Namespace: "team-a", Name: "datacenter-1", Version: 3, Author: "bob", Deleted: true})
+
+	select {
+	case <-changed:
+	default:
+		assert.Fail(t, "Watchers were not woken up")
+	}
+	events, next, _, err := bus.since(head)
+	assert.NoError(t, err, "Unexpected since error")
+	assert.Equal(t, head+2, next, "Incorrect revision")
+	require.Len(t, events, 2, "Incorrect events")
+	assert.Equal(t, contract.Event{Revision: head + 1, Type: contract.EventPut, Namespace: DefaultNamespace,
+		Name: "datacenter-1", Version: 1, Author: "alice", Config: &contract.GetConfigResponse{
+			Namespace: DefaultNamespace, Config: contract.Config{Name: "datacenter-1"}, Version: 1, UpdatedBy: "alice",
+		}}, events[0], "Incorrect put event")
+	assert.Equal(t, contract.Event{Revision: head + 2, Type: contract.EventDelete, Namespace: "team-a",
+		Name: "datacenter-1", Version: 3, Author: "bob"}, events[1], "Incorrect delete event")
+
+	events, _, _, err = bus.since(head + 1)
+	assert.NoError(t, err, "Unexpected since error")
+	assert.Len(t, events, 1, "Incorrect events after resuming")
+}
+
+func TestEventBusSinceForExpiredRevision(t *testing.T) {
+	bus := newEventBus(busStart)
+	_, head, _, err := bus.since(0)
+	require.NoError(t, err, "Unexpected since error")
+	for i := 1; i <= eventBufferSize+1; i++ {
+		bus.put(model.Config{Name: "datacenter-1", Version: int64(i)})
+	}
+
+	_, _, _, err = bus.since(head)
+	assert.True(t, errors.Is(err, ErrWatchExpired), "Incorrect since error for an evicted revision")
+	events, _, _, err := bus.since(head + 1)
+	assert.NoError(t, err, "Unexpected since error")
+	assert.Len(t, events, eventBufferSize, "Incorrect events")
+	_, _, _, err = bus.since(head + eventBufferSize + 2)
+	assert.True(t, errors.Is(err, ErrWatchExpired), "Incorrect since error for a future revision")
+	_, _, _, err = newEventBus(busStart.Add(time.Second)).since(head + 1)
+	assert.True(t, errors.Is(err, ErrWatchExpired), "Incorrect since error for a revision before a restart")
+}
+
+func TestEventBusDropsSupersededChanges(t *testing.T) {
+	bus := newEventBus(busStart)
+	_, head, _, err := bus.since(0)
+	require.NoError(t, err, "Unexpected since error")
+
+	bus.put(model.Config{Name: "datacenter-1", Version: 2})
+	bus.put(model.Config{Name: "datacenter-1", Version: 1})
+	bus.put(model.Config{Namespace: "team-a", Name: "datacenter-1", Version: 1})
+
+	events, _, _, err := bus.since(head)
+	assert.NoError(t, err, "Unexpected since error")
+	require.Len(t, events, 2, "Incorrect events")
+	assert.Equal(t, int64(2), events[0].Version, "Incorrect version")
+	assert.Equal(t, "team-a", events[1].Namespace, "Incorrect namespace")
+}
+
+func TestEventBusForgetsDroppedChanges(t *testing.T) {
+	bus := newEventBus(busStart)
+	bus.put(model.Config{Name: "datacenter-1", Version: 2})
+	for i := 0; i < 2*eventBufferSize; i++ {
+		bus.put(model.Config{Name: fmt.Sprintf("datacenter-%d", i+2), Version: 1})
+	}
+	bus.put(model.Config{Name: "datacenter-3", Version: 2})
+
+	assert.Len(t, bus.latest, eventBufferSize, "Incorrect number of versions kept")
+	assert.NotContains(t, bus.latest, [2]string{DefaultNamespace, "datacenter-1"}, "Version of a dropped change was kept")
+	assert.Equal(t, int64(2), bus.latest[[2]string{DefaultNamespace, "datacenter-3"}], "Incorrect kept version")
+}
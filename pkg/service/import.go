@@ -0,0 +1,196 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/model"
+)
+
+// Export returns every config of a namespace ordered by name, in the representation Import
+// accepts
+func (c configManager) Export(namespace string) ([]contract.GetConfigResponse, error) {
+	all, err := c.configRepo.GetAll(namespace)
+	if err != nil {
+		return nil, fmt.Errorf("export: %w", err)
+	}
+
+	resp := make([]contract.GetConfigResponse, 0, len(all))
+	for _, item := range all {
+		resp = append(resp, toResponse(item))
+	}
+	return resp, nil
+}
+
+// Import writes configs to a namespace in a single batch as given by the mode of the request, and
+// reports the outcome of each of them. Configs that fail validation, or whose name is repeated,
+// are reported invalid and left out while the others are imported; replace-all does not delete
+// them either. Configs whose content is already stored are left unchanged. When the store fails,
+// the configs it did not get to write are reported failed.
+func (c configManager) Import(req contract.ImportRequest) (*contract.ImportResponse, error) {
+	mode, dryRun := req.Mode, req.DryRun
+	switch mode {
+	case "":
+		mode = contract.ImportMerge
+	case contract.ImportMerge, contract.ImportReplaceAll:
+	case contract.ImportDryRun:
+		mode, dryRun = contract.ImportMerge, true
+	default:
+		return nil, fmt.Errorf("import: %w: unknown mode %q", ErrInvalidImport, req.Mode)
+	}
+
+	current, err := c.configRepo.GetAll(req.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("import: %w", err)
+	}
+
+	resp := &contract.ImportResponse{Mode: mode, DryRun: dryRun, Results: make([]contract.ImportResult, 0, len(req.Configs))}
+	// Valid configs, along with their position in the results
+	var items []model.Config
+	var positions []int
+	seen := make(map[string]bool, len(req.Configs))
+	for _, config := range req.Configs {
+		item := model.Config{
+			Namespace: req.Namespace,
+			Name:      config.Name,
+			Metadata:  config.Metadata,
+			Schema:    config.Schema,
+			UpdatedBy: req.Author,
+		}
+		if err := c.validateImport(item, seen); err != nil {
+			if !errors.Is(err, ErrInvalidConfig) && !errors.Is(err, ErrSchemaViolation) {
+				return nil, fmt.Errorf("import: %w", err)
+			}
+			resp.Results = append(resp.Results, contract.ImportResult{Name: item.Name, Result: contract.ImportInvalid,
+				Error: err.Error()})
+			continue
+		}
+
+		seen[item.Name] = true
+		positions = append(positions, len(resp.Results))
+		resp.Results = append(resp.Results, contract.ImportResult{Name: item.Name})
+		items = append(items, item)
+	}
+
+	if dryRun {
+		preview(resp, current, items, positions, mode == contract.ImportReplaceAll)
+		return resp, nil
+	}
+
+	var results []db.BatchResult
+	if mode == contract.ImportReplaceAll {
+		// Invalid configs are kept as they are rather than deleted
+		var invalid []string
+		for _, config := range req.Configs {
+			if !seen[config.Name] {
+				invalid = append(invalid, config.Name)
+			}
+		}
+		results, err = c.configRepo.ReplaceAll(req.Namespace, items, invalid, req.Author)
+	} else {
+		results, err = c.configRepo.UpsertAll(req.Namespace, items)
+	}
+
+	// Configs written before a failure are stored all the same, so watchers are told either way
+	for i, result := range results {
+		switch {
+		case result.Revision == nil:
+			resp.Results[positions[i]] = contract.ImportResult{Name: result.Name, Result: contract.ImportUnchanged,
+				Version: result.Config.Version}
+		case result.Config == nil:
+			c.events.delete(*result.Revision)
+			resp.Results = append(resp.Results, contract.ImportResult{Name: result.Name, Result: contract.ImportDeleted,
+				Version: result.Revision.Version})
+		default:
+			c.events.put(*result.Config)
+			outcome := contract.ImportCreated
+			if result.Previous != nil {
+				outcome = contract.ImportUpdated
+			}
+			resp.Results[positions[i]] = contract.ImportResult{Name: result.Name, Result: outcome,
+				Version: result.Config.Version}
+		}
+	}
+	if err != nil {
+		for _, i := range positions {
+			if resp.Results[i].Result == "" {
+				resp.Results[i].Result, resp.Results[i].Error = contract.ImportFailed, err.Error()
+			}
+		}
+	}
+
+	countResults(resp)
+	return resp, nil
+}
+
+// validateImport checks an imported config like a single write would, and that its name is not
+// among those seen before
+func (c configManager) validateImport(item model.Config, seen map[string]bool) error {
+	if err := validateName(item.Name); err != nil {
+		return err
+	}
+	if seen[item.Name] {
+		return fmt.Errorf("%w: config %s is imported more than once", ErrInvalidConfig, item.Name)
+	}
+	if err := c.validateMetadata(item); err != nil {
+		return err
+	}
+	return nil
+}
+
+// preview sets the results an import would have, given the current configs of the namespace
+func preview(resp *contract.ImportResponse, current, items []model.Config, positions []int, replace bool) {
+	stored := make(map[string]model.Config, len(current))
+	for _, config := range current {
+		stored[config.Name] = config
+	}
+
+	for i, item := range items {
+		result := &resp.Results[positions[i]]
+		existing, ok := stored[item.Name]
+		switch {
+		case !ok:
+			result.Result = contract.ImportCreated
+		case db.SameContent(existing, item):
+			result.Result, result.Version = contract.ImportUnchanged, existing.Version
+		default:
+			result.Result = contract.ImportUpdated
+		}
+	}
+
+	if replace {
+		imported := make(map[string]bool, len(resp.Results))
+		for _, result := range resp.Results {
+			imported[result.Name] = true
+		}
+		for _, config := range current {
+			if !imported[config.Name] {
+				resp.Results = append(resp.Results, contract.ImportResult{Name: config.Name, Result: contract.ImportDeleted})
+			}
+		}
+	}
+
+	countResults(resp)
+}
+
+// countResults sets the number of configs of each outcome
+func countResults(resp *contract.ImportResponse) {
+	for _, result := range resp.Results {
+		switch result.Result {
+		case contract.ImportCreated:
+			resp.Created++
+		case contract.ImportUpdated:
+			resp.Updated++
+		case contract.ImportUnchanged:
+			resp.Unchanged++
+		case contract.ImportDeleted:
+			resp.Deleted++
+		case contract.ImportInvalid:
+			resp.Invalid++
+		case contract.ImportFailed:
+			resp.Failed++
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/model"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+func TestExport(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("GetAll", "team-a").Return([]model.Config{dc1Item, dc2Item}, nil)
+
+	configs, err := manager.Export("team-a")
+
+	assert.NoError(t, err, "Unexpected export error")
+	assert.Equal(t, []contract.GetConfigResponse{dc1GetResp, dc2GetResp}, configs, "Incorrect configs")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestImportMerge(t *testing.T) {
+	manager := NewConfigManager(db.NewConfigRepo(), db.NewSchemaRepo(), db.NewWebhookRepo())
+	_, err := manager.Create(dc1CreateReq)
+	require.NoError(t, err, "Unexpected create error")
+
+	resp, err := manager.Import(contract.ImportRequest{
+		Namespace: DefaultNamespace,
+		Mode:      contract.ImportMerge,
+		Author:    "alice",
+		Configs:   []contract.Config{dc1Data, dc2Data, {Name: "search"}, dc2Data},
+	})
+
+	assert.NoError(t, err, "Unexpected import error")
+	assert.Equal(t, 1, resp.Created, "Incorrect number of created configs")
+	assert.Equal(t, 1, resp.Unchanged, "Incorrect number of unchanged configs")
+	assert.Equal(t, 2, resp.Invalid, "Incorrect number of invalid configs")
+	assert.Equal(t, []contract.ImportResult{
+		{Name: "datacenter-1", Result: contract.ImportUnchanged, Version: 1},
+		{Name: "datacenter-2", Result: contract.ImportCreated, Version: 1},
+		{Name: "search", Result: contract.ImportInvalid, Error: `invalid config: config name "search" is reserved`},
+		{Name: "datacenter-2", Result: contract.ImportInvalid,
+			Error: "invalid config: config datacenter-2 is imported more than once"},
+	}, resp.Results, "Incorrect results")
+	config, err := manager.Get(DefaultNamespace, "datacenter-2")
+	require.NoError(t, err, "Unexpected get error")
+	assert.Equal(t, "alice", config.UpdatedBy, "Incorrect author")
+}
+
+func TestImportReplaceAll(t *testing.T) {
+	manager := NewConfigManager(db.NewConfigRepo(), db.NewSchemaRepo(), db.NewWebhookRepo())
+	for _, req := range []contract.UpsertConfigRequest{dc1CreateReq, {Config: dc2Data},
+		{Config: contract.Config{Name: "datacenter-3"}}} {
+		_, err := manager.Create(req)
+		require.NoError(t, err, "Unexpected create error")
+	}
+
+	resp, err := manager.Import(contract.ImportRequest{
+		Namespace: DefaultNamespace,
+		Mode:      contract.ImportReplaceAll,
+		Author:    "alice",
+		Configs:   []contract.Config{dc1Data, {Name: "datacenter-3", Schema: "missing"}},
+	})
+
+	assert.NoError(t, err, "Unexpected import error")
+	assert.Equal(t, []contract.ImportResult{
+		{Name: "datacenter-1", Result: contract.ImportUnchanged, Version: 1},
+		{Name: "datacenter-3", Result: contract.ImportInvalid, Error: `invalid config: schema "missing" does not exist`},
+		{Name: "datacenter-2", Result: contract.ImportDeleted, Version: 2},
+	}, resp.Results, "Incorrect results")
+	_, err = manager.Get(DefaultNamespace, "datacenter-2")
+	assert.True(t, errors.Is(err, ErrNotFound), "Config left out of the import was not deleted")
+	_, err = manager.Get(DefaultNamespace, "datacenter-3")
+	assert.NoError(t, err, "Invalid config was deleted")
+}
+
+func TestImportDryRun(t *testing.T) {
+	manager := NewConfigManager(db.NewConfigRepo(), db.NewSchemaRepo(), db.NewWebhookRepo())
+	_, err := manager.Create(dc1CreateReq)
+	require.NoError(t, err, "Unexpected create error")
+
+	resp, err := manager.Import(contract.ImportRequest{
+		Namespace: DefaultNamespace,
+		Mode:      contract.ImportReplaceAll,
+		DryRun:    true,
+		Configs:   []contract.Config{dc2Data},
+	})
+
+	assert.NoError(t, err, "Unexpected import error")
+	assert.Equal(t, &contract.ImportResponse{
+		Mode:    contract.ImportReplaceAll,
+		DryRun:  true,
+		Created: 1,
+		Deleted: 1,
+		Results: []contract.ImportResult{
+			{Name: "datacenter-2", Result: contract.ImportCreated},
+			{Name: "datacenter-1", Result: contract.ImportDeleted},
+		},
+	}, resp, "Incorrect response")
+	_, err = manager.Get(DefaultNamespace, "datacenter-2")
+	assert.True(t, errors.Is(err, ErrNotFound), "Dry run created a config")
+	_, err = manager.Get(DefaultNamespace, "datacenter-1")
+	assert.NoError(t, err, "Dry run deleted a config")
+}
+
+func TestImportForInvalidMode(t *testing.T) {
+	manager := NewConfigManager(new(mocks.Config), db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	_, err := manager.Import(contract.ImportRequest{Namespace: DefaultNamespace, Mode: "overwrite"})
+
+	assert.True(t, errors.Is(err, ErrInvalidImport), "Incorrect import error")
+}
+
+func TestImportForRepoError(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+	created := dc1Item
+	created.Namespace, created.Version = DefaultNamespace, 1
+
+	configRepo.On("GetAll", DefaultNamespace).Return([]model.Config{}, nil)
+	configRepo.On("UpsertAll", DefaultNamespace, mock.Anything).Return([]db.BatchResult{
+		{Name: "datacenter-1", Config: &created, Revision: &model.Revision{Name: "datacenter-1", Version: 1}},
+	}, errors.New("disk full"))
+
+	resp, err := manager.Import(contract.ImportRequest{Namespace: DefaultNamespace, Configs: []contract.Config{dc1Data, dc2Data}})
+
+	assert.NoError(t, err, "Unexpected import error")
+	assert.Equal(t, []contract.ImportResult{
+		{Name: "datacenter-1", Result: contract.ImportCreated, Version: 1},
+		{Name: "datacenter-2", Result: contract.ImportFailed, Error: "disk full"},
+	}, resp.Results, "Incorrect results")
+	assert.Equal(t, 1, resp.Failed, "Incorrect number of failed configs")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
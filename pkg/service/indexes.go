@@ -0,0 +1,43 @@
+package service
+
+import (
+	"fmt"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/query"
+)
+
+func (c configManager) Indexes() ([]contract.GetIndexResponse, error) {
+	indexes, err := c.configRepo.Indexes()
+	if err != nil {
+		return nil, fmt.Errorf("indexes: %w", err)
+	}
+
+	resp := make([]contract.GetIndexResponse, 0, len(indexes))
+	for _, index := range indexes {
+		resp = append(resp, contract.GetIndexResponse{Path: index.Path, Values: index.Values, Entries: index.Entries})
+	}
+	return resp, nil
+}
+
+// CreateIndex indexes the configs on a path, which must be usable in a search expression. The
+// index is used by every later search comparing the path for equality or order.
+func (c configManager) CreateIndex(req contract.CreateIndexRequest) error {
+	if !query.IsPath(req.Path) {
+		return fmt.Errorf("create index: %w: %q is not a path", ErrInvalidIndex, req.Path)
+	}
+
+	if err := c.configRepo.CreateIndex(req.Path); err != nil {
+		return fmt.Errorf("create index: %w", err)
+	}
+
+	return nil
+}
+
+func (c configManager) DropIndex(path string) error {
+	if err := c.configRepo.DropIndex(path); err != nil {
+		return fmt.Errorf("drop index: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/model"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+func TestIndexes(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("Indexes").Return([]model.Index{{Path: "metadata.region", Values: 2, Entries: 3}}, nil)
+
+	indexes, err := manager.Indexes()
+
+	assert.NoError(t, err, "Unexpected indexes error")
+	assert.Equal(t, []contract.GetIndexResponse{{Path: "metadata.region", Values: 2, Entries: 3}}, indexes,
+		"Incorrect indexes")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestCreateIndex(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("CreateIndex", "metadata.region").Return(nil)
+
+	err := manager.CreateIndex(contract.CreateIndexRequest{Path: "metadata.region"})
+
+	assert.NoError(t, err, "Unexpected create index error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestCreateIndexForInvalidPath(t *testing.T) {
+	for _, path := range []string{"", "metadata region", "metadata.region = 1", "1"} {
+		t.Run(path, func(t *testing.T) {
+			configRepo := new(mocks.Config)
+			manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+			err := manager.CreateIndex(contract.CreateIndexRequest{Path: path})
+
+			assert.Errorf(t, err, "Missing create index error")
+			assert.True(t, errors.Is(err, ErrInvalidIndex), "Incorrect create index error")
+			mock.AssertExpectationsForObjects(t, configRepo)
+		})
+	}
+}
+
+func TestCreateIndexForExistingIndex(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("CreateIndex", "metadata.region").Return(db.ErrIndexExists)
+
+	err := manager.CreateIndex(contract.CreateIndexRequest{Path: "metadata.region"})
+
+	assert.Errorf(t, err, "Missing create index error")
+	assert.True(t, errors.Is(err, ErrIndexExists), "Incorrect create index error")
+	assert.True(t, errors.Is(err, ErrConflict), "Incorrect create index error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestDropIndexForMissingIndex(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("DropIndex", "metadata.region").Return(db.ErrIndexNotFound)
+
+	err := manager.DropIndex("metadata.region")
+
+	assert.Errorf(t, err, "Missing drop index error")
+	assert.True(t, errors.Is(err, ErrIndexNotFound), "Incorrect drop index error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
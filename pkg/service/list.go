@@ -0,0 +1,225 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/model"
+	"jsonstore/pkg/query"
+)
+
+// Largest number of configs in a page
+const maxLimit = 1000
+
+// Fields are projected by paths of plain keys, without the gjson wildcards and queries
+var fieldPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
+// listing orders configs by the value at a path, then by name, and splits them into pages
+type listing struct {
+	sort       string
+	path       string
+	descending bool
+	limit      int
+	after      *cursor
+	fields     []string
+}
+
+// cursor is the position of the last config of a page, which the next page starts after
+type cursor struct {
+	Sort string `json:"s"`
+	// Raw JSON of the sort key, empty when the config has no value at the sort path
+	Key  string `json:"k,omitempty"`
+	Name string `json:"n"`
+}
+
+type listItem struct {
+	config model.Config
+	key    gjson.Result
+}
+
+func newListing(opts contract.ListOptions) (listing, error) {
+	l := listing{sort: opts.Sort, limit: opts.Limit, fields: opts.Fields}
+	if l.sort == "" {
+		l.sort = "name"
+	}
+	l.path, l.descending = strings.TrimPrefix(l.sort, "-"), strings.HasPrefix(l.sort, "-")
+	if !query.IsPath(l.path) {
+		return listing{}, fmt.Errorf("%w: cannot sort by %q", ErrInvalidListOptions, opts.Sort)
+	}
+	if l.limit < 0 || l.limit > maxLimit {
+		return listing{}, fmt.Errorf("%w: limit %d is not between 1 and %d", ErrInvalidListOptions, l.limit, maxLimit)
+	}
+	for _, field := range l.fields {
+		if !fieldPattern.MatchString(field) {
+			return listing{}, fmt.Errorf("%w: cannot project field %q", ErrInvalidListOptions, field)
+		}
+	}
+
+	if opts.Cursor != "" {
+		after, err := decodeCursor(opts.Cursor)
+		if err != nil || after.Sort != l.sort {
+			return listing{}, fmt.Errorf("%w: invalid cursor %q", ErrInvalidListOptions, opts.Cursor)
+		}
+		l.after = &after
+	}
+
+	return l, nil
+}
+
+// page returns the page of configs selected by the listing
+func (l listing) page(configs []model.Config) (*contract.ListConfigsResponse, error) {
+	items := make([]listItem, 0, len(configs))
+	for _, config := range configs {
+		key, err := l.key(config)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, listItem{config: config, key: key})
+	}
+	sort.Slice(items, func(i, j int) bool { return l.less(items[i], items[j]) })
+
+	if l.after != nil {
+		after := listItem{config: model.Config{Name: l.after.Name}, key: gjson.Parse(l.after.Key)}
+		items = items[sort.Search(len(items), func(i int) bool { return l.less(after, items[i]) }):]
+	}
+
+	resp := &contract.ListConfigsResponse{}
+	if l.limit > 0 && len(items) > l.limit {
+		items = items[:l.limit]
+		last := items[len(items)-1]
+		resp.Next = encodeCursor(cursor{Sort: l.sort, Key: last.key.Raw, Name: last.config.Name})
+	}
+
+	if len(l.fields) > 0 {
+		resp.Projected = make([]map[string]interface{}, 0, len(items))
+		for _, item := range items {
+			projected, err := project(toResponse(item.config), l.fields)
+			if err != nil {
+				return nil, err
+			}
+			resp.Projected = append(resp.Projected, projected)
+		}
+		return resp, nil
+	}
+
+	resp.Configs = make([]contract.GetConfigResponse, 0, len(items))
+	for _, item := range items {
+		resp.Configs = append(resp.Configs, toResponse(item.config))
+	}
+	return resp, nil
+}
+
+// key returns the value of a config that it is sorted by
+func (l listing) key(config model.Config) (gjson.Result, error) {
+	if l.path == "name" {
+		name, err := json.Marshal(config.Name)
+		return gjson.ParseBytes(name), err
+	}
+
+	doc, err := json.Marshal(config)
+	if err != nil {
+		return gjson.Result{}, fmt.Errorf("encode config %s: %w", config.Name, err)
+	}
+	return gjson.GetBytes(doc, l.path), nil
+}
+
+func (l listing) less(a, b listItem) bool {
+	if cmp := compareKeys(a.key, b.key); cmp != 0 {
+		return (cmp < 0) != l.descending
+	}
+	return a.config.Name < b.config.Name
+}
+
+// compareKeys orders missing values first, followed by null, false, true, numbers, strings and
+// finally objects and arrays, which are compared by their raw JSON
+func compareKeys(a, b gjson.Result) int {
+	if rankA, rankB := keyRank(a), keyRank(b); rankA != rankB {
+		return rankA - rankB
+	}
+
+	switch a.Type {
+	case gjson.Number:
+		switch {
+		case a.Num < b.Num:
+			return -1
+		case a.Num > b.Num:
+			return 1
+		}
+		return 0
+	case gjson.String:
+		return strings.Compare(a.Str, b.Str)
+	case gjson.JSON:
+		return strings.Compare(a.Raw, b.Raw)
+	default:
+		return 0
+	}
+}
+
+func keyRank(r gjson.Result) int {
+	switch {
+	case !r.Exists():
+		return 0
+	case r.Type == gjson.Null:
+		return 1
+	case r.Type == gjson.False:
+		return 2
+	case r.Type == gjson.True:
+		return 3
+	case r.Type == gjson.Number:
+		return 4
+	case r.Type == gjson.String:
+		return 5
+	default:
+		return 6
+	}
+}
+
+// project returns the name of a config along with the given fields of its representation
+func project(config contract.GetConfigResponse, fields []string) (map[string]interface{}, error) {
+	doc, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("encode config %s: %w", config.Name, err)
+	}
+
+	projected := map[string]interface{}{"name": config.Name}
+	for _, field := range fields {
+		if value := gjson.GetBytes(doc, field); value.Exists() {
+			setField(projected, strings.Split(field, "."), value.Value())
+		}
+	}
+	return projected, nil
+}
+
+func setField(doc map[string]interface{}, keys []string, value interface{}) {
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := doc[key].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			doc[key] = child
+		}
+		doc = child
+	}
+	doc[keys[len(keys)-1]] = value
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+
+	return c, json.Unmarshal(data, &c)
+}
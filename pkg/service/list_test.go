@@ -0,0 +1,169 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/model"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+func listConfigs() []model.Config {
+	return []model.Config{
+		{Name: "dc-1", Version: 1, Metadata: map[string]interface{}{"region": "eu", "replicas": 3.0}},
+		{Name: "dc-2", Version: 1, Metadata: map[string]interface{}{"region": "us", "replicas": 1.0}},
+		{Name: "dc-3", Version: 1, Metadata: map[string]interface{}{"region": "eu"}},
+		{Name: "dc-4", Version: 1, Metadata: map[string]interface{}{"region": "ap", "replicas": 3.0}},
+		{Name: "dc-5", Version: 1, Metadata: map[string]interface{}{"region": "us", "replicas": "many"}},
+	}
+}
+
+func pageNames(resp *contract.ListConfigsResponse) []string {
+	names := []string{}
+	for _, config := range resp.Configs {
+		names = append(names, config.Name)
+	}
+	return names
+}
+
+func TestGetAllSorted(t *testing.T) {
+	tests := []struct {
+		sort     string
+		expected []string
+	}{
+		{"", []string{"dc-1", "dc-2", "dc-3", "dc-4", "dc-5"}},
+		{"-name", []string{"dc-5", "dc-4", "dc-3", "dc-2", "dc-1"}},
+		{"metadata.region", []string{"dc-4", "dc-1", "dc-3", "dc-2", "dc-5"}},
+		{"metadata.replicas", []string{"dc-3", "dc-2", "dc-1", "dc-4", "dc-5"}},
+		{"-metadata.replicas", []string{"dc-5", "dc-1", "dc-4", "dc-2", "dc-3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			configRepo := new(mocks.Config)
+			manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+			configRepo.On("GetAll", model.DefaultNamespace).Return(listConfigs(), nil)
+
+			resp, err := manager.GetAll(DefaultNamespace, contract.ListOptions{Sort: tt.sort})
+
+			require.NoError(t, err, "Unexpected get all configs error")
+			assert.Equal(t, tt.expected, pageNames(resp), "Incorrect configs order")
+			assert.Empty(t, resp.Next, "Unexpected next page")
+			mock.AssertExpectationsForObjects(t, configRepo)
+		})
+	}
+}
+
+func TestGetAllPaginated(t *testing.T) {
+	for _, sort := range []string{"name", "-metadata.replicas", "metadata.region"} {
+		t.Run(sort, func(t *testing.T) {
+			configRepo := new(mocks.Config)
+			manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+			configRepo.On("GetAll", model.DefaultNamespace).Return(listConfigs(), nil)
+
+			all, err := manager.GetAll(DefaultNamespace, contract.ListOptions{Sort: sort})
+			require.NoError(t, err, "Unexpected get all configs error")
+
+			var names []string
+			opts := contract.ListOptions{Sort: sort, Limit: 2}
+			for pages := 1; ; pages++ {
+				resp, err := manager.GetAll(DefaultNamespace, opts)
+				require.NoError(t, err, "Unexpected get all configs error")
+				require.True(t, len(resp.Configs) <= 2, "Incorrect page size")
+				names = append(names, pageNames(resp)...)
+				if resp.Next == "" {
+					assert.Equal(t, 3, pages, "Incorrect number of pages")
+					break
+				}
+				opts.Cursor = resp.Next
+			}
+
+			assert.Equal(t, pageNames(all), names, "Incorrect configs of the pages")
+		})
+	}
+}
+
+func TestGetAllPaginatedAfterDeletion(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+	configs := listConfigs()
+
+	configRepo.On("GetAll", model.DefaultNamespace).Return(configs, nil).Once()
+	configRepo.On("GetAll", model.DefaultNamespace).Return(append(configs[:1:1], configs[2:]...), nil).Once()
+
+	first, err := manager.GetAll(DefaultNamespace, contract.ListOptions{Limit: 2})
+	require.NoError(t, err, "Unexpected get all configs error")
+	second, err := manager.GetAll(DefaultNamespace, contract.ListOptions{Limit: 2, Cursor: first.Next})
+	require.NoError(t, err, "Unexpected get all configs error")
+
+	assert.Equal(t, []string{"dc-1", "dc-2"}, pageNames(first), "Incorrect first page")
+	assert.Equal(t, []string{"dc-3", "dc-4"}, pageNames(second), "Incorrect second page")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestGetAllProjected(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("GetAll", model.DefaultNamespace).Return(listConfigs()[:2], nil)
+
+	resp, err := manager.GetAll(DefaultNamespace, contract.ListOptions{Fields: []string{"metadata.replicas", "version", "metadata.missing"}})
+
+	require.NoError(t, err, "Unexpected get all configs error")
+	assert.Equal(t, []map[string]interface{}{
+		{"name": "dc-1", "metadata": map[string]interface{}{"replicas": 3.0}, "version": 1.0},
+		{"name": "dc-2", "metadata": map[string]interface{}{"replicas": 1.0}, "version": 1.0},
+	}, resp.Projected, "Incorrect projected configs")
+	assert.Empty(t, resp.Configs, "Unexpected unprojected configs")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestGetAllProjectedOmitsOtherFields(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("GetAll", model.DefaultNamespace).Return(listConfigs()[:1], nil)
+
+	resp, err := manager.GetAll(DefaultNamespace, contract.ListOptions{Fields: []string{"name"}})
+
+	require.NoError(t, err, "Unexpected get all configs error")
+	body, err := json.Marshal(resp.Projected)
+	require.NoError(t, err, "Unexpected encode error")
+	assert.JSONEq(t, `[{"name": "dc-1"}]`, string(body), "Incorrect projected configs")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestGetAllForInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts contract.ListOptions
+	}{
+		{"sort", contract.ListOptions{Sort: "metadata region"}},
+		{"limit", contract.ListOptions{Limit: maxLimit + 1}},
+		{"cursor", contract.ListOptions{Cursor: "not a cursor"}},
+		{"cursor of another sort", contract.ListOptions{Sort: "-name", Cursor: encodeCursor(cursor{Sort: "name", Name: "dc-1"})}},
+		{"fields", contract.ListOptions{Fields: []string{"metadata.#"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configRepo := new(mocks.Config)
+			manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+			_, err := manager.GetAll(DefaultNamespace, tt.opts)
+
+			assert.Errorf(t, err, "Missing get all configs error")
+			assert.True(t, errors.Is(err, ErrInvalidListOptions), "Incorrect get all configs error")
+			mock.AssertExpectationsForObjects(t, configRepo)
+		})
+	}
+}
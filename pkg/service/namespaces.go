@@ -0,0 +1,86 @@
+package service
+
+import (
+	"fmt"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/model"
+)
+
+// Namespace of the configs addressed without one, such as those under /configs
+const DefaultNamespace = model.DefaultNamespace
+
+func (c configManager) Namespaces() ([]contract.GetNamespaceResponse, error) {
+	namespaces, err := c.configRepo.Namespaces()
+	if err != nil {
+		return nil, fmt.Errorf("namespaces: %w", err)
+	}
+
+	resp := make([]contract.GetNamespaceResponse, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		resp = append(resp, toNamespaceResponse(namespace))
+	}
+	return resp, nil
+}
+
+func (c configManager) Namespace(name string) (*contract.GetNamespaceResponse, error) {
+	namespace, err := c.configRepo.Namespace(name)
+	if err != nil {
+		return nil, fmt.Errorf("namespace: %w", err)
+	}
+
+	resp := toNamespaceResponse(*namespace)
+	return &resp, nil
+}
+
+// CreateNamespace creates an empty namespace, whose name must be usable as a URL path segment
+func (c configManager) CreateNamespace(req contract.CreateNamespaceRequest) (*contract.GetNamespaceResponse, error) {
+	if !namePattern.MatchString(req.Name) || len(req.Name) > maxNameLength {
+		return nil, fmt.Errorf("create namespace: %w: name %q must start with a letter or digit and only contain "+
+			"letters, digits, '.', '_' and '-'", ErrInvalidNamespace, req.Name)
+	}
+
+	namespace, err := c.configRepo.CreateNamespace(req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("create namespace: %w", err)
+	}
+
+	resp := toNamespaceResponse(*namespace)
+	return &resp, nil
+}
+
+// DeleteNamespace removes a namespace. Unless the request cascades, it fails with
+// ErrNamespaceNotEmpty when the namespace still holds configs. The default namespace cannot be
+// deleted.
+func (c configManager) DeleteNamespace(req contract.DeleteNamespaceRequest) error {
+	if req.Name == DefaultNamespace {
+		return fmt.Errorf("delete namespace: %w: the default namespace cannot be deleted", ErrInvalidNamespace)
+	}
+
+	deleted, err := c.configRepo.DeleteNamespace(req.Name, req.Cascade, req.Author)
+	if err != nil {
+		return fmt.Errorf("delete namespace: %w", err)
+	}
+	for _, revision := range deleted {
+		c.events.delete(revision)
+	}
+	return nil
+}
+
+// canonicalNamespace returns the name of a namespace, where an empty name stands for the default
+// one
+func canonicalNamespace(namespace string) string {
+	if namespace == "" {
+		return DefaultNamespace
+	}
+	return namespace
+}
+
+func toNamespaceResponse(namespace model.Namespace) contract.GetNamespaceResponse {
+	resp := contract.GetNamespaceResponse{Name: namespace.Name}
+	if !namespace.CreatedAt.IsZero() {
+		resp.CreatedAt = &namespace.CreatedAt
+	}
+
+	return resp
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/model"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+func TestNamespaces(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+	created := time.Date(2021, time.September, 1, 10, 0, 0, 0, time.UTC)
+
+	configRepo.On("Namespaces").
+		Return([]model.Namespace{{Name: model.DefaultNamespace}, {Name: "team-a", CreatedAt: created}}, nil)
+
+	namespaces, err := manager.Namespaces()
+
+	assert.NoError(t, err, "Unexpected namespaces error")
+	assert.Equal(t, []contract.GetNamespaceResponse{{Name: DefaultNamespace}, {Name: "team-a", CreatedAt: &created}},
+		namespaces, "Incorrect namespaces")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestCreateNamespace(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("CreateNamespace", "team-a").Return(&model.Namespace{Name: "team-a"}, nil)
+
+	namespace, err := manager.CreateNamespace(contract.CreateNamespaceRequest{Name: "team-a"})
+
+	assert.NoError(t, err, "Unexpected create namespace error")
+	assert.Equal(t, &contract.GetNamespaceResponse{Name: "team-a"}, namespace, "Incorrect namespace")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestCreateNamespaceForInvalidName(t *testing.T) {
+	for _, name := range []string{"", "team/a", "-team", "team a"} {
+		t.Run(name, func(t *testing.T) {
+			configRepo := new(mocks.Config)
+			manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+			_, err := manager.CreateNamespace(contract.CreateNamespaceRequest{Name: name})
+
+			assert.True(t, errors.Is(err, ErrInvalidNamespace), "Incorrect create namespace error")
+			mock.AssertExpectationsForObjects(t, configRepo)
+		})
+	}
+}
+
+func TestDeleteNamespace(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("DeleteNamespace", "team-a", true, "alice").Return(
+		[]model.Revision{{Namespace: "team-a", Name: "datacenter-1", Version: 2, Author: "alice", Deleted: true}}, nil)
+
+	err := manager.DeleteNamespace(contract.DeleteNamespaceRequest{Name: "team-a", Cascade: true, Author: "alice"})
+
+	assert.NoError(t, err, "Unexpected delete namespace error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestDeleteNamespaceForDefaultNamespace(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	err := manager.DeleteNamespace(contract.DeleteNamespaceRequest{Name: DefaultNamespace, Cascade: true})
+
+	assert.True(t, errors.Is(err, ErrInvalidNamespace), "Incorrect delete namespace error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestConfigsAreScopedByNamespace(t *testing.T) {
+	configRepo := db.NewConfigRepo()
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+	_, err := manager.CreateNamespace(contract.CreateNamespaceRequest{Name: "team-a"})
+	assert.NoError(t, err, "Unexpected create namespace error")
+
+	_, err = manager.Create(contract.UpsertConfigRequest{Namespace: "team-a", Config: dc1Data})
+	assert.NoError(t, err, "Unexpected create config error")
+	_, err = manager.Create(contract.UpsertConfigRequest{Namespace: "team-b", Config: dc1Data})
+	assert.True(t, errors.Is(err, ErrNamespaceNotFound), "Incorrect create config error")
+
+	config, err := manager.Get("team-a", dc1Data.Name)
+	assert.NoError(t, err, "Unexpected get config error")
+	assert.Equal(t, "team-a", config.Namespace, "Incorrect namespace")
+	_, err = manager.Get(DefaultNamespace, dc1Data.Name)
+	assert.True(t, errors.Is(err, ErrNotFound), "Incorrect get config error")
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/model"
+	"jsonstore/pkg/query"
+	"jsonstore/pkg/schema"
+)
+
+// ValidationError reports every violation of the schemas of a config by its metadata; it is also
+// an ErrSchemaViolation
+type ValidationError struct {
+	Violations []schema.Violation
+}
+
+func (e *ValidationError) Error() string {
+	violations := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		violations = append(violations, v.String())
+	}
+	return fmt.Sprintf("%v: %s", ErrSchemaViolation, strings.Join(violations, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrSchemaViolation
+}
+
+// schemaCache holds the compiled schemas by name along with the version they were compiled from,
+// so that config writes do not compile them again
+type schemaCache struct {
+	sync.RWMutex
+	schemas map[string]compiledSchema
+}
+
+type compiledSchema struct {
+	version int64
+	schema  *schema.Schema
+}
+
+func newSchemaCache() *schemaCache {
+	return &schemaCache{schemas: map[string]compiledSchema{}}
+}
+
+// compiled returns the compiled schema, which is compiled and cached unless the cache holds its
+// version
+func (c *schemaCache) compiled(s model.Schema) (*schema.Schema, error) {
+	c.RLock()
+	cached, ok := c.schemas[s.Name]
+	c.RUnlock()
+	if ok && cached.version == s.Version {
+		return cached.schema, nil
+	}
+
+	compiled, err := schema.Compile(s.Schema)
+	if err != nil {
+		return nil, err
+	}
+	c.put(s, compiled)
+	return compiled, nil
+}
+
+func (c *schemaCache) put(s model.Schema, compiled *schema.Schema) {
+	c.Lock()
+	defer c.Unlock()
+	c.schemas[s.Name] = compiledSchema{version: s.Version, schema: compiled}
+}
+
+func (c *schemaCache) delete(name string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.schemas, name)
+}
+
+func (c configManager) Schemas() ([]contract.GetSchemaResponse, error) {
+	schemas, err := c.schemaRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("schemas: %w", err)
+	}
+
+	resp := make([]contract.GetSchemaResponse, 0, len(schemas))
+	for _, s := range schemas {
+		resp = append(resp, toSchemaResponse(s))
+	}
+	return resp, nil
+}
+
+func (c configManager) Schema(name string) (*contract.GetSchemaResponse, error) {
+	s, err := c.schemaRepo.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+
+	resp := toSchemaResponse(*s)
+	return &resp, nil
+}
+
+// PutSchema registers or replaces a JSON Schema. Configs are validated against it on their next
+// write; those already stored are left untouched.
+func (c configManager) PutSchema(req contract.PutSchemaRequest) (*contract.GetSchemaResponse, error) {
+	if !namePattern.MatchString(req.Name) || len(req.Name) > maxNameLength {
+		return nil, fmt.Errorf("put schema: %w: name %q must start with a letter or digit and only contain "+
+			"letters, digits, '.', '_' and '-'", ErrInvalidSchema, req.Name)
+	}
+	if _, err := path.Match(req.Pattern, ""); err != nil {
+		return nil, fmt.Errorf("put schema: %w: invalid pattern %q", ErrInvalidSchema, req.Pattern)
+	}
+	compiled, err := schema.Compile(req.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("put schema: %w: %v", ErrInvalidSchema, err)
+	}
+
+	stored, err := c.schemaRepo.Upsert(model.Schema{Name: req.Name, Pattern: req.Pattern, Schema: req.Schema})
+	if err != nil {
+		return nil, fmt.Errorf("put schema: %w", err)
+	}
+	c.schemas.put(*stored, compiled)
+
+	resp := toSchemaResponse(*stored)
+	return &resp, nil
+}
+
+// DeleteSchema removes a schema, unless a config of any namespace names it in its schema field
+func (c configManager) DeleteSchema(name string) error {
+	if _, err := c.schemaRepo.Get(name); err != nil {
+		return fmt.Errorf("delete schema: %w", err)
+	}
+
+	namespaces, err := c.configRepo.Namespaces()
+	if err != nil {
+		return fmt.Errorf("delete schema: %w", err)
+	}
+	for _, namespace := range namespaces {
+		users, err := c.configRepo.Search(namespace.Name, query.Comparison{
+			Path:  "schema",
+			Op:    query.OpEq,
+			Value: query.Value{Kind: query.String, Str: name},
+		})
+		if err != nil {
+			return fmt.Errorf("delete schema: %w", err)
+		}
+		if len(users) > 0 {
+			return fmt.Errorf("delete schema: %w: %d configs such as %s/%s follow it", ErrSchemaInUse, len(users),
+				namespace.Name, users[0].Name)
+		}
+	}
+
+	if err := c.schemaRepo.Delete(name); err != nil {
+		return fmt.Errorf("delete schema: %w", err)
+	}
+	c.schemas.delete(name)
+	return nil
+}
+
+// validateMetadata checks the metadata of a config against the schema it names and every schema
+// whose pattern matches its name, and returns a ValidationError listing all the violations
+func (c configManager) validateMetadata(config model.Config) error {
+	all, err := c.schemaRepo.GetAll()
+	if err != nil {
+		return fmt.Errorf("schemas: %w", err)
+	}
+
+	var applicable []model.Schema
+	named := config.Schema == ""
+	for _, s := range all {
+		matched, _ := path.Match(s.Pattern, config.Name)
+		if s.Name == config.Schema {
+			named = true
+		} else if s.Pattern == "" || !matched {
+			continue
+		}
+		applicable = append(applicable, s)
+	}
+	if !named {
+		return fmt.Errorf("%w: schema %q does not exist", ErrInvalidConfig, config.Schema)
+	}
+	if len(applicable) == 0 {
+		return nil
+	}
+
+	var doc interface{}
+	if err := remarshal(config.Metadata, &doc); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+
+	var violations []schema.Violation
+	for _, s := range applicable {
+		compiled, err := c.schemas.compiled(s)
+		if err != nil {
+			return fmt.Errorf("compile schema %s: %w", s.Name, err)
+		}
+		for _, v := range compiled.Validate(doc) {
+			v.Path = "/metadata" + v.Path
+			violations = append(violations, v)
+		}
+	}
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+func toSchemaResponse(s model.Schema) contract.GetSchemaResponse {
+	return contract.GetSchemaResponse{
+		Name:      s.Name,
+		Pattern:   s.Pattern,
+		Schema:    json.RawMessage(s.Schema),
+		Version:   s.Version,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/model"
+	"jsonstore/pkg/query"
+	"jsonstore/pkg/schema"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+func newSchemaRepo(t *testing.T, schemas ...model.Schema) db.Schema {
+	repo := db.NewSchemaRepo()
+	for _, s := range schemas {
+		_, err := repo.Upsert(s)
+		require.NoError(t, err, "Unexpected upsert schema error")
+	}
+	return repo
+}
+
+var (
+	datacenterSchema = model.Schema{
+		Name:    "datacenter",
+		Pattern: "datacenter-*",
+		Schema: json.RawMessage(`{"type":"object","required":["monitoring"],` +
+			`"properties":{"monitoring":{"type":"object","properties":{"enabled":{"type":"string"}}}}}`),
+	}
+	limitsSchema = model.Schema{
+		Name:   "limits",
+		Schema: json.RawMessage(`{"required":["limits"]}`),
+	}
+)
+
+func TestUpsertValidatesMetadata(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, newSchemaRepo(t, datacenterSchema, limitsSchema), db.NewWebhookRepo())
+	req := contract.UpsertConfigRequest{Config: contract.Config{
+		Name:     "datacenter-1",
+		Metadata: map[string]interface{}{"monitoring": map[string]interface{}{"enabled": true}},
+		Schema:   "limits",
+	}}
+
+	_, err := manager.Upsert(req, contract.Precondition{})
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr), "Incorrect upsert config error: %v", err)
+	assert.True(t, errors.Is(err, ErrSchemaViolation), "Incorrect upsert config error")
+	assert.Equal(t, []schema.Violation{
+		{Path: "/metadata/monitoring/enabled", Message: "expected string, got boolean"},
+		{Path: "/metadata/limits", Message: "property is required"},
+	}, validationErr.Violations, "Incorrect violations")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestCreateValidatesMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   contract.Config
+		expected error
+	}{
+		{"valid", contract.Config{Name: "datacenter-1", Metadata: map[string]interface{}{"monitoring": map[string]interface{}{}}}, nil},
+		{"null", contract.Config{Name: "datacenter-1"}, ErrSchemaViolation},
+		{"unmatched name", contract.Config{Name: "cluster-1", Metadata: "any"}, nil},
+		{"named schema", contract.Config{Name: "cluster-1", Metadata: map[string]interface{}{}, Schema: "limits"}, ErrSchemaViolation},
+		{"missing schema", contract.Config{Name: "cluster-1", Schema: "cluster"}, ErrInvalidConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configRepo := new(mocks.Config)
+			manager := NewConfigManager(configRepo, newSchemaRepo(t, datacenterSchema, limitsSchema), db.NewWebhookRepo())
+			item := model.Config{Name: tt.config.Name, Metadata: tt.config.Metadata, Schema: tt.config.Schema}
+			if tt.expected == nil {
+				configRepo.On("Create", item).Return(&item, nil)
+			}
+
+			_, err := manager.Create(contract.UpsertConfigRequest{Config: tt.config})
+
+			if tt.expected == nil {
+				assert.NoError(t, err, "Unexpected create config error")
+			} else {
+				assert.True(t, errors.Is(err, tt.expected), "Incorrect create config error: %v", err)
+			}
+			mock.AssertExpectationsForObjects(t, configRepo)
+		})
+	}
+}
+
+func TestPutSchema(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	resp, err := manager.PutSchema(contract.PutSchemaRequest{
+		Name:    "datacenter",
+		Pattern: "datacenter-*",
+		Schema:  json.RawMessage(`{"type":"object"}`),
+	})
+
+	require.NoError(t, err, "Unexpected put schema error")
+	assert.Equal(t, int64(1), resp.Version, "Incorrect schema version")
+	stored, err := manager.Schema("datacenter")
+	require.NoError(t, err, "Unexpected get schema error")
+	assert.Equal(t, resp, stored, "Incorrect stored schema")
+}
+
+func TestSchemaChangesApplyToWrites(t *testing.T) {
+	manager := NewConfigManager(db.NewConfigRepo(), db.NewSchemaRepo(), db.NewWebhookRepo())
+	put := func(schema string) {
+		_, err := manager.PutSchema(contract.PutSchemaRequest{Name: "datacenter", Pattern: "datacenter-*",
+			Schema: json.RawMessage(schema)})
+		require.NoError(t, err, "Unexpected put schema error")
+	}
+	write := func() error {
+		_, err := manager.Upsert(contract.UpsertConfigRequest{Config: contract.Config{Name: "datacenter-1",
+			Metadata: map[string]interface{}{"region": "eu"}}}, contract.Precondition{})
+		return err
+	}
+
+	put(`{"required":["region"]}`)
+	assert.NoError(t, write(), "Unexpected upsert error")
+	put(`{"required":["zone"]}`)
+	assert.True(t, errors.Is(write(), ErrSchemaViolation), "Replaced schema was not applied")
+	_, err := manager.Delete(contract.DeleteConfigRequest{Namespace: DefaultNamespace, Name: "datacenter-1"},
+		contract.Precondition{})
+	require.NoError(t, err, "Unexpected delete error")
+	require.NoError(t, manager.DeleteSchema("datacenter"), "Unexpected delete schema error")
+	put(`{"required":["region"]}`)
+	assert.NoError(t, write(), "Schema put again after its deletion was not applied")
+}
+
+func TestPutSchemaForInvalidSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		req  contract.PutSchemaRequest
+	}{
+		{"name", contract.PutSchemaRequest{Name: "-datacenter", Schema: json.RawMessage(`true`)}},
+		{"pattern", contract.PutSchemaRequest{Name: "datacenter", Pattern: "[", Schema: json.RawMessage(`true`)}},
+		{"schema", contract.PutSchemaRequest{Name: "datacenter", Schema: json.RawMessage(`{"type":"decimal"}`)}},
+		{"missing schema", contract.PutSchemaRequest{Name: "datacenter"}},
+		{"reference cycle", contract.PutSchemaRequest{Name: "datacenter", Schema: json.RawMessage(`{"$ref":"#"}`)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewConfigManager(new(mocks.Config), db.NewSchemaRepo(), db.NewWebhookRepo())
+
+			_, err := manager.PutSchema(tt.req)
+
+			assert.True(t, errors.Is(err, ErrInvalidSchema), "Incorrect put schema error: %v", err)
+		})
+	}
+}
+
+func TestDeleteSchemaForSchemaInUse(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, newSchemaRepo(t, limitsSchema), db.NewWebhookRepo())
+
+	configRepo.On("Namespaces").Return([]model.Namespace{{Name: "alpha"}, {Name: model.DefaultNamespace}}, nil)
+	configRepo.On("Search", "alpha", mock.Anything).Return(nil, nil)
+	configRepo.On("Search", model.DefaultNamespace, query.Comparison{
+		Path:  "schema",
+		Op:    query.OpEq,
+		Value: query.Value{Kind: query.String, Str: "limits"},
+	}).Return([]model.Config{{Name: "cluster-1", Schema: "limits"}}, nil)
+
+	err := manager.DeleteSchema("limits")
+
+	assert.True(t, errors.Is(err, ErrSchemaInUse), "Incorrect delete schema error: %v", err)
+	assert.True(t, errors.Is(err, ErrConflict), "Incorrect delete schema error")
+	_, err = manager.Schema("limits")
+	assert.NoError(t, err, "Unexpected get schema error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestDeleteSchemaForMissingSchema(t *testing.T) {
+	manager := NewConfigManager(new(mocks.Config), db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	err := manager.DeleteSchema("limits")
+
+	assert.True(t, errors.Is(err, ErrSchemaNotFound), "Incorrect delete schema error: %v", err)
+}
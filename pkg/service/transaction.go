@@ -0,0 +1,132 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/model"
+	"jsonstore/pkg/patch"
+)
+
+// Transact makes the operations of a transaction on configs of a namespace in order, all or
+// nothing. Each operation is validated like a single write, and its conditions are checked
+// against the configs as left by the operations before it; the first one failing aborts the
+// transaction with ErrPreconditionFailed.
+func (c configManager) Transact(req contract.TransactionRequest) (*contract.TransactionResponse, error) {
+	if len(req.Operations) == 0 {
+		return nil, fmt.Errorf("transaction: %w: no operations", ErrInvalidTransaction)
+	}
+
+	ops := make([]db.TxOp, 0, len(req.Operations))
+	for i, operation := range req.Operations {
+		op, err := c.toTxOp(req, operation)
+		if err != nil {
+			return nil, fmt.Errorf("transaction: operation %d: %w", i, atPath(err, fmt.Sprintf("/operations/%d", i)))
+		}
+		ops = append(ops, op)
+	}
+
+	results, err := c.configRepo.Transact(req.Namespace, ops)
+	if err != nil {
+		return nil, fmt.Errorf("transaction: %w", err)
+	}
+
+	resp := &contract.TransactionResponse{Results: make([]contract.TransactionResult, 0, len(results))}
+	for i, result := range results {
+		res := contract.TransactionResult{Op: req.Operations[i].Op, Name: result.Name}
+		switch {
+		case result.Revision == nil:
+			if result.Config != nil {
+				res.Version = result.Config.Version
+			}
+		case result.Config == nil:
+			c.events.delete(*result.Revision)
+			res.Version = result.Revision.Version
+		default:
+			c.events.put(*result.Config)
+			res.Version = result.Config.Version
+		}
+		resp.Results = append(resp.Results, res)
+	}
+	return resp, nil
+}
+
+// toTxOp validates an operation of a transaction and returns it as made by the store
+func (c configManager) toTxOp(req contract.TransactionRequest, operation contract.TransactionOperation) (db.TxOp, error) {
+	if err := validateName(operation.Name); err != nil {
+		return db.TxOp{}, err
+	}
+
+	op := db.TxOp{Name: operation.Name, Author: req.Author}
+	switch operation.Op {
+	case contract.TransactionPut:
+		config := model.Config{
+			Namespace: req.Namespace,
+			Name:      operation.Name,
+			Metadata:  operation.Metadata,
+			Schema:    operation.Schema,
+			UpdatedBy: req.Author,
+		}
+		if err := c.validateMetadata(config); err != nil {
+			return db.TxOp{}, err
+		}
+		op.Config = &config
+	case contract.TransactionDelete, contract.TransactionCheck:
+		if operation.Metadata != nil || operation.Schema != "" {
+			return db.TxOp{}, fmt.Errorf("%w: only a put takes metadata and a schema", ErrInvalidTransaction)
+		}
+		op.Delete = operation.Op == contract.TransactionDelete
+	default:
+		return db.TxOp{}, fmt.Errorf("%w: unknown operation %q", ErrInvalidTransaction, operation.Op)
+	}
+
+	var tests []patch.Operation
+	for _, cond := range operation.If {
+		switch {
+		case cond.Exists != nil && cond.Version == 0 && cond.Path == "":
+			op.Precondition.MatchAny = op.Precondition.MatchAny || *cond.Exists
+			op.Precondition.NoneMatchAny = op.Precondition.NoneMatchAny || !*cond.Exists
+		case cond.Version > 0 && cond.Exists == nil && cond.Path == "":
+			if len(op.Precondition.Match) > 0 {
+				return db.TxOp{}, fmt.Errorf("%w: version is given more than once", ErrInvalidTransaction)
+			}
+			op.Precondition.Match = []int64{cond.Version}
+		case strings.HasPrefix(cond.Path, "/") && len(cond.Value) > 0 && cond.Exists == nil && cond.Version == 0:
+			tests = append(tests, patch.Operation{Op: "test", Path: cond.Path, Value: cond.Value})
+		default:
+			return db.TxOp{}, fmt.Errorf("%w: a condition must be one of exists, a positive version, "+
+				"or a path starting with / and a value", ErrInvalidTransaction)
+		}
+	}
+	if len(tests) > 0 {
+		op.Test = func(current *model.Config) error {
+			return testConfig(current, tests)
+		}
+	}
+	return op, nil
+}
+
+// testConfig checks that a config, nil when missing, holds the value of every test operation
+func testConfig(current *model.Config, tests []patch.Operation) error {
+	if current == nil {
+		return fmt.Errorf("%w: config does not exist", ErrPreconditionFailed)
+	}
+
+	doc, err := toDocument(*current)
+	if err != nil {
+		return err
+	}
+	for _, test := range tests {
+		_, err := patch.Apply(doc, []patch.Operation{test})
+		if errors.Is(err, patch.ErrTestFailed) {
+			return fmt.Errorf("%w: %s is not %s", ErrPreconditionFailed, test.Path, test.Value)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+		}
+	}
+	return nil
+}
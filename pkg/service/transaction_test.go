@@ -0,0 +1,144 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/schema"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+func exists(v bool) *bool {
+	return &v
+}
+
+func TestTransact(t *testing.T) {
+	manager := newApplyManager(t, euConfig, usConfig)
+
+	resp, err := manager.Transact(contract.TransactionRequest{
+		Namespace: DefaultNamespace,
+		Author:    "alice",
+		Operations: []contract.TransactionOperation{
+			{Op: contract.TransactionPut, Name: "datacenter-1", Metadata: map[string]interface{}{"region": "ap"},
+				If: []contract.TransactionCondition{{Version: 1}, {Path: "/metadata/region", Value: json.RawMessage(`"eu"`)}}},
+			{Op: contract.TransactionDelete, Name: "datacenter-2", If: []contract.TransactionCondition{{Exists: exists(true)}}},
+			{Op: contract.TransactionCheck, Name: "datacenter-3", If: []contract.TransactionCondition{{Exists: exists(false)}}},
+		},
+	})
+
+	assert.NoError(t, err, "Unexpected transaction error")
+	assert.Equal(t, &contract.TransactionResponse{Results: []contract.TransactionResult{
+		{Op: contract.TransactionPut, Name: "datacenter-1", Version: 2},
+		{Op: contract.TransactionDelete, Name: "datacenter-2", Version: 2},
+		{Op: contract.TransactionCheck, Name: "datacenter-3"},
+	}}, resp, "Incorrect response")
+	config, err := manager.Get(DefaultNamespace, "datacenter-1")
+	require.NoError(t, err, "Unexpected get error")
+	assert.Equal(t, "alice", config.UpdatedBy, "Incorrect author")
+	_, err = manager.Get(DefaultNamespace, "datacenter-2")
+	assert.True(t, errors.Is(err, ErrNotFound), "Config was not deleted")
+}
+
+func TestTransactForFailedCondition(t *testing.T) {
+	tests := []struct {
+		name string
+		cond contract.TransactionCondition
+	}{
+		{"exists", contract.TransactionCondition{Exists: exists(false)}},
+		{"version", contract.TransactionCondition{Version: 2}},
+		{"value", contract.TransactionCondition{Path: "/metadata/region", Value: json.RawMessage(`"us"`)}},
+		{"missing path", contract.TransactionCondition{Path: "/metadata/zone", Value: json.RawMessage(`"a"`)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := newApplyManager(t, euConfig)
+
+			_, err := manager.Transact(contract.TransactionRequest{
+				Namespace: DefaultNamespace,
+				Operations: []contract.TransactionOperation{
+					{Op: contract.TransactionPut, Name: "datacenter-2"},
+					{Op: contract.TransactionCheck, Name: "datacenter-1", If: []contract.TransactionCondition{tt.cond}},
+				},
+			})
+
+			assert.True(t, errors.Is(err, ErrPreconditionFailed), "Incorrect transaction error: %v", err)
+			assert.Contains(t, err.Error(), "operation 1", "Failed operation is not named")
+			_, err = manager.Get(DefaultNamespace, "datacenter-2")
+			assert.True(t, errors.Is(err, ErrNotFound), "Part of the transaction was applied")
+		})
+	}
+}
+
+func TestTransactForInvalidOperation(t *testing.T) {
+	tests := []struct {
+		name       string
+		operations []contract.TransactionOperation
+		expected   error
+	}{
+		{"no operations", nil, ErrInvalidTransaction},
+		{"unknown operation", []contract.TransactionOperation{{Op: "patch", Name: "datacenter-1"}}, ErrInvalidTransaction},
+		{"reserved name", []contract.TransactionOperation{{Op: contract.TransactionPut, Name: "search"}}, ErrInvalidConfig},
+		{"metadata of a deletion", []contract.TransactionOperation{
+			{Op: contract.TransactionDelete, Name: "datacenter-1", Metadata: "a"}}, ErrInvalidTransaction},
+		{"empty condition", []contract.TransactionOperation{
+			{Op: contract.TransactionCheck, Name: "datacenter-1", If: []contract.TransactionCondition{{}}}}, ErrInvalidTransaction},
+		{"mixed condition", []contract.TransactionOperation{{Op: contract.TransactionCheck, Name: "datacenter-1",
+			If: []contract.TransactionCondition{{Exists: exists(true), Version: 1}}}}, ErrInvalidTransaction},
+		{"path without value", []contract.TransactionOperation{{Op: contract.TransactionCheck, Name: "datacenter-1",
+			If: []contract.TransactionCondition{{Path: "/metadata"}}}}, ErrInvalidTransaction},
+		{"repeated version", []contract.TransactionOperation{{Op: contract.TransactionCheck, Name: "datacenter-1",
+			If: []contract.TransactionCondition{{Version: 1}, {Version: 2}}}}, ErrInvalidTransaction},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewConfigManager(new(mocks.Config), db.NewSchemaRepo(), db.NewWebhookRepo())
+
+			_, err := manager.Transact(contract.TransactionRequest{Namespace: DefaultNamespace, Operations: tt.operations})
+
+			assert.True(t, errors.Is(err, tt.expected), "Incorrect transaction error: %v", err)
+		})
+	}
+}
+
+func TestTransactLocatesViolations(t *testing.T) {
+	manager := newApplyManager(t)
+
+	_, err := manager.Transact(contract.TransactionRequest{
+		Namespace: DefaultNamespace,
+		Operations: []contract.TransactionOperation{
+			{Op: contract.TransactionCheck, Name: "datacenter-1"},
+			{Op: contract.TransactionPut, Name: "datacenter-2", Metadata: map[string]interface{}{}, Schema: "limits"},
+		},
+	})
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr), "Incorrect transaction error: %v", err)
+	assert.Equal(t, []schema.Violation{{Path: "/operations/1/metadata/limits", Message: "property is required"}},
+		validationErr.Violations, "Incorrect violations")
+}
+
+func TestTransactForRepoError(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("Transact", "team-a", []db.TxOp{{Name: "datacenter-1", Delete: true, Author: "alice"}}).
+		Return(nil, db.ErrNamespaceNotFound)
+
+	_, err := manager.Transact(contract.TransactionRequest{
+		Namespace:  "team-a",
+		Author:     "alice",
+		Operations: []contract.TransactionOperation{{Op: contract.TransactionDelete, Name: "datacenter-1"}},
+	})
+
+	assert.True(t, errors.Is(err, ErrNamespaceNotFound), "Incorrect transaction error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Maximum length of a config name
+const maxNameLength = 128
+
+var (
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+	// Names that would be shadowed by other routes under /configs
+	reservedNames = map[string]bool{
+		"search": true,
+		"watch":  true,
+		"export": true,
+		"import": true,
+		"apply":  true,
+	}
+)
+
+// validateName checks that a config name can be used as a single URL path segment
+func validateName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("%w: missing config name", ErrInvalidConfig)
+	case len(name) > maxNameLength:
+		return fmt.Errorf("%w: config name is longer than %d characters", ErrInvalidConfig, maxNameLength)
+	case !namePattern.MatchString(name):
+		return fmt.Errorf("%w: config name %q must start with a letter or digit and only contain letters, "+
+			"digits, '.', '_' and '-'", ErrInvalidConfig, name)
+	case reservedNames[name]:
+		return fmt.Errorf("%w: config name %q is reserved", ErrInvalidConfig, name)
+	}
+
+	return nil
+}
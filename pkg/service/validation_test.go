@@ -0,0 +1,42 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"datacenter-1", true},
+		{"DC_1.eu-west", true},
+		{"1", true},
+		{strings.Repeat("a", maxNameLength), true},
+		{"", false},
+		{strings.Repeat("a", maxNameLength+1), false},
+		{"-datacenter", false},
+		{".hidden", false},
+		{"data center", false},
+		{"datacenter/1", false},
+		{"datacenter?1", false},
+		{"search", false},
+		{"watch", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateName(tt.name)
+
+			if tt.valid {
+				assert.NoError(t, err, "Unexpected validation error")
+			} else {
+				assert.True(t, errors.Is(err, ErrInvalidConfig), "Incorrect validation error: %v", err)
+			}
+		})
+	}
+}
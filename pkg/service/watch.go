@@ -0,0 +1,38 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"jsonstore/pkg/contract"
+)
+
+// Watch returns the changes to a config, or to the configs whose name starts with a prefix, made
+// since a revision. It fails with ErrWatchExpired when those changes are no longer known, in which
+// case watchers get the configs anew and watch from the current revision.
+func (c configManager) Watch(req contract.WatchRequest) (*contract.WatchResponse, error) {
+	if req.Revision < 0 {
+		return nil, fmt.Errorf("watch: %w: revision %d is negative", ErrInvalidWatch, req.Revision)
+	}
+	if _, err := c.configRepo.Namespace(req.Namespace); err != nil {
+		return nil, fmt.Errorf("watch: %w", err)
+	}
+
+	events, head, changed, err := c.events.since(req.Revision)
+	if err != nil {
+		return nil, fmt.Errorf("watch: %w: revision %d", err, req.Revision)
+	}
+
+	namespace := canonicalNamespace(req.Namespace)
+	resp := &contract.WatchResponse{Revision: head, Events: []contract.Event{}, Changed: changed}
+	for _, event := range events {
+		if event.Namespace != namespace {
+			continue
+		}
+		if req.Name != "" && event.Name != req.Name || req.Name == "" && !strings.HasPrefix(event.Name, req.Prefix) {
+			continue
+		}
+		resp.Events = append(resp.Events, event)
+	}
+	return resp, nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+func TestWatch(t *testing.T) {
+	manager := NewConfigManager(db.NewConfigRepo(), db.NewSchemaRepo(), db.NewWebhookRepo())
+	start, err := manager.Watch(contract.WatchRequest{Namespace: DefaultNamespace, Prefix: "datacenter-"})
+	require.NoError(t, err, "Unexpected watch error")
+	assert.Empty(t, start.Events, "Unexpected events before any change")
+
+	_, err = manager.Create(contract.UpsertConfigRequest{Namespace: DefaultNamespace, Config: dc1Data, Author: "alice"})
+	require.NoError(t, err, "Unexpected create config error")
+	_, err = manager.Create(contract.UpsertConfigRequest{Namespace: DefaultNamespace,
+		Config: contract.Config{Name: "cluster-1", Metadata: []byte(dc2)}})
+	require.NoError(t, err, "Unexpected create config error")
+	_, err = manager.Delete(contract.DeleteConfigRequest{Namespace: DefaultNamespace, Name: "datacenter-1", Author: "bob"},
+		contract.Precondition{})
+	require.NoError(t, err, "Unexpected delete config error")
+
+	select {
+	case <-start.Changed:
+	default:
+		assert.Fail(t, "Watchers were not woken up")
+	}
+	resp, err := manager.Watch(contract.WatchRequest{Namespace: DefaultNamespace, Prefix: "datacenter-",
+		Revision: start.Revision})
+	assert.NoError(t, err, "Unexpected watch error")
+	assert.Equal(t, start.Revision+3, resp.Revision, "Incorrect revision")
+	require.Len(t, resp.Events, 2, "Incorrect events")
+	assert.Equal(t, contract.EventPut, resp.Events[0].Type, "Incorrect event type")
+	assert.Equal(t, "alice", resp.Events[0].Config.UpdatedBy, "Incorrect event config")
+	assert.Equal(t, contract.Event{Revision: start.Revision + 3, Type: contract.EventDelete, Namespace: DefaultNamespace,
+		Name: "datacenter-1", Version: 2, Timestamp: resp.Events[1].Timestamp, Author: "bob"}, resp.Events[1],
+		"Incorrect delete event")
+
+	resp, err = manager.Watch(contract.WatchRequest{Namespace: DefaultNamespace, Name: "cluster-1",
+		Revision: start.Revision})
+	assert.NoError(t, err, "Unexpected watch error")
+	require.Len(t, resp.Events, 1, "Incorrect events for a single config")
+	assert.Equal(t, "cluster-1", resp.Events[0].Name, "Incorrect event name")
+}
+
+func TestWatchForCascadingNamespaceDeletion(t *testing.T) {
+	manager := NewConfigManager(db.NewConfigRepo(), db.NewSchemaRepo(), db.NewWebhookRepo())
+	_, err := manager.CreateNamespace(contract.CreateNamespaceRequest{Name: "team-a"})
+	require.NoError(t, err, "Unexpected create namespace error")
+	_, err = manager.Create(contract.UpsertConfigRequest{Namespace: "team-a", Config: dc1Data})
+	require.NoError(t, err, "Unexpected create config error")
+	start, err := manager.Watch(contract.WatchRequest{Namespace: "team-a"})
+	require.NoError(t, err, "Unexpected watch error")
+
+	err = manager.DeleteNamespace(contract.DeleteNamespaceRequest{Name: "team-a", Cascade: true, Author: "alice"})
+	require.NoError(t, err, "Unexpected delete namespace error")
+	_, err = manager.CreateNamespace(contract.CreateNamespaceRequest{Name: "team-a"})
+	require.NoError(t, err, "Unexpected create namespace error")
+
+	resp, err := manager.Watch(contract.WatchRequest{Namespace: "team-a", Revision: start.Revision})
+	assert.NoError(t, err, "Unexpected watch error")
+	require.Len(t, resp.Events, 1, "Incorrect events")
+	assert.Equal(t, contract.EventDelete, resp.Events[0].Type, "Incorrect event type")
+	assert.Equal(t, "alice", resp.Events[0].Author, "Incorrect event author")
+}
+
+func TestWatchForExpiredRevision(t *testing.T) {
+	manager := NewConfigManager(db.NewConfigRepo(), db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	_, err := manager.Watch(contract.WatchRequest{Namespace: DefaultNamespace, Revision: 1})
+
+	assert.True(t, errors.Is(err, ErrWatchExpired), "Incorrect watch error")
+}
+
+func TestWatchForInvalidRevision(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	_, err := manager.Watch(contract.WatchRequest{Namespace: DefaultNamespace, Revision: -1})
+
+	assert.True(t, errors.Is(err, ErrInvalidWatch), "Incorrect watch error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
+
+func TestWatchForMissingNamespace(t *testing.T) {
+	configRepo := new(mocks.Config)
+	manager := NewConfigManager(configRepo, db.NewSchemaRepo(), db.NewWebhookRepo())
+
+	configRepo.On("Namespace", "team-a").Return(nil, db.ErrNamespaceNotFound)
+
+	_, err := manager.Watch(contract.WatchRequest{Namespace: "team-a"})
+
+	assert.True(t, errors.Is(err, ErrNamespaceNotFound), "Incorrect watch error")
+	mock.AssertExpectationsForObjects(t, configRepo)
+}
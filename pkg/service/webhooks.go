@@ -0,0 +1,110 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/model"
+	"jsonstore/pkg/query"
+)
+
+func (c configManager) Webhooks() ([]contract.GetWebhookResponse, error) {
+	webhooks, err := c.webhookRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("webhooks: %w", err)
+	}
+
+	resp := make([]contract.GetWebhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		resp = append(resp, c.toWebhookResponse(webhook))
+	}
+	return resp, nil
+}
+
+func (c configManager) Webhook(id string) (*contract.GetWebhookResponse, error) {
+	webhook, err := c.webhookRepo.Get(id)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: %w", err)
+	}
+
+	resp := c.toWebhookResponse(*webhook)
+	return &resp, nil
+}
+
+// CreateWebhook subscribes a URL to the changes of the configs of a namespace, which are posted
+// to it from then on
+func (c configManager) CreateWebhook(req contract.CreateWebhookRequest) (*contract.GetWebhookResponse, error) {
+	if err := validateWebhook(req); err != nil {
+		return nil, fmt.Errorf("create webhook: %w", err)
+	}
+	namespace := canonicalNamespace(req.Namespace)
+	if _, err := c.configRepo.Namespace(namespace); err != nil {
+		return nil, fmt.Errorf("create webhook: %w", err)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("create webhook: generate id: %w", err)
+	}
+	webhook, err := c.webhookRepo.Create(model.Webhook{
+		ID:        hex.EncodeToString(id),
+		Namespace: namespace,
+		URL:       req.URL,
+		Prefix:    req.Prefix,
+		Query:     req.Query,
+		Secret:    req.Secret,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create webhook: %w", err)
+	}
+	c.deliveries.start()
+
+	resp := c.toWebhookResponse(*webhook)
+	return &resp, nil
+}
+
+// DeleteWebhook unsubscribes a webhook; the deliveries still pending are dropped
+func (c configManager) DeleteWebhook(id string) error {
+	if err := c.webhookRepo.Delete(id); err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+	c.deliveries.forget(id)
+
+	return nil
+}
+
+func validateWebhook(req contract.CreateWebhookRequest) error {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url %q is not an absolute http or https URL", ErrInvalidWebhook, req.URL)
+	}
+	if req.Secret == "" {
+		return fmt.Errorf("%w: missing secret", ErrInvalidWebhook)
+	}
+	if req.Query != "" {
+		if _, err := query.Parse(req.Query); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+		}
+	}
+
+	return nil
+}
+
+func (c configManager) toWebhookResponse(webhook model.Webhook) contract.GetWebhookResponse {
+	resp := contract.GetWebhookResponse{
+		ID:         webhook.ID,
+		Namespace:  webhook.Namespace,
+		URL:        webhook.URL,
+		Prefix:     webhook.Prefix,
+		Query:      webhook.Query,
+		Deliveries: c.deliveries.status(webhook.ID),
+	}
+	if !webhook.CreatedAt.IsZero() {
+		resp.CreatedAt = &webhook.CreatedAt
+	}
+
+	return resp
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"jsonstore/pkg/contract"
+	"jsonstore/pkg/db"
+	"jsonstore/pkg/testlib/mocks"
+)
+
+func TestCreateWebhook(t *testing.T) {
+	manager := newWebhookManager(t)
+
+	created, err := manager.CreateWebhook(contract.CreateWebhookRequest{URL: "https://example.com/hooks",
+		Prefix: "datacenter-", Query: "metadata.region exists", Secret: "s3cret"})
+
+	require.NoError(t, err, "Unexpected create webhook error")
+	assert.Len(t, created.ID, 32, "Incorrect webhook id")
+	assert.Equal(t, DefaultNamespace, created.Namespace, "Incorrect namespace")
+	webhooks, err := manager.Webhooks()
+	require.NoError(t, err, "Unexpected get webhooks error")
+	assert.Equal(t, []contract.GetWebhookResponse{*created}, webhooks, "Incorrect webhooks")
+}
+
+func TestCreateWebhookForInvalidRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		req  contract.CreateWebhookRequest
+		err  error
+	}{
+		{"relative url", contract.CreateWebhookRequest{URL: "/hooks", Secret: "s3cret"}, ErrInvalidWebhook},
+		{"unsupported scheme", contract.CreateWebhookRequest{URL: "ftp://example.com", Secret: "s3cret"}, ErrInvalidWebhook},
+		{"missing secret", contract.CreateWebhookRequest{URL: "https://example.com"}, ErrInvalidWebhook},
+		{"invalid query", contract.CreateWebhookRequest{URL: "https://example.com", Query: "name =", Secret: "s3cret"},
+			ErrInvalidQuery},
+		{"missing namespace", contract.CreateWebhookRequest{Namespace: "team-a", URL: "https://example.com",
+			Secret: "s3cret"}, ErrNamespaceNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manager := newWebhookManager(t)
+
+			_, err := manager.CreateWebhook(test.req)
+
+			assert.True(t, errors.Is(err, test.err), "Incorrect create webhook error: %v", err)
+			webhooks, err := manager.Webhooks()
+			require.NoError(t, err, "Unexpected get webhooks error")
+			assert.Empty(t, webhooks, "Webhook was created")
+		})
+	}
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	manager := newWebhookManager(t)
+	created, err := manager.CreateWebhook(contract.CreateWebhookRequest{URL: "https://example.com", Secret: "s3cret"})
+	require.NoError(t, err, "Unexpected create webhook error")
+
+	err = manager.DeleteWebhook(created.ID)
+
+	assert.NoError(t, err, "Unexpected delete webhook error")
+	_, err = manager.Webhook(created.ID)
+	assert.True(t, errors.Is(err, ErrWebhookNotFound), "Incorrect get webhook error")
+	assert.True(t, errors.Is(manager.DeleteWebhook(created.ID), ErrNotFound), "Incorrect delete webhook error")
+}
+
+func TestWebhooksForRepoError(t *testing.T) {
+	webhookRepo := new(mocks.Webhook)
+	webhookRepo.On("GetAll").Return(nil, errors.New("some error"))
+	manager := NewConfigManager(new(mocks.Config), db.NewSchemaRepo(), webhookRepo)
+
+	_, err := manager.Webhooks()
+
+	assert.Error(t, err, "Missing get webhooks error")
+	assert.Contains(t, err.Error(), "webhooks:", "Incorrect get webhooks error")
+	mock.AssertExpectationsForObjects(t, webhookRepo)
+}
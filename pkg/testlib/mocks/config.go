@@ -3,7 +3,9 @@
 package mocks
 
 import (
+	db "jsonstore/pkg/db"
 	model "jsonstore/pkg/model"
+	query "jsonstore/pkg/query"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -13,8 +15,54 @@ type Config struct {
 	mock.Mock
 }
 
-// Delete provides a mock function with given fields: _a0
-func (_m *Config) Delete(_a0 string) error {
+// ApplyAll provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Config) ApplyAll(_a0 string, _a1 []model.Config, _a2 bool, _a3 string) ([]db.BatchResult, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []db.BatchResult
+	if rf, ok := ret.Get(0).(func(string, []model.Config, bool, string) []db.BatchResult); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.BatchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []model.Config, bool, string) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: _a0
+func (_m *Config) Create(_a0 model.Config) (*model.Config, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *model.Config
+	if rf, ok := ret.Get(0).(func(model.Config) *model.Config); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Config)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(model.Config) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateIndex provides a mock function with given fields: _a0
+func (_m *Config) CreateIndex(_a0 string) error {
 	ret := _m.Called(_a0)
 
 	var r0 error
@@ -27,19 +75,125 @@ func (_m *Config) Delete(_a0 string) error {
 	return r0
 }
 
-// Get provides a mock function with given fields: _a0
-func (_m *Config) Get(_a0 string) (*model.Config, error) {
+// CreateNamespace provides a mock function with given fields: _a0
+func (_m *Config) CreateNamespace(_a0 string) (*model.Namespace, error) {
 	ret := _m.Called(_a0)
 
-	var r0 *model.Config
-	if rf, ok := ret.Get(0).(func(string) *model.Config); ok {
+	var r0 *model.Namespace
+	if rf, ok := ret.Get(0).(func(string) *model.Namespace); ok {
 		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Namespace)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Config) Delete(_a0 string, _a1 string, _a2 db.Precondition, _a3 string) (*model.Revision, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 *model.Revision
+	if rf, ok := ret.Get(0).(func(string, string, db.Precondition, string) *model.Revision); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Revision)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, db.Precondition, string) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteNamespace provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Config) DeleteNamespace(_a0 string, _a1 bool, _a2 string) ([]model.Revision, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []model.Revision
+	if rf, ok := ret.Get(0).(func(string, bool, string) []model.Revision); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Revision)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, bool, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DropIndex provides a mock function with given fields: _a0
+func (_m *Config) DropIndex(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Get provides a mock function with given fields: _a0, _a1
+func (_m *Config) Get(_a0 string, _a1 string) (*model.Config, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *model.Config
+	if rf, ok := ret.Get(0).(func(string, string) *model.Config); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Config)
 		}
 	}
 
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAll provides a mock function with given fields: _a0
+func (_m *Config) GetAll(_a0 string) ([]model.Config, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []model.Config
+	if rf, ok := ret.Get(0).(func(string) []model.Config); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Config)
+		}
+	}
+
 	var r1 error
 	if rf, ok := ret.Get(1).(func(string) error); ok {
 		r1 = rf(_a0)
@@ -50,16 +204,39 @@ func (_m *Config) Get(_a0 string) (*model.Config, error) {
 	return r0, r1
 }
 
-// GetAll provides a mock function with given fields:
-func (_m *Config) GetAll() ([]model.Config, error) {
+// History provides a mock function with given fields: _a0, _a1
+func (_m *Config) History(_a0 string, _a1 string) ([]model.Revision, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []model.Revision
+	if rf, ok := ret.Get(0).(func(string, string) []model.Revision); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Revision)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Indexes provides a mock function with given fields:
+func (_m *Config) Indexes() ([]model.Index, error) {
 	ret := _m.Called()
 
-	var r0 []model.Config
-	if rf, ok := ret.Get(0).(func() []model.Config); ok {
+	var r0 []model.Index
+	if rf, ok := ret.Get(0).(func() []model.Index); ok {
 		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]model.Config)
+			r0 = ret.Get(0).([]model.Index)
 		}
 	}
 
@@ -73,12 +250,104 @@ func (_m *Config) GetAll() ([]model.Config, error) {
 	return r0, r1
 }
 
+// Namespace provides a mock function with given fields: _a0
+func (_m *Config) Namespace(_a0 string) (*model.Namespace, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *model.Namespace
+	if rf, ok := ret.Get(0).(func(string) *model.Namespace); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Namespace)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Namespaces provides a mock function with given fields:
+func (_m *Config) Namespaces() ([]model.Namespace, error) {
+	ret := _m.Called()
+
+	var r0 []model.Namespace
+	if rf, ok := ret.Get(0).(func() []model.Namespace); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Namespace)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReplaceAll provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Config) ReplaceAll(_a0 string, _a1 []model.Config, _a2 []string, _a3 string) ([]db.BatchResult, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []db.BatchResult
+	if rf, ok := ret.Get(0).(func(string, []model.Config, []string, string) []db.BatchResult); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.BatchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []model.Config, []string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Revision provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Config) Revision(_a0 string, _a1 string, _a2 int64) (*model.Revision, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *model.Revision
+	if rf, ok := ret.Get(0).(func(string, string, int64) *model.Revision); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Revision)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, int64) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Search provides a mock function with given fields: _a0, _a1
-func (_m *Config) Search(_a0 string, _a1 string) ([]model.Config, error) {
+func (_m *Config) Search(_a0 string, _a1 query.Expr) ([]model.Config, error) {
 	ret := _m.Called(_a0, _a1)
 
 	var r0 []model.Config
-	if rf, ok := ret.Get(0).(func(string, string) []model.Config); ok {
+	if rf, ok := ret.Get(0).(func(string, query.Expr) []model.Config); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
@@ -87,7 +356,7 @@ func (_m *Config) Search(_a0 string, _a1 string) ([]model.Config, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+	if rf, ok := ret.Get(1).(func(string, query.Expr) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -96,16 +365,94 @@ func (_m *Config) Search(_a0 string, _a1 string) ([]model.Config, error) {
 	return r0, r1
 }
 
-// Upsert provides a mock function with given fields: _a0
-func (_m *Config) Upsert(_a0 model.Config) error {
-	ret := _m.Called(_a0)
+// Transact provides a mock function with given fields: _a0, _a1
+func (_m *Config) Transact(_a0 string, _a1 []db.TxOp) ([]db.BatchResult, error) {
+	ret := _m.Called(_a0, _a1)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(model.Config) error); ok {
-		r0 = rf(_a0)
+	var r0 []db.BatchResult
+	if rf, ok := ret.Get(0).(func(string, []db.TxOp) []db.BatchResult); ok {
+		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.BatchResult)
+		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []db.TxOp) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Config) Update(_a0 string, _a1 string, _a2 db.Precondition, _a3 db.UpdateFunc) (*model.Config, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 *model.Config
+	if rf, ok := ret.Get(0).(func(string, string, db.Precondition, db.UpdateFunc) *model.Config); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Config)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, db.Precondition, db.UpdateFunc) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Upsert provides a mock function with given fields: _a0, _a1
+func (_m *Config) Upsert(_a0 model.Config, _a1 db.Precondition) (*model.Config, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *model.Config
+	if rf, ok := ret.Get(0).(func(model.Config, db.Precondition) *model.Config); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Config)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(model.Config, db.Precondition) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpsertAll provides a mock function with given fields: _a0, _a1
+func (_m *Config) UpsertAll(_a0 string, _a1 []model.Config) ([]db.BatchResult, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []db.BatchResult
+	if rf, ok := ret.Get(0).(func(string, []model.Config) []db.BatchResult); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.BatchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []model.Config) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
@@ -13,8 +13,179 @@ type Manager struct {
 	mock.Mock
 }
 
-// Delete provides a mock function with given fields: _a0
-func (_m *Manager) Delete(_a0 string) error {
+// Apply provides a mock function with given fields: _a0
+func (_m *Manager) Apply(_a0 contract.ApplyRequest) (*contract.ApplyResponse, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *contract.ApplyResponse
+	if rf, ok := ret.Get(0).(func(contract.ApplyRequest) *contract.ApplyResponse); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.ApplyResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(contract.ApplyRequest) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: _a0
+func (_m *Manager) Create(_a0 contract.UpsertConfigRequest) (*contract.GetConfigResponse, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *contract.GetConfigResponse
+	if rf, ok := ret.Get(0).(func(contract.UpsertConfigRequest) *contract.GetConfigResponse); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.GetConfigResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(contract.UpsertConfigRequest) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateIndex provides a mock function with given fields: _a0
+func (_m *Manager) CreateIndex(_a0 contract.CreateIndexRequest) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(contract.CreateIndexRequest) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateNamespace provides a mock function with given fields: _a0
+func (_m *Manager) CreateNamespace(_a0 contract.CreateNamespaceRequest) (*contract.GetNamespaceResponse, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *contract.GetNamespaceResponse
+	if rf, ok := ret.Get(0).(func(contract.CreateNamespaceRequest) *contract.GetNamespaceResponse); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.GetNamespaceResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(contract.CreateNamespaceRequest) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateWebhook provides a mock function with given fields: _a0
+func (_m *Manager) CreateWebhook(_a0 contract.CreateWebhookRequest) (*contract.GetWebhookResponse, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *contract.GetWebhookResponse
+	if rf, ok := ret.Get(0).(func(contract.CreateWebhookRequest) *contract.GetWebhookResponse); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.GetWebhookResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(contract.CreateWebhookRequest) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: _a0, _a1
+func (_m *Manager) Delete(_a0 contract.DeleteConfigRequest, _a1 contract.Precondition) (*contract.GetRevisionResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *contract.GetRevisionResponse
+	if rf, ok := ret.Get(0).(func(contract.DeleteConfigRequest, contract.Precondition) *contract.GetRevisionResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.GetRevisionResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(contract.DeleteConfigRequest, contract.Precondition) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteNamespace provides a mock function with given fields: _a0
+func (_m *Manager) DeleteNamespace(_a0 contract.DeleteNamespaceRequest) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(contract.DeleteNamespaceRequest) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteSchema provides a mock function with given fields: _a0
+func (_m *Manager) DeleteSchema(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteWebhook provides a mock function with given fields: _a0
+func (_m *Manager) DeleteWebhook(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DropIndex provides a mock function with given fields: _a0
+func (_m *Manager) DropIndex(_a0 string) error {
 	ret := _m.Called(_a0)
 
 	var r0 error
@@ -27,19 +198,295 @@ func (_m *Manager) Delete(_a0 string) error {
 	return r0
 }
 
-// Get provides a mock function with given fields: _a0
-func (_m *Manager) Get(_a0 string) (*contract.GetConfigResponse, error) {
+// Export provides a mock function with given fields: _a0
+func (_m *Manager) Export(_a0 string) ([]contract.GetConfigResponse, error) {
 	ret := _m.Called(_a0)
 
+	var r0 []contract.GetConfigResponse
+	if rf, ok := ret.Get(0).(func(string) []contract.GetConfigResponse); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]contract.GetConfigResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Get provides a mock function with given fields: _a0, _a1
+func (_m *Manager) Get(_a0 string, _a1 string) (*contract.GetConfigResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
 	var r0 *contract.GetConfigResponse
-	if rf, ok := ret.Get(0).(func(string) *contract.GetConfigResponse); ok {
+	if rf, ok := ret.Get(0).(func(string, string) *contract.GetConfigResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.GetConfigResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAll provides a mock function with given fields: _a0, _a1
+func (_m *Manager) GetAll(_a0 string, _a1 contract.ListOptions) (*contract.ListConfigsResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *contract.ListConfigsResponse
+	if rf, ok := ret.Get(0).(func(string, contract.ListOptions) *contract.ListConfigsResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.ListConfigsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, contract.ListOptions) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// History provides a mock function with given fields: _a0, _a1
+func (_m *Manager) History(_a0 string, _a1 string) ([]contract.GetRevisionResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []contract.GetRevisionResponse
+	if rf, ok := ret.Get(0).(func(string, string) []contract.GetRevisionResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]contract.GetRevisionResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Import provides a mock function with given fields: _a0
+func (_m *Manager) Import(_a0 contract.ImportRequest) (*contract.ImportResponse, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *contract.ImportResponse
+	if rf, ok := ret.Get(0).(func(contract.ImportRequest) *contract.ImportResponse); ok {
 		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.ImportResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(contract.ImportRequest) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Indexes provides a mock function with given fields:
+func (_m *Manager) Indexes() ([]contract.GetIndexResponse, error) {
+	ret := _m.Called()
+
+	var r0 []contract.GetIndexResponse
+	if rf, ok := ret.Get(0).(func() []contract.GetIndexResponse); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]contract.GetIndexResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Namespace provides a mock function with given fields: _a0
+func (_m *Manager) Namespace(_a0 string) (*contract.GetNamespaceResponse, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *contract.GetNamespaceResponse
+	if rf, ok := ret.Get(0).(func(string) *contract.GetNamespaceResponse); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.GetNamespaceResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Namespaces provides a mock function with given fields:
+func (_m *Manager) Namespaces() ([]contract.GetNamespaceResponse, error) {
+	ret := _m.Called()
+
+	var r0 []contract.GetNamespaceResponse
+	if rf, ok := ret.Get(0).(func() []contract.GetNamespaceResponse); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]contract.GetNamespaceResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Patch provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Manager) Patch(_a0 string, _a1 string, _a2 contract.PatchConfigRequest, _a3 contract.Precondition) (*contract.GetConfigResponse, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 *contract.GetConfigResponse
+	if rf, ok := ret.Get(0).(func(string, string, contract.PatchConfigRequest, contract.Precondition) *contract.GetConfigResponse); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*contract.GetConfigResponse)
 		}
 	}
 
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, contract.PatchConfigRequest, contract.Precondition) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PutSchema provides a mock function with given fields: _a0
+func (_m *Manager) PutSchema(_a0 contract.PutSchemaRequest) (*contract.GetSchemaResponse, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *contract.GetSchemaResponse
+	if rf, ok := ret.Get(0).(func(contract.PutSchemaRequest) *contract.GetSchemaResponse); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.GetSchemaResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(contract.PutSchemaRequest) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Revision provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Manager) Revision(_a0 string, _a1 string, _a2 int64) (*contract.GetRevisionResponse, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *contract.GetRevisionResponse
+	if rf, ok := ret.Get(0).(func(string, string, int64) *contract.GetRevisionResponse); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.GetRevisionResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, int64) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Rollback provides a mock function with given fields: _a0, _a1
+func (_m *Manager) Rollback(_a0 contract.RollbackConfigRequest, _a1 contract.Precondition) (*contract.GetConfigResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *contract.GetConfigResponse
+	if rf, ok := ret.Get(0).(func(contract.RollbackConfigRequest, contract.Precondition) *contract.GetConfigResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.GetConfigResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(contract.RollbackConfigRequest, contract.Precondition) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Schema provides a mock function with given fields: _a0
+func (_m *Manager) Schema(_a0 string) (*contract.GetSchemaResponse, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *contract.GetSchemaResponse
+	if rf, ok := ret.Get(0).(func(string) *contract.GetSchemaResponse); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.GetSchemaResponse)
+		}
+	}
+
 	var r1 error
 	if rf, ok := ret.Get(1).(func(string) error); ok {
 		r1 = rf(_a0)
@@ -50,16 +497,16 @@ func (_m *Manager) Get(_a0 string) (*contract.GetConfigResponse, error) {
 	return r0, r1
 }
 
-// GetAll provides a mock function with given fields:
-func (_m *Manager) GetAll() ([]contract.GetConfigResponse, error) {
+// Schemas provides a mock function with given fields:
+func (_m *Manager) Schemas() ([]contract.GetSchemaResponse, error) {
 	ret := _m.Called()
 
-	var r0 []contract.GetConfigResponse
-	if rf, ok := ret.Get(0).(func() []contract.GetConfigResponse); ok {
+	var r0 []contract.GetSchemaResponse
+	if rf, ok := ret.Get(0).(func() []contract.GetSchemaResponse); ok {
 		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]contract.GetConfigResponse)
+			r0 = ret.Get(0).([]contract.GetSchemaResponse)
 		}
 	}
 
@@ -73,21 +520,90 @@ func (_m *Manager) GetAll() ([]contract.GetConfigResponse, error) {
 	return r0, r1
 }
 
-// Search provides a mock function with given fields: _a0, _a1
-func (_m *Manager) Search(_a0 string, _a1 string) ([]contract.GetConfigResponse, error) {
+// Search provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Manager) Search(_a0 string, _a1 string, _a2 contract.ListOptions) (*contract.ListConfigsResponse, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *contract.ListConfigsResponse
+	if rf, ok := ret.Get(0).(func(string, string, contract.ListOptions) *contract.ListConfigsResponse); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.ListConfigsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, contract.ListOptions) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchValue provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Manager) SearchValue(_a0 string, _a1 string, _a2 string, _a3 contract.ListOptions) (*contract.ListConfigsResponse, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 *contract.ListConfigsResponse
+	if rf, ok := ret.Get(0).(func(string, string, string, contract.ListOptions) *contract.ListConfigsResponse); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.ListConfigsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string, contract.ListOptions) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Transact provides a mock function with given fields: _a0
+func (_m *Manager) Transact(_a0 contract.TransactionRequest) (*contract.TransactionResponse, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *contract.TransactionResponse
+	if rf, ok := ret.Get(0).(func(contract.TransactionRequest) *contract.TransactionResponse); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.TransactionResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(contract.TransactionRequest) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Upsert provides a mock function with given fields: _a0, _a1
+func (_m *Manager) Upsert(_a0 contract.UpsertConfigRequest, _a1 contract.Precondition) (*contract.GetConfigResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 []contract.GetConfigResponse
-	if rf, ok := ret.Get(0).(func(string, string) []contract.GetConfigResponse); ok {
+	var r0 *contract.GetConfigResponse
+	if rf, ok := ret.Get(0).(func(contract.UpsertConfigRequest, contract.Precondition) *contract.GetConfigResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]contract.GetConfigResponse)
+			r0 = ret.Get(0).(*contract.GetConfigResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+	if rf, ok := ret.Get(1).(func(contract.UpsertConfigRequest, contract.Precondition) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -96,16 +612,71 @@ func (_m *Manager) Search(_a0 string, _a1 string) ([]contract.GetConfigResponse,
 	return r0, r1
 }
 
-// Upsert provides a mock function with given fields: _a0
-func (_m *Manager) Upsert(_a0 contract.UpsertConfigRequest) error {
+// Watch provides a mock function with given fields: _a0
+func (_m *Manager) Watch(_a0 contract.WatchRequest) (*contract.WatchResponse, error) {
 	ret := _m.Called(_a0)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(contract.UpsertConfigRequest) error); ok {
+	var r0 *contract.WatchResponse
+	if rf, ok := ret.Get(0).(func(contract.WatchRequest) *contract.WatchResponse); ok {
 		r0 = rf(_a0)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.WatchResponse)
+		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(contract.WatchRequest) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Webhook provides a mock function with given fields: _a0
+func (_m *Manager) Webhook(_a0 string) (*contract.GetWebhookResponse, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *contract.GetWebhookResponse
+	if rf, ok := ret.Get(0).(func(string) *contract.GetWebhookResponse); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*contract.GetWebhookResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Webhooks provides a mock function with given fields:
+func (_m *Manager) Webhooks() ([]contract.GetWebhookResponse, error) {
+	ret := _m.Called()
+
+	var r0 []contract.GetWebhookResponse
+	if rf, ok := ret.Get(0).(func() []contract.GetWebhookResponse); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]contract.GetWebhookResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }